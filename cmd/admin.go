@@ -32,6 +32,8 @@ var (
 			subcmdRepoSyncReleases,
 			subcmdRegenerate,
 			subcmdAuth,
+			subcmdMigrateStorage,
+			subcmdLFSGarbageCollect,
 		},
 	}
 
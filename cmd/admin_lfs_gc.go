@@ -0,0 +1,88 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	lfs_service "code.gitea.io/gitea/services/lfs"
+
+	"github.com/urfave/cli"
+)
+
+var subcmdLFSGarbageCollect = cli.Command{
+	Name:  "lfs-gc",
+	Usage: "Report and remove LFS objects no repository references anymore",
+	Description: `Walks the history of every repository with LFS objects and lists the objects
+no reachable commit points to, and the stored files without any LFS object.
+Nothing is removed unless --delete is given, and only objects older than the grace period are.`,
+	Action: runLFSGarbageCollect,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "delete",
+			Usage: "Remove the unreferenced objects older than the grace period",
+		},
+		cli.DurationFlag{
+			Name:  "grace-period",
+			Usage: "Minimum age of the objects to remove, defaults to GRACE_PERIOD of [cron.lfs_gc]",
+		},
+		cli.BoolFlag{
+			Name:  "verbose, v",
+			Usage: "List every unreferenced object and orphaned file",
+		},
+	},
+}
+
+func runLFSGarbageCollect(c *cli.Context) error {
+	if err := initDB(); err != nil {
+		return err
+	}
+	if !setting.LFS.StartServer {
+		return fmt.Errorf("LFS is not enabled")
+	}
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	opts := lfs_service.GarbageCollectOptions{
+		Delete:      c.Bool("delete"),
+		GracePeriod: setting.Cron.LFSGarbageCollect.GracePeriod,
+	}
+	if c.IsSet("grace-period") {
+		opts.GracePeriod = c.Duration("grace-period")
+	}
+
+	report, err := lfs_service.GarbageCollect(opts)
+	if report == nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "Repository\tUnreferenced\tSize\tReclaimable\tRemoved\t\n")
+	for _, repo := range report.Repos {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t\n", repo.Name(), len(repo.Unreferenced),
+			base.FileSize(repo.UnreferencedSize), base.FileSize(repo.ReclaimableSize), repo.Deleted)
+		if c.Bool("verbose") {
+			for _, mo := range repo.Unreferenced {
+				fmt.Fprintf(w, "  %s\t\t%s\t\t\t\n", mo.Oid, base.FileSize(mo.Size))
+			}
+		}
+	}
+	fmt.Fprintf(w, "Orphaned files\t%d\t%s\t%s\t\t\n", len(report.OrphanFiles), base.FileSize(report.OrphanSize), base.FileSize(report.OrphanSize))
+	if c.Bool("verbose") {
+		for _, info := range report.OrphanFiles {
+			fmt.Fprintf(w, "  %s\t\t%s\t\t\t\n", info.Path, base.FileSize(info.Size))
+		}
+	}
+	w.Flush()
+
+	fmt.Println(report)
+	return err
+}
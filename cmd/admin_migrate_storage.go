@@ -0,0 +1,218 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+var subcmdMigrateStorage = cli.Command{
+	Name:  "migrate-storage",
+	Usage: "Copy attachments, avatars or LFS objects to another storage",
+	Description: `Copies every object referenced in the database from the configured storage to
+the given one and checks the copies. Objects already present at the destination
+are copied again unless --resume is given, so an interrupted migration can be continued.
+Once complete, change the storage settings to the destination and restart Gitea.`,
+	Action: runMigrateStorage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Usage: "Kind of objects to migrate: attachments, avatars, repo-avatars or lfs",
+		},
+		cli.StringFlag{
+			Name:  "storage, s",
+			Value: setting.LocalStorageType,
+			Usage: "Type of the destination storage: local or minio",
+		},
+		cli.StringFlag{
+			Name:  "path, p",
+			Usage: "Root directory of the local destination storage",
+		},
+		cli.StringFlag{
+			Name:  "minio-endpoint",
+			Value: "localhost:9000",
+			Usage: "Address of the S3 compatible endpoint",
+		},
+		cli.StringFlag{
+			Name:  "minio-access-key-id",
+			Usage: "Access key of the endpoint",
+		},
+		cli.StringFlag{
+			Name:  "minio-secret-access-key",
+			Usage: "Secret key of the endpoint",
+		},
+		cli.StringFlag{
+			Name:  "minio-bucket",
+			Value: "gitea",
+			Usage: "Bucket to store the objects in, it is created when missing",
+		},
+		cli.StringFlag{
+			Name:  "minio-location",
+			Value: "us-east-1",
+			Usage: "Region the bucket is created in",
+		},
+		cli.StringFlag{
+			Name:  "minio-base-path",
+			Usage: "Prefix of the object keys, defaults to the kind of objects followed by a slash",
+		},
+		cli.BoolFlag{
+			Name:  "minio-use-ssl",
+			Usage: "Use https to connect to the endpoint",
+		},
+		cli.BoolFlag{
+			Name:  "verify",
+			Usage: "Compare the content of every copy with its source instead of only its size",
+		},
+		cli.BoolFlag{
+			Name:  "resume",
+			Usage: "Skip the objects already present at the destination",
+		},
+	},
+}
+
+// storageMigration copies the objects of one storage to another and counts the outcomes
+type storageMigration struct {
+	dst     storage.ObjectStorage
+	opts    storage.MigrateOptions
+	visited map[string]bool
+
+	copied, skipped, missing, failed int
+}
+
+func (m *storageMigration) migrate(src storage.ObjectStorage, path string) error {
+	if m.visited[path] {
+		return nil
+	}
+	m.visited[path] = true
+
+	copied, err := storage.Migrate(m.dst, src, path, m.opts)
+	switch {
+	case storage.IsNotExist(err):
+		// the database references an object which has been lost before
+		log.Warn("Missing object %s", path)
+		m.missing++
+	case storage.IsErrVerificationFailed(err):
+		log.Error("%v", err)
+		m.failed++
+	case err != nil:
+		return fmt.Errorf("Migrate %s: %v", path, err)
+	case copied:
+		log.Trace("Copied %s", path)
+		m.copied++
+	default:
+		m.skipped++
+	}
+	return nil
+}
+
+func runMigrateStorage(ctx *cli.Context) error {
+	if err := initDB(); err != nil {
+		return err
+	}
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	kind := ctx.String("type")
+	var src storage.ObjectStorage
+	var srcCfg setting.Storage
+	switch kind {
+	case "attachments":
+		src, srcCfg = storage.Attachments, setting.AttachmentStorage
+	case "avatars":
+		src, srcCfg = storage.Avatars, setting.AvatarStorage
+	case "repo-avatars":
+		src, srcCfg = storage.RepoAvatars, setting.RepositoryAvatarStorage
+	case "lfs":
+		src, srcCfg = storage.LFS, setting.LFS.Storage
+	default:
+		return fmt.Errorf("unknown type %q, must be one of attachments, avatars, repo-avatars or lfs", kind)
+	}
+
+	var dstCfg setting.Storage
+	dstCfg.Type = ctx.String("storage")
+	switch dstCfg.Type {
+	case setting.LocalStorageType:
+		if !ctx.IsSet("path") {
+			return fmt.Errorf("--path is required for local storage")
+		}
+		path, err := filepath.Abs(ctx.String("path"))
+		if err != nil {
+			return err
+		}
+		dstCfg.Path = path
+		if srcCfg.Type == setting.LocalStorageType && filepath.Clean(srcCfg.Path) == dstCfg.Path {
+			return fmt.Errorf("the destination is the configured storage %s", srcCfg.Path)
+		}
+	case setting.MinioStorageType:
+		dstCfg.Minio.Endpoint = ctx.String("minio-endpoint")
+		dstCfg.Minio.AccessKeyID = ctx.String("minio-access-key-id")
+		dstCfg.Minio.SecretAccessKey = ctx.String("minio-secret-access-key")
+		dstCfg.Minio.Bucket = ctx.String("minio-bucket")
+		dstCfg.Minio.Location = ctx.String("minio-location")
+		dstCfg.Minio.BasePath = kind + "/"
+		if ctx.IsSet("minio-base-path") {
+			dstCfg.Minio.BasePath = ctx.String("minio-base-path")
+		}
+		dstCfg.Minio.UseSSL = ctx.Bool("minio-use-ssl")
+		if srcCfg.Type == setting.MinioStorageType && srcCfg.Minio == dstCfg.Minio {
+			return fmt.Errorf("the destination is the configured storage %s/%s%s", srcCfg.Minio.Endpoint, srcCfg.Minio.Bucket, srcCfg.Minio.BasePath)
+		}
+	default:
+		return fmt.Errorf("unknown storage %q, must be local or minio", dstCfg.Type)
+	}
+
+	dst, err := storage.NewStorage(dstCfg)
+	if err != nil {
+		return fmt.Errorf("destination storage: %v", err)
+	}
+
+	m := &storageMigration{
+		dst: dst,
+		opts: storage.MigrateOptions{
+			Verify: ctx.Bool("verify"),
+			Resume: ctx.Bool("resume"),
+		},
+		visited: make(map[string]bool),
+	}
+
+	log.Trace("Migrating %s (this may take a while)", kind)
+	switch kind {
+	case "attachments":
+		err = models.IterateAttachment(func(attach *models.Attachment) error {
+			return m.migrate(src, attach.RelativePath())
+		})
+	case "avatars":
+		err = models.IterateUserAvatars(func(u *models.User) error {
+			return m.migrate(src, u.CustomAvatarRelativePath())
+		})
+	case "repo-avatars":
+		err = models.IterateRepoAvatars(func(repo *models.Repository) error {
+			return m.migrate(src, repo.CustomAvatarRelativePath())
+		})
+	case "lfs":
+		err = models.IterateLFS(func(mo *models.LFSMetaObject) error {
+			return m.migrate(src, mo.RelativePath())
+		})
+	}
+
+	fmt.Printf("%d copied, %d already present, %d missing at the source, %d failed verification\n",
+		m.copied, m.skipped, m.missing, m.failed)
+	if err != nil {
+		return fmt.Errorf("%v, run again with --resume to continue", err)
+	}
+	if m.failed > 0 {
+		return fmt.Errorf("%d objects failed verification, run again with --resume --verify to copy them again", m.failed)
+	}
+	return nil
+}
@@ -17,18 +17,20 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/lfs/transfer"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/pprof"
 	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/setting"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/unknwon/com"
 	"github.com/urfave/cli"
 )
 
 const (
 	lfsAuthenticateVerb = "git-lfs-authenticate"
+	lfsTransferVerb     = "git-lfs-transfer"
 )
 
 // CmdServ represents the available serv sub-command.
@@ -63,6 +65,7 @@ var (
 		"git-upload-archive": models.AccessModeRead,
 		"git-receive-pack":   models.AccessModeWrite,
 		lfsAuthenticateVerb:  models.AccessModeNone,
+		lfsTransferVerb:      models.AccessModeNone,
 	}
 )
 
@@ -118,10 +121,14 @@ func runServ(c *cli.Context) error {
 	verb, args := parseCmd(cmd)
 
 	var lfsVerb string
-	if verb == lfsAuthenticateVerb {
+	if verb == lfsAuthenticateVerb || verb == lfsTransferVerb {
 		if !setting.LFS.StartServer {
 			fail("Unknown git command", "LFS authentication request over SSH denied, LFS support is disabled")
 		}
+		// the client falls back to git-lfs-authenticate and HTTP
+		if verb == lfsTransferVerb && !setting.LFS.AllowPureSSH {
+			fail("Unknown git command", "LFS transfer over SSH denied, LFS_ALLOW_PURE_SSH is disabled")
+		}
 
 		argsSplit := strings.Split(args, " ")
 		if len(argsSplit) >= 2 {
@@ -162,7 +169,7 @@ func runServ(c *cli.Context) error {
 		fail("Unknown git command", "Unknown git command %s", verb)
 	}
 
-	if verb == lfsAuthenticateVerb {
+	if verb == lfsAuthenticateVerb || verb == lfsTransferVerb {
 		if lfsVerb == "upload" {
 			requestedMode = models.AccessModeWrite
 		} else if lfsVerb == "download" {
@@ -198,25 +205,15 @@ func runServ(c *cli.Context) error {
 	if verb == lfsAuthenticateVerb {
 		url := fmt.Sprintf("%s%s/%s.git/info/lfs", setting.AppURL, url.PathEscape(results.OwnerName), url.PathEscape(results.RepoName))
 
-		now := time.Now()
-		claims := jwt.MapClaims{
-			"repo": results.RepoID,
-			"op":   lfsVerb,
-			"exp":  now.Add(setting.LFS.HTTPAuthExpiry).Unix(),
-			"nbf":  now.Unix(),
-			"user": results.UserID,
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-		// Sign and get the complete encoded token as a string using the secret
-		tokenString, err := token.SignedString(setting.LFS.JWTSecretBytes)
+		tokenString, err := lfs.NewToken(results.RepoID, results.UserID, lfsVerb)
 		if err != nil {
 			fail("Internal error", "Failed to sign JWT token: %v", err)
 		}
 
 		tokenAuthentication := &models.LFSTokenResponse{
-			Header: make(map[string]string),
-			Href:   url,
+			Header:    make(map[string]string),
+			Href:      url,
+			ExpiresIn: int64(setting.LFS.HTTPAuthExpiry / time.Second),
 		}
 		tokenAuthentication.Header["Authorization"] = fmt.Sprintf("Bearer %s", tokenString)
 
@@ -228,6 +225,18 @@ func runServ(c *cli.Context) error {
 		return nil
 	}
 
+	// LFS transfer over the SSH connection, the objects are exchanged with the LFS server of Gitea
+	if verb == lfsTransferVerb {
+		backend := transfer.NewHTTPBackend(results.OwnerName, results.RepoName, func() (string, error) {
+			tokenString, err := lfs.NewToken(results.RepoID, results.UserID, lfsVerb)
+			return "Bearer " + tokenString, err
+		})
+		if err := transfer.Serve(os.Stdin, os.Stdout, lfsVerb, backend); err != nil {
+			fail("Internal error", "LFS transfer failed: %v", err)
+		}
+		return nil
+	}
+
 	// Special handle for Windows.
 	if setting.IsWindows {
 		verb = strings.Replace(verb, "-", " ", 1)
@@ -43,3 +43,121 @@ func TestAPIGetBranch(t *testing.T) {
 		testAPIGetBranch(t, test.BranchName, test.Exists)
 	}
 }
+
+func TestAPIBranchProtection(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user2/repo1/branch_protections"
+
+	// unknown users and teams are refused
+	req := NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName:             "master",
+		EnablePush:             true,
+		EnablePushWhitelist:    true,
+		PushWhitelistUsernames: []string{"user2", "user-does-not-exist"},
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName:          "master",
+		EnablePush:          true,
+		EnablePushWhitelist: true,
+		PushWhitelistTeams:  []string{"team1"},
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName: "does-not-exist",
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName:              "master",
+		EnablePush:              true,
+		EnablePushWhitelist:     true,
+		PushWhitelistUsernames:  []string{"user2"},
+		PushWhitelistDeployKeys: true,
+		EnableStatusCheck:       true,
+		StatusCheckContexts:     []string{"ci"},
+		RequiredApprovals:       2,
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var bp api.BranchProtection
+	DecodeJSON(t, resp, &bp)
+	assert.EqualValues(t, "master", bp.BranchName)
+	assert.True(t, bp.EnablePushWhitelist)
+	assert.EqualValues(t, []string{"user2"}, bp.PushWhitelistUsernames)
+	assert.True(t, bp.PushWhitelistDeployKeys)
+	assert.EqualValues(t, []string{"ci"}, bp.StatusCheckContexts)
+	assert.EqualValues(t, 2, bp.RequiredApprovals)
+
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{BranchName: "master"})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	// a user without write access can't be whitelisted for pushes
+	disabled := false
+	approvals := int64(1)
+	req = NewRequestWithJSON(t, "PATCH", urlStr+"/master?token="+token, &api.EditBranchProtectionOption{
+		PushWhitelistUsernames: []string{"user2", "user4"},
+		EnableStatusCheck:      &disabled,
+		RequiredApprovals:      &approvals,
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &bp)
+	assert.EqualValues(t, []string{"user2"}, bp.PushWhitelistUsernames)
+	assert.True(t, bp.PushWhitelistDeployKeys)
+	assert.False(t, bp.EnableStatusCheck)
+	assert.Empty(t, bp.StatusCheckContexts)
+	assert.EqualValues(t, 1, bp.RequiredApprovals)
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/branches/master?token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var branch api.Branch
+	DecodeJSON(t, resp, &branch)
+	assert.True(t, branch.Protected)
+	if assert.NotNil(t, branch.Protection) {
+		assert.EqualValues(t, 1, branch.Protection.RequiredApprovals)
+	}
+
+	req = NewRequestf(t, "GET", "%s?token=%s", urlStr, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var bps []*api.BranchProtection
+	DecodeJSON(t, resp, &bps)
+	assert.Len(t, bps, 1)
+
+	// only the admins of the repository manage the protections
+	session4 := loginUser(t, "user4")
+	token4 := getTokenForLoggedInUser(t, session4)
+	req = NewRequestf(t, "GET", "%s/master?token=%s", urlStr, token4)
+	session4.MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestf(t, "DELETE", "%s/master?token=%s", urlStr, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequestf(t, "GET", "%s/master?token=%s", urlStr, token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPIOrgBranchProtection(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user3/repo3/branch_protections?token=" + token
+
+	req := NewRequestWithJSON(t, "POST", urlStr, &api.CreateBranchProtectionOption{
+		BranchName:           "master",
+		EnableMergeWhitelist: true,
+		MergeWhitelistTeams:  []string{"team-does-not-exist"},
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.CreateBranchProtectionOption{
+		BranchName:           "master",
+		EnableMergeWhitelist: true,
+		MergeWhitelistTeams:  []string{"team1"},
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var bp api.BranchProtection
+	DecodeJSON(t, resp, &bp)
+	assert.EqualValues(t, []string{"team1"}, bp.MergeWhitelistTeams)
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIIssueReactions(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	_ = issue.LoadRepo()
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: issue.Repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/" + owner.Name + "/" + issue.Repo.Name + "/issues/1/reactions?token=" + token
+
+	req := NewRequestWithJSON(t, "POST", urlStr, &api.EditReactionOption{Reaction: "wrong"})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.EditReactionOption{Reaction: "rocket"})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var reaction api.Reaction
+	DecodeJSON(t, resp, &reaction)
+	assert.EqualValues(t, "rocket", reaction.Reaction)
+	assert.EqualValues(t, owner.Name, reaction.User.UserName)
+
+	// reacting the same way again returns the existing reaction
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.EditReactionOption{Reaction: "rocket"})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.EditReactionOption{Reaction: "heart"})
+	session.MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequest(t, "GET", urlStr)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var reactions []*api.Reaction
+	DecodeJSON(t, resp, &reactions)
+	assert.Len(t, reactions, 2)
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/issues/1?token=%s", owner.Name, issue.Repo.Name, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var apiIssue api.Issue
+	DecodeJSON(t, resp, &apiIssue)
+	if assert.NotNil(t, apiIssue.Reactions) {
+		assert.EqualValues(t, 2, apiIssue.Reactions.TotalCount)
+		assert.EqualValues(t, map[string]int{"rocket": 1, "heart": 1}, apiIssue.Reactions.Counts)
+	}
+
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.EditReactionOption{Reaction: "rocket"})
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Reaction{IssueID: issue.ID, UserID: owner.ID, Type: "rocket"})
+}
+
+func TestAPICommentReactions(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	comment := models.AssertExistsAndLoadBean(t, &models.Comment{ID: 2}).(*models.Comment)
+	_ = comment.LoadIssue()
+	issue := comment.Issue
+	_ = issue.LoadRepo()
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: issue.Repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/" + owner.Name + "/" + issue.Repo.Name + "/issues/comments/2/reactions?token=" + token
+
+	req := NewRequestWithJSON(t, "POST", urlStr, &api.EditReactionOption{Reaction: "+1"})
+	session.MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequest(t, "GET", urlStr)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var reactions []*api.Reaction
+	DecodeJSON(t, resp, &reactions)
+	if assert.Len(t, reactions, 1) {
+		assert.EqualValues(t, "+1", reactions[0].Reaction)
+	}
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/issues/%d/comments?token=%s", owner.Name, issue.Repo.Name, issue.Index, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var comments []*api.Comment
+	DecodeJSON(t, resp, &comments)
+	for _, c := range comments {
+		if c.ID == comment.ID {
+			assert.EqualValues(t, 1, c.Reactions.Counts["+1"])
+		} else {
+			assert.EqualValues(t, 0, c.Reactions.TotalCount)
+		}
+	}
+
+	// the comment isn't found in another repository
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo2/issues/comments/2/reactions?token=%s", owner.Name, token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.EditReactionOption{Reaction: "+1"})
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Reaction{CommentID: comment.ID})
+}
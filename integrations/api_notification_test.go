@@ -0,0 +1,144 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPINotification(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	// the unread and pinned threads are listed by default
+	req := NewRequest(t, "GET", "/api/v1/notifications?token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var threads []*api.NotificationThread
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 3)
+	assert.EqualValues(t, "3", resp.Header().Get("X-Total-Count"))
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?all=true&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 4)
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?status-types=read&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	if assert.Len(t, threads, 1) {
+		assert.EqualValues(t, 2, threads[0].ID)
+		assert.False(t, threads[0].Unread)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?since=2000-01-02T00:00:00Z&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 0)
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?status-types=unknown&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequest(t, "GET", "/api/v1/notifications?since=yesterday&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// the threads of a repository don't include the ones of the other repositories
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/notifications?all=true&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 3)
+	for _, thread := range threads {
+		assert.EqualValues(t, "user2/repo1", thread.Repository.FullName)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo2/notifications?all=true&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	if assert.Len(t, threads, 1) {
+		assert.EqualValues(t, 5, threads[0].ID)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/notifications/new?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var count api.NotificationCount
+	DecodeJSON(t, resp, &count)
+	assert.EqualValues(t, 1, count.New)
+
+	// a thread links to its subject
+	req = NewRequest(t, "GET", "/api/v1/notifications/threads/4?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var thread api.NotificationThread
+	DecodeJSON(t, resp, &thread)
+	assert.True(t, thread.Unread)
+	assert.EqualValues(t, "user2/repo1", thread.Repository.FullName)
+	assert.EqualValues(t, api.NotificationSubjectPull, thread.Subject.Type)
+	assert.EqualValues(t, setting.AppURL+"api/v1/repos/user2/repo1/pulls/2", thread.Subject.URL)
+	assert.EqualValues(t, setting.AppURL+"user2/repo1/pulls/2", thread.Subject.HTMLURL)
+
+	req = NewRequest(t, "GET", thread.Subject.URL[len(setting.AppURL)-1:]+"?token="+token)
+	session.MakeRequest(t, req, http.StatusOK)
+
+	// the threads of other users aren't found
+	req = NewRequest(t, "GET", "/api/v1/notifications/threads/1?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/1?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 1, Status: models.NotificationStatusUnread})
+
+	// a thread is marked as read or pinned
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/4?token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 4, Status: models.NotificationStatusRead})
+
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/4?to-status=pinned&token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	req = NewRequest(t, "GET", "/api/v1/notifications/threads/4?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &thread)
+	assert.False(t, thread.Unread)
+	assert.True(t, thread.Pinned)
+
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/4?to-status=archived&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// the pinned threads are marked as read in bulk, only the ones updated before the last read
+	req = NewRequest(t, "PUT", "/api/v1/notifications?status-types=pinned&last_read_at=1999-12-31T00:00:00Z&token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 3, Status: models.NotificationStatusPinned})
+
+	req = NewRequest(t, "PUT", "/api/v1/repos/user2/repo1/notifications?status-types=pinned&token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 3, Status: models.NotificationStatusRead})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 4, Status: models.NotificationStatusRead})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 5, Status: models.NotificationStatusPinned})
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	if assert.Len(t, threads, 1) {
+		assert.EqualValues(t, 5, threads[0].ID)
+	}
+
+	// all the threads are marked as unread
+	req = NewRequest(t, "PUT", "/api/v1/notifications?all=true&to-status=unread&token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	req = NewRequest(t, "GET", "/api/v1/notifications/new?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &count)
+	assert.EqualValues(t, 4, count.New)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 1, Status: models.NotificationStatusUnread})
+
+	// a token is required
+	req = NewRequest(t, "GET", "/api/v1/notifications")
+	MakeRequest(t, req, http.StatusUnauthorized)
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIPullReview(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		posterSession := loginUser(t, "user4")
+		testRepoFork(t, posterSession, "user2", "repo1", "user4", "repo1")
+		testEditFile(t, posterSession, "user4", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+		resp := testPullCreate(t, posterSession, "user4", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+		pullURL := fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%s", elem[4])
+
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		posterToken := getTokenForLoggedInUser(t, posterSession)
+
+		// the poster can't request changes on their own pull request
+		req := NewRequestWithJSON(t, "POST", pullURL+"/reviews?token="+posterToken, &api.CreatePullReviewOptions{
+			Event: api.ReviewStateRequestChanges,
+			Body:  "no",
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		// a comment needs a line
+		req = NewRequestWithJSON(t, "POST", pullURL+"/reviews?token="+token, &api.CreatePullReviewOptions{
+			Event:    api.ReviewStateRequestChanges,
+			Comments: []api.CreatePullReviewComment{{Path: "README.md", Body: "typo"}},
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", pullURL+"/reviews?token="+token, &api.CreatePullReviewOptions{
+			Event:    api.ReviewStateRequestChanges,
+			Body:     "please fix",
+			Comments: []api.CreatePullReviewComment{{Path: "README.md", Body: "typo", NewLineNum: 1}},
+		})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var review api.PullReview
+		DecodeJSON(t, resp, &review)
+		assert.EqualValues(t, api.ReviewStateRequestChanges, review.State)
+		assert.EqualValues(t, "please fix", review.Body)
+		assert.EqualValues(t, "user2", review.Reviewer.UserName)
+		assert.EqualValues(t, 1, review.CodeCommentsCount)
+		assert.NotEmpty(t, review.CommitID)
+		assert.False(t, review.Stale)
+
+		req = NewRequestf(t, "GET", "%s/reviews/%d/comments", pullURL, review.ID)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var comments []*api.PullReviewComment
+		DecodeJSON(t, resp, &comments)
+		if assert.Len(t, comments, 1) {
+			assert.EqualValues(t, "typo", comments[0].Body)
+			assert.EqualValues(t, "README.md", comments[0].Path)
+			assert.EqualValues(t, 1, comments[0].LineNum)
+			assert.EqualValues(t, review.ID, comments[0].ReviewID)
+		}
+
+		// a pending review is only seen by its reviewer
+		req = NewRequestWithJSON(t, "POST", pullURL+"/reviews?token="+token, &api.CreatePullReviewOptions{Body: "pending"})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var pending api.PullReview
+		DecodeJSON(t, resp, &pending)
+		assert.EqualValues(t, api.ReviewStatePending, pending.State)
+
+		req = NewRequestWithJSON(t, "POST", pullURL+"/reviews?token="+token, &api.CreatePullReviewOptions{Body: "pending"})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestf(t, "GET", "%s/reviews/%d?token=%s", pullURL, pending.ID, posterToken)
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestf(t, "GET", "%s/reviews?token=%s", pullURL, token)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var reviews []*api.PullReview
+		DecodeJSON(t, resp, &reviews)
+		assert.Len(t, reviews, 2)
+
+		req = NewRequestf(t, "DELETE", "%s/reviews/%d?token=%s", pullURL, pending.ID, token)
+		session.MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.Review{ID: pending.ID})
+
+		// the rejection is dismissed by a writer of the pull requests
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/reviews/%d/dismissals?token=%s", pullURL, review.ID, posterToken),
+			&api.DismissPullReviewOptions{Message: "fixed"})
+		session.MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/reviews/%d/dismissals?token=%s", pullURL, review.ID, token),
+			&api.DismissPullReviewOptions{Message: "fixed"})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &review)
+		assert.True(t, review.Dismissed)
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/reviews/%d/dismissals?token=%s", pullURL, review.ID, token),
+			&api.DismissPullReviewOptions{})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		// the poster requests reviews
+		req = NewRequestWithJSON(t, "POST", pullURL+"/requested_reviewers?token="+posterToken, &api.PullReviewRequestOptions{
+			Reviewers: []string{"user4"},
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", pullURL+"/requested_reviewers?token="+posterToken, &api.PullReviewRequestOptions{
+			Reviewers: []string{"user-does-not-exist"},
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", pullURL+"/requested_reviewers?token="+posterToken, &api.PullReviewRequestOptions{
+			Reviewers: []string{"user5"},
+		})
+		resp = session.MakeRequest(t, req, http.StatusCreated)
+		DecodeJSON(t, resp, &reviews)
+		if assert.Len(t, reviews, 1) {
+			assert.EqualValues(t, api.ReviewStateRequestReview, reviews[0].State)
+			assert.EqualValues(t, "user5", reviews[0].Reviewer.UserName)
+		}
+
+		req = NewRequestWithJSON(t, "DELETE", pullURL+"/requested_reviewers?token="+posterToken, &api.PullReviewRequestOptions{
+			Reviewers: []string{"user5"},
+		})
+		session.MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.Review{ReviewerID: 5, Type: models.ReviewTypeRequest})
+	})
+}
@@ -5,8 +5,10 @@
 package integrations
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"code.gitea.io/gitea/models"
@@ -93,3 +95,132 @@ func TestAPICreatePullSuccess2(t *testing.T) {
 
 	session.MakeRequest(t, req, 201)
 }
+
+func TestAPIPullCompetingBinaryFiles(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		ctx := NewAPITestContext(t, "user2", "repo1")
+
+		// a PNG header makes git consider the files binary
+		png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+		for _, kase := range []struct {
+			Branch   string
+			TreePath string
+		}{
+			{"pages-alice", "pages/page_12.png"},
+			{"pages-bob", "pages/page_12.png"},
+			{"pages-carol", "pages/Page_12.png"},
+			{"pages-dave", "pages/page_13.png"},
+		} {
+			req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/contents/%s?token=%s", kase.TreePath, token), &api.CreateFileOptions{
+				FileOptions: api.FileOptions{
+					BranchName:    "master",
+					NewBranchName: kase.Branch,
+					Message:       "Add " + kase.TreePath,
+				},
+				Content: png,
+			})
+			session.MakeRequest(t, req, http.StatusCreated)
+		}
+
+		alice, err := doAPICreatePullRequest(ctx, "user2", "repo1", "master", "pages-alice")(t)
+		assert.NoError(t, err)
+		bob, err := doAPICreatePullRequest(ctx, "user2", "repo1", "master", "pages-bob")(t)
+		assert.NoError(t, err)
+		carol, err := doAPICreatePullRequest(ctx, "user2", "repo1", "master", "pages-carol")(t)
+		assert.NoError(t, err)
+		dave, err := doAPICreatePullRequest(ctx, "user2", "repo1", "master", "pages-dave")(t)
+		assert.NoError(t, err)
+
+		req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/%d/competing", alice.Index)
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var competing []*api.CompetingPullRequest
+		DecodeJSON(t, resp, &competing)
+		if assert.Len(t, competing, 2) {
+			assert.EqualValues(t, bob.Index, competing[0].PullRequest.Index)
+			assert.EqualValues(t, []*api.BinaryCollision{{Type: "same_path", Path: "pages/page_12.png", OtherPath: "pages/page_12.png"}}, competing[0].Collisions)
+			assert.EqualValues(t, carol.Index, competing[1].PullRequest.Index)
+			assert.EqualValues(t, []*api.BinaryCollision{{Type: "name", Path: "pages/page_12.png", OtherPath: "pages/Page_12.png"}}, competing[1].Collisions)
+		}
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/%d/competing", dave.Index)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &competing)
+		assert.Len(t, competing, 0)
+
+		// Test a file added with the name of a file of the base branch differing by case
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/contents/Readme.md?token=%s", token), &api.CreateFileOptions{
+			FileOptions: api.FileOptions{
+				BranchName:    "master",
+				NewBranchName: "readme-erin",
+				Message:       "Add Readme.md",
+			},
+			Content: base64.StdEncoding.EncodeToString([]byte("# Readme")),
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+		erin, err := doAPICreatePullRequest(ctx, "user2", "repo1", "master", "readme-erin")(t)
+		assert.NoError(t, err)
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/%d/competing", erin.Index)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &competing)
+		if assert.Len(t, competing, 1) {
+			assert.Nil(t, competing[0].PullRequest)
+			assert.EqualValues(t, []*api.BinaryCollision{{Type: "name", Path: "Readme.md", OtherPath: "README.md"}}, competing[0].Collisions)
+		}
+
+		req = NewRequestf(t, "GET", "/user2/repo1/pulls/%d", erin.Index)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, "/user2/repo1/src/branch/master", htmlDoc.doc.Find(".competing-pulls ~ .item a").AttrOr("href", ""))
+
+		// Test the directories of the added files are compared by name too
+		for _, kase := range []struct {
+			Branch   string
+			TreePath string
+		}{
+			{"", "docs/Guide.md"},
+			{"docs-frank", "Docs/guide.md"},
+		} {
+			req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/contents/%s?token=%s", kase.TreePath, token), &api.CreateFileOptions{
+				FileOptions: api.FileOptions{
+					BranchName:    "master",
+					NewBranchName: kase.Branch,
+					Message:       "Add " + kase.TreePath,
+				},
+				Content: base64.StdEncoding.EncodeToString([]byte("# Guide")),
+			})
+			session.MakeRequest(t, req, http.StatusCreated)
+		}
+		frank, err := doAPICreatePullRequest(ctx, "user2", "repo1", "master", "docs-frank")(t)
+		assert.NoError(t, err)
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/%d/competing", frank.Index)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &competing)
+		if assert.Len(t, competing, 1) {
+			assert.EqualValues(t, []*api.BinaryCollision{{Type: "name", Path: "Docs/guide.md", OtherPath: "docs/Guide.md"}}, competing[0].Collisions)
+		}
+
+		// Test the pull request view lists the competing pull requests
+		req = NewRequestf(t, "GET", "/user2/repo1/pulls/%d", carol.Index)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 2, htmlDoc.doc.Find(".competing-pulls ~ .item a").Length())
+
+		// Test a closed pull request doesn't compete anymore
+		closed := "closed"
+		req = NewRequestWithJSON(t, http.MethodPatch, fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d?token=%s", bob.Index, token), &api.EditPullRequestOption{
+			State: &closed,
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/%d/competing", alice.Index)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &competing)
+		if assert.Len(t, competing, 1) {
+			assert.EqualValues(t, carol.Index, competing[0].PullRequest.Index)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoImportAssets(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)               // owner of repo1
+		repo10 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 10}).(*models.Repository) // public repo of user12
+
+		token := getTokenForLoggedInUser(t, loginUser(t, user2.Name))
+		session := emptyTestSession(t)
+		importURL := fmt.Sprintf("/api/v1/repos/%s/repo1/assets/import?token=%s", user2.Name, token)
+
+		// Test import a file of another repository
+		req := NewRequestWithJSON(t, "POST", importURL, &api.ImportAssetsOption{
+			SourceOwner: "user12",
+			SourceRepo:  repo10.Name,
+			Paths:       []string{"README.md"},
+			TreePath:    "borrowed",
+		})
+		resp := session.MakeRequest(t, req, http.StatusCreated)
+		var usages []*api.AssetUsage
+		DecodeJSON(t, resp, &usages)
+		if assert.Len(t, usages, 1) {
+			assert.Equal(t, "borrowed/README.md", usages[0].Path)
+			assert.Equal(t, "README.md", usages[0].SourcePath)
+			assert.Equal(t, "user12/repo10", usages[0].SourceRepository.FullName)
+			assert.Len(t, usages[0].SourceCommitID, 40)
+			assert.Equal(t, user2.ID, usages[0].Importer.ID)
+		}
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/contents/borrowed/README.md", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var contents *api.ContentsResponse
+		DecodeJSON(t, resp, &contents)
+		// the blob is copied as is
+		assert.Equal(t, "4b4851ad51df6a7d9f25c979345979eaeb5b349f", contents.SHA)
+
+		// Test an import never overwrites a file
+		req = NewRequestWithJSON(t, "POST", importURL, &api.ImportAssetsOption{
+			SourceOwner: "user12",
+			SourceRepo:  repo10.Name,
+			Paths:       []string{"README.md"},
+			TreePath:    "borrowed",
+		})
+		session.MakeRequest(t, req, http.StatusConflict)
+
+		// Test import a missing file
+		req = NewRequestWithJSON(t, "POST", importURL, &api.ImportAssetsOption{
+			SourceOwner: "user12",
+			SourceRepo:  repo10.Name,
+			Paths:       []string{"missing.png"},
+		})
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		// Test import from a repository the doer can not read
+		req = NewRequestWithJSON(t, "POST", importURL, &api.ImportAssetsOption{
+			SourceOwner: "user3",
+			SourceRepo:  "repo5",
+			Paths:       []string{"README.md"},
+		})
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		// Test the usage is listed on both sides, private repositories are hidden from anonymous users
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/upstream", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &usages)
+		assert.Len(t, usages, 2)
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/upstream?token=%s", user2.Name, token)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &usages)
+		assert.Len(t, usages, 3)
+
+		req = NewRequest(t, "GET", "/api/v1/repos/user12/repo10/assets/downstream")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &usages)
+		if assert.Len(t, usages, 2) {
+			assert.Equal(t, "borrowed/README.md", usages[0].Path)
+			assert.Equal(t, "user2/repo1", usages[0].Repository.FullName)
+		}
+
+		// Test the source repository is notified
+		models.AssertExistsAndLoadBean(t, &models.Action{
+			OpType:    models.ActionImportAssets,
+			ActUserID: user2.ID,
+			RepoID:    repo10.ID,
+		})
+
+		// Test the file view shows where the file was borrowed from
+		req = NewRequestf(t, "GET", "/%s/repo1/src/branch/master/borrowed/README.md", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "/user12/repo10/src/commit/"+usages[0].SourceCommitID+"/README.md")
+	})
+}
+
+func TestAPIRepoAssetTags(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User) // owner of repo1 and of org3
+
+		token := getTokenForLoggedInUser(t, loginUser(t, user2.Name))
+		session := emptyTestSession(t)
+		tagsURL := fmt.Sprintf("/api/v1/repos/%s/repo1/assets/tags/README.md?token=%s", user2.Name, token)
+
+		// Test replace the tags of a file, they are sanitized
+		req := NewRequestWithJSON(t, "PUT", tagsURL, &api.SetAssetTagsOption{
+			Tags: []string{"Character:Hero", " palette ", "palette"},
+		})
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var tags *api.AssetTags
+		DecodeJSON(t, resp, &tags)
+		assert.Equal(t, "master", tags.Branch)
+		assert.Equal(t, "README.md", tags.Path)
+		assert.EqualValues(t, []string{"character:hero", "palette"}, tags.Tags)
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/tags/README.md", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &tags)
+		assert.EqualValues(t, []string{"character:hero", "palette"}, tags.Tags)
+
+		// Test invalid tags, a missing branch and a missing file
+		req = NewRequestWithJSON(t, "PUT", tagsURL, &api.SetAssetTagsOption{Tags: []string{"no spaces"}})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+		req = NewRequestWithJSON(t, "PUT", tagsURL, &api.SetAssetTagsOption{Branch: "missing", Tags: []string{"hero"}})
+		session.MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("/api/v1/repos/%s/repo1/assets/tags/missing.png?token=%s", user2.Name, token), &api.SetAssetTagsOption{Tags: []string{"hero"}})
+		session.MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/tags/README.md?branch=missing", user2.Name)
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		// Test tagging requires write access
+		req = NewRequestWithJSON(t, "PUT", "/api/v1/repos/user12/repo10/assets/tags/README.md?token="+token, &api.SetAssetTagsOption{Tags: []string{"hero"}})
+		session.MakeRequest(t, req, http.StatusForbidden)
+
+		// Test search the library of a repository
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/library?tags=character:hero&type=document", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "1", resp.Header().Get("X-Total-Count"))
+		var assets []*api.Asset
+		DecodeJSON(t, resp, &assets)
+		if assert.Len(t, assets, 1) {
+			assert.Equal(t, "README.md", assets[0].Path)
+			assert.Equal(t, "master", assets[0].Branch)
+			assert.Equal(t, "document", assets[0].Type)
+			assert.Equal(t, "user2/repo1", assets[0].Repository.FullName)
+			assert.EqualValues(t, []string{"character:hero", "palette"}, assets[0].Tags)
+		}
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/library?q=readme&type=image", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &assets)
+		assert.Len(t, assets, 0)
+
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/assets/library?since=yesterday", user2.Name)
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		// Test the library of an organization only lists the repositories the doer can read
+		req = NewRequestWithJSON(t, "PUT", "/api/v1/repos/user3/repo3/assets/tags/doc?token="+token, &api.SetAssetTagsOption{Tags: []string{"documentation"}})
+		session.MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", "/api/v1/orgs/user3/assets/library?tags=documentation&token="+token)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &assets)
+		if assert.Len(t, assets, 1) {
+			assert.Equal(t, "doc", assets[0].Path)
+			assert.True(t, assets[0].IsDir)
+			assert.Equal(t, "folder", assets[0].Type)
+		}
+
+		req = NewRequest(t, "GET", "/api/v1/orgs/user3/assets/library?tags=documentation")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &assets)
+		assert.Len(t, assets, 0)
+
+		// Test the web pages
+		req = NewRequestf(t, "GET", "/%s/repo1/assets?tags=palette", user2.Name)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "/user2/repo1/src/branch/master/README.md")
+		req = NewRequest(t, "GET", "/org/user3/assets")
+		session.MakeRequest(t, req, http.StatusOK)
+	})
+}
+
+func TestAPIRepoAssetsRequireCodeAccess(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// user4 reads the issues but not the code of repo3 through team1
+	team := models.AssertExistsAndLoadBean(t, &models.Team{ID: 2}).(*models.Team)
+	assert.NoError(t, models.UpdateTeamUnits(team, []models.TeamUnit{
+		{OrgID: team.OrgID, TeamID: team.ID, Type: models.UnitTypeIssues},
+	}))
+
+	session := loginUser(t, "user4")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user3/repo3/issues?token="+token)
+	session.MakeRequest(t, req, http.StatusOK)
+
+	for _, path := range []string{"upstream", "downstream", "tags/README.md", "library"} {
+		req = NewRequestf(t, "GET", "/api/v1/repos/user3/repo3/assets/%s?token=%s", path, token)
+		session.MakeRequest(t, req, http.StatusForbidden)
+	}
+}
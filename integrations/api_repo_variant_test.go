@@ -0,0 +1,91 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoVariants(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User) // owner of repo1
+		user4 := models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User) // reader of repo1
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		token2 := getTokenForLoggedInUser(t, loginUser(t, user2.Name))
+		token4 := getTokenForLoggedInUser(t, loginUser(t, user4.Name))
+		session := emptyTestSession(t)
+		baseURL := fmt.Sprintf("/api/v1/repos/%s/%s/variants", user2.Name, repo1.Name)
+
+		// Test list variants
+		req := NewRequest(t, "GET", baseURL)
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var variants []*api.Variant
+		DecodeJSON(t, resp, &variants)
+		assert.Len(t, variants, 1)
+		assert.Equal(t, "develop", variants[0].Branch)
+		assert.Equal(t, 1, variants[0].Stars)
+		assert.NotNil(t, variants[0].Activity)
+
+		// Test create a variant on a new branch
+		req = NewRequestWithJSON(t, "POST", baseURL+"?token="+token2, &api.CreateVariantOption{
+			Branch:     "bright-timeline",
+			BaseBranch: "master",
+			Title:      "Bright timeline",
+		})
+		resp = session.MakeRequest(t, req, http.StatusCreated)
+		var variant *api.Variant
+		DecodeJSON(t, resp, &variant)
+		assert.Equal(t, "bright-timeline", variant.Branch)
+		assert.Equal(t, user2.ID, variant.Owner.ID)
+		assert.Equal(t, 0, variant.CommitsAhead)
+		req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/branches/%s", user2.Name, repo1.Name, "bright-timeline")
+		session.MakeRequest(t, req, http.StatusOK)
+
+		// Test create a variant on a branch which already has one
+		req = NewRequestWithJSON(t, "POST", baseURL+"?token="+token2, &api.CreateVariantOption{
+			Branch: "develop",
+			Title:  "Another dark timeline",
+		})
+		session.MakeRequest(t, req, http.StatusConflict)
+
+		// Test a reader can neither create nor edit a variant
+		req = NewRequestWithJSON(t, "POST", baseURL+"?token="+token4, &api.CreateVariantOption{
+			Branch: "reader-timeline",
+			Title:  "Reader timeline",
+		})
+		session.MakeRequest(t, req, http.StatusForbidden)
+		title := "Renamed"
+		req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("%s/%d?token=%s", baseURL, variant.ID, token4), &api.EditVariantOption{Title: &title})
+		session.MakeRequest(t, req, http.StatusForbidden)
+
+		// Test a reader can back a variant
+		req = NewRequestf(t, "PUT", "%s/%d/star?token=%s", baseURL, variant.ID, token4)
+		session.MakeRequest(t, req, http.StatusNoContent)
+		req = NewRequestf(t, "GET", "%s/%d", baseURL, variant.ID)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &variant)
+		assert.Equal(t, 1, variant.Stars)
+
+		// Test the owner can edit and delete the variant
+		req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("%s/%d?token=%s", baseURL, variant.ID, token2), &api.EditVariantOption{Title: &title})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &variant)
+		assert.Equal(t, "Renamed", variant.Title)
+
+		req = NewRequestf(t, "DELETE", "%s/%d?token=%s", baseURL, variant.ID, token2)
+		session.MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.Variant{ID: variant.ID})
+		models.AssertNotExistsBean(t, &models.VariantStar{VariantID: variant.ID})
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIWikiRead(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/pages")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var pages []*api.WikiPageMetaData
+	DecodeJSON(t, resp, &pages)
+	assert.Equal(t, "3", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, pages, 3) {
+		assert.EqualValues(t, "Home", pages[0].Title)
+		assert.EqualValues(t, "Page With Image", pages[1].Title)
+		assert.EqualValues(t, "Page-With-Image", pages[1].SubURL)
+		assert.NotNil(t, pages[0].LastCommit)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/pages?page=2&per_page=2")
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &pages)
+	if assert.Len(t, pages, 1) {
+		assert.EqualValues(t, "Page With Spaced Name", pages[0].Title)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/page/Home")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var page api.WikiPage
+	DecodeJSON(t, resp, &page)
+	assert.EqualValues(t, "Home", page.Title)
+	assert.EqualValues(t, "# Home page\n\nThis is the home page!\n", page.Content)
+	assert.Contains(t, page.HTML, "This is the home page!")
+	assert.EqualValues(t, 1, page.CommitCount)
+	assert.EqualValues(t, "Add Home.md", strings.TrimSpace(page.LastCommit.Message))
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/page/Unknown")
+	MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/revisions/Home")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var revisions api.WikiCommitList
+	DecodeJSON(t, resp, &revisions)
+	assert.EqualValues(t, 1, revisions.Count)
+	assert.Len(t, revisions.WikiCommits, 1)
+
+	// a repository without wiki has no page
+	req = NewRequest(t, "GET", "/api/v1/repos/user5/repo4/wiki/pages")
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &pages)
+	assert.Len(t, pages, 0)
+}
+
+func TestAPIWikiWrite(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		readerToken := getTokenForLoggedInUser(t, loginUser(t, "user4"))
+		wikiURL := "/api/v1/repos/user2/repo1/wiki"
+
+		req := NewRequestWithJSON(t, "POST", wikiURL+"/new?token="+readerToken, &api.CreateWikiPageOptions{Title: "Lore"})
+		session.MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithJSON(t, "POST", wikiURL+"/new?token="+token, &api.CreateWikiPageOptions{Content: "no title"})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", wikiURL+"/new?token="+token, &api.CreateWikiPageOptions{Title: "_pages"})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", wikiURL+"/new?token="+token, &api.CreateWikiPageOptions{Title: "Home"})
+		session.MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequestWithJSON(t, "POST", wikiURL+"/new?token="+token, &api.CreateWikiPageOptions{
+			Title:   "Lore",
+			Content: "# Lore\n",
+		})
+		resp := session.MakeRequest(t, req, http.StatusCreated)
+		var page api.WikiPage
+		DecodeJSON(t, resp, &page)
+		assert.EqualValues(t, "Lore", page.Title)
+		assert.EqualValues(t, "# Lore\n", page.Content)
+		assert.EqualValues(t, "Add 'Lore'", strings.TrimSpace(page.LastCommit.Message))
+
+		req = NewRequestWithJSON(t, "PATCH", wikiURL+"/page/Lore?token="+token, &api.CreateWikiPageOptions{
+			Content: "# Lore\n\nOnce upon a time\n",
+			Message: "Tell the story",
+		})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &page)
+		assert.EqualValues(t, "# Lore\n\nOnce upon a time\n", page.Content)
+		assert.EqualValues(t, 2, page.CommitCount)
+
+		req = NewRequestf(t, "GET", "%s/revisions/Lore", wikiURL)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var revisions api.WikiCommitList
+		DecodeJSON(t, resp, &revisions)
+		assert.EqualValues(t, 2, revisions.Count)
+		if assert.Len(t, revisions.WikiCommits, 2) {
+			assert.EqualValues(t, "Tell the story", strings.TrimSpace(revisions.WikiCommits[0].Message))
+			assert.EqualValues(t, "Add 'Lore'", strings.TrimSpace(revisions.WikiCommits[1].Message))
+		}
+
+		// renaming keeps the content when none is given
+		req = NewRequestWithJSON(t, "PATCH", wikiURL+"/page/Lore?token="+token, &api.CreateWikiPageOptions{Title: "Home"})
+		session.MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequestWithJSON(t, "PATCH", wikiURL+"/page/Lore?token="+token, &api.CreateWikiPageOptions{Title: "World Lore"})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &page)
+		assert.EqualValues(t, "World Lore", page.Title)
+		assert.EqualValues(t, "World-Lore", page.SubURL)
+		assert.EqualValues(t, "# Lore\n\nOnce upon a time\n", page.Content)
+
+		req = NewRequestf(t, "GET", "%s/page/Lore", wikiURL)
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithJSON(t, "PATCH", wikiURL+"/page/Unknown?token="+token, &api.CreateWikiPageOptions{Content: "new"})
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestf(t, "DELETE", "%s/page/World-Lore?token=%s", wikiURL, readerToken)
+		session.MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestf(t, "DELETE", "%s/page/World-Lore?token=%s", wikiURL, token)
+		session.MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequestf(t, "DELETE", "%s/page/World-Lore?token=%s", wikiURL, token)
+		session.MakeRequest(t, req, http.StatusNotFound)
+	})
+}
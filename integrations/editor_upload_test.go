@@ -0,0 +1,130 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newUploadRequest(t *testing.T, urlStr, csrf string, fields map[string]string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		assert.NoError(t, writer.WriteField(key, value))
+	}
+	part, err := writer.CreateFormFile("file", "upload")
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	req := NewRequestWithBody(t, "POST", urlStr, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Csrf-Token", csrf)
+	return req
+}
+
+func TestUploadFolderInChunks(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		defer func(extensions []string) {
+			setting.Repository.Upload.LFSAutoTrackExtensions = extensions
+		}(setting.Repository.Upload.LFSAutoTrackExtensions)
+		setting.Repository.Upload.LFSAutoTrackExtensions = []string{".psd"}
+
+		session := loginUser(t, "user2")
+		csrf := GetCSRF(t, session, "/user2/repo1/_upload/master/")
+
+		// a file of the folder uploaded in one request keeps its relative path
+		req := newUploadRequest(t, "/user2/repo1/upload-file", csrf, map[string]string{
+			"full_path": "art/notes.txt",
+		}, []byte("notes"))
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var notes struct{ UUID string }
+		DecodeJSON(t, resp, &notes)
+
+		// a big file of the folder uploaded in chunks, a chunk is sent again after an interruption
+		content := []byte("8BPS a Photoshop document")
+		fields := map[string]string{
+			"full_path":  "art/cover art.PSD",
+			"total_size": strconv.Itoa(len(content)),
+		}
+		req = newUploadRequest(t, "/user2/repo1/upload-file/chunk", csrf, fields, content[:10])
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var chunks struct {
+			UUID string
+			Size int64
+		}
+		DecodeJSON(t, resp, &chunks)
+		assert.NotEmpty(t, chunks.UUID)
+		assert.EqualValues(t, 10, chunks.Size)
+		fields["uuid"] = chunks.UUID
+
+		// a chunk beyond the received size is refused with the size to resume from
+		fields["offset"] = "15"
+		req = newUploadRequest(t, "/user2/repo1/upload-file/chunk", csrf, fields, content[15:])
+		resp = session.MakeRequest(t, req, http.StatusConflict)
+		DecodeJSON(t, resp, &chunks)
+		assert.EqualValues(t, 10, chunks.Size)
+
+		req = NewRequest(t, "GET", "/user2/repo1/upload-file/chunk?uuid="+chunks.UUID)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &chunks)
+		assert.EqualValues(t, 10, chunks.Size)
+
+		// the upload can only be resumed by its uploader
+		req = NewRequest(t, "GET", "/user2/repo1/upload-file/chunk?uuid="+chunks.UUID)
+		loginUser(t, "user1").MakeRequest(t, req, http.StatusNotFound)
+
+		fields["offset"] = "5"
+		req = newUploadRequest(t, "/user2/repo1/upload-file/chunk", csrf, fields, content[5:])
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &chunks)
+		assert.EqualValues(t, len(content), chunks.Size)
+
+		upload, err := models.GetUploadByUUID(chunks.UUID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "art/cover art.PSD", upload.Name)
+
+		// an unknown upload can't be resumed
+		req = NewRequest(t, "GET", "/user2/repo1/upload-file/chunk?uuid=../../../etc")
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		// both files are committed with the PSD file stored in LFS and tracked in the same commit
+		values := url.Values{
+			"_csrf":         {csrf},
+			"tree_path":     {"uploads"},
+			"commit_choice": {"direct"},
+			"files":         {notes.UUID, chunks.UUID},
+		}
+		req = NewRequestWithBody(t, "POST", "/user2/repo1/_upload/master/", strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		session.MakeRequest(t, req, http.StatusFound)
+
+		req = NewRequest(t, "GET", "/user2/repo1/raw/branch/master/uploads/art/notes.txt")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "notes", resp.Body.String())
+
+		req = NewRequest(t, "GET", "/user2/repo1/raw/branch/master/.gitattributes")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "*.PSD filter=lfs diff=lfs merge=lfs -text\n", resp.Body.String())
+
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		oid, err := models.GenerateLFSOid(bytes.NewReader(content))
+		assert.NoError(t, err)
+		_, err = repo.GetLFSMetaObjectByOid(oid)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitPushLockedFile(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		ctx := NewAPITestContext(t, "user2", "repo-locked-file")
+		t.Run("CreateRepo", doAPICreateRepository(ctx, false))
+
+		dstPath, err := ioutil.TempDir("", ctx.Reponame)
+		assert.NoError(t, err)
+		defer os.RemoveAll(dstPath)
+		u.Path = ctx.GitPath()
+		u.User = url.UserPassword(ctx.Username, userPassword)
+		t.Run("Clone", doGitClone(dstPath, u))
+
+		// README.md is locked by user1
+		repo, err := models.GetRepositoryByOwnerAndName(ctx.Username, ctx.Reponame)
+		assert.NoError(t, err)
+		user1 := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+		lock, err := models.CreateLFSLock(&models.LFSLock{Repo: repo, Owner: user1, Path: "README.md"})
+		assert.NoError(t, err)
+
+		// a new branch without change to the locked file is accepted
+		t.Run("CreateBranch", doGitCreateBranch(dstPath, "feature"))
+		commitFile(t, dstPath, "notes.md", "notes")
+		t.Run("PushNewBranch", doGitPushTestRepository(dstPath, "origin", "feature"))
+
+		// the paths are case-sensitive
+		commitFile(t, dstPath, "readme.md", "another file")
+		t.Run("PushOtherCase", doGitPushTestRepository(dstPath, "origin", "feature"))
+
+		// the change is found even when the commit is already reachable from another ref
+		commitFile(t, dstPath, "README.md", "changed")
+		t.Run("PushTag", doGitPushTestRepository(dstPath, "origin", "HEAD:refs/tags/sneaky"))
+		t.Run("PushLockedFile", doGitPushTestRepositoryFail(dstPath, "origin", "feature"))
+		t.Run("PushLockedFileToNewBranch", doGitPushTestRepositoryFail(dstPath, "origin", "feature:refs/heads/other"))
+
+		// the change is accepted once the file is unlocked
+		_, err = models.DeleteLFSLockByID(lock.ID, user1, true)
+		assert.NoError(t, err)
+		t.Run("PushUnlockedFile", doGitPushTestRepository(dstPath, "origin", "feature"))
+	})
+}
+
+func commitFile(t *testing.T, repoPath, name, content string) {
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644))
+	assert.NoError(t, git.AddChanges(repoPath, false, name))
+	signature := git.Signature{
+		Email: "user2@example.com",
+		Name:  "User Two",
+		When:  time.Now(),
+	}
+	assert.NoError(t, git.CommitChanges(repoPath, git.CommitChangesOptions{
+		Committer: &signature,
+		Author:    &signature,
+		Message:   "Change " + name,
+	}))
+}
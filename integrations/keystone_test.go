@@ -0,0 +1,102 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func enableKeystoneUnit(t *testing.T, repo *models.Repository) {
+	_, err := repo.GetUnit(models.UnitTypeCode)
+	assert.NoError(t, err)
+	units := []models.RepoUnit{{
+		RepoID: repo.ID,
+		Type:   models.UnitTypeKeystone,
+		Config: new(models.UnitConfig),
+	}}
+	for _, unit := range repo.Units {
+		units = append(units, *unit)
+	}
+	assert.NoError(t, models.UpdateRepositoryUnits(repo, units))
+}
+
+func testEditKeystone(t *testing.T, session *TestSession, user, repo, commitChoice, newBranch, content string, expectedStatus int) *http.Response {
+	link := path.Join(user, repo, "keystone", "keystone", "_edit")
+	req := NewRequest(t, "GET", link)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	lastCommit, _ := htmlDoc.doc.Find("input[name=last_commit]").Attr("value")
+
+	req = NewRequestWithValues(t, "POST", link, map[string]string{
+		"_csrf":           htmlDoc.GetCSRF(),
+		"last_commit":     lastCommit,
+		"content":         content,
+		"commit_choice":   commitChoice,
+		"new_branch_name": newBranch,
+	})
+	return session.MakeRequest(t, req, expectedStatus).Result()
+}
+
+func TestKeystoneProposal(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		enableKeystoneUnit(t, repo)
+		assert.NoError(t, repofiles.InitKeystone(repo, doer))
+
+		session := loginUser(t, "user2")
+		resp := testEditKeystone(t, session, "user2", "repo1", "commit-to-new-branch", "keystone-proposal", "# Another story\n", http.StatusFound)
+		link := resp.Header.Get("Location")
+		assert.True(t, strings.HasPrefix(link, "/user2/repo1/pulls/"), link)
+
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{HeadBranch: "keystone-proposal"}).(*models.PullRequest)
+		assert.Equal(t, repofiles.KeystoneRefName, pr.BaseBranch)
+		assert.Contains(t, getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[0]), "# repo1 KEYSTONE")
+
+		testPullMerge(t, session, "user2", "repo1", path.Base(link), models.MergeStyleMerge)
+		assert.Equal(t, "# Another story\n", getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[0]))
+	})
+}
+
+func TestKeystoneProposalRequiresCodeWrite(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 3}).(*models.Repository)
+		doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		enableKeystoneUnit(t, repo)
+		assert.NoError(t, repofiles.InitKeystone(repo, doer))
+
+		// user5 may only write the keystone
+		team := &models.Team{
+			OrgID:     repo.OwnerID,
+			Name:      "keystone-writers",
+			Authorize: models.AccessModeWrite,
+			Units: []*models.TeamUnit{{
+				OrgID: repo.OwnerID,
+				Type:  models.UnitTypeKeystone,
+			}},
+		}
+		assert.NoError(t, models.NewTeam(team))
+		assert.NoError(t, models.AddTeamMember(team, 5))
+		assert.NoError(t, team.AddRepository(repo))
+
+		session := loginUser(t, "user5")
+		testEditKeystone(t, session, "user3", "repo3", "commit-to-new-branch", "keystone-proposal", "# Another story\n", http.StatusOK)
+		assert.False(t, git.IsBranchExist(repo.RepoPath(), "keystone-proposal"))
+		models.AssertNotExistsBean(t, &models.PullRequest{HeadBranch: "keystone-proposal"})
+
+		testEditKeystone(t, session, "user3", "repo3", "direct", "", "# Another story\n", http.StatusFound)
+		assert.Equal(t, "# Another story\n", getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[0]))
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/repofiles"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func readerPageFiles(doc *HTMLDoc) []string {
+	var files []string
+	doc.doc.Find("#reader .reader-page").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("data-src")
+		files = append(files, src[strings.LastIndex(src, "/")+1:])
+	})
+	return files
+}
+
+func TestRepoReader(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		for _, treePath := range []string{"chapter/10.png", "chapter/2.png", "chapter/1.png", "chapter/notes.txt"} {
+			_, err := repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+				OldBranch: repo1.DefaultBranch,
+				TreePath:  treePath,
+				Content:   "page",
+				IsNewFile: true,
+			})
+			assert.NoError(t, err)
+		}
+
+		session := loginUser(t, "user2")
+
+		// the folder can be read and its images are the pages in natural order
+		req := NewRequest(t, "GET", "/user2/repo1/src/branch/master/chapter")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		link, exists := NewHTMLParser(t, resp.Body).doc.Find(`#file-buttons a[href$="/reader/branch/master/chapter"]`).Attr("href")
+		assert.True(t, exists)
+
+		req = NewRequest(t, "GET", link)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, []string{"1.png", "2.png", "10.png"}, readerPageFiles(NewHTMLParser(t, resp.Body)))
+
+		// a manifest lists the pages
+		_, err := repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo1.DefaultBranch,
+			TreePath:  "chapter/" + repofiles.ReaderManifest,
+			Content:   "title: Chapter One\ndirection: rtl\npages:\n  - 10.png\n  - file: 1.png\n    wide: true\n  - missing.png\n  - ../README.md\n",
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+
+		req = NewRequest(t, "GET", "/user2/repo1/reader/branch/master/chapter")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		doc := NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, []string{"10.png", "1.png"}, readerPageFiles(doc))
+		assert.True(t, doc.doc.Find("#reader").HasClass("rtl"))
+		assert.True(t, doc.doc.Find("#page-2").HasClass("wide"))
+		assert.Contains(t, doc.doc.Find(".reader-menu h3").Text(), "Chapter One")
+		assert.EqualValues(t, 2, doc.doc.Find(".warning.message li").Length())
+
+		// the reader can be opened at a commit and from the manifest
+		permaLink := doc.doc.Find(".reader-menu .sha").Parent().AttrOr("href", "")
+		assert.Contains(t, permaLink, "/user2/repo1/reader/commit/")
+		req = NewRequest(t, "GET", permaLink)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, []string{"10.png", "1.png"}, readerPageFiles(NewHTMLParser(t, resp.Body)))
+
+		req = NewRequest(t, "GET", "/user2/repo1/src/branch/master/chapter/"+repofiles.ReaderManifest)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		link, exists = NewHTMLParser(t, resp.Body).doc.Find(`#file-buttons a[href*="/reader/"]`).Attr("href")
+		assert.True(t, exists)
+		req = NewRequest(t, "GET", link)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, []string{"10.png", "1.png"}, readerPageFiles(NewHTMLParser(t, resp.Body)))
+
+		// an invalid manifest is reported
+		_, err = repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo1.DefaultBranch,
+			TreePath:  "chapter/" + repofiles.ReaderManifest,
+			Content:   "direction: up\n",
+		})
+		assert.NoError(t, err)
+		req = NewRequest(t, "GET", "/user2/repo1/reader/branch/master/chapter")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, 1, NewHTMLParser(t, resp.Body).doc.Find(".negative.message").Length())
+
+		// only folders and manifests can be read
+		req = NewRequest(t, "GET", "/user2/repo1/reader/branch/master/chapter/1.png")
+		session.MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequest(t, "GET", "/user2/repo1/reader/branch/master/missing")
+		session.MakeRequest(t, req, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getKeystoneContent(t *testing.T, repo *models.Repository, ref string, page repofiles.KeystonePage) string {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	assert.NoError(t, err)
+	defer gitRepo.Close()
+	commitID, err := gitRepo.GetRefCommitID(ref)
+	assert.NoError(t, err)
+	commit, err := gitRepo.GetCommit(commitID)
+	assert.NoError(t, err)
+	data, err := repofiles.GetKeystonePageContent(commit, page)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestInitKeystone(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		assert.NoError(t, repofiles.InitKeystone(repo, doer))
+
+		gitRepo, err := git.OpenRepository(repo.RepoPath())
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		commit, err := repofiles.GetKeystoneCommit(gitRepo)
+		assert.NoError(t, err)
+		// the keystone does not share history with the code
+		assert.EqualValues(t, 0, commit.ParentCount())
+
+		// an existing keystone is kept
+		assert.NoError(t, repofiles.InitKeystone(repo, doer))
+		commitID, err := gitRepo.GetRefCommitID(repofiles.KeystoneRefName)
+		assert.NoError(t, err)
+		assert.Equal(t, commit.ID.String(), commitID)
+
+		// the keystone is not a branch
+		branches, err := gitRepo.GetBranches()
+		assert.NoError(t, err)
+		assert.NotContains(t, branches, "keystone")
+
+		assert.Contains(t, getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[0]), "# repo1 KEYSTONE")
+		assert.Contains(t, getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[1]), "# repo1 BLUEPRINT")
+	})
+}
+
+func TestUpdateKeystonePage(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		assert.NoError(t, repofiles.InitKeystone(repo, doer))
+
+		resp, err := repofiles.UpdateKeystonePage(repo, doer, repofiles.KeystonePages[1], &repofiles.UpdateKeystonePageOptions{
+			Content: "# Chapters\r\n",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "BLUEPRINT.md", resp.Content.Path)
+		assert.Equal(t, "Update BLUEPRINT.md\n", resp.Commit.Message)
+		assert.Equal(t, "# Chapters\n", getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[1]))
+
+		// a proposal leaves the keystone untouched
+		_, err = repofiles.UpdateKeystonePage(repo, doer, repofiles.KeystonePages[0], &repofiles.UpdateKeystonePageOptions{
+			NewBranch: "proposal",
+			Content:   "# Another story\n",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "# Another story\n", getKeystoneContent(t, repo, git.BranchPrefix+"proposal", repofiles.KeystonePages[0]))
+		assert.Contains(t, getKeystoneContent(t, repo, repofiles.KeystoneRefName, repofiles.KeystonePages[0]), "# repo1 KEYSTONE")
+
+		_, err = repofiles.UpdateKeystonePage(repo, doer, repofiles.KeystonePages[0], &repofiles.UpdateKeystonePageOptions{
+			NewBranch: "proposal",
+			Content:   "# Yet another story\n",
+		})
+		assert.True(t, models.IsErrBranchAlreadyExists(err))
+	})
+}
@@ -52,6 +52,8 @@ const (
 	ActionMirrorSyncDelete                         // 20
 	ActionApprovePullRequest                       // 21
 	ActionRejectPullRequest                        // 22
+	ActionCreateVariant                            // 23
+	ActionImportAssets                             // 24
 )
 
 // Action represents user operation type and other information to
@@ -0,0 +1,186 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+var assetTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9:._-]*$`)
+
+// AssetTag is a tag put on a file or a folder of a branch, like "character:hero" or "palette"
+type AssetTag struct {
+	ID         int64  `xorm:"pk autoincr"`
+	RepoID     int64  `xorm:"UNIQUE(s) NOT NULL"`
+	BranchName string `xorm:"UNIQUE(s) NOT NULL"`
+	TreePath   string `xorm:"UNIQUE(s) NOT NULL"`
+	Name       string `xorm:"UNIQUE(s) INDEX VARCHAR(50) NOT NULL"`
+	DoerID     int64
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// ErrInvalidAssetTags represents an error that some asset tags are invalid
+type ErrInvalidAssetTags struct {
+	Tags []string
+}
+
+// IsErrInvalidAssetTags checks if an error is an ErrInvalidAssetTags.
+func IsErrInvalidAssetTags(err error) bool {
+	_, ok := err.(ErrInvalidAssetTags)
+	return ok
+}
+
+func (err ErrInvalidAssetTags) Error() string {
+	return fmt.Sprintf("invalid asset tags [tags: %s]", strings.Join(err.Tags, ", "))
+}
+
+// ValidateAssetTag checks an asset tag by length and match pattern rules
+func ValidateAssetTag(tag string) bool {
+	return len(tag) <= 50 && assetTagPattern.MatchString(tag)
+}
+
+// SanitizeAndValidateAssetTags sanitizes and checks an array of asset tags
+func SanitizeAndValidateAssetTags(tags []string) (validTags []string, invalidTags []string) {
+	validTags = make([]string, 0)
+	mValidTags := make(map[string]struct{})
+	invalidTags = make([]string, 0)
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.ToLower(tag))
+		// ignore empty string
+		if len(tag) == 0 {
+			continue
+		}
+		// ignore same tag twice
+		if _, ok := mValidTags[tag]; ok {
+			continue
+		}
+		if ValidateAssetTag(tag) {
+			validTags = append(validTags, tag)
+			mValidTags[tag] = struct{}{}
+		} else {
+			invalidTags = append(invalidTags, tag)
+		}
+	}
+
+	return validTags, invalidTags
+}
+
+// GetAssetTags returns the names of the tags of a path of a branch, sorted by name
+func GetAssetTags(repoID int64, branchName, treePath string) ([]string, error) {
+	tags := make([]string, 0, 5)
+	return tags, x.Table("asset_tag").
+		Where("repo_id = ? AND branch_name = ? AND tree_path = ?", repoID, branchName, treePath).
+		Asc("name").
+		Cols("name").
+		Find(&tags)
+}
+
+// SetAssetTags replaces the tags of a path of a branch, the tags must be sanitized
+func SetAssetTags(repoID int64, branchName, treePath string, doerID int64, tags []string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("repo_id = ? AND branch_name = ? AND tree_path = ?", repoID, branchName, treePath).
+		Delete(new(AssetTag)); err != nil {
+		return err
+	}
+	for _, name := range tags {
+		if _, err := sess.Insert(&AssetTag{
+			RepoID:     repoID,
+			BranchName: branchName,
+			TreePath:   treePath,
+			Name:       name,
+			DoerID:     doerID,
+		}); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// TaggedAsset is a path of a branch with its tags
+type TaggedAsset struct {
+	RepoID     int64
+	BranchName string
+	TreePath   string
+	Tags       []string
+}
+
+// FindTaggedAssets returns the tagged paths of a repository, of all repositories if repoID is 0,
+// and of all branches if branchName is empty
+func FindTaggedAssets(repoID int64, branchName string) ([]*TaggedAsset, error) {
+	cond := builder.NewCond()
+	if repoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": repoID})
+	}
+	if branchName != "" {
+		cond = cond.And(builder.Eq{"branch_name": branchName})
+	}
+
+	tags := make([]*AssetTag, 0, 10)
+	if err := x.Where(cond).
+		Asc("repo_id", "branch_name", "tree_path", "name").
+		Find(&tags); err != nil {
+		return nil, err
+	}
+
+	assets := make([]*TaggedAsset, 0, len(tags))
+	var last *TaggedAsset
+	for _, tag := range tags {
+		if last == nil || last.RepoID != tag.RepoID || last.BranchName != tag.BranchName || last.TreePath != tag.TreePath {
+			last = &TaggedAsset{
+				RepoID:     tag.RepoID,
+				BranchName: tag.BranchName,
+				TreePath:   tag.TreePath,
+			}
+			assets = append(assets, last)
+		}
+		last.Tags = append(last.Tags, tag.Name)
+	}
+	return assets, nil
+}
+
+// AssetTagCount is an asset tag with the number of paths it is put on
+type AssetTagCount struct {
+	Name  string
+	Count int64
+}
+
+// GetAssetTagCounts returns the tags used in some repositories, the most used first
+func GetAssetTagCounts(repoIDs []int64) ([]*AssetTagCount, error) {
+	counts := make([]*AssetTagCount, 0, 10)
+	if len(repoIDs) == 0 {
+		return counts, nil
+	}
+	return counts, x.Table("asset_tag").
+		Select("name, count(*) AS count").
+		In("repo_id", repoIDs).
+		GroupBy("name").
+		OrderBy("count DESC, name ASC").
+		Find(&counts)
+}
+
+// DeleteAssetTagsByBranch deletes the tags of the paths of a branch,
+// it is called when the branch is deleted.
+func DeleteAssetTagsByBranch(repoID int64, branchName string) error {
+	_, err := x.Where("repo_id = ? AND branch_name = ?", repoID, branchName).Delete(new(AssetTag))
+	return err
+}
+
+func deleteAssetTagsByRepoID(e Engine, repoID int64) error {
+	_, err := e.Where("repo_id = ?", repoID).Delete(new(AssetTag))
+	return err
+}
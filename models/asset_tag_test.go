@@ -0,0 +1,80 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeAndValidateAssetTags(t *testing.T) {
+	valid, invalid := SanitizeAndValidateAssetTags([]string{" Character:Hero ", "palette", "palette", "", "bad tag", "-leading"})
+	assert.EqualValues(t, []string{"character:hero", "palette"}, valid)
+	assert.EqualValues(t, []string{"bad tag", "-leading"}, invalid)
+}
+
+func TestGetAssetTags(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	tags, err := GetAssetTags(1, "master", "README.md")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"documentation", "palette"}, tags)
+
+	tags, err = GetAssetTags(1, "master", "missing.png")
+	assert.NoError(t, err)
+	assert.Len(t, tags, 0)
+}
+
+func TestSetAssetTags(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, SetAssetTags(1, "master", "README.md", 2, []string{"background", "palette"}))
+	tags, err := GetAssetTags(1, "master", "README.md")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"background", "palette"}, tags)
+	// the other branches keep their tags
+	AssertExistsAndLoadBean(t, &AssetTag{ID: 3})
+
+	assert.NoError(t, SetAssetTags(1, "master", "README.md", 2, nil))
+	AssertNotExistsBean(t, &AssetTag{RepoID: 1, BranchName: "master"})
+	CheckConsistencyFor(t, &AssetTag{})
+}
+
+func TestFindTaggedAssets(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assets, err := FindTaggedAssets(0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, assets, 3) {
+		assert.EqualValues(t, &TaggedAsset{RepoID: 1, BranchName: "develop", TreePath: "README.md", Tags: []string{"documentation"}}, assets[0])
+		assert.EqualValues(t, &TaggedAsset{RepoID: 1, BranchName: "master", TreePath: "README.md", Tags: []string{"documentation", "palette"}}, assets[1])
+		assert.EqualValues(t, 10, assets[2].RepoID)
+	}
+
+	assets, err = FindTaggedAssets(1, "master")
+	assert.NoError(t, err)
+	assert.Len(t, assets, 1)
+}
+
+func TestGetAssetTagCounts(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	counts, err := GetAssetTagCounts([]int64{1, 10})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*AssetTagCount{
+		{Name: "documentation", Count: 2},
+		{Name: "character:hero", Count: 1},
+		{Name: "palette", Count: 1},
+	}, counts)
+}
+
+func TestDeleteAssetTagsByBranch(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteAssetTagsByBranch(1, "master"))
+	AssertNotExistsBean(t, &AssetTag{RepoID: 1, BranchName: "master"})
+	AssertExistsAndLoadBean(t, &AssetTag{RepoID: 1, BranchName: "develop"})
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// AssetUsage records the provenance of an asset imported from another repository:
+// the file at TreePath of the repository was copied from SourceTreePath of the
+// source repository at SourceCommitID by the commit CommitID.
+type AssetUsage struct {
+	ID             int64       `xorm:"pk autoincr"`
+	RepoID         int64       `xorm:"INDEX NOT NULL"`
+	Repo           *Repository `xorm:"-"`
+	TreePath       string
+	CommitID       string      `xorm:"VARCHAR(40)"`
+	SourceRepoID   int64       `xorm:"INDEX NOT NULL"`
+	SourceRepo     *Repository `xorm:"-"`
+	SourceTreePath string
+	SourceCommitID string `xorm:"VARCHAR(40)"`
+	// IsLFS is true if the asset is stored with LFS, the object is shared with the source repository
+	IsLFS  bool
+	DoerID int64 `xorm:"INDEX"`
+	Doer   *User `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+func (u *AssetUsage) loadAttributes(e Engine) (err error) {
+	if u.Repo == nil {
+		if u.Repo, err = getRepositoryByID(e, u.RepoID); err != nil {
+			return err
+		}
+	}
+	if u.SourceRepo == nil {
+		if u.SourceRepo, err = getRepositoryByID(e, u.SourceRepoID); err != nil {
+			return err
+		}
+	}
+	if u.Doer == nil {
+		if u.Doer, err = getUserByID(e, u.DoerID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			u.Doer = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// LoadAttributes loads the repositories and the doer of the asset usage
+func (u *AssetUsage) LoadAttributes() error {
+	return u.loadAttributes(x)
+}
+
+// ShortSourceCommitID returns the abbreviated commit the asset was copied from
+func (u *AssetUsage) ShortSourceCommitID() string {
+	if len(u.SourceCommitID) > 10 {
+		return u.SourceCommitID[:10]
+	}
+	return u.SourceCommitID
+}
+
+// SourceHTMLURL returns the url of the asset in the source repository at the commit it was copied from
+func (u *AssetUsage) SourceHTMLURL() string {
+	return u.SourceRepo.HTMLURL() + "/src/commit/" + u.SourceCommitID + "/" + util.PathEscapeSegments(u.SourceTreePath)
+}
+
+// APIFormat returns this AssetUsage in API format, the attributes must be loaded.
+func (u *AssetUsage) APIFormat() *api.AssetUsage {
+	return &api.AssetUsage{
+		ID: u.ID,
+		Repository: &api.RepositoryMeta{
+			ID:       u.Repo.ID,
+			Name:     u.Repo.Name,
+			FullName: u.Repo.FullName(),
+		},
+		Path:     u.TreePath,
+		CommitID: u.CommitID,
+		SourceRepository: &api.RepositoryMeta{
+			ID:       u.SourceRepo.ID,
+			Name:     u.SourceRepo.Name,
+			FullName: u.SourceRepo.FullName(),
+		},
+		SourcePath:     u.SourceTreePath,
+		SourceCommitID: u.SourceCommitID,
+		SourceURL:      u.SourceHTMLURL(),
+		IsLFS:          u.IsLFS,
+		Importer:       u.Doer.APIFormat(),
+		Created:        u.CreatedUnix.AsTime(),
+	}
+}
+
+// AssetUsageList is a list of asset usages
+type AssetUsageList []*AssetUsage
+
+// LoadAttributes loads the repositories and the doers of the asset usages
+func (usages AssetUsageList) LoadAttributes() error {
+	for _, u := range usages {
+		if err := u.loadAttributes(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewAssetUsages records the provenance of imported assets
+func NewAssetUsages(usages []*AssetUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	for _, u := range usages {
+		if _, err := sess.Insert(u); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// GetUpstreamAssetUsages returns the assets a repository imported from other repositories, newest first
+func GetUpstreamAssetUsages(repoID int64, page, pageSize int) (AssetUsageList, error) {
+	return findAssetUsages(builder.Eq{"repo_id": repoID}, page, pageSize)
+}
+
+// GetDownstreamAssetUsages returns the assets of a repository imported by other repositories, newest first
+func GetDownstreamAssetUsages(sourceRepoID int64, page, pageSize int) (AssetUsageList, error) {
+	return findAssetUsages(builder.Eq{"source_repo_id": sourceRepoID}, page, pageSize)
+}
+
+func findAssetUsages(cond builder.Cond, page, pageSize int) (AssetUsageList, error) {
+	sess := x.Where(cond).Desc("id")
+	if page > 0 {
+		sess = sess.Limit(pageSize, (page-1)*pageSize)
+	}
+	usages := make(AssetUsageList, 0, pageSize)
+	return usages, sess.Find(&usages)
+}
+
+// GetAssetUsageByTreePath returns the latest provenance of the file at treePath of a repository,
+// nil if the file was not imported
+func GetAssetUsageByTreePath(repoID int64, treePath string) (*AssetUsage, error) {
+	u := new(AssetUsage)
+	has, err := x.Where("repo_id = ? AND tree_path = ?", repoID, treePath).Desc("id").Get(u)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return u, nil
+}
+
+// deleteAssetUsagesByRepoID deletes the provenance on both sides of a repository:
+// like a fork losing its base, an asset does not keep a link to a deleted repository
+func deleteAssetUsagesByRepoID(e Engine, repoID int64) error {
+	_, err := e.Where("repo_id = ? OR source_repo_id = ?", repoID, repoID).Delete(new(AssetUsage))
+	return err
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAssetUsages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	usage := &AssetUsage{
+		RepoID:         4,
+		TreePath:       "art/hero.png",
+		CommitID:       "65f1bf27bc3bf70f64657658635e66094edbcb4d",
+		SourceRepoID:   10,
+		SourceTreePath: "characters/hero.png",
+		SourceCommitID: "2a47ca4b614a9f5a43abbd5ad851a54a616ffee6",
+		DoerID:         5,
+	}
+	assert.NoError(t, NewAssetUsages([]*AssetUsage{usage}))
+	AssertExistsAndLoadBean(t, &AssetUsage{ID: usage.ID, RepoID: 4, SourceRepoID: 10})
+	CheckConsistencyFor(t, &AssetUsage{})
+}
+
+func TestGetUpstreamAssetUsages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	usages, err := GetUpstreamAssetUsages(1, 1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, usages, 2) {
+		// newest first
+		assert.EqualValues(t, 2, usages[0].ID)
+		assert.EqualValues(t, 1, usages[1].ID)
+	}
+
+	usages, err = GetUpstreamAssetUsages(1, 2, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, usages, 1) {
+		assert.EqualValues(t, 1, usages[0].ID)
+	}
+
+	usages, err = GetUpstreamAssetUsages(10, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, usages, 0)
+}
+
+func TestGetDownstreamAssetUsages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	usages, err := GetDownstreamAssetUsages(10, 1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, usages, 1) {
+		assert.NoError(t, usages.LoadAttributes())
+		assert.EqualValues(t, 1, usages[0].Repo.ID)
+		assert.EqualValues(t, 10, usages[0].SourceRepo.ID)
+		assert.EqualValues(t, 2, usages[0].Doer.ID)
+	}
+}
+
+func TestGetAssetUsageByTreePath(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	usage, err := GetAssetUsageByTreePath(1, "art/hero.png")
+	assert.NoError(t, err)
+	if assert.NotNil(t, usage) {
+		assert.EqualValues(t, 1, usage.ID)
+		assert.NoError(t, usage.LoadAttributes())
+		assert.Equal(t, usage.SourceRepo.HTMLURL()+"/src/commit/2a47ca4b614a9f5a43abbd5ad851a54a616ffee6/characters/hero.png", usage.SourceHTMLURL())
+		assert.Equal(t, "2a47ca4b61", usage.ShortSourceCommitID())
+	}
+
+	usage, err = GetAssetUsageByTreePath(1, "README.md")
+	assert.NoError(t, err)
+	assert.Nil(t, usage)
+}
+
+func TestDeleteAssetUsagesByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, deleteAssetUsagesByRepoID(x, 10))
+	AssertNotExistsBean(t, &AssetUsage{ID: 1})
+	AssertExistsAndLoadBean(t, &AssetUsage{ID: 2})
+}
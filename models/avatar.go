@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"sync"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/storage"
+)
+
+// avatarKey identifies an avatar in a storage
+type avatarKey struct {
+	bucket storage.ObjectStorage
+	path   string
+}
+
+// presentAvatars caches the avatars known to be present, so rendering their links
+// doesn't ask the storage every time
+var presentAvatars sync.Map
+
+// saveAvatar encodes the avatar as PNG into the given storage
+func saveAvatar(bucket storage.ObjectStorage, path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("Encode: %v", err)
+	}
+	if _, err := bucket.Save(path, &buf, int64(buf.Len())); err != nil {
+		return fmt.Errorf("Save: %v", err)
+	}
+	presentAvatars.Store(avatarKey{bucket, path}, true)
+	return nil
+}
+
+// deleteAvatar removes the avatar from the given storage
+func deleteAvatar(bucket storage.ObjectStorage, path string) error {
+	presentAvatars.Delete(avatarKey{bucket, path})
+	return bucket.Delete(path)
+}
+
+// avatarExists returns whether the avatar is present in the given storage
+func avatarExists(bucket storage.ObjectStorage, path string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	key := avatarKey{bucket, path}
+	if _, ok := presentAvatars.Load(key); ok {
+		return true
+	}
+	_, err := bucket.Stat(path)
+	if err != nil {
+		if !storage.IsNotExist(err) {
+			log.Error("Stat avatar %s: %v", path, err)
+		}
+		return false
+	}
+	presentAvatars.Store(key, true)
+	return true
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type statCountingStorage struct {
+	storage.ObjectStorage
+	stats int
+}
+
+func (s *statCountingStorage) Stat(path string) (*storage.ObjectInfo, error) {
+	s.stats++
+	return s.ObjectStorage.Stat(path)
+}
+
+func TestAvatarExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avatars")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	local, err := storage.NewLocalStorage(dir)
+	assert.NoError(t, err)
+	bucket := &statCountingStorage{ObjectStorage: local}
+
+	assert.False(t, avatarExists(bucket, ""))
+	assert.False(t, avatarExists(bucket, "missing"))
+	assert.Equal(t, 1, bucket.stats)
+
+	// the storage is asked once for an avatar found present
+	assert.NoError(t, ioutil.WriteFile(dir+"/present", []byte("png"), 0644))
+	assert.True(t, avatarExists(bucket, "present"))
+	assert.True(t, avatarExists(bucket, "present"))
+	assert.Equal(t, 2, bucket.stats)
+
+	// and never for an avatar saved
+	assert.NoError(t, saveAvatar(bucket, "saved", image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	assert.True(t, avatarExists(bucket, "saved"))
+	assert.Equal(t, 2, bucket.stats)
+
+	assert.NoError(t, deleteAvatar(bucket, "saved"))
+	assert.False(t, avatarExists(bucket, "saved"))
+	assert.Equal(t, 3, bucket.stats)
+}
@@ -156,6 +156,7 @@ func (protectBranch *ProtectedBranch) GetGrantedApprovalsCount(pr *PullRequest)
 	approvals, err := x.Where("issue_id = ?", pr.Issue.ID).
 		And("type = ?", ReviewTypeApprove).
 		And("official = ?", true).
+		And("dismissed = ?", false).
 		Count(new(Review))
 	if err != nil {
 		log.Error("GetGrantedApprovalsCount: %v", err)
@@ -27,7 +27,10 @@ func CheckConsistencyForAll(t *testing.T) {
 		&Milestone{},
 		&Label{},
 		&Team{},
-		&Action{})
+		&Action{},
+		&Variant{},
+		&AssetUsage{},
+		&AssetTag{})
 }
 
 // CheckConsistencyFor test that all matching database entries are consistent
@@ -167,3 +170,18 @@ func (action *Action) checkForConsistency(t *testing.T) {
 	repo := AssertExistsAndLoadBean(t, &Repository{ID: action.RepoID}).(*Repository)
 	assert.Equal(t, repo.IsPrivate, action.IsPrivate, "action: %+v", action)
 }
+
+func (variant *Variant) checkForConsistency(t *testing.T) {
+	assertCount(t, &VariantStar{VariantID: variant.ID}, variant.NumStars)
+	AssertExistsAndLoadBean(t, &Repository{ID: variant.RepoID})
+}
+
+func (usage *AssetUsage) checkForConsistency(t *testing.T) {
+	AssertExistsAndLoadBean(t, &Repository{ID: usage.RepoID})
+	AssertExistsAndLoadBean(t, &Repository{ID: usage.SourceRepoID})
+}
+
+func (tag *AssetTag) checkForConsistency(t *testing.T) {
+	AssertExistsAndLoadBean(t, &Repository{ID: tag.RepoID})
+	assert.True(t, ValidateAssetTag(tag.Name), "asset tag: %+v", tag)
+}
@@ -1302,6 +1302,36 @@ func (err ErrCommentNotExist) Error() string {
 	return fmt.Sprintf("comment does not exist [id: %d, issue_id: %d]", err.ID, err.IssueID)
 }
 
+// ErrForbiddenIssueReaction is used when a reaction isn't one of the allowed reactions
+type ErrForbiddenIssueReaction struct {
+	Reaction string
+}
+
+// IsErrForbiddenIssueReaction checks if an error is a ErrForbiddenIssueReaction.
+func IsErrForbiddenIssueReaction(err error) bool {
+	_, ok := err.(ErrForbiddenIssueReaction)
+	return ok
+}
+
+func (err ErrForbiddenIssueReaction) Error() string {
+	return fmt.Sprintf("'%s' is not an allowed reaction", err.Reaction)
+}
+
+// ErrReactionAlreadyExist is used when the user already reacted with the same reaction
+type ErrReactionAlreadyExist struct {
+	Reaction string
+}
+
+// IsErrReactionAlreadyExist checks if an error is a ErrReactionAlreadyExist.
+func IsErrReactionAlreadyExist(err error) bool {
+	_, ok := err.(ErrReactionAlreadyExist)
+	return ok
+}
+
+func (err ErrReactionAlreadyExist) Error() string {
+	return fmt.Sprintf("reaction '%s' already exists", err.Reaction)
+}
+
 //  _________ __                                __         .__
 //  /   _____//  |_  ____ ________  _  _______ _/  |_  ____ |  |__
 //  \_____  \\   __\/  _ \\____ \ \/ \/ /\__  \\   __\/ ___\|  |  \
@@ -1392,6 +1422,149 @@ func (err ErrMilestoneNotExist) Error() string {
 	return fmt.Sprintf("milestone does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
 }
 
+// __________                   __               __
+// \______   \_______  ____    |__| ____   _____/  |_
+//  |     ___/\_  __ \/  _ \   |  |/ __ \_/ ___\   __\
+//  |    |     |  | \(  <_> )  |  \  ___/\  \___|  |
+//  |____|     |__|   \____/\__|  |\___  >\___  >__|
+//                         \______|    \/     \/
+
+// ErrProjectNotExist represents a "ProjectNotExist" kind of error.
+type ErrProjectNotExist struct {
+	ID      int64
+	RepoID  int64
+	OwnerID int64
+}
+
+// IsErrProjectNotExist checks if an error is a ErrProjectNotExist.
+func IsErrProjectNotExist(err error) bool {
+	_, ok := err.(ErrProjectNotExist)
+	return ok
+}
+
+func (err ErrProjectNotExist) Error() string {
+	return fmt.Sprintf("project does not exist [id: %d, repo_id: %d, owner_id: %d]", err.ID, err.RepoID, err.OwnerID)
+}
+
+// ErrProjectColumnNotExist represents a "ProjectColumnNotExist" kind of error.
+type ErrProjectColumnNotExist struct {
+	ID        int64
+	ProjectID int64
+}
+
+// IsErrProjectColumnNotExist checks if an error is a ErrProjectColumnNotExist.
+func IsErrProjectColumnNotExist(err error) bool {
+	_, ok := err.(ErrProjectColumnNotExist)
+	return ok
+}
+
+func (err ErrProjectColumnNotExist) Error() string {
+	return fmt.Sprintf("project column does not exist [id: %d, project_id: %d]", err.ID, err.ProjectID)
+}
+
+// ErrProjectColumnIsDefault represents a "ProjectColumnIsDefault" kind of error.
+type ErrProjectColumnIsDefault struct {
+	ID int64
+}
+
+// IsErrProjectColumnIsDefault checks if an error is a ErrProjectColumnIsDefault.
+func IsErrProjectColumnIsDefault(err error) bool {
+	_, ok := err.(ErrProjectColumnIsDefault)
+	return ok
+}
+
+func (err ErrProjectColumnIsDefault) Error() string {
+	return fmt.Sprintf("the default column of a project can not be deleted [id: %d]", err.ID)
+}
+
+// ErrProjectCardNotExist represents a "ProjectCardNotExist" kind of error.
+type ErrProjectCardNotExist struct {
+	ID        int64
+	ProjectID int64
+}
+
+// IsErrProjectCardNotExist checks if an error is a ErrProjectCardNotExist.
+func IsErrProjectCardNotExist(err error) bool {
+	_, ok := err.(ErrProjectCardNotExist)
+	return ok
+}
+
+func (err ErrProjectCardNotExist) Error() string {
+	return fmt.Sprintf("project card does not exist [id: %d, project_id: %d]", err.ID, err.ProjectID)
+}
+
+// ErrProjectCardAlreadyExist represents a "ProjectCardAlreadyExist" kind of error.
+type ErrProjectCardAlreadyExist struct {
+	ProjectID int64
+	IssueID   int64
+}
+
+// IsErrProjectCardAlreadyExist checks if an error is a ErrProjectCardAlreadyExist.
+func IsErrProjectCardAlreadyExist(err error) bool {
+	_, ok := err.(ErrProjectCardAlreadyExist)
+	return ok
+}
+
+func (err ErrProjectCardAlreadyExist) Error() string {
+	return fmt.Sprintf("issue is already on the project [project_id: %d, issue_id: %d]", err.ProjectID, err.IssueID)
+}
+
+// ____   ____            .__               __
+// \   \ /   /____ _______|__|____    _____/  |_
+//  \   Y   /\__  \\_  __ \  \__  \  /    \   __\
+//   \     /  / __ \|  | \/  |/ __ \|   |  \  |
+//    \___/  (____  /__|  |__(____  /___|  /__|
+//                \/              \/     \/
+
+// ErrVariantNotExist represents a "VariantNotExist" kind of error.
+type ErrVariantNotExist struct {
+	ID         int64
+	RepoID     int64
+	BranchName string
+}
+
+// IsErrVariantNotExist checks if an error is a ErrVariantNotExist.
+func IsErrVariantNotExist(err error) bool {
+	_, ok := err.(ErrVariantNotExist)
+	return ok
+}
+
+func (err ErrVariantNotExist) Error() string {
+	return fmt.Sprintf("variant does not exist [id: %d, repo_id: %d, branch: %s]", err.ID, err.RepoID, err.BranchName)
+}
+
+// ErrVariantAlreadyExists represents a "VariantAlreadyExists" kind of error.
+type ErrVariantAlreadyExists struct {
+	RepoID     int64
+	BranchName string
+}
+
+// IsErrVariantAlreadyExists checks if an error is a ErrVariantAlreadyExists.
+func IsErrVariantAlreadyExists(err error) bool {
+	_, ok := err.(ErrVariantAlreadyExists)
+	return ok
+}
+
+func (err ErrVariantAlreadyExists) Error() string {
+	return fmt.Sprintf("branch already holds a variant [repo_id: %d, branch: %s]", err.RepoID, err.BranchName)
+}
+
+// ErrVariantInvalidCover represents a "VariantInvalidCover" kind of error.
+type ErrVariantInvalidCover struct {
+	BranchName string
+	Path       string
+}
+
+// IsErrVariantInvalidCover checks if an error is a ErrVariantInvalidCover.
+func IsErrVariantInvalidCover(err error) bool {
+	_, ok := err.(ErrVariantInvalidCover)
+	return ok
+}
+
+func (err ErrVariantInvalidCover) Error() string {
+	return fmt.Sprintf("cover is not an image of the branch [branch: %s, path: %s]", err.BranchName, err.Path)
+}
+
 //    _____   __    __                .__                           __
 //   /  _  \_/  |__/  |______    ____ |  |__   _____   ____   _____/  |_
 //  /  /_\  \   __\   __\__  \ _/ ___\|  |  \ /     \_/ __ \ /    \   __\
@@ -1542,12 +1715,29 @@ type ErrUploadNotExist struct {
 
 // IsErrUploadNotExist checks if an error is a ErrUploadNotExist.
 func IsErrUploadNotExist(err error) bool {
-	_, ok := err.(ErrAttachmentNotExist)
+	_, ok := err.(ErrUploadNotExist)
 	return ok
 }
 
 func (err ErrUploadNotExist) Error() string {
-	return fmt.Sprintf("attachment does not exist [id: %d, uuid: %s]", err.ID, err.UUID)
+	return fmt.Sprintf("upload does not exist [id: %d, uuid: %s]", err.ID, err.UUID)
+}
+
+// ErrUploadChunkOffset represents a "UploadChunkOffset" kind of error.
+type ErrUploadChunkOffset struct {
+	UUID   string
+	Offset int64
+	Size   int64
+}
+
+// IsErrUploadChunkOffset checks if an error is a ErrUploadChunkOffset.
+func IsErrUploadChunkOffset(err error) bool {
+	_, ok := err.(ErrUploadChunkOffset)
+	return ok
+}
+
+func (err ErrUploadChunkOffset) Error() string {
+	return fmt.Sprintf("upload chunk offset is beyond the received size [uuid: %s, offset: %d, size: %d]", err.UUID, err.Offset, err.Size)
 }
 
 //  ___________         __                             .__    .____                 .__          ____ ___
@@ -1719,6 +1909,22 @@ func (err ErrReviewNotExist) Error() string {
 	return fmt.Sprintf("review does not exist [id: %d]", err.ID)
 }
 
+// ErrReviewNotDismissable represents a review which can't be dismissed, only the approvals and the
+// rejections which aren't already dismissed can be
+type ErrReviewNotDismissable struct {
+	ID int64
+}
+
+// IsErrReviewNotDismissable checks if an error is a ErrReviewNotDismissable
+func IsErrReviewNotDismissable(err error) bool {
+	_, ok := err.(ErrReviewNotDismissable)
+	return ok
+}
+
+func (err ErrReviewNotDismissable) Error() string {
+	return fmt.Sprintf("review can't be dismissed [id: %d]", err.ID)
+}
+
 //  ________      _____          __  .__
 //  \_____  \    /  _  \  __ ___/  |_|  |__
 //   /   |   \  /  /_\  \|  |  \   __\  |  \
@@ -239,6 +239,11 @@ func (issue *Issue) loadReactions(e Engine) (err error) {
 	return nil
 }
 
+// LoadReactions loads the reactions of the issue and of its loaded comments
+func (issue *Issue) LoadReactions() error {
+	return issue.loadReactions(x)
+}
+
 func (issue *Issue) loadAttributes(e Engine) (err error) {
 	if err = issue.loadRepo(e); err != nil {
 		return
@@ -351,7 +356,7 @@ func (issue *Issue) State() api.StateType {
 
 // APIFormat assumes some fields assigned with values:
 // Required - Poster, Labels,
-// Optional - Milestone, Assignee, PullRequest
+// Optional - Milestone, Assignee, PullRequest, Reactions
 func (issue *Issue) APIFormat() *api.Issue {
 	return issue.apiFormat(x)
 }
@@ -413,6 +418,8 @@ func (issue *Issue) apiFormat(e Engine) *api.Issue {
 		apiIssue.Deadline = issue.DeadlineUnix.AsTimePtr()
 	}
 
+	apiIssue.Reactions = issue.Reactions.APISummary()
+
 	return apiIssue
 }
 
@@ -82,6 +82,12 @@ const (
 	CommentTypeLock
 	// Unlocks a previously locked issue
 	CommentTypeUnlock
+	// Added to or removed from a project board
+	CommentTypeProject
+	// Requests a review from a user, or removes the request
+	CommentTypeReviewRequest
+	// Dismisses a review
+	CommentTypeDismissReview
 )
 
 // CommentTag defines comment tag type
@@ -111,6 +117,10 @@ type Comment struct {
 	MilestoneID      int64
 	OldMilestone     *Milestone `xorm:"-"`
 	Milestone        *Milestone `xorm:"-"`
+	OldProjectID     int64
+	ProjectID        int64
+	OldProject       *Project `xorm:"-"`
+	Project          *Project `xorm:"-"`
 	AssigneeID       int64
 	RemovedAssignee  bool
 	Assignee         *User `xorm:"-"`
@@ -128,6 +138,13 @@ type Comment struct {
 	// Path represents the 4 lines of code cemented by this comment
 	Patch string `xorm:"TEXT"`
 
+	// Blob of the image and region commented on, if the comment is not on a line
+	BlobSHA      string `xorm:"VARCHAR(40)"`
+	RegionX      float64
+	RegionY      float64
+	RegionWidth  float64
+	RegionHeight float64
+
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
 
@@ -229,6 +246,45 @@ func (c *Comment) HTMLURL() string {
 	return fmt.Sprintf("%s#%s", c.Issue.HTMLURL(), c.HashTag())
 }
 
+// APIURL returns the absolute API URL of the comment
+func (c *Comment) APIURL() string {
+	err := c.LoadIssue()
+	if err != nil { // Silently dropping errors :unamused:
+		log.Error("LoadIssue(%d): %v", c.IssueID, err)
+		return ""
+	}
+	err = c.Issue.loadRepo(x)
+	if err != nil { // Silently dropping errors :unamused:
+		log.Error("loadRepo(%d): %v", c.Issue.RepoID, err)
+		return ""
+	}
+	return fmt.Sprintf("%s/issues/comments/%d", c.Issue.Repo.APIURL(), c.ID)
+}
+
+// APIReviewFormat converts a comment on a line of a pull request to api.PullReviewComment, its poster must be loaded
+func (c *Comment) APIReviewFormat() *api.PullReviewComment {
+	comment := &api.PullReviewComment{
+		ID:          c.ID,
+		Body:        c.Content,
+		Poster:      c.Poster.APIFormat(),
+		ReviewID:    c.ReviewID,
+		Path:        c.TreePath,
+		CommitID:    c.CommitSHA,
+		DiffHunk:    c.Patch,
+		Outdated:    c.Invalidated,
+		Created:     c.CreatedUnix.AsTime(),
+		Updated:     c.UpdatedUnix.AsTime(),
+		HTMLURL:     c.HTMLURL(),
+		HTMLPullURL: c.PRURL(),
+	}
+	if c.Line < 0 {
+		comment.OldLineNum = c.UnsignedLine()
+	} else {
+		comment.LineNum = c.UnsignedLine()
+	}
+	return comment
+}
+
 // IssueURL formats a URL-string to the issue
 func (c *Comment) IssueURL() string {
 	err := c.LoadIssue()
@@ -269,17 +325,19 @@ func (c *Comment) PRURL() string {
 	return c.Issue.HTMLURL()
 }
 
-// APIFormat converts a Comment to the api.Comment format
+// APIFormat converts a Comment to the api.Comment format,
+// the reactions are only counted if they are loaded
 func (c *Comment) APIFormat() *api.Comment {
 	return &api.Comment{
-		ID:       c.ID,
-		Poster:   c.Poster.APIFormat(),
-		HTMLURL:  c.HTMLURL(),
-		IssueURL: c.IssueURL(),
-		PRURL:    c.PRURL(),
-		Body:     c.Content,
-		Created:  c.CreatedUnix.AsTime(),
-		Updated:  c.UpdatedUnix.AsTime(),
+		ID:        c.ID,
+		Poster:    c.Poster.APIFormat(),
+		HTMLURL:   c.HTMLURL(),
+		IssueURL:  c.IssueURL(),
+		PRURL:     c.PRURL(),
+		Body:      c.Content,
+		Created:   c.CreatedUnix.AsTime(),
+		Updated:   c.UpdatedUnix.AsTime(),
+		Reactions: c.Reactions.APISummary(),
 	}
 }
 
@@ -338,6 +396,30 @@ func (c *Comment) LoadMilestone() error {
 	return nil
 }
 
+// LoadProject if comment.Type is CommentTypeProject, then load the projects
+func (c *Comment) LoadProject() error {
+	if c.OldProjectID > 0 {
+		var oldProject Project
+		has, err := x.ID(c.OldProjectID).Get(&oldProject)
+		if err != nil {
+			return err
+		} else if has {
+			c.OldProject = &oldProject
+		}
+	}
+
+	if c.ProjectID > 0 {
+		var project Project
+		has, err := x.ID(c.ProjectID).Get(&project)
+		if err != nil {
+			return err
+		} else if has {
+			c.Project = &project
+		}
+	}
+	return nil
+}
+
 // LoadPoster loads comment poster
 func (c *Comment) LoadPoster() error {
 	return c.loadPoster(x)
@@ -442,6 +524,10 @@ func (c *Comment) LoadReview() error {
 }
 
 func (c *Comment) checkInvalidation(doer *User, repo *git.Repository, branch string) error {
+	if c.IsRegionComment() {
+		return c.checkRegionInvalidation(doer, repo, branch)
+	}
+
 	// FIXME differentiate between previous and proposed line
 	commit, err := repo.LineBlame(branch, repo.Path, c.TreePath, uint(c.UnsignedLine()))
 	if err != nil && strings.Contains(err.Error(), "fatal: no such path") {
@@ -458,6 +544,23 @@ func (c *Comment) checkInvalidation(doer *User, repo *git.Repository, branch str
 	return nil
 }
 
+// checkRegionInvalidation invalidates the comment once the image it is on has been changed or removed
+func (c *Comment) checkRegionInvalidation(doer *User, repo *git.Repository, branch string) error {
+	commit, err := repo.GetBranchCommit(branch)
+	if err != nil {
+		return err
+	}
+	entry, err := commit.GetTreeEntryByPath(c.TreePath)
+	if err != nil && !git.IsErrNotExist(err) {
+		return err
+	}
+	if entry == nil || entry.ID.String() != c.BlobSHA {
+		c.Invalidated = true
+		return UpdateComment(c, doer)
+	}
+	return nil
+}
+
 // CheckInvalidation checks if the line of code comment got changed by another commit.
 // If the line got changed the comment is going to be invalidated.
 func (c *Comment) CheckInvalidation(repo *git.Repository, doer *User, branch string) error {
@@ -480,6 +583,21 @@ func (c *Comment) UnsignedLine() uint64 {
 	return uint64(c.Line)
 }
 
+// IsRegionComment returns true if the comment is on a region of an image instead of a line of code
+func (c *Comment) IsRegionComment() bool {
+	return c.Type == CommentTypeCode && c.BlobSHA != ""
+}
+
+// Region returns the region of the image the comment is on
+func (c *Comment) Region() CommentRegion {
+	return CommentRegion{
+		X:      c.RegionX,
+		Y:      c.RegionY,
+		Width:  c.RegionWidth,
+		Height: c.RegionHeight,
+	}
+}
+
 // CodeCommentURL returns the url to a comment in code
 func (c *Comment) CodeCommentURL() string {
 	err := c.LoadIssue()
@@ -509,6 +627,8 @@ func createCommentWithNoAction(e *xorm.Session, opts *CreateCommentOptions) (_ *
 		LabelID:          LabelID,
 		OldMilestoneID:   opts.OldMilestoneID,
 		MilestoneID:      opts.MilestoneID,
+		OldProjectID:     opts.OldProjectID,
+		ProjectID:        opts.ProjectID,
 		RemovedAssignee:  opts.RemovedAssignee,
 		AssigneeID:       opts.AssigneeID,
 		CommitID:         opts.CommitID,
@@ -521,12 +641,19 @@ func createCommentWithNoAction(e *xorm.Session, opts *CreateCommentOptions) (_ *
 		TreePath:         opts.TreePath,
 		ReviewID:         opts.ReviewID,
 		Patch:            opts.Patch,
+		BlobSHA:          opts.BlobSHA,
 		RefRepoID:        opts.RefRepoID,
 		RefIssueID:       opts.RefIssueID,
 		RefCommentID:     opts.RefCommentID,
 		RefAction:        opts.RefAction,
 		RefIsPull:        opts.RefIsPull,
 	}
+	if opts.Region != nil {
+		comment.RegionX = opts.Region.X
+		comment.RegionY = opts.Region.Y
+		comment.RegionWidth = opts.Region.Width
+		comment.RegionHeight = opts.Region.Height
+	}
 	if _, err = e.Insert(comment); err != nil {
 		return nil, err
 	}
@@ -725,6 +852,8 @@ type CreateCommentOptions struct {
 	DependentIssueID int64
 	OldMilestoneID   int64
 	MilestoneID      int64
+	OldProjectID     int64
+	ProjectID        int64
 	AssigneeID       int64
 	RemovedAssignee  bool
 	OldTitle         string
@@ -732,6 +861,8 @@ type CreateCommentOptions struct {
 	CommitID         int64
 	CommitSHA        string
 	Patch            string
+	BlobSHA          string
+	Region           *CommentRegion
 	LineNum          int64
 	TreePath         string
 	ReviewID         int64
@@ -819,8 +950,12 @@ func CreateRefComment(doer *User, repo *Repository, issue *Issue, content, commi
 
 // GetCommentByID returns the comment by given ID.
 func GetCommentByID(id int64) (*Comment, error) {
+	return getCommentByID(x, id)
+}
+
+func getCommentByID(e Engine, id int64) (*Comment, error) {
 	c := new(Comment)
-	has, err := x.ID(id).Get(c)
+	has, err := e.ID(id).Get(c)
 	if err != nil {
 		return nil, err
 	} else if !has {
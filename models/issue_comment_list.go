@@ -476,6 +476,28 @@ func (comments CommentList) loadReviews(e Engine) error {
 }
 
 // loadAttributes loads all attributes
+func (comments CommentList) loadReactions(e Engine) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	reactions := make([]*Reaction, 0, len(comments))
+	if err := e.
+		In("comment_id", comments.getCommentIDs()).
+		Find(&reactions); err != nil {
+		return err
+	}
+
+	commentReactions := make(map[int64]ReactionList, len(comments))
+	for _, reaction := range reactions {
+		commentReactions[reaction.CommentID] = append(commentReactions[reaction.CommentID], reaction)
+	}
+	for _, comment := range comments {
+		comment.Reactions = commentReactions[comment.ID]
+	}
+	return nil
+}
+
 func (comments CommentList) loadAttributes(e Engine) (err error) {
 	if err = comments.loadPosters(e); err != nil {
 		return
@@ -532,6 +554,11 @@ func (comments CommentList) LoadPosters() error {
 	return comments.loadPosters(x)
 }
 
+// LoadReactions loads the reactions on the comments, without their users
+func (comments CommentList) LoadReactions() error {
+	return comments.loadReactions(x)
+}
+
 // LoadIssues loads issues of comments
 func (comments CommentList) LoadIssues() error {
 	return comments.loadIssues(x)
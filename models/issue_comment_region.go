@@ -0,0 +1,113 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+
+	"xorm.io/builder"
+)
+
+// CommentRegion is a region of an image, normalized to its size so it does not depend
+// on how the image is displayed: 0 is the left or top edge and 1 the right or bottom edge.
+// A point has no width and height.
+type CommentRegion struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// IsValid returns true if the region is inside of the image
+func (r CommentRegion) IsValid() bool {
+	return r.X >= 0 && r.Y >= 0 && r.Width >= 0 && r.Height >= 0 &&
+		r.X+r.Width <= 1 && r.Y+r.Height <= 1
+}
+
+// IsPoint returns true if the region has no size
+func (r CommentRegion) IsPoint() bool {
+	return r.Width == 0 && r.Height == 0
+}
+
+// APIRegionFormat converts a comment on a region of an image to the api.PullReviewImageComment format
+func (c *Comment) APIRegionFormat() *api.PullReviewImageComment {
+	return &api.PullReviewImageComment{
+		ID:       c.ID,
+		ReviewID: c.ReviewID,
+		HTMLURL:  c.HTMLURL(),
+		PRURL:    c.PRURL(),
+		Poster:   c.Poster.APIFormat(),
+		Body:     c.Content,
+		Path:     c.TreePath,
+		BlobSHA:  c.BlobSHA,
+		Region: &api.ImageRegion{
+			X:      c.RegionX,
+			Y:      c.RegionY,
+			Width:  c.RegionWidth,
+			Height: c.RegionHeight,
+		},
+		Outdated: c.Invalidated,
+		Created:  c.CreatedUnix.AsTime(),
+		Updated:  c.UpdatedUnix.AsTime(),
+	}
+}
+
+// GroupRegionComments groups the comments on the same region of the same image into threads,
+// the threads are in the order of their first comment
+func GroupRegionComments(comments []*Comment) [][]*Comment {
+	type threadKey struct {
+		treePath string
+		blobSHA  string
+		region   CommentRegion
+	}
+	threads := make([][]*Comment, 0, len(comments))
+	indexes := make(map[threadKey]int, len(comments))
+	for _, comment := range comments {
+		if !comment.IsRegionComment() {
+			continue
+		}
+		key := threadKey{comment.TreePath, comment.BlobSHA, comment.Region()}
+		if i, ok := indexes[key]; ok {
+			threads[i] = append(threads[i], comment)
+			continue
+		}
+		indexes[key] = len(threads)
+		threads = append(threads, []*Comment{comment})
+	}
+	return threads
+}
+
+// FindRegionComments returns the comments on regions of the given image blobs in the pull requests
+// of a repository, the comments of pending reviews are left out unless doer is their reviewer
+func FindRegionComments(repoID int64, doer *User, blobSHAs ...string) ([]*Comment, error) {
+	if len(blobSHAs) == 0 {
+		return nil, nil
+	}
+
+	var pendingCond builder.Cond = builder.Eq{"type": ReviewTypePending}
+	if doer != nil {
+		pendingCond = pendingCond.And(builder.Neq{"reviewer_id": doer.ID})
+	}
+
+	comments := make([]*Comment, 0, 10)
+	if err := x.Join("INNER", "issue", "issue.id = comment.issue_id").
+		Where(builder.Eq{"issue.repo_id": repoID}).
+		And(builder.Eq{"comment.type": CommentTypeCode}).
+		And(builder.In("comment.blob_sha", blobSHAs)).
+		And(builder.NotIn("comment.review_id", builder.Select("id").From("review").Where(pendingCond))).
+		Asc("comment.created_unix").
+		Asc("comment.id").
+		Find(&comments); err != nil {
+		return nil, err
+	}
+
+	if err := CommentList(comments).loadPosters(x); err != nil {
+		return nil, err
+	}
+	if err := CommentList(comments).loadIssues(x); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentRegion_IsValid(t *testing.T) {
+	assert.True(t, CommentRegion{X: 0.25, Y: 0.5, Width: 0.5, Height: 0.25}.IsValid())
+	assert.True(t, CommentRegion{X: 1, Y: 1}.IsValid())
+	assert.False(t, CommentRegion{X: -0.1, Y: 0.5}.IsValid())
+	assert.False(t, CommentRegion{X: 0.75, Y: 0.5, Width: 0.5}.IsValid())
+	assert.False(t, CommentRegion{X: 0.5, Y: 0.5, Height: -0.25}.IsValid())
+}
+
+func TestGroupRegionComments(t *testing.T) {
+	region := CommentRegion{X: 0.25, Y: 0.5, Width: 0.5, Height: 0.25}
+	comments := []*Comment{
+		{ID: 1, Type: CommentTypeCode, TreePath: "a.png", BlobSHA: "aaaa", RegionX: 0.25, RegionY: 0.5, RegionWidth: 0.5, RegionHeight: 0.25},
+		{ID: 2, Type: CommentTypeCode, TreePath: "a.png", Line: 4},
+		{ID: 3, Type: CommentTypeCode, TreePath: "a.png", BlobSHA: "aaaa", RegionX: 0.1, RegionY: 0.1},
+		{ID: 4, Type: CommentTypeCode, TreePath: "a.png", BlobSHA: "bbbb", RegionX: 0.25, RegionY: 0.5, RegionWidth: 0.5, RegionHeight: 0.25},
+		{ID: 5, Type: CommentTypeCode, TreePath: "a.png", BlobSHA: "aaaa", RegionX: 0.25, RegionY: 0.5, RegionWidth: 0.5, RegionHeight: 0.25},
+	}
+
+	threads := GroupRegionComments(comments)
+	if assert.Len(t, threads, 3) {
+		assert.Equal(t, []*Comment{comments[0], comments[4]}, threads[0])
+		assert.Equal(t, []*Comment{comments[2]}, threads[1])
+		assert.Equal(t, []*Comment{comments[3]}, threads[2])
+	}
+	assert.Equal(t, region, threads[0][0].Region())
+	assert.True(t, threads[1][0].Region().IsPoint())
+}
+
+func TestFindRegionComments(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	assert.NoError(t, issue.LoadRepo())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	const blobSHA = "1c4d5e2a2b1d0a6f2c4e0d1a8b7c6d5e4f3a2b1c"
+
+	create := func(reviewID int64, content string) *Comment {
+		comment, err := CreateCommentWithNoAction(&CreateCommentOptions{
+			Type:     CommentTypeCode,
+			Doer:     doer,
+			Repo:     issue.Repo,
+			Issue:    issue,
+			Content:  content,
+			TreePath: "image.png",
+			BlobSHA:  blobSHA,
+			Region:   &CommentRegion{X: 0.25, Y: 0.5, Width: 0.5, Height: 0.25},
+			ReviewID: reviewID,
+		})
+		assert.NoError(t, err)
+		return comment
+	}
+	submitted := create(1, "too dark")
+	pending := create(4, "pending")
+	single := create(0, "")
+
+	comments, err := FindRegionComments(issue.RepoID, nil, blobSHA)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 2) {
+		assert.Equal(t, submitted.ID, comments[0].ID)
+		assert.Equal(t, single.ID, comments[1].ID)
+		assert.True(t, comments[0].IsRegionComment())
+		assert.Equal(t, CommentRegion{X: 0.25, Y: 0.5, Width: 0.5, Height: 0.25}, comments[0].Region())
+		assert.NotNil(t, comments[0].Poster)
+		assert.NotNil(t, comments[0].Issue)
+	}
+
+	// the reviewer sees the comments of their pending review, the others don't
+	comments, err = FindRegionComments(issue.RepoID, doer, blobSHA)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 3) {
+		assert.Equal(t, pending.ID, comments[1].ID)
+	}
+	comments, err = FindRegionComments(issue.RepoID, AssertExistsAndLoadBean(t, &User{ID: 2}).(*User), blobSHA)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+
+	comments, err = FindRegionComments(issue.RepoID+1, nil, blobSHA)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 0)
+
+	comments, err = FindRegionComments(issue.RepoID, nil)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 0)
+}
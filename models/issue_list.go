@@ -403,6 +403,29 @@ func (issues IssueList) loadComments(e Engine, cond builder.Cond) (err error) {
 	return nil
 }
 
+func (issues IssueList) loadReactions(e Engine) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	reactions := make([]*Reaction, 0, len(issues))
+	if err := e.
+		In("issue_id", issues.getIssueIDs()).
+		And("comment_id = 0").
+		Find(&reactions); err != nil {
+		return err
+	}
+
+	issueReactions := make(map[int64]ReactionList, len(issues))
+	for _, reaction := range reactions {
+		issueReactions[reaction.IssueID] = append(issueReactions[reaction.IssueID], reaction)
+	}
+	for _, issue := range issues {
+		issue.Reactions = issueReactions[issue.ID]
+	}
+	return nil
+}
+
 func (issues IssueList) loadTotalTrackedTimes(e Engine) (err error) {
 	type totalTimesByIssue struct {
 		IssueID int64
@@ -510,6 +533,11 @@ func (issues IssueList) LoadComments() error {
 	return issues.loadComments(x, builder.NewCond())
 }
 
+// LoadReactions loads the reactions on the issues, without their users
+func (issues IssueList) LoadReactions() error {
+	return issues.loadReactions(x)
+}
+
 // LoadDiscussComments loads discuss comments
 func (issues IssueList) LoadDiscussComments() error {
 	return issues.loadComments(x, builder.Eq{"comment.type": CommentTypeComment})
@@ -9,7 +9,9 @@ import (
 	"fmt"
 
 	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 
 	"xorm.io/builder"
 	"xorm.io/xorm"
@@ -26,10 +28,29 @@ type Reaction struct {
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 }
 
+// LoadUser loads the user of the reaction, a ghost user if it has been deleted
+func (r *Reaction) LoadUser() error {
+	if r.User != nil {
+		return nil
+	}
+	user, err := getUserByID(x, r.UserID)
+	if err != nil {
+		if !IsErrUserNotExist(err) {
+			return err
+		}
+		user = NewGhostUser()
+	}
+	r.User = user
+	return nil
+}
+
 // FindReactionsOptions describes the conditions to Find reactions
 type FindReactionsOptions struct {
-	IssueID   int64
+	IssueID int64
+	// CommentID is -1 to find the reactions on the issue only
 	CommentID int64
+	UserID    int64
+	Type      string
 }
 
 func (opts *FindReactionsOptions) toConds() builder.Cond {
@@ -39,6 +60,14 @@ func (opts *FindReactionsOptions) toConds() builder.Cond {
 	}
 	if opts.CommentID > 0 {
 		cond = cond.And(builder.Eq{"reaction.comment_id": opts.CommentID})
+	} else if opts.CommentID == -1 {
+		cond = cond.And(builder.Eq{"reaction.comment_id": 0})
+	}
+	if opts.UserID != 0 {
+		cond = cond.And(builder.Eq{"reaction.user_id": opts.UserID})
+	}
+	if opts.Type != "" {
+		cond = cond.And(builder.Eq{"reaction.type": opts.Type})
 	}
 	return cond
 }
@@ -51,7 +80,56 @@ func findReactions(e Engine, opts FindReactionsOptions) ([]*Reaction, error) {
 		Find(&reactions)
 }
 
+// FindIssueReactions returns the reactions on an issue, not on its comments, with their users
+func FindIssueReactions(issue *Issue) (ReactionList, error) {
+	return findReactionsWithUsers(x, FindReactionsOptions{
+		IssueID:   issue.ID,
+		CommentID: -1,
+	})
+}
+
+// FindCommentReactions returns the reactions on a comment with their users
+func FindCommentReactions(comment *Comment) (ReactionList, error) {
+	return findReactionsWithUsers(x, FindReactionsOptions{
+		IssueID:   comment.IssueID,
+		CommentID: comment.ID,
+	})
+}
+
+func findReactionsWithUsers(e Engine, opts FindReactionsOptions) (ReactionList, error) {
+	reactions, err := findReactions(e, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ReactionList(reactions).loadUsers(e); err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// createReaction creates a reaction if it is allowed and the user didn't already react the same way
 func createReaction(e *xorm.Session, opts *ReactionOptions) (*Reaction, error) {
+	if !util.IsStringInSlice(opts.Type, setting.UI.Reactions) {
+		return nil, ErrForbiddenIssueReaction{opts.Type}
+	}
+
+	findOpts := FindReactionsOptions{
+		IssueID:   opts.Issue.ID,
+		CommentID: -1,
+		UserID:    opts.Doer.ID,
+		Type:      opts.Type,
+	}
+	if opts.Comment != nil {
+		findOpts.CommentID = opts.Comment.ID
+	}
+	existing, err := findReactions(e, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, ErrReactionAlreadyExist{opts.Type}
+	}
+
 	reaction := &Reaction{
 		Type:    opts.Type,
 		UserID:  opts.Doer.ID,
@@ -247,3 +325,33 @@ func (list ReactionList) GetMoreUserCount() int {
 	}
 	return len(list) - setting.UI.ReactionMaxUserNum
 }
+
+// APIFormat converts a reaction to api.Reaction, its user must be loaded
+func (r *Reaction) APIFormat() *api.Reaction {
+	return &api.Reaction{
+		User:     r.User.APIFormat(),
+		Reaction: r.Type,
+		Created:  r.CreatedUnix.AsTime(),
+	}
+}
+
+// APIFormat converts the reactions to api.Reaction, their users must be loaded
+func (list ReactionList) APIFormat() []*api.Reaction {
+	apiReactions := make([]*api.Reaction, 0, len(list))
+	for _, reaction := range list {
+		apiReactions = append(apiReactions, reaction.APIFormat())
+	}
+	return apiReactions
+}
+
+// APISummary returns the number of reactions by type
+func (list ReactionList) APISummary() *api.ReactionSummary {
+	summary := &api.ReactionSummary{
+		TotalCount: len(list),
+		Counts:     make(map[string]int, len(setting.UI.Reactions)),
+	}
+	for _, reaction := range list {
+		summary.Counts[reaction.Type]++
+	}
+	return summary
+}
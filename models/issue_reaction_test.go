@@ -164,3 +164,59 @@ func TestIssueCommentReactionCount(t *testing.T) {
 
 	AssertNotExistsBean(t, &Reaction{Type: "heart", UserID: user1.ID, IssueID: issue1.ID, CommentID: comment1.ID})
 }
+
+func TestIssueAddForbiddenReaction(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	user1 := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	issue1 := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+
+	reaction, err := CreateIssueReaction(user1, issue1, "not-an-emoji")
+	assert.True(t, IsErrForbiddenIssueReaction(err))
+	assert.Nil(t, reaction)
+
+	addReaction(t, user1, issue1, nil, "heart")
+	_, err = CreateIssueReaction(user1, issue1, "heart")
+	assert.True(t, IsErrReactionAlreadyExist(err))
+}
+
+func TestFindIssueAndCommentReactions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	user1 := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	issue1 := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	comment1 := AssertExistsAndLoadBean(t, &Comment{ID: 1}).(*Comment)
+
+	addReaction(t, user1, issue1, nil, "heart")
+	addReaction(t, user2, issue1, nil, "heart")
+	addReaction(t, user2, issue1, nil, "+1")
+	addReaction(t, user1, issue1, comment1, "eyes")
+
+	reactions, err := FindIssueReactions(issue1)
+	assert.NoError(t, err)
+	assert.Len(t, reactions, 3)
+	assert.Equal(t, user1.Name, reactions[0].User.Name)
+
+	summary := reactions.APISummary()
+	assert.Equal(t, 3, summary.TotalCount)
+	assert.Equal(t, map[string]int{"heart": 2, "+1": 1}, summary.Counts)
+
+	reactions, err = FindCommentReactions(comment1)
+	assert.NoError(t, err)
+	if assert.Len(t, reactions, 1) {
+		apiReaction := reactions[0].APIFormat()
+		assert.Equal(t, "eyes", apiReaction.Reaction)
+		assert.Equal(t, user1.Name, apiReaction.User.UserName)
+	}
+
+	issue2 := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	assert.NoError(t, IssueList{issue1, issue2}.LoadReactions())
+	assert.Equal(t, 3, issue1.APIFormat().Reactions.TotalCount)
+	assert.Equal(t, 0, issue2.APIFormat().Reactions.TotalCount)
+
+	comment2 := AssertExistsAndLoadBean(t, &Comment{ID: 2}).(*Comment)
+	assert.NoError(t, CommentList{comment1, comment2}.LoadReactions())
+	assert.Len(t, comment1.Reactions, 1)
+	assert.Empty(t, comment2.Reactions)
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
 
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 
 	"xorm.io/builder"
@@ -27,12 +29,23 @@ func (m *LFSMetaObject) Pointer() string {
 	return fmt.Sprintf("%s\n%s%s\nsize %d\n", LFSMetaFileIdentifier, LFSMetaFileOidPrefix, m.Oid, m.Size)
 }
 
+// RelativePath returns the path of the content of the object in the LFS storage
+func (m *LFSMetaObject) RelativePath() string {
+	if len(m.Oid) < 5 {
+		return m.Oid
+	}
+
+	return path.Join(m.Oid[0:2], m.Oid[2:4], m.Oid[4:])
+}
+
 // LFSTokenResponse defines the JSON structure in which the JWT token is stored.
 // This structure is fetched via SSH and passed by the Git LFS client to the server
 // endpoint for authorization.
 type LFSTokenResponse struct {
 	Header map[string]string `json:"header"`
 	Href   string            `json:"href"`
+	// ExpiresIn is the number of seconds the token is valid, the client asks for a new one afterwards
+	ExpiresIn int64 `json:"expires_in,omitempty"`
 }
 
 var (
@@ -153,6 +166,11 @@ func (repo *Repository) CountLFSMetaObjects() (int64, error) {
 	return x.Count(&LFSMetaObject{RepositoryID: repo.ID})
 }
 
+// LFSObjectIsAssociated checks if a provided Oid is associated with any repository
+func LFSObjectIsAssociated(oid string) (bool, error) {
+	return x.Exist(&LFSMetaObject{Oid: oid})
+}
+
 // LFSObjectAccessible checks if a provided Oid is accessible to the user
 func LFSObjectAccessible(user *User, oid string) (bool, error) {
 	if user.IsAdmin {
@@ -198,3 +216,13 @@ func LFSAutoAssociate(metas []*LFSMetaObject, user *User, repoID int64) error {
 
 	return sess.Commit()
 }
+
+// IterateLFS iterates LFS meta objects, an object shared by several repositories is visited once for each of them.
+func IterateLFS(f func(mo *LFSMetaObject) error) error {
+	return x.
+		Where("id > 0").BufferSize(setting.Database.IterateBufferSize).
+		Iterate(new(LFSMetaObject),
+			func(idx int, bean interface{}) error {
+				return f(bean.(*LFSMetaObject))
+			})
+}
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	api "code.gitea.io/gitea/modules/structs"
 
+	"xorm.io/builder"
 	"xorm.io/xorm"
 )
 
@@ -115,6 +116,46 @@ func GetLFSLockByRepoID(repoID int64) (locks []*LFSLock, err error) {
 	return
 }
 
+// FindLFSLocksOptions represents the options to search the locks of a repository
+type FindLFSLocksOptions struct {
+	RepoID int64
+	// OwnerID restricts the locks to the ones of a user if set
+	OwnerID int64
+	// Keyword is matched against the locked paths
+	Keyword  string
+	Page     int
+	PageSize int
+}
+
+func (opts *FindLFSLocksOptions) toConds() builder.Cond {
+	cond := builder.NewCond().And(builder.Eq{"repo_id": opts.RepoID})
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.Keyword != "" {
+		cond = cond.And(builder.Like{"LOWER(path)", strings.ToLower(opts.Keyword)})
+	}
+	return cond
+}
+
+// FindLFSLocks returns the locks matching the options, the most recent first.
+func FindLFSLocks(opts *FindLFSLocksOptions) ([]*LFSLock, error) {
+	sess := x.Where(opts.toConds()).Desc("created", "id")
+	if opts.PageSize > 0 {
+		if opts.Page <= 0 {
+			opts.Page = 1
+		}
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	locks := make([]*LFSLock, 0, opts.PageSize)
+	return locks, sess.Find(&locks)
+}
+
+// CountLFSLocks returns the number of locks matching the options.
+func CountLFSLocks(opts *FindLFSLocksOptions) (int64, error) {
+	return x.Where(opts.toConds()).Count(new(LFSLock))
+}
+
 // DeleteLFSLockByID deletes a lock by given ID.
 func DeleteLFSLockByID(id int64, u *User, force bool) (*LFSLock, error) {
 	lock, err := GetLFSLockByID(id)
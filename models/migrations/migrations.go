@@ -278,6 +278,26 @@ var migrations = []Migration{
 	NewMigration("change review content type to text", changeReviewContentToText),
 	// v111 -> v112
 	NewMigration("update branch protection for can push and whitelist enable", addBranchProtectionCanPushAndEnableWhitelist),
+	// v112 -> v113
+	NewMigration("add blob and image region to comment", addRegionToComment),
+	// v113 -> v114
+	NewMigration("add projects tables and project columns to comment", addProjectsTables),
+	// v114 -> v115
+	NewMigration("add owner id to project for organization projects", addOwnerIDToProject),
+	// v115 -> v116
+	NewMigration("add variant and variant star tables", addVariantTables),
+	// v116 -> v117
+	NewMigration("add asset usage table", addAssetUsageTable),
+	// v117 -> v118
+	NewMigration("add asset tag table", addAssetTagTable),
+	// v118 -> v119
+	NewMigration("add binary files to pull request", addBinaryFilesToPullRequest),
+	// v119 -> v120
+	NewMigration("add dismissed and commit id to review", addDismissedAndCommitIDToReview),
+	// v120 -> v121
+	NewMigration("add upload in chunks table", addUploadInChunksTable),
+	// v121 -> v122
+	NewMigration("add updated unix to upload in chunks", addUpdatedUnixToUploadInChunks),
 }
 
 // Migrate database to current version
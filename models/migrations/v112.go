@@ -0,0 +1,21 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+func addRegionToComment(x *xorm.Engine) error {
+	type Comment struct {
+		BlobSHA      string `xorm:"VARCHAR(40)"`
+		RegionX      float64
+		RegionY      float64
+		RegionWidth  float64
+		RegionHeight float64
+	}
+
+	return x.Sync2(new(Comment))
+}
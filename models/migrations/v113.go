@@ -0,0 +1,56 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addProjectsTables(x *xorm.Engine) error {
+	type Project struct {
+		ID          int64  `xorm:"pk autoincr"`
+		RepoID      int64  `xorm:"INDEX"`
+		CreatorID   int64  `xorm:"NOT NULL"`
+		Title       string `xorm:"NOT NULL"`
+		Description string `xorm:"TEXT"`
+		IsClosed    bool   `xorm:"INDEX"`
+
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+		ClosedDateUnix timeutil.TimeStamp
+	}
+
+	type ProjectColumn struct {
+		ID        int64  `xorm:"pk autoincr"`
+		ProjectID int64  `xorm:"INDEX NOT NULL"`
+		CreatorID int64  `xorm:"NOT NULL"`
+		Title     string `xorm:"NOT NULL"`
+		IsDefault bool   `xorm:"NOT NULL DEFAULT false"`
+		Sorting   int    `xorm:"NOT NULL DEFAULT 0"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ProjectCard struct {
+		ID        int64 `xorm:"pk autoincr"`
+		ProjectID int64 `xorm:"UNIQUE(s) NOT NULL"`
+		IssueID   int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ColumnID  int64 `xorm:"INDEX NOT NULL"`
+		Sorting   int   `xorm:"NOT NULL DEFAULT 0"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type Comment struct {
+		OldProjectID int64
+		ProjectID    int64
+	}
+
+	return x.Sync2(new(Project), new(ProjectColumn), new(ProjectCard), new(Comment))
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addVariantTables(x *xorm.Engine) error {
+	type Variant struct {
+		ID          int64  `xorm:"pk autoincr"`
+		RepoID      int64  `xorm:"UNIQUE(s) NOT NULL"`
+		BranchName  string `xorm:"UNIQUE(s) NOT NULL"`
+		OwnerID     int64  `xorm:"INDEX NOT NULL"`
+		Title       string `xorm:"NOT NULL"`
+		Description string `xorm:"TEXT"`
+		CoverPath   string
+		NumStars    int `xorm:"NOT NULL DEFAULT 0"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type VariantStar struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UID         int64              `xorm:"UNIQUE(s)"`
+		VariantID   int64              `xorm:"UNIQUE(s)"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	return x.Sync2(new(Variant), new(VariantStar))
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addAssetUsageTable(x *xorm.Engine) error {
+	type AssetUsage struct {
+		ID             int64 `xorm:"pk autoincr"`
+		RepoID         int64 `xorm:"INDEX NOT NULL"`
+		TreePath       string
+		CommitID       string `xorm:"VARCHAR(40)"`
+		SourceRepoID   int64  `xorm:"INDEX NOT NULL"`
+		SourceTreePath string
+		SourceCommitID string `xorm:"VARCHAR(40)"`
+		IsLFS          bool
+		DoerID         int64 `xorm:"INDEX"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	return x.Sync2(new(AssetUsage))
+}
@@ -0,0 +1,25 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addAssetTagTable(x *xorm.Engine) error {
+	type AssetTag struct {
+		ID          int64  `xorm:"pk autoincr"`
+		RepoID      int64  `xorm:"UNIQUE(s) NOT NULL"`
+		BranchName  string `xorm:"UNIQUE(s) NOT NULL"`
+		TreePath    string `xorm:"UNIQUE(s) NOT NULL"`
+		Name        string `xorm:"UNIQUE(s) INDEX VARCHAR(50) NOT NULL"`
+		DoerID      int64
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	return x.Sync2(new(AssetTag))
+}
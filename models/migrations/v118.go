@@ -0,0 +1,28 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+func addBinaryFilesToPullRequest(x *xorm.Engine) error {
+	type PullRequest struct {
+		BinaryFiles []string `xorm:"TEXT JSON"`
+	}
+
+	if err := x.Sync2(new(PullRequest)); err != nil {
+		return err
+	}
+
+	// The binary files are found while testing the patch, so the open pull requests are tested again.
+	const pullRequestStatusChecking = 1
+	_, err := x.Table("pull_request").
+		Where(builder.Eq{"has_merged": false}.And(
+			builder.In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"is_closed": false})))).
+		Update(map[string]interface{}{"status": pullRequestStatusChecking})
+	return err
+}
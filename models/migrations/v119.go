@@ -0,0 +1,18 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+func addDismissedAndCommitIDToReview(x *xorm.Engine) error {
+	type Review struct {
+		Dismissed bool   `xorm:"NOT NULL DEFAULT false"`
+		CommitID  string `xorm:"VARCHAR(40)"`
+	}
+
+	return x.Sync2(new(Review))
+}
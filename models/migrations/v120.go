@@ -0,0 +1,24 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addUploadInChunksTable(x *xorm.Engine) error {
+	type UploadInChunks struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UUID        string             `xorm:"uuid UNIQUE"`
+		UploaderID  int64              `xorm:"INDEX"`
+		RepoID      int64              `xorm:"INDEX"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	// the chunks of uploads started before have no row, they are removed by the upload cleanup task
+	return x.Sync2(new(UploadInChunks))
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addUpdatedUnixToUploadInChunks(x *xorm.Engine) error {
+	// UploadInChunks see models/upload.go
+	type UploadInChunks struct {
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	if err := x.Sync2(new(UploadInChunks)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	_, err := x.Exec("UPDATE `upload_in_chunks` SET `updated_unix` = `created_unix`")
+	return err
+}
@@ -63,6 +63,7 @@ func init() {
 		new(Collaboration),
 		new(Access),
 		new(Upload),
+		new(UploadInChunks),
 		new(Watch),
 		new(Star),
 		new(Follow),
@@ -113,6 +114,13 @@ func init() {
 		new(OAuth2AuthorizationCode),
 		new(OAuth2Grant),
 		new(Task),
+		new(Project),
+		new(ProjectColumn),
+		new(ProjectCard),
+		new(Variant),
+		new(VariantStar),
+		new(AssetUsage),
+		new(AssetTag),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
@@ -6,8 +6,14 @@ package models
 
 import (
 	"fmt"
+	"path"
 
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
 )
 
 type (
@@ -58,6 +64,68 @@ type Notification struct {
 	UpdatedUnix timeutil.TimeStamp `xorm:"updated INDEX NOT NULL"`
 }
 
+// FindNotificationOptions represents the filters of the notifications of a user, the zero values are ignored
+type FindNotificationOptions struct {
+	UserID            int64
+	RepoID            int64
+	Status            []NotificationStatus
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
+	Page              int
+	PageSize          int
+}
+
+// ToCond converts the options into a xorm condition
+func (opts *FindNotificationOptions) ToCond() builder.Cond {
+	cond := builder.NewCond()
+	if opts.UserID != 0 {
+		cond = cond.And(builder.Eq{"notification.user_id": opts.UserID})
+	}
+	if opts.RepoID != 0 {
+		cond = cond.And(builder.Eq{"notification.repo_id": opts.RepoID})
+	}
+	if len(opts.Status) > 0 {
+		cond = cond.And(builder.In("notification.status", opts.Status))
+	}
+	if opts.UpdatedAfterUnix != 0 {
+		cond = cond.And(builder.Gte{"notification.updated_unix": opts.UpdatedAfterUnix})
+	}
+	if opts.UpdatedBeforeUnix != 0 {
+		cond = cond.And(builder.Lte{"notification.updated_unix": opts.UpdatedBeforeUnix})
+	}
+	return cond
+}
+
+// ToSession converts the options into a xorm session, most recently updated notifications first
+func (opts *FindNotificationOptions) ToSession(e Engine) *xorm.Session {
+	sess := e.Where(opts.ToCond()).OrderBy("notification.updated_unix DESC")
+	if opts.Page > 0 && opts.PageSize > 0 {
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	return sess
+}
+
+// FindNotifications returns the notifications matching the options
+func FindNotifications(opts *FindNotificationOptions) (NotificationList, error) {
+	notifications := make([]*Notification, 0, opts.PageSize)
+	return notifications, opts.ToSession(x).Find(&notifications)
+}
+
+// CountNotifications returns the number of notifications matching the options
+func CountNotifications(opts *FindNotificationOptions) (int64, error) {
+	return x.Where(opts.ToCond()).Count(&Notification{})
+}
+
+// SetNotificationsStatus changes the status of the notifications matching the options and returns
+// the number of changed notifications
+func SetNotificationsStatus(opts *FindNotificationOptions, status NotificationStatus) (int64, error) {
+	return x.
+		Where(opts.ToCond()).
+		And("status != ?", status).
+		Cols("status", "updated_unix").
+		Update(&Notification{Status: status})
+}
+
 // CreateOrUpdateIssueNotifications creates an issue notification
 // for each watcher, or updates it if already exists
 func CreateOrUpdateIssueNotifications(issueID, commentID int64, notificationAuthorID int64) error {
@@ -264,9 +332,98 @@ func (n *Notification) HTMLURL() string {
 	return n.Issue.HTMLURL()
 }
 
+// LoadAttributes loads the repository, the issue and the comment of the notification
+func (n *Notification) LoadAttributes() (err error) {
+	return n.loadAttributes(x)
+}
+
+func (n *Notification) loadAttributes(e Engine) (err error) {
+	if n.Repository == nil {
+		if n.Repository, err = getRepositoryByID(e, n.RepoID); err != nil {
+			return err
+		}
+	}
+	if n.Issue == nil {
+		if n.Issue, err = getIssueByID(e, n.IssueID); err != nil {
+			return err
+		}
+		n.Issue.Repo = n.Repository
+	}
+	if n.Comment == nil && n.CommentID != 0 {
+		if n.Comment, err = getCommentByID(e, n.CommentID); err != nil && !IsErrCommentNotExist(err) {
+			return err
+		}
+		if n.Comment != nil {
+			n.Comment.Issue = n.Issue
+		}
+	}
+	return nil
+}
+
+// APIURL returns the API URL of the notification thread
+func (n *Notification) APIURL() string {
+	return setting.AppURL + path.Join("api/v1/notifications/threads", fmt.Sprint(n.ID))
+}
+
+// APIFormat converts a Notification to api.NotificationThread, its attributes must be loaded
+func (n *Notification) APIFormat() *api.NotificationThread {
+	thread := &api.NotificationThread{
+		ID:         n.ID,
+		Repository: n.Repository.APIFormat(AccessModeNone),
+		Subject: &api.NotificationSubject{
+			Title:   n.Issue.Title,
+			Type:    api.NotificationSubjectIssue,
+			State:   n.Issue.State(),
+			URL:     n.Issue.APIURL(),
+			HTMLURL: n.Issue.HTMLURL(),
+		},
+		Unread:    n.Status == NotificationStatusUnread,
+		Pinned:    n.Status == NotificationStatusPinned,
+		UpdatedAt: n.UpdatedUnix.AsTime(),
+		URL:       n.APIURL(),
+	}
+	if n.Issue.IsPull {
+		thread.Subject.Type = api.NotificationSubjectPull
+		thread.Subject.URL = fmt.Sprintf("%s/pulls/%d", n.Repository.APIURL(), n.Issue.Index)
+	}
+	if n.Comment != nil {
+		thread.Subject.LatestCommentURL = n.Comment.APIURL()
+		thread.Subject.LatestCommentHTMLURL = n.Comment.HTMLURL()
+	}
+	return thread
+}
+
 // NotificationList contains a list of notifications
 type NotificationList []*Notification
 
+// LoadAttributes loads the repositories, the issues and the comments of the notifications
+func (nl NotificationList) LoadAttributes() error {
+	if _, err := nl.LoadRepos(); err != nil {
+		return err
+	}
+	if err := nl.LoadIssues(); err != nil {
+		return err
+	}
+	if err := nl.LoadComments(); err != nil {
+		return err
+	}
+	for _, notification := range nl {
+		if notification.Comment != nil && notification.Comment.Issue == nil {
+			notification.Comment.Issue = notification.Issue
+		}
+	}
+	return nil
+}
+
+// APIFormat converts a NotificationList to api.NotificationThread list, its attributes must be loaded
+func (nl NotificationList) APIFormat() []*api.NotificationThread {
+	threads := make([]*api.NotificationThread, 0, len(nl))
+	for _, notification := range nl {
+		threads = append(threads, notification.APIFormat())
+	}
+	return threads
+}
+
 func (nl NotificationList) getPendingRepoIDs() []int64 {
 	var ids = make(map[int64]struct{}, len(nl))
 	for _, notification := range nl {
@@ -501,6 +658,11 @@ func SetNotificationStatus(notificationID int64, user *User, status Notification
 	return err
 }
 
+// GetNotificationByID returns the notification with the given ID
+func GetNotificationByID(notificationID int64) (*Notification, error) {
+	return getNotificationByID(notificationID)
+}
+
 func getNotificationByID(notificationID int64) (*Notification, error) {
 	notification := new(Notification)
 	ok, err := x.
@@ -512,7 +674,7 @@ func getNotificationByID(notificationID int64) (*Notification, error) {
 	}
 
 	if !ok {
-		return nil, fmt.Errorf("Notification %d does not exists", notificationID)
+		return nil, ErrNotExist{ID: notificationID}
 	}
 
 	return notification, nil
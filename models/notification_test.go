@@ -99,3 +99,69 @@ func TestUpdateNotificationStatuses(t *testing.T) {
 	AssertExistsAndLoadBean(t,
 		&Notification{ID: notfPinned.ID, Status: NotificationStatusPinned})
 }
+
+func TestFindNotifications(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	notfs, err := FindNotifications(&FindNotificationOptions{
+		UserID: 2,
+		Status: []NotificationStatus{NotificationStatusUnread, NotificationStatusPinned},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, notfs, 3)
+	for _, notf := range notfs {
+		assert.EqualValues(t, 2, notf.UserID)
+		assert.NotEqual(t, NotificationStatusRead, notf.Status)
+	}
+
+	cnt, err := CountNotifications(&FindNotificationOptions{UserID: 2, RepoID: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	cnt, err = CountNotifications(&FindNotificationOptions{UserID: 2, UpdatedAfterUnix: 946684801})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	notfs, err = FindNotifications(&FindNotificationOptions{UserID: 2, Page: 2, PageSize: 2})
+	assert.NoError(t, err)
+	assert.Len(t, notfs, 2)
+}
+
+func TestSetNotificationsStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cnt, err := SetNotificationsStatus(&FindNotificationOptions{
+		UserID: 2,
+		RepoID: 1,
+		Status: []NotificationStatus{NotificationStatusUnread, NotificationStatusPinned},
+	}, NotificationStatusRead)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	AssertExistsAndLoadBean(t, &Notification{ID: 3, Status: NotificationStatusRead})
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusRead})
+	AssertExistsAndLoadBean(t, &Notification{ID: 5, Status: NotificationStatusPinned})
+	AssertExistsAndLoadBean(t, &Notification{ID: 1, Status: NotificationStatusUnread})
+}
+
+func TestNotification_APIFormat(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	notfs, err := FindNotifications(&FindNotificationOptions{UserID: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, notfs.LoadAttributes())
+	threads := notfs.APIFormat()
+	if assert.Len(t, threads, 1) {
+		assert.EqualValues(t, 1, threads[0].ID)
+		assert.True(t, threads[0].Unread)
+		assert.False(t, threads[0].Pinned)
+		assert.EqualValues(t, "user2/repo1", threads[0].Repository.FullName)
+		assert.EqualValues(t, "issue1", threads[0].Subject.Title)
+		assert.EqualValues(t, "Issue", threads[0].Subject.Type)
+		assert.EqualValues(t, "https://try.gitea.io/api/v1/repos/user2/repo1/issues/1", threads[0].Subject.URL)
+		assert.EqualValues(t, "https://try.gitea.io/user2/repo1/issues/1", threads[0].Subject.HTMLURL)
+		assert.EqualValues(t, "https://try.gitea.io/api/v1/notifications/threads/1", threads[0].URL)
+	}
+
+	notf := AssertExistsAndLoadBean(t, &Notification{ID: 4}).(*Notification)
+	assert.NoError(t, notf.LoadAttributes())
+	thread := notf.APIFormat()
+	assert.EqualValues(t, "Pull", thread.Subject.Type)
+	assert.EqualValues(t, "https://try.gitea.io/api/v1/repos/user2/repo1/pulls/2", thread.Subject.URL)
+}
@@ -12,9 +12,9 @@ import (
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 
-	"github.com/unknwon/com"
 	"xorm.io/builder"
 	"xorm.io/xorm"
 )
@@ -34,6 +34,32 @@ func (org *User) CanCreateOrgRepo(uid int64) (bool, error) {
 	return CanCreateOrgRepo(org.ID, uid)
 }
 
+// GetProjectsAccessMode returns the access the user has to the projects of the organization,
+// granted by the teams of the user which have the projects unit enabled.
+func (org *User) GetProjectsAccessMode(user *User) (AccessMode, error) {
+	if user == nil {
+		return AccessModeNone, nil
+	}
+	if user.IsAdmin {
+		return AccessModeOwner, nil
+	}
+
+	teams, err := org.GetUserTeams(user.ID)
+	if err != nil {
+		return AccessModeNone, err
+	}
+	mode := AccessModeNone
+	for _, team := range teams {
+		if team.IsOwnerTeam() {
+			return AccessModeOwner, nil
+		}
+		if team.Authorize > mode && team.UnitEnabled(UnitTypeProjects) {
+			mode = team.Authorize
+		}
+	}
+	return mode, nil
+}
+
 func (org *User) getTeam(e Engine, name string) (*Team, error) {
 	return getTeam(e, org.ID, name)
 }
@@ -293,6 +319,10 @@ func deleteOrg(e *xorm.Session, u *User) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := deleteProjectsByOwnerID(e, u.ID); err != nil {
+		return fmt.Errorf("deleteProjectsByOwnerID: %v", err)
+	}
+
 	if _, err = e.ID(u.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
@@ -307,11 +337,8 @@ func deleteOrg(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		if err := deleteAvatar(storage.Avatars, u.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", u.Avatar, err)
 		}
 	}
 
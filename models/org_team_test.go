@@ -507,3 +507,22 @@ func TestIncludesAllRepositoriesTeams(t *testing.T) {
 	}
 	assert.NoError(t, DeleteOrganization(org), "DeleteOrganization")
 }
+
+func TestGetTeamIDsByNames(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	ids, err := GetTeamIDsByNames(3, []string{"Owners", "team1", "does-not-exist"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	_, err = GetTeamIDsByNames(3, []string{"Owners", "does-not-exist"}, false)
+	assert.True(t, IsErrTeamNotExist(err))
+}
+
+func TestGetTeamNamesByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	names, err := GetTeamNamesByID([]int64{2, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Owners", "team1"}, names)
+}
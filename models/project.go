@@ -0,0 +1,365 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ProjectColumnTemplate is the set of columns a new project starts with
+type ProjectColumnTemplate uint8
+
+// Enumerate all the column templates
+const (
+	// A single backlog column
+	ProjectColumnTemplateNone ProjectColumnTemplate = iota
+	// To do, in progress and done columns
+	ProjectColumnTemplateBasicKanban
+	// Columns to triage bugs by priority
+	ProjectColumnTemplateBugTriage
+)
+
+// projectColumnTemplates contains the titles of the columns of each template,
+// the first one is the default column new cards are added to
+var projectColumnTemplates = map[ProjectColumnTemplate][]string{
+	ProjectColumnTemplateNone:        {"Backlog"},
+	ProjectColumnTemplateBasicKanban: {"To Do", "In Progress", "Done"},
+	ProjectColumnTemplateBugTriage:   {"Needs Triage", "High Priority", "Low Priority", "Closed"},
+}
+
+// ProjectColumnTemplateConfig describes a column template offered when creating a project
+type ProjectColumnTemplateConfig struct {
+	Template    ProjectColumnTemplate
+	Translation string
+	Columns     []string
+}
+
+// GetProjectColumnTemplates returns the column templates projects can be created with
+func GetProjectColumnTemplates() []ProjectColumnTemplateConfig {
+	return []ProjectColumnTemplateConfig{
+		{ProjectColumnTemplateNone, "repo.projects.template.none", projectColumnTemplates[ProjectColumnTemplateNone]},
+		{ProjectColumnTemplateBasicKanban, "repo.projects.template.basic_kanban", projectColumnTemplates[ProjectColumnTemplateBasicKanban]},
+		{ProjectColumnTemplateBugTriage, "repo.projects.template.bug_triage", projectColumnTemplates[ProjectColumnTemplateBugTriage]},
+	}
+}
+
+// ProjectColumnTemplateFromName returns the column template of the given name,
+// as used by the API: none, basic_kanban or bug_triage.
+func ProjectColumnTemplateFromName(name string) (ProjectColumnTemplate, bool) {
+	for _, t := range GetProjectColumnTemplates() {
+		if t.Translation == "repo.projects.template."+name {
+			return t.Template, true
+		}
+	}
+	return ProjectColumnTemplateNone, false
+}
+
+// IsValid returns whether the template is known
+func (t ProjectColumnTemplate) IsValid() bool {
+	_, ok := projectColumnTemplates[t]
+	return ok
+}
+
+// Project represents a kanban board of a repository, or of an organization
+// when its cards may reference issues of all repositories of the organization
+type Project struct {
+	ID              int64  `xorm:"pk autoincr"`
+	RepoID          int64  `xorm:"INDEX"`
+	OwnerID         int64  `xorm:"INDEX NOT NULL DEFAULT 0"`
+	CreatorID       int64  `xorm:"NOT NULL"`
+	Creator         *User  `xorm:"-"`
+	Title           string `xorm:"NOT NULL"`
+	Description     string `xorm:"TEXT"`
+	RenderedContent string `xorm:"-"`
+	IsClosed        bool   `xorm:"INDEX"`
+
+	Columns  ProjectColumnList `xorm:"-"`
+	NumCards int               `xorm:"-"`
+
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	ClosedDateUnix timeutil.TimeStamp
+}
+
+// State returns string representation of project status.
+func (p *Project) State() api.StateType {
+	if p.IsClosed {
+		return api.StateClosed
+	}
+	return api.StateOpen
+}
+
+// IsOrganizationProject returns true if the project belongs to an organization instead of a repository.
+func (p *Project) IsOrganizationProject() bool {
+	return p.OwnerID > 0
+}
+
+func (p *Project) loadCreator(e Engine) (err error) {
+	if p.Creator != nil {
+		return nil
+	}
+	p.Creator, err = getUserByID(e, p.CreatorID)
+	if IsErrUserNotExist(err) {
+		p.Creator = NewGhostUser()
+		return nil
+	}
+	return err
+}
+
+// LoadCreator loads the user who created the project
+func (p *Project) LoadCreator() error {
+	return p.loadCreator(x)
+}
+
+// APIFormat returns this Project in API format.
+func (p *Project) APIFormat() *api.Project {
+	apiProject := &api.Project{
+		ID:          p.ID,
+		Title:       p.Title,
+		Description: p.Description,
+		State:       p.State(),
+		Created:     p.CreatedUnix.AsTime(),
+		Updated:     p.UpdatedUnix.AsTime(),
+	}
+	if p.Creator != nil {
+		apiProject.Creator = p.Creator.APIFormat()
+	}
+	if p.IsClosed {
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+	return apiProject
+}
+
+// NewProject creates a new project of a repository or an organization with the columns of the template.
+func NewProject(p *Project, template ProjectColumnTemplate) (err error) {
+	if !template.IsValid() {
+		template = ProjectColumnTemplateNone
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Insert(p); err != nil {
+		return err
+	}
+
+	columns := make([]*ProjectColumn, 0, len(projectColumnTemplates[template]))
+	for i, title := range projectColumnTemplates[template] {
+		columns = append(columns, &ProjectColumn{
+			ProjectID: p.ID,
+			CreatorID: p.CreatorID,
+			Title:     title,
+			IsDefault: i == 0,
+			Sorting:   i,
+		})
+	}
+	if _, err = sess.Insert(&columns); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func getProjectByID(e Engine, id int64) (*Project, error) {
+	p := new(Project)
+	has, err := e.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetProjectByID returns the project via id.
+func GetProjectByID(id int64) (*Project, error) {
+	return getProjectByID(x, id)
+}
+
+// GetProjectByRepoID returns the project in a repository.
+func GetProjectByRepoID(repoID, id int64) (*Project, error) {
+	p := &Project{
+		ID:     id,
+		RepoID: repoID,
+	}
+	has, err := x.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{ID: id, RepoID: repoID}
+	}
+	return p, nil
+}
+
+// GetProjectByOwnerID returns the project of an organization.
+func GetProjectByOwnerID(ownerID, id int64) (*Project, error) {
+	p := &Project{
+		ID:      id,
+		OwnerID: ownerID,
+	}
+	has, err := x.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{ID: id, OwnerID: ownerID}
+	}
+	return p, nil
+}
+
+// GetProjects returns a list of projects of given repository and status.
+func GetProjects(repoID int64, page int, isClosed bool, sortType string) ([]*Project, error) {
+	return getProjects(builder.Eq{"repo_id": repoID}, page, isClosed, sortType)
+}
+
+// GetOrgProjects returns a list of projects of given organization and status.
+func GetOrgProjects(ownerID int64, page int, isClosed bool, sortType string) ([]*Project, error) {
+	return getProjects(builder.Eq{"owner_id": ownerID}, page, isClosed, sortType)
+}
+
+func getProjects(cond builder.Cond, page int, isClosed bool, sortType string) ([]*Project, error) {
+	projects := make([]*Project, 0, setting.UI.IssuePagingNum)
+	sess := x.Where(cond.And(builder.Eq{"is_closed": isClosed}))
+	if page > 0 {
+		sess = sess.Limit(setting.UI.IssuePagingNum, (page-1)*setting.UI.IssuePagingNum)
+	}
+
+	switch sortType {
+	case "oldest":
+		sess.Asc("created_unix")
+	case "recentupdate":
+		sess.Desc("updated_unix")
+	case "leastupdate":
+		sess.Asc("updated_unix")
+	default:
+		sess.Desc("created_unix")
+	}
+	return projects, sess.Find(&projects)
+}
+
+// ProjectStats returns number of open and closed projects of given repository.
+func ProjectStats(repoID int64) (open int64, closed int64, err error) {
+	return projectStats(builder.Eq{"repo_id": repoID})
+}
+
+// OrgProjectStats returns number of open and closed projects of given organization.
+func OrgProjectStats(ownerID int64) (open int64, closed int64, err error) {
+	return projectStats(builder.Eq{"owner_id": ownerID})
+}
+
+func projectStats(cond builder.Cond) (open int64, closed int64, err error) {
+	open, err = x.
+		Where(cond.And(builder.Eq{"is_closed": false})).
+		Count(new(Project))
+	if err != nil {
+		return 0, 0, err
+	}
+	closed, err = x.
+		Where(cond.And(builder.Eq{"is_closed": true})).
+		Count(new(Project))
+	return open, closed, err
+}
+
+// UpdateProject updates the title and description of given project.
+func UpdateProject(p *Project) error {
+	_, err := x.ID(p.ID).Cols("title", "description").Update(p)
+	return err
+}
+
+// ChangeProjectStatus changes the project open/closed status.
+func ChangeProjectStatus(p *Project, isClosed bool) error {
+	p.IsClosed = isClosed
+	if isClosed {
+		p.ClosedDateUnix = timeutil.TimeStampNow()
+	}
+	_, err := x.ID(p.ID).Cols("is_closed", "closed_date_unix").Update(p)
+	return err
+}
+
+// DeleteProjectByRepoID deletes a project with its columns and cards from a repository.
+func DeleteProjectByRepoID(repoID, id int64) error {
+	p, err := GetProjectByRepoID(repoID, id)
+	if err != nil {
+		if IsErrProjectNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteProject(p)
+}
+
+// DeleteProjectByOwnerID deletes a project with its columns and cards from an organization.
+func DeleteProjectByOwnerID(ownerID, id int64) error {
+	p, err := GetProjectByOwnerID(ownerID, id)
+	if err != nil {
+		if IsErrProjectNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteProject(p)
+}
+
+func deleteProject(p *Project) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = deleteBeans(sess,
+		&ProjectCard{ProjectID: p.ID},
+		&ProjectColumn{ProjectID: p.ID},
+		&Project{ID: p.ID},
+	); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// deleteProjectsByRepoID deletes the projects of a repository and the cards of its issues
+func deleteProjectsByRepoID(e Engine, repoID int64) error {
+	projectIDs := builder.Select("id").From("project").Where(builder.Eq{"repo_id": repoID})
+	if _, err := e.In("project_id", projectIDs).Delete(new(ProjectCard)); err != nil {
+		return err
+	}
+	if _, err := e.In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})).
+		Delete(new(ProjectCard)); err != nil {
+		return err
+	}
+	if _, err := e.In("project_id", projectIDs).Delete(new(ProjectColumn)); err != nil {
+		return err
+	}
+	_, err := e.Delete(&Project{RepoID: repoID})
+	return err
+}
+
+// deleteProjectsByOwnerID deletes the projects of an organization
+func deleteProjectsByOwnerID(e Engine, ownerID int64) error {
+	projectIDs := builder.Select("id").From("project").Where(builder.Eq{"owner_id": ownerID})
+	if _, err := e.In("project_id", projectIDs).Delete(new(ProjectCard)); err != nil {
+		return err
+	}
+	if _, err := e.In("project_id", projectIDs).Delete(new(ProjectColumn)); err != nil {
+		return err
+	}
+	_, err := e.Delete(&Project{OwnerID: ownerID})
+	return err
+}
+
+// deleteOrgProjectCardsByRepoID removes the issues of a repository from the projects of
+// an organization, used when the repository leaves the organization
+func deleteOrgProjectCardsByRepoID(e Engine, ownerID, repoID int64) error {
+	_, err := e.
+		In("project_id", builder.Select("id").From("project").Where(builder.Eq{"owner_id": ownerID})).
+		In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})).
+		Delete(new(ProjectCard))
+	return err
+}
@@ -0,0 +1,298 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ProjectCard places an issue or a pull request in a column of a project
+type ProjectCard struct {
+	ID        int64 `xorm:"pk autoincr"`
+	ProjectID int64 `xorm:"UNIQUE(s) NOT NULL"`
+	IssueID   int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ColumnID  int64 `xorm:"INDEX NOT NULL"`
+	Sorting   int   `xorm:"NOT NULL DEFAULT 0"`
+
+	Issue   *Issue         `xorm:"-"`
+	Project *Project       `xorm:"-"`
+	Column  *ProjectColumn `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// APIFormat returns this ProjectCard in API format, the issue has to be loaded.
+func (c *ProjectCard) APIFormat() *api.ProjectCard {
+	apiCard := &api.ProjectCard{
+		ID:        c.ID,
+		ProjectID: c.ProjectID,
+		ColumnID:  c.ColumnID,
+		Sorting:   c.Sorting,
+		Created:   c.CreatedUnix.AsTime(),
+		Updated:   c.UpdatedUnix.AsTime(),
+	}
+	if c.Issue != nil {
+		apiCard.Issue = c.Issue.APIFormat()
+	}
+	return apiCard
+}
+
+// ProjectCardList is a list of cards
+type ProjectCardList []*ProjectCard
+
+func (cards ProjectCardList) loadIssues(e Engine) error {
+	issueIDs := make([]int64, 0, len(cards))
+	for _, card := range cards {
+		if card.Issue == nil {
+			issueIDs = append(issueIDs, card.IssueID)
+		}
+	}
+	if len(issueIDs) == 0 {
+		return nil
+	}
+
+	issues, err := getIssuesByIDs(e, issueIDs)
+	if err != nil {
+		return err
+	}
+	issueMap := make(map[int64]*Issue, len(issues))
+	for _, issue := range issues {
+		issueMap[issue.ID] = issue
+	}
+	for _, card := range cards {
+		if card.Issue == nil {
+			card.Issue = issueMap[card.IssueID]
+		}
+	}
+	return IssueList(issues).loadAttributes(e)
+}
+
+// LoadIssues loads the issues of the cards with their attributes
+func (cards ProjectCardList) LoadIssues() error {
+	return cards.loadIssues(x)
+}
+
+func getProjectCards(e Engine, projectID int64) (ProjectCardList, error) {
+	cards := make(ProjectCardList, 0, 10)
+	return cards, e.Where("project_id = ?", projectID).
+		Asc("sorting").
+		Asc("id").
+		Find(&cards)
+}
+
+// GetProjectCards returns the cards of all columns of a project in order.
+func GetProjectCards(projectID int64) (ProjectCardList, error) {
+	return getProjectCards(x, projectID)
+}
+
+func getProjectColumnCards(e Engine, columnID int64) (ProjectCardList, error) {
+	cards := make(ProjectCardList, 0, 10)
+	return cards, e.Where("column_id = ?", columnID).
+		Asc("sorting").
+		Asc("id").
+		Find(&cards)
+}
+
+// GetProjectColumnCards returns the cards of a column in order.
+func GetProjectColumnCards(columnID int64) (ProjectCardList, error) {
+	return getProjectColumnCards(x, columnID)
+}
+
+// nextProjectCardSorting returns the sorting which places a card at the end of the column
+func nextProjectCardSorting(e Engine, columnID int64) (int, error) {
+	last := new(ProjectCard)
+	has, err := e.Where("column_id = ?", columnID).Desc("sorting").Get(last)
+	if err != nil || !has {
+		return 0, err
+	}
+	return last.Sorting + 1, nil
+}
+
+// GetProjectCard returns the card of a project.
+func GetProjectCard(projectID, id int64) (*ProjectCard, error) {
+	card := &ProjectCard{
+		ID:        id,
+		ProjectID: projectID,
+	}
+	has, err := x.Get(card)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectCardNotExist{ID: id, ProjectID: projectID}
+	}
+	return card, nil
+}
+
+// GetProjectCardsByIssueID returns the cards of an issue with their projects and columns.
+func GetProjectCardsByIssueID(issueID int64) (ProjectCardList, error) {
+	cards := make(ProjectCardList, 0, 2)
+	if err := x.Where("issue_id = ?", issueID).Asc("project_id").Find(&cards); err != nil {
+		return nil, err
+	}
+	for _, card := range cards {
+		var err error
+		if card.Project, err = getProjectByID(x, card.ProjectID); err != nil {
+			return nil, err
+		}
+		if card.Column, err = getProjectColumn(x, card.ProjectID, card.ColumnID); err != nil {
+			return nil, err
+		}
+	}
+	return cards, nil
+}
+
+// LoadColumns loads the columns of the project with their cards. Cards whose issue
+// can not be loaded any more, or no longer belongs to the organization of the project,
+// are left out.
+func (p *Project) LoadColumns() (err error) {
+	if p.Columns, err = getProjectColumns(x, p.ID); err != nil {
+		return err
+	}
+	cards, err := getProjectCards(x, p.ID)
+	if err != nil {
+		return err
+	}
+	if err = cards.loadIssues(x); err != nil {
+		return err
+	}
+
+	columnMap := make(map[int64]*ProjectColumn, len(p.Columns))
+	for _, column := range p.Columns {
+		columnMap[column.ID] = column
+	}
+	p.NumCards = 0
+	for _, card := range cards {
+		column, ok := columnMap[card.ColumnID]
+		if !ok || card.Issue == nil {
+			continue
+		}
+		if p.IsOrganizationProject() && (card.Issue.Repo == nil || card.Issue.Repo.OwnerID != p.OwnerID) {
+			continue
+		}
+		card.Column = column
+		column.Cards = append(column.Cards, card)
+		p.NumCards++
+	}
+	return nil
+}
+
+// AddProjectCard adds a card for the issue to the end of the column, or to the end of the
+// default column of the project if columnID is 0. The issue has to belong to the repository
+// of the project, or to a repository of the organization of the project.
+func AddProjectCard(doer *User, project *Project, issue *Issue, columnID int64) (_ *ProjectCard, err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if err = issue.loadRepo(sess); err != nil {
+		return nil, err
+	}
+	if (project.IsOrganizationProject() && issue.Repo.OwnerID != project.OwnerID) ||
+		(!project.IsOrganizationProject() && issue.RepoID != project.RepoID) {
+		return nil, ErrIssueNotExist{ID: issue.ID}
+	}
+
+	var column *ProjectColumn
+	if columnID == 0 {
+		column, err = getDefaultProjectColumn(sess, project.ID)
+	} else {
+		column, err = getProjectColumn(sess, project.ID, columnID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	has, err := sess.Exist(&ProjectCard{ProjectID: project.ID, IssueID: issue.ID})
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrProjectCardAlreadyExist{ProjectID: project.ID, IssueID: issue.ID}
+	}
+
+	sorting, err := nextProjectCardSorting(sess, column.ID)
+	if err != nil {
+		return nil, err
+	}
+	card := &ProjectCard{
+		ProjectID: project.ID,
+		IssueID:   issue.ID,
+		ColumnID:  column.ID,
+		Sorting:   sorting,
+		Issue:     issue,
+		Project:   project,
+		Column:    column,
+	}
+	if _, err = sess.Insert(card); err != nil {
+		return nil, err
+	}
+
+	if _, err = createCommentWithNoAction(sess, &CreateCommentOptions{
+		Type:      CommentTypeProject,
+		Doer:      doer,
+		Repo:      issue.Repo,
+		Issue:     issue,
+		ProjectID: project.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return card, sess.Commit()
+}
+
+// DeleteProjectCard removes the card of an issue from its project.
+func DeleteProjectCard(doer *User, card *ProjectCard) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.ID(card.ID).Delete(new(ProjectCard)); err != nil {
+		return err
+	}
+
+	if card.Issue == nil {
+		if card.Issue, err = getIssueByID(sess, card.IssueID); err != nil {
+			return err
+		}
+	}
+	if err = card.Issue.loadRepo(sess); err != nil {
+		return err
+	}
+	if _, err = createCommentWithNoAction(sess, &CreateCommentOptions{
+		Type:         CommentTypeProject,
+		Doer:         doer,
+		Repo:         card.Issue.Repo,
+		Issue:        card.Issue,
+		OldProjectID: card.ProjectID,
+	}); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// MoveProjectCards moves the cards into the column in the order of cardIDs,
+// ids of cards of other projects are ignored.
+func MoveProjectCards(column *ProjectColumn, cardIDs []int64) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for i, id := range cardIDs {
+		if _, err = sess.Where("id = ? AND project_id = ?", id, column.ProjectID).
+			Cols("column_id", "sorting").
+			Update(&ProjectCard{ColumnID: column.ID, Sorting: i}); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
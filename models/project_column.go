@@ -0,0 +1,193 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ProjectColumn is a column of a project holding cards
+type ProjectColumn struct {
+	ID        int64  `xorm:"pk autoincr"`
+	ProjectID int64  `xorm:"INDEX NOT NULL"`
+	CreatorID int64  `xorm:"NOT NULL"`
+	Title     string `xorm:"NOT NULL"`
+	// New cards are added to the default column of a project
+	IsDefault bool `xorm:"NOT NULL DEFAULT false"`
+	Sorting   int  `xorm:"NOT NULL DEFAULT 0"`
+
+	Cards ProjectCardList `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// APIFormat returns this ProjectColumn in API format.
+func (c *ProjectColumn) APIFormat() *api.ProjectColumn {
+	return &api.ProjectColumn{
+		ID:        c.ID,
+		ProjectID: c.ProjectID,
+		Title:     c.Title,
+		Default:   c.IsDefault,
+		Sorting:   c.Sorting,
+		Created:   c.CreatedUnix.AsTime(),
+		Updated:   c.UpdatedUnix.AsTime(),
+	}
+}
+
+// ProjectColumnList is a list of columns of a project
+type ProjectColumnList []*ProjectColumn
+
+func getProjectColumns(e Engine, projectID int64) (ProjectColumnList, error) {
+	columns := make(ProjectColumnList, 0, 5)
+	return columns, e.Where("project_id = ?", projectID).
+		Asc("sorting").
+		Asc("id").
+		Find(&columns)
+}
+
+// GetProjectColumns returns the columns of a project in order.
+func GetProjectColumns(projectID int64) (ProjectColumnList, error) {
+	return getProjectColumns(x, projectID)
+}
+
+func getDefaultProjectColumn(e Engine, projectID int64) (*ProjectColumn, error) {
+	column := new(ProjectColumn)
+	has, err := e.Where("project_id = ? AND is_default = ?", projectID, true).Get(column)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectColumnNotExist{ProjectID: projectID}
+	}
+	return column, nil
+}
+
+func getProjectColumn(e Engine, projectID, id int64) (*ProjectColumn, error) {
+	column := &ProjectColumn{
+		ID:        id,
+		ProjectID: projectID,
+	}
+	has, err := e.Get(column)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectColumnNotExist{ID: id, ProjectID: projectID}
+	}
+	return column, nil
+}
+
+// GetProjectColumn returns the column of a project.
+func GetProjectColumn(projectID, id int64) (*ProjectColumn, error) {
+	return getProjectColumn(x, projectID, id)
+}
+
+// NewProjectColumn adds a column after the existing columns of a project,
+// the first column of a project becomes its default column.
+func NewProjectColumn(column *ProjectColumn) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	columns, err := getProjectColumns(sess, column.ProjectID)
+	if err != nil {
+		return err
+	}
+	column.IsDefault = len(columns) == 0
+	column.Sorting = 0
+	if len(columns) > 0 {
+		column.Sorting = columns[len(columns)-1].Sorting + 1
+	}
+
+	if _, err = sess.Insert(column); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// UpdateProjectColumn updates the title of a column.
+func UpdateProjectColumn(column *ProjectColumn) error {
+	_, err := x.ID(column.ID).Cols("title").Update(column)
+	return err
+}
+
+// SetDefaultProjectColumn makes the column the one new cards of its project are added to.
+func SetDefaultProjectColumn(column *ProjectColumn) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Where("project_id = ?", column.ProjectID).
+		Cols("is_default").
+		Update(&ProjectColumn{IsDefault: false}); err != nil {
+		return err
+	}
+	column.IsDefault = true
+	if _, err = sess.ID(column.ID).Cols("is_default").Update(column); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// DeleteProjectColumn deletes a column, its cards are moved to the end of the default column.
+func DeleteProjectColumn(column *ProjectColumn) (err error) {
+	if column.IsDefault {
+		return ErrProjectColumnIsDefault{ID: column.ID}
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	defaultColumn, err := getDefaultProjectColumn(sess, column.ProjectID)
+	if err != nil {
+		return err
+	}
+	cards, err := getProjectColumnCards(sess, column.ID)
+	if err != nil {
+		return err
+	}
+	sorting, err := nextProjectCardSorting(sess, defaultColumn.ID)
+	if err != nil {
+		return err
+	}
+	for i, card := range cards {
+		card.ColumnID = defaultColumn.ID
+		card.Sorting = sorting + i
+		if _, err = sess.ID(card.ID).Cols("column_id", "sorting").Update(card); err != nil {
+			return err
+		}
+	}
+
+	if _, err = sess.ID(column.ID).Delete(new(ProjectColumn)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// SortProjectColumns orders the columns of a project as given by columnIDs,
+// ids of columns of other projects are ignored.
+func SortProjectColumns(projectID int64, columnIDs []int64) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for i, id := range columnIDs {
+		if _, err = sess.Where("id = ? AND project_id = ?", id, projectID).
+			Cols("sorting").
+			Update(&ProjectColumn{Sorting: i}); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
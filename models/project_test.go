@@ -0,0 +1,235 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProject_State(t *testing.T) {
+	assert.Equal(t, api.StateOpen, (&Project{IsClosed: false}).State())
+	assert.Equal(t, api.StateClosed, (&Project{IsClosed: true}).State())
+}
+
+func TestNewProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := &Project{
+		RepoID:    1,
+		CreatorID: 2,
+		Title:     "triage",
+	}
+	assert.NoError(t, NewProject(project, ProjectColumnTemplateBugTriage))
+	AssertExistsAndLoadBean(t, project)
+
+	columns, err := GetProjectColumns(project.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, columns, 4) {
+		assert.Equal(t, "Needs Triage", columns[0].Title)
+		assert.True(t, columns[0].IsDefault)
+		assert.Equal(t, "Closed", columns[3].Title)
+		assert.False(t, columns[3].IsDefault)
+	}
+
+	project = &Project{RepoID: 1, CreatorID: 2, Title: "unknown template"}
+	assert.NoError(t, NewProject(project, ProjectColumnTemplate(100)))
+	AssertCount(t, &ProjectColumn{ProjectID: project.ID}, 1)
+}
+
+func TestProjectColumnTemplateFromName(t *testing.T) {
+	template, ok := ProjectColumnTemplateFromName("basic_kanban")
+	assert.True(t, ok)
+	assert.Equal(t, ProjectColumnTemplateBasicKanban, template)
+
+	_, ok = ProjectColumnTemplateFromName("scrum")
+	assert.False(t, ok)
+}
+
+func TestProject_LoadColumns(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	assert.NoError(t, project.LoadColumns())
+	assert.EqualValues(t, 3, project.NumCards)
+	if assert.Len(t, project.Columns, 3) {
+		assert.Len(t, project.Columns[0].Cards, 1)
+		if assert.Len(t, project.Columns[1].Cards, 2) {
+			assert.EqualValues(t, 2, project.Columns[1].Cards[0].IssueID)
+			assert.EqualValues(t, 5, project.Columns[1].Cards[1].IssueID)
+		}
+		assert.Len(t, project.Columns[2].Cards, 0)
+	}
+}
+
+func TestAddProjectCard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	project := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+
+	card, err := AddProjectCard(doer, project, issue, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, card.ColumnID)
+	assert.EqualValues(t, 1, card.Sorting)
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypeProject, IssueID: issue.ID, ProjectID: project.ID})
+
+	_, err = AddProjectCard(doer, project, issue, 3)
+	assert.True(t, IsErrProjectCardAlreadyExist(err))
+
+	_, err = AddProjectCard(doer, project, AssertExistsAndLoadBean(t, &Issue{ID: 5}).(*Issue), 4)
+	assert.True(t, IsErrProjectColumnNotExist(err))
+
+	// issue 4 belongs to another repository
+	_, err = AddProjectCard(doer, project, AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue), 0)
+	assert.True(t, IsErrIssueNotExist(err))
+}
+
+func TestDeleteProjectCard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	card := AssertExistsAndLoadBean(t, &ProjectCard{ID: 1}).(*ProjectCard)
+	assert.NoError(t, DeleteProjectCard(doer, card))
+	AssertNotExistsBean(t, &ProjectCard{ID: 1})
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypeProject, IssueID: 1, OldProjectID: 1})
+}
+
+func TestMoveProjectCards(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	column := AssertExistsAndLoadBean(t, &ProjectColumn{ID: 3}).(*ProjectColumn)
+	// card 4 belongs to another project, it is ignored
+	assert.NoError(t, MoveProjectCards(column, []int64{3, 4, 1}))
+
+	cards, err := GetProjectColumnCards(column.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, cards, 2) {
+		assert.EqualValues(t, 3, cards[0].ID)
+		assert.EqualValues(t, 1, cards[1].ID)
+	}
+	AssertCount(t, &ProjectCard{ColumnID: 2}, 1)
+}
+
+func TestDeleteProjectColumn(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	column := AssertExistsAndLoadBean(t, &ProjectColumn{ID: 1}).(*ProjectColumn)
+	assert.True(t, IsErrProjectColumnIsDefault(DeleteProjectColumn(column)))
+
+	column = AssertExistsAndLoadBean(t, &ProjectColumn{ID: 2}).(*ProjectColumn)
+	assert.NoError(t, DeleteProjectColumn(column))
+	AssertNotExistsBean(t, &ProjectColumn{ID: 2})
+
+	cards, err := GetProjectColumnCards(1)
+	assert.NoError(t, err)
+	if assert.Len(t, cards, 3) {
+		assert.EqualValues(t, 1, cards[0].ID)
+		assert.EqualValues(t, 2, cards[1].ID)
+		assert.EqualValues(t, 3, cards[2].ID)
+	}
+}
+
+func TestSetDefaultProjectColumn(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	column := AssertExistsAndLoadBean(t, &ProjectColumn{ID: 3}).(*ProjectColumn)
+	assert.NoError(t, SetDefaultProjectColumn(column))
+	assert.True(t, AssertExistsAndLoadBean(t, &ProjectColumn{ID: 3}).(*ProjectColumn).IsDefault)
+	assert.False(t, AssertExistsAndLoadBean(t, &ProjectColumn{ID: 1}).(*ProjectColumn).IsDefault)
+}
+
+func TestDeleteProjectByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteProjectByRepoID(1, 1))
+	AssertNotExistsBean(t, &Project{ID: 1})
+	AssertCount(t, &ProjectColumn{ProjectID: 1}, 0)
+	AssertCount(t, &ProjectCard{ProjectID: 1}, 0)
+	AssertExistsAndLoadBean(t, &Project{ID: 2})
+
+	// deleting a project of another repository does nothing
+	assert.NoError(t, DeleteProjectByRepoID(2, 2))
+	AssertExistsAndLoadBean(t, &Project{ID: 2})
+}
+
+func TestGetOrgProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	projects, err := GetOrgProjects(3, 1, false, "")
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 3, projects[0].ID)
+		assert.True(t, projects[0].IsOrganizationProject())
+	}
+
+	open, closed, err := OrgProjectStats(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, open)
+	assert.EqualValues(t, 0, closed)
+
+	_, err = GetProjectByOwnerID(3, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+	project, err := GetProjectByOwnerID(3, 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, project.ID)
+}
+
+func TestAddProjectCard_Organization(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	project := AssertExistsAndLoadBean(t, &Project{ID: 3}).(*Project)
+
+	// issue 1 belongs to a repository of user2, not of org3
+	_, err := AddProjectCard(doer, project, AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue), 0)
+	assert.True(t, IsErrIssueNotExist(err))
+
+	assert.NoError(t, project.LoadColumns())
+	if assert.Len(t, project.Columns, 1) && assert.Len(t, project.Columns[0].Cards, 1) {
+		assert.EqualValues(t, 6, project.Columns[0].Cards[0].IssueID)
+	}
+}
+
+func TestUser_GetProjectsAccessMode(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	testSuccess := func(userID int64, expected AccessMode) {
+		var user *User
+		if userID > 0 {
+			user = AssertExistsAndLoadBean(t, &User{ID: userID}).(*User)
+		}
+		mode, err := org.GetProjectsAccessMode(user)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+	testSuccess(0, AccessModeNone)
+	testSuccess(1, AccessModeOwner)
+	testSuccess(2, AccessModeOwner)
+	testSuccess(4, AccessModeNone)
+	testSuccess(5, AccessModeNone)
+
+	_, err := x.Insert(&TeamUnit{OrgID: 3, TeamID: 2, Type: UnitTypeProjects})
+	assert.NoError(t, err)
+	testSuccess(4, AccessModeWrite)
+}
+
+func TestDeleteProjectByOwnerID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// deleting a project of a repository does nothing
+	assert.NoError(t, DeleteProjectByOwnerID(3, 1))
+	AssertExistsAndLoadBean(t, &Project{ID: 1})
+
+	assert.NoError(t, DeleteProjectByOwnerID(3, 3))
+	AssertNotExistsBean(t, &Project{ID: 3})
+	AssertCount(t, &ProjectColumn{ProjectID: 3}, 0)
+	AssertCount(t, &ProjectCard{ProjectID: 3}, 0)
+}
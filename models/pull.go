@@ -8,6 +8,7 @@ package models
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -54,6 +55,7 @@ type PullRequest struct {
 	Type            PullRequestType
 	Status          PullRequestStatus
 	ConflictedFiles []string `xorm:"TEXT JSON"`
+	BinaryFiles     []string `xorm:"TEXT JSON"`
 
 	IssueID int64  `xorm:"INDEX"`
 	Issue   *Issue `xorm:"-"`
@@ -203,6 +205,15 @@ func (pr *PullRequest) GetGitRefName() string {
 	return fmt.Sprintf("refs/pull/%d/head", pr.Index)
 }
 
+// GetBaseRefName returns the git ref the pull request is merged into,
+// a base outside of the branches is stored with its full ref name
+func (pr *PullRequest) GetBaseRefName() string {
+	if strings.HasPrefix(pr.BaseBranch, "refs/") {
+		return pr.BaseBranch
+	}
+	return git.BranchPrefix + pr.BaseBranch
+}
+
 // APIFormat assumes following fields have been assigned with valid values:
 // Required - Issue
 // Optional - Merger
@@ -210,6 +221,31 @@ func (pr *PullRequest) APIFormat() *api.PullRequest {
 	return pr.apiFormat(x)
 }
 
+// apiBaseRefInfo returns the base of a pull request merged into a ref outside of the branches,
+// or nil if there is no such ref
+func (pr *PullRequest) apiBaseRefInfo(e Engine) *api.PRBranchInfo {
+	if !strings.HasPrefix(pr.BaseBranch, "refs/") {
+		return nil
+	}
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", pr.BaseRepo.RepoPath(), err)
+		return nil
+	}
+	defer gitRepo.Close()
+	sha, err := gitRepo.GetRefCommitID(pr.BaseBranch)
+	if err != nil {
+		return nil
+	}
+	return &api.PRBranchInfo{
+		Name:       pr.BaseBranch,
+		Ref:        pr.BaseBranch,
+		Sha:        sha,
+		RepoID:     pr.BaseRepoID,
+		Repository: pr.BaseRepo.innerAPIFormat(e, AccessModeNone, false),
+	}
+}
+
 func (pr *PullRequest) apiFormat(e Engine) *api.PullRequest {
 	var (
 		baseBranch *git.Branch
@@ -268,7 +304,7 @@ func (pr *PullRequest) apiFormat(e Engine) *api.PullRequest {
 	baseBranch, err = pr.BaseRepo.GetBranch(pr.BaseBranch)
 	if err != nil {
 		if git.IsErrBranchNotExist(err) {
-			apiPullRequest.Base = nil
+			apiPullRequest.Base = pr.apiBaseRefInfo(e)
 		} else {
 			log.Error("GetBranch[%s]: %v", pr.BaseBranch, err)
 			return nil
@@ -589,6 +625,67 @@ var patchConflicts = []string{
 	"error:",
 }
 
+// binaryFilesOfPatch returns the paths of the binary files and of the Git LFS pointers changed by a patch,
+// they can't be merged line by line so concurrent changes to them can't be reconciled.
+func binaryFilesOfPatch(repoPath, patchPath string) ([]string, error) {
+	stdout, err := git.NewCommand("apply", "--numstat", "-z", patchPath).RunInDirWithEnv("", []string{"GIT_DIR=" + repoPath})
+	if err != nil {
+		return nil, fmt.Errorf("git apply --numstat: %v", err)
+	}
+
+	// The files of the patch are listed in the same order by git apply
+	lfsPointers := make(map[int]bool)
+	patch, err := os.Open(patchPath)
+	if err != nil {
+		return nil, err
+	}
+	defer patch.Close()
+	reader := bufio.NewReader(patch)
+	fileIndex := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, "diff --git ") {
+			fileIndex++
+		} else if fileIndex >= 0 && len(line) > 0 && strings.ContainsRune(" +-", rune(line[0])) &&
+			strings.TrimSpace(line[1:]) == LFSMetaFileIdentifier {
+			lfsPointers[fileIndex] = true
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Each file is listed as "added\tdeleted\tpath\0" or "added\tdeleted\t\0old path\0new path\0" when renamed,
+	// added and deleted are "-" for binary files.
+	binaryFiles := make([]string, 0, 5)
+	seen := make(map[string]bool)
+	fields := strings.Split(stdout, "\x00")
+	fileIndex = 0
+	for i := 0; i < len(fields); i++ {
+		stats := strings.SplitN(fields[i], "\t", 3)
+		if len(stats) != 3 {
+			continue
+		}
+		paths := stats[2:]
+		if stats[2] == "" && i+2 < len(fields) {
+			paths = fields[i+1 : i+3]
+			i += 2
+		}
+		if (stats[0] == "-" && stats[1] == "-") || lfsPointers[fileIndex] {
+			for _, p := range paths {
+				if !seen[p] {
+					seen[p] = true
+					binaryFiles = append(binaryFiles, p)
+				}
+			}
+		}
+		fileIndex++
+	}
+	return binaryFiles, nil
+}
+
 // testPatch checks if patch can be merged to base repository without conflict.
 func (pr *PullRequest) testPatch(e Engine) (err error) {
 	if pr.BaseRepo == nil {
@@ -616,6 +713,11 @@ func (pr *PullRequest) testPatch(e Engine) (err error) {
 
 	pr.Status = PullRequestStatusChecking
 
+	pr.BinaryFiles, err = binaryFilesOfPatch(pr.BaseRepo.RepoPath(), patchPath)
+	if err != nil {
+		return fmt.Errorf("binaryFilesOfPatch: %v", err)
+	}
+
 	indexTmpPath := filepath.Join(os.TempDir(), "gitea-"+pr.BaseRepo.Name+"-"+strconv.Itoa(time.Now().Nanosecond()))
 	defer os.Remove(indexTmpPath)
 
@@ -969,7 +1071,7 @@ func (pr *PullRequest) checkAndUpdateStatus() {
 
 	// Make sure there is no waiting test to process before leaving the checking status.
 	if !pullRequestQueue.Exist(pr.ID) {
-		if err := pr.UpdateCols("status, conflicted_files"); err != nil {
+		if err := pr.UpdateCols("status, conflicted_files, binary_files"); err != nil {
 			log.Error("Update[%d]: %v", pr.ID, err)
 		}
 	}
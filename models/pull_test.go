@@ -5,6 +5,8 @@
 package models
 
 import (
+	"io/ioutil"
+	"os"
 	"strconv"
 	"testing"
 	"time"
@@ -262,3 +264,40 @@ func TestPullRequest_GetWorkInProgressPrefixWorkInProgress(t *testing.T) {
 	pr.Issue.Title = "[wip] " + original
 	assert.Equal(t, "[wip]", pr.GetWorkInProgressPrefix())
 }
+
+func TestBinaryFilesOfPatch(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	patch := `diff --git a/pages/page_12.png b/pages/page_12.png
+new file mode 100644
+index 0000000..2222222
+Binary files /dev/null and b/pages/page_12.png differ
+diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-# repo1
++# repo1 with pages
+diff --git a/pages/cover.psd b/pages/cover.psd
+index 1111111..2222222 100644
+--- a/pages/cover.psd
++++ b/pages/cover.psd
+@@ -1,3 +1,3 @@
+ version https://git-lfs.github.com/spec/v1
+-oid sha256:2eccdb43825d2a49d99d542daa20075cff1d97d9d2349a8977efe9c03661737c
+-size 107
++oid sha256:7b6b2c88dba9f760a1a58469b67fee2b698ef7e9399c4ca4f34a14ccbe39f623
++size 27
+`
+	f, err := ioutil.TempFile("", "binary-files-*.patch")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(patch)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	binaryFiles, err := binaryFilesOfPatch(RepoPath("user2", "repo1"), f.Name())
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"pages/page_12.png", "pages/cover.psd"}, binaryFiles)
+}
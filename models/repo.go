@@ -14,7 +14,6 @@ import (
 
 	// Needed for jpeg support
 	_ "image/jpeg"
-	"image/png"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -31,6 +30,7 @@ import (
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/sync"
@@ -345,6 +345,14 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 		allowRebaseMerge = config.AllowRebaseMerge
 		allowSquash = config.AllowSquash
 	}
+	hasProjects := false
+	if _, err := repo.getUnit(e, UnitTypeProjects); err == nil {
+		hasProjects = true
+	}
+	hasKeystone := false
+	if _, err := repo.getUnit(e, UnitTypeKeystone); err == nil {
+		hasKeystone = true
+	}
 
 	return &api.Repository{
 		ID:                        repo.ID,
@@ -385,6 +393,8 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 		AllowRebase:               allowRebase,
 		AllowRebaseMerge:          allowRebaseMerge,
 		AllowSquash:               allowSquash,
+		HasProjects:               hasProjects,
+		HasKeystone:               hasKeystone,
 		AvatarURL:                 repo.avatarLink(e),
 	}
 }
@@ -1696,6 +1706,9 @@ func TransferOwnership(doer *User, newOwnerName string, repo *Repository) error
 		if err = oldOwner.removeOrgRepo(sess, repo.ID); err != nil {
 			return fmt.Errorf("removeOrgRepo: %v", err)
 		}
+		if err = deleteOrgProjectCardsByRepoID(sess, oldOwner.ID, repo.ID); err != nil {
+			return fmt.Errorf("deleteOrgProjectCardsByRepoID: %v", err)
+		}
 	}
 
 	if newOwner.IsOrganization() {
@@ -2009,6 +2022,22 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteProjectsByRepoID(sess, repoID); err != nil {
+		return fmt.Errorf("deleteProjectsByRepoID: %v", err)
+	}
+
+	if err = deleteVariantsByRepoID(sess, repoID); err != nil {
+		return fmt.Errorf("deleteVariantsByRepoID: %v", err)
+	}
+
+	if err = deleteAssetUsagesByRepoID(sess, repoID); err != nil {
+		return fmt.Errorf("deleteAssetUsagesByRepoID: %v", err)
+	}
+
+	if err = deleteAssetTagsByRepoID(sess, repoID); err != nil {
+		return fmt.Errorf("deleteAssetTagsByRepoID: %v", err)
+	}
+
 	deleteCond := builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})
 	// Delete comments and attachments
 	if _, err = sess.In("issue_id", deleteCond).
@@ -2044,7 +2073,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 	for j := range attachments {
-		attachmentPaths = append(attachmentPaths, attachments[j].LocalPath())
+		attachmentPaths = append(attachmentPaths, attachments[j].RelativePath())
 	}
 
 	if _, err = sess.In("issue_id", deleteCond).
@@ -2081,7 +2110,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 
 	// Remove attachment files.
 	for i := range attachmentPaths {
-		removeAllWithNotice(sess, "Delete attachment", attachmentPaths[i])
+		removeStorageWithNotice(sess, storage.Attachments, "Delete attachment", attachmentPaths[i])
 	}
 
 	// Remove LFS objects
@@ -2099,8 +2128,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 			continue
 		}
 
-		oidPath := filepath.Join(setting.LFS.ContentPath, v.Oid[0:2], v.Oid[2:4], v.Oid[4:len(v.Oid)])
-		removeAllWithNotice(sess, "Delete orphaned LFS file", oidPath)
+		removeStorageWithNotice(sess, storage.LFS, "Delete orphaned LFS file", v.RelativePath())
 	}
 
 	if _, err := sess.Delete(&LFSMetaObject{RepositoryID: repoID}); err != nil {
@@ -2124,11 +2152,8 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 
 	if len(repo.Avatar) > 0 {
-		avatarPath := repo.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		if err := deleteAvatar(storage.RepoAvatars, repo.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", repo.Avatar, err)
 		}
 	}
 
@@ -2702,13 +2727,9 @@ func (repo *Repository) GetUserFork(userID int64) (*Repository, error) {
 	return &forkedRepo, nil
 }
 
-// CustomAvatarPath returns repository custom avatar file path.
-func (repo *Repository) CustomAvatarPath() string {
-	// Avatar empty by default
-	if len(repo.Avatar) == 0 {
-		return ""
-	}
-	return filepath.Join(setting.RepositoryAvatarUploadPath, repo.Avatar)
+// CustomAvatarRelativePath returns the path of the repository custom avatar in the repository avatar storage.
+func (repo *Repository) CustomAvatarRelativePath() string {
+	return repo.Avatar
 }
 
 // generateRandomAvatar generates a random avatar for repository.
@@ -2722,17 +2743,8 @@ func (repo *Repository) generateRandomAvatar(e Engine) error {
 	}
 
 	repo.Avatar = idToString
-	if err = os.MkdirAll(filepath.Dir(repo.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err = saveAvatar(storage.RepoAvatars, repo.CustomAvatarRelativePath(), img); err != nil {
+		return err
 	}
 	log.Info("New random avatar created for repository: %d", repo.ID)
 
@@ -2743,6 +2755,16 @@ func (repo *Repository) generateRandomAvatar(e Engine) error {
 	return nil
 }
 
+// IterateRepoAvatars iterates the repositories having an avatar in the repository avatar storage.
+func IterateRepoAvatars(f func(repo *Repository) error) error {
+	return x.
+		Where("avatar != ''").BufferSize(setting.Database.IterateBufferSize).
+		Iterate(new(Repository),
+			func(idx int, bean interface{}) error {
+				return f(bean.(*Repository))
+			})
+}
+
 // RemoveRandomAvatars removes the randomly generated avatars that were created for repositories
 func RemoveRandomAvatars() error {
 	return x.
@@ -2765,8 +2787,7 @@ func (repo *Repository) RelAvatarLink() string {
 
 func (repo *Repository) relAvatarLink(e Engine) string {
 	// If no avatar - path is empty
-	avatarPath := repo.CustomAvatarPath()
-	if len(avatarPath) == 0 || !com.IsFile(avatarPath) {
+	if !avatarExists(storage.RepoAvatars, repo.CustomAvatarRelativePath()) {
 		switch mode := setting.RepositoryAvatarFallback; mode {
 		case "image":
 			return setting.RepositoryAvatarFallbackImage
@@ -2808,7 +2829,7 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return err
 	}
 
-	oldAvatarPath := repo.CustomAvatarPath()
+	oldAvatarPath := repo.CustomAvatarRelativePath()
 
 	// Users can upload the same image to other repo - prefix it with ID
 	// Then repo will be removed - only it avatar file will be removed
@@ -2817,22 +2838,12 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return fmt.Errorf("UploadAvatar: Update repository avatar: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.RepositoryAvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("UploadAvatar: Failed to create dir %s: %v", setting.RepositoryAvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("UploadAvatar: Create file: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("UploadAvatar: Encode png: %v", err)
+	if err = saveAvatar(storage.RepoAvatars, repo.CustomAvatarRelativePath(), *m); err != nil {
+		return fmt.Errorf("UploadAvatar: %v", err)
 	}
 
-	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarPath() {
-		if err := os.Remove(oldAvatarPath); err != nil {
+	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarRelativePath() {
+		if err := deleteAvatar(storage.RepoAvatars, oldAvatarPath); err != nil {
 			return fmt.Errorf("UploadAvatar: Failed to remove old repo avatar %s: %v", oldAvatarPath, err)
 		}
 	}
@@ -2848,7 +2859,7 @@ func (repo *Repository) DeleteAvatar() error {
 		return nil
 	}
 
-	avatarPath := repo.CustomAvatarPath()
+	avatarPath := repo.CustomAvatarRelativePath()
 	log.Trace("DeleteAvatar[%d]: %s", repo.ID, avatarPath)
 
 	sess := x.NewSession()
@@ -2862,13 +2873,8 @@ func (repo *Repository) DeleteAvatar() error {
 		return fmt.Errorf("DeleteAvatar: Update repository avatar: %v", err)
 	}
 
-	if _, err := os.Stat(avatarPath); err == nil {
-		if err := os.Remove(avatarPath); err != nil {
-			return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
-		}
-	} else {
-		// // Schrodinger: file may or may not exist. See err for details.
-		log.Trace("DeleteAvatar[%d]: %v", err)
+	if err := deleteAvatar(storage.RepoAvatars, avatarPath); err != nil {
+		return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
 	}
 	return sess.Commit()
 }
@@ -130,6 +130,30 @@ func GetActivityStatsTopAuthors(repo *Repository, timeFrom time.Time, count int)
 	return v[:cnt], nil
 }
 
+// GetVariantsActivityStats returns code statistics of the branches of the variants of a repository
+// since given time, keyed by variant id
+func GetVariantsActivityStats(repo *Repository, variants []*Variant, timeFrom time.Time) (map[int64]*git.CodeActivityStats, error) {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	stats := make(map[int64]*git.CodeActivityStats, len(variants))
+	for _, v := range variants {
+		if !gitRepo.IsBranchExist(v.BranchName) {
+			stats[v.ID] = &git.CodeActivityStats{}
+			continue
+		}
+		code, err := gitRepo.GetCodeActivityStats(timeFrom, v.BranchName)
+		if err != nil {
+			return nil, fmt.Errorf("GetCodeActivityStats[%s]: %v", v.BranchName, err)
+		}
+		stats[v.ID] = code
+	}
+	return stats, nil
+}
+
 // ActivePRCount returns total active pull request count
 func (stats *ActivityStats) ActivePRCount() int {
 	return stats.OpenedPRCount() + stats.MergedPRCount()
@@ -5,8 +5,11 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 
 	"xorm.io/builder"
@@ -27,6 +30,8 @@ const (
 	ReviewTypeComment
 	// ReviewTypeReject gives feedback blocking merge
 	ReviewTypeReject
+	// ReviewTypeRequest is a review requested from a reviewer, until they submit one
+	ReviewTypeRequest
 )
 
 // Icon returns the corresponding icon for the review type
@@ -36,6 +41,8 @@ func (rt ReviewType) Icon() string {
 		return "eye"
 	case ReviewTypeReject:
 		return "x"
+	case ReviewTypeRequest:
+		return "primitive-dot"
 	case ReviewTypeComment, ReviewTypeUnknown:
 		return "comment"
 	default:
@@ -54,6 +61,10 @@ type Review struct {
 	Content    string `xorm:"TEXT"`
 	// Official is a review made by an assigned approver (counts towards approval)
 	Official bool `xorm:"NOT NULL DEFAULT false"`
+	// Dismissed is an approval or a rejection which doesn't count anymore
+	Dismissed bool `xorm:"NOT NULL DEFAULT false"`
+	// CommitID is the head commit of the pull request when the review was submitted
+	CommitID string `xorm:"VARCHAR(40)"`
 
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
@@ -165,6 +176,7 @@ type CreateReviewOptions struct {
 	Issue    *Issue
 	Reviewer *User
 	Official bool
+	CommitID string
 }
 
 // IsOfficialReviewer check if reviewer can make official reviews in issue (counts towards required approvals)
@@ -196,6 +208,7 @@ func createReview(e Engine, opts CreateReviewOptions) (*Review, error) {
 		ReviewerID: opts.Reviewer.ID,
 		Content:    opts.Content,
 		Official:   opts.Official,
+		CommitID:   opts.CommitID,
 	}
 	if _, err := e.Insert(review); err != nil {
 		return nil, err
@@ -253,14 +266,20 @@ func IsContentEmptyErr(err error) bool {
 	return ok
 }
 
-// SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist
-func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content string) (*Review, *Comment, error) {
+// SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist,
+// commitID is the head commit of the pull request reviewed
+func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content, commitID string) (*Review, *Comment, error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
 		return nil, nil, err
 	}
 
+	// the review requested from doer is answered
+	if _, err := sess.Delete(&Review{IssueID: issue.ID, ReviewerID: doer.ID, Type: ReviewTypeRequest}); err != nil {
+		return nil, nil, err
+	}
+
 	var official = false
 
 	review, err := getCurrentReview(sess, doer, issue)
@@ -291,6 +310,7 @@ func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content strin
 			Reviewer: doer,
 			Content:  content,
 			Official: official,
+			CommitID: commitID,
 		})
 		if err != nil {
 			return nil, nil, err
@@ -318,8 +338,9 @@ func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content strin
 		review.Issue = issue
 		review.Content = content
 		review.Type = reviewType
+		review.CommitID = commitID
 
-		if _, err := sess.ID(review.ID).Cols("content, type, official").Update(review); err != nil {
+		if _, err := sess.ID(review.ID).Cols("content, type, official, commit_id").Update(review); err != nil {
 			return nil, nil, err
 		}
 	}
@@ -351,8 +372,8 @@ func GetReviewersByIssueID(issueID int64) (reviews []*Review, err error) {
 	}
 
 	// Get latest review of each reviwer, sorted in order they were made
-	if err := sess.SQL("SELECT * FROM review WHERE id IN (SELECT max(id) as id FROM review WHERE issue_id = ? AND type in (?, ?) GROUP BY issue_id, reviewer_id) ORDER BY review.updated_unix ASC",
-		issueID, ReviewTypeApprove, ReviewTypeReject).
+	if err := sess.SQL("SELECT * FROM review WHERE id IN (SELECT max(id) as id FROM review WHERE issue_id = ? AND type in (?, ?, ?) GROUP BY issue_id, reviewer_id) ORDER BY review.updated_unix ASC",
+		issueID, ReviewTypeApprove, ReviewTypeReject, ReviewTypeRequest).
 		Find(&reviewsUnfiltered); err != nil {
 		return nil, err
 	}
@@ -370,3 +391,199 @@ func GetReviewersByIssueID(issueID int64) (reviews []*Review, err error) {
 
 	return reviews, nil
 }
+
+// ToReviewType returns the review type of an API review state, ReviewTypeUnknown if it's unknown
+func ToReviewType(state api.ReviewStateType) ReviewType {
+	switch state {
+	case api.ReviewStatePending:
+		return ReviewTypePending
+	case api.ReviewStateApproved:
+		return ReviewTypeApprove
+	case api.ReviewStateComment:
+		return ReviewTypeComment
+	case api.ReviewStateRequestChanges:
+		return ReviewTypeReject
+	case api.ReviewStateRequestReview:
+		return ReviewTypeRequest
+	}
+	return ReviewTypeUnknown
+}
+
+// APIState returns the API review state of the review type
+func (rt ReviewType) APIState() api.ReviewStateType {
+	switch rt {
+	case ReviewTypePending:
+		return api.ReviewStatePending
+	case ReviewTypeApprove:
+		return api.ReviewStateApproved
+	case ReviewTypeComment:
+		return api.ReviewStateComment
+	case ReviewTypeReject:
+		return api.ReviewStateRequestChanges
+	case ReviewTypeRequest:
+		return api.ReviewStateRequestReview
+	}
+	return api.ReviewStateUnknown
+}
+
+// APIFormat converts a Review to api.PullReview, its reviewer and its issue must be loaded
+func (r *Review) APIFormat() *api.PullReview {
+	codeComments, err := x.Where("review_id = ? AND type = ?", r.ID, CommentTypeCode).Count(new(Comment))
+	if err != nil { // Silently dropping errors :unamused:
+		log.Error("Count code comments of review %d: %v", r.ID, err)
+	}
+	return &api.PullReview{
+		ID:                r.ID,
+		Reviewer:          r.Reviewer.APIFormat(),
+		State:             r.Type.APIState(),
+		Body:              r.Content,
+		CommitID:          r.CommitID,
+		Official:          r.Official,
+		Dismissed:         r.Dismissed,
+		CodeCommentsCount: int(codeComments),
+		Submitted:         r.UpdatedUnix.AsTime(),
+		HTMLURL:           r.HTMLURL(),
+		HTMLPullURL:       r.Issue.HTMLURL(),
+	}
+}
+
+// HTMLURL returns the URL of the review in the conversation of the pull request
+func (r *Review) HTMLURL() string {
+	comment := new(Comment)
+	has, err := x.Where("review_id = ? AND type = ?", r.ID, CommentTypeReview).Get(comment)
+	if err != nil || !has {
+		return ""
+	}
+	comment.Issue = r.Issue
+	return comment.HTMLURL()
+}
+
+// DismissReview dismisses an approval or a rejection so it doesn't count anymore, the dismissal is
+// added to the conversation of the pull request with the message of doer
+func DismissReview(doer *User, review *Review, message string) (*Comment, error) {
+	if review.Dismissed || (review.Type != ReviewTypeApprove && review.Type != ReviewTypeReject) {
+		return nil, ErrReviewNotDismissable{ID: review.ID}
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if err := review.loadIssue(sess); err != nil {
+		return nil, err
+	}
+	if err := review.Issue.loadRepo(sess); err != nil {
+		return nil, err
+	}
+
+	review.Dismissed = true
+	review.Official = false
+	if _, err := sess.ID(review.ID).Cols("dismissed, official").Update(review); err != nil {
+		return nil, err
+	}
+
+	comment, err := createCommentWithNoAction(sess, &CreateCommentOptions{
+		Type:       CommentTypeDismissReview,
+		Doer:       doer,
+		Repo:       review.Issue.Repo,
+		Issue:      review.Issue,
+		AssigneeID: review.ReviewerID,
+		ReviewID:   review.ID,
+		Content:    message,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
+// AddReviewRequest requests a review of a pull request from reviewer, it returns a nil comment if a review
+// is already requested from them
+func AddReviewRequest(doer, reviewer *User, issue *Issue) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	has, err := sess.Exist(&Review{IssueID: issue.ID, ReviewerID: reviewer.ID, Type: ReviewTypeRequest})
+	if err != nil || has {
+		return nil, err
+	}
+	if err := issue.loadRepo(sess); err != nil {
+		return nil, err
+	}
+
+	if _, err := createReview(sess, CreateReviewOptions{
+		Type:     ReviewTypeRequest,
+		Issue:    issue,
+		Reviewer: reviewer,
+	}); err != nil {
+		return nil, err
+	}
+	comment, err := createCommentWithNoAction(sess, &CreateCommentOptions{
+		Type:       CommentTypeReviewRequest,
+		Doer:       doer,
+		Repo:       issue.Repo,
+		Issue:      issue,
+		AssigneeID: reviewer.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
+// RemoveReviewRequest removes the review of a pull request requested from reviewer, it returns a nil comment
+// if no review is requested from them
+func RemoveReviewRequest(doer, reviewer *User, issue *Issue) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	deleted, err := sess.Delete(&Review{IssueID: issue.ID, ReviewerID: reviewer.ID, Type: ReviewTypeRequest})
+	if err != nil || deleted == 0 {
+		return nil, err
+	}
+	if err := issue.loadRepo(sess); err != nil {
+		return nil, err
+	}
+
+	comment, err := createCommentWithNoAction(sess, &CreateCommentOptions{
+		Type:            CommentTypeReviewRequest,
+		Doer:            doer,
+		Repo:            issue.Repo,
+		Issue:           issue,
+		AssigneeID:      reviewer.ID,
+		RemovedAssignee: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
+// DeletePendingReview deletes a review which isn't submitted yet with its comments
+func DeletePendingReview(review *Review) error {
+	if review.Type != ReviewTypePending {
+		return fmt.Errorf("review %d is not pending", review.ID)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("review_id = ?", review.ID).Delete(new(Comment)); err != nil {
+		return err
+	}
+	if _, err := sess.ID(review.ID).Delete(new(Review)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
@@ -48,7 +48,8 @@ func TestReviewType_Icon(t *testing.T) {
 	assert.Equal(t, "x", ReviewTypeReject.Icon())
 	assert.Equal(t, "comment", ReviewTypeComment.Icon())
 	assert.Equal(t, "comment", ReviewTypeUnknown.Icon())
-	assert.Equal(t, "comment", ReviewType(4).Icon())
+	assert.Equal(t, "primitive-dot", ReviewTypeRequest.Icon())
+	assert.Equal(t, "comment", ReviewType(5).Icon())
 }
 
 func TestFindReviews(t *testing.T) {
@@ -132,3 +133,59 @@ func TestGetReviewersByIssueID(t *testing.T) {
 		assert.Equal(t, expectedReviews[i].UpdatedUnix, review.UpdatedUnix)
 	}
 }
+
+func TestDismissReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	review := AssertExistsAndLoadBean(t, &Review{ID: 8}).(*Review)
+
+	comment, err := DismissReview(doer, review, "outdated")
+	assert.NoError(t, err)
+	assert.Equal(t, CommentTypeDismissReview, comment.Type)
+	assert.Equal(t, review.ReviewerID, comment.AssigneeID)
+	assert.Equal(t, "outdated", comment.Content)
+	AssertExistsAndLoadBean(t, &Review{ID: 8, Dismissed: true})
+
+	_, err = DismissReview(doer, review, "")
+	assert.True(t, IsErrReviewNotDismissable(err))
+
+	// comments can't be dismissed
+	_, err = DismissReview(doer, AssertExistsAndLoadBean(t, &Review{ID: 5}).(*Review), "")
+	assert.True(t, IsErrReviewNotDismissable(err))
+}
+
+func TestAddRemoveReviewRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	reviewer := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+
+	comment, err := AddReviewRequest(doer, reviewer, issue)
+	assert.NoError(t, err)
+	assert.Equal(t, CommentTypeReviewRequest, comment.Type)
+	AssertExistsAndLoadBean(t, &Review{ReviewerID: reviewer.ID, IssueID: issue.ID, Type: ReviewTypeRequest})
+
+	comment, err = AddReviewRequest(doer, reviewer, issue)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+
+	comment, err = RemoveReviewRequest(doer, reviewer, issue)
+	assert.NoError(t, err)
+	assert.True(t, comment.RemovedAssignee)
+	AssertNotExistsBean(t, &Review{ReviewerID: reviewer.ID, IssueID: issue.ID, Type: ReviewTypeRequest})
+
+	comment, err = RemoveReviewRequest(doer, reviewer, issue)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+}
+
+func TestDeletePendingReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	review := AssertExistsAndLoadBean(t, &Review{ID: 4}).(*Review)
+	assert.NoError(t, DeletePendingReview(review))
+	AssertNotExistsBean(t, &Review{ID: 4})
+	AssertNotExistsBean(t, &Comment{ReviewID: 4})
+}
@@ -23,6 +23,8 @@ const (
 	UnitTypeWiki                                // 5 Wiki
 	UnitTypeExternalWiki                        // 6 ExternalWiki
 	UnitTypeExternalTracker                     // 7 ExternalTracker
+	UnitTypeProjects                            // 8 Kanban board
+	UnitTypeKeystone                            // 9 KEYSTONE and BLUEPRINT
 )
 
 // Value returns integer value for unit type
@@ -46,6 +48,10 @@ func (u UnitType) String() string {
 		return "UnitTypeExternalWiki"
 	case UnitTypeExternalTracker:
 		return "UnitTypeExternalTracker"
+	case UnitTypeProjects:
+		return "UnitTypeProjects"
+	case UnitTypeKeystone:
+		return "UnitTypeKeystone"
 	}
 	return fmt.Sprintf("Unknown UnitType %d", u)
 }
@@ -67,6 +73,8 @@ var (
 		UnitTypeWiki,
 		UnitTypeExternalWiki,
 		UnitTypeExternalTracker,
+		UnitTypeProjects,
+		UnitTypeKeystone,
 	}
 
 	// DefaultRepoUnits contains the default unit types
@@ -76,6 +84,8 @@ var (
 		UnitTypePullRequests,
 		UnitTypeReleases,
 		UnitTypeWiki,
+		UnitTypeProjects,
+		UnitTypeKeystone,
 	}
 
 	// MustRepoUnits contains the units could not be disabled currently
@@ -165,6 +175,22 @@ var (
 		4,
 	}
 
+	UnitProjects = Unit{
+		UnitTypeProjects,
+		"repo.projects",
+		"/projects",
+		"repo.projects.desc",
+		5,
+	}
+
+	UnitKeystone = Unit{
+		UnitTypeKeystone,
+		"repo.keystone",
+		"/keystone",
+		"repo.keystone.desc",
+		6,
+	}
+
 	// Units contains all the units
 	Units = map[UnitType]Unit{
 		UnitTypeCode:            UnitCode,
@@ -174,6 +200,8 @@ var (
 		UnitTypeReleases:        UnitReleases,
 		UnitTypeWiki:            UnitWiki,
 		UnitTypeExternalWiki:    UnitExternalWiki,
+		UnitTypeProjects:        UnitProjects,
+		UnitTypeKeystone:        UnitKeystone,
 	}
 )
 
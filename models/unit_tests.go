@@ -16,6 +16,7 @@ import (
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/unknwon/com"
@@ -59,6 +60,21 @@ func MainTest(m *testing.M, pathToGiteaRoot string) {
 		fatalTestError("TempDir: %v\n", err)
 	}
 	setting.AppWorkPath = pathToGiteaRoot
+	setting.LFS.Storage.Type = setting.LocalStorageType
+	setting.LFS.Storage.Path = filepath.Join(setting.AppDataPath, "lfs")
+	setting.LFS.ContentPath = setting.LFS.Storage.Path
+	setting.AttachmentStorage.Type = setting.LocalStorageType
+	setting.AttachmentStorage.Path = filepath.Join(setting.AppDataPath, "attachments")
+	setting.AttachmentPath = setting.AttachmentStorage.Path
+	setting.AvatarStorage.Type = setting.LocalStorageType
+	setting.AvatarStorage.Path = filepath.Join(setting.AppDataPath, "avatars")
+	setting.AvatarUploadPath = setting.AvatarStorage.Path
+	setting.RepositoryAvatarStorage.Type = setting.LocalStorageType
+	setting.RepositoryAvatarStorage.Path = filepath.Join(setting.AppDataPath, "repo-avatars")
+	setting.RepositoryAvatarUploadPath = setting.RepositoryAvatarStorage.Path
+	if err = storage.Init(); err != nil {
+		fatalTestError("storage.Init: %v\n", err)
+	}
 	setting.StaticRootPath = pathToGiteaRoot
 	setting.GravatarSourceURL, err = url.Parse("https://secure.gravatar.com/avatar/")
 	if err != nil {
@@ -11,8 +11,13 @@ import (
 	"mime/multipart"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 
 	gouuid "github.com/satori/go.uuid"
 	"github.com/unknwon/com"
@@ -74,6 +79,202 @@ func NewUpload(name string, buf []byte, file multipart.File) (_ *Upload, err err
 	return upload, nil
 }
 
+// UploadInChunks represents an upload sent in chunks which is not complete yet,
+// it is removed by the cleanup task once no chunk was received for too long.
+type UploadInChunks struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UUID        string             `xorm:"uuid UNIQUE"`
+	UploaderID  int64              `xorm:"INDEX"`
+	RepoID      int64              `xorm:"INDEX"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// UploadChunksPath returns where the chunks received of an upload in progress are stored based on given UUID.
+func UploadChunksPath(uuid string) string {
+	return UploadLocalPath(uuid) + ".part"
+}
+
+// NewUploadInChunks starts an upload sent in chunks by a user to a repository and returns its UUID.
+func NewUploadInChunks(uploaderID, repoID int64) (string, error) {
+	upload := &UploadInChunks{
+		UUID:       gouuid.NewV4().String(),
+		UploaderID: uploaderID,
+		RepoID:     repoID,
+	}
+	localPath := UploadChunksPath(upload.UUID)
+	if err := os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
+		return "", fmt.Errorf("MkdirAll: %v", err)
+	}
+	fw, err := os.Create(localPath)
+	if err != nil {
+		return "", fmt.Errorf("Create: %v", err)
+	}
+	if err = fw.Close(); err != nil {
+		return "", err
+	}
+	if _, err = x.Insert(upload); err != nil {
+		os.Remove(localPath)
+		return "", err
+	}
+	return upload.UUID, nil
+}
+
+// openUploadChunks opens the chunks received of an upload in progress,
+// only the user who started the upload in the repository can access them
+func openUploadChunks(uuid string, uploaderID, repoID int64) (*UploadInChunks, *os.File, error) {
+	upload, err := getUploadInChunks(uuid, uploaderID, repoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	fw, err := os.OpenFile(UploadChunksPath(uuid), os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrUploadNotExist{0, uuid}
+	}
+	return upload, fw, err
+}
+
+// getUploadInChunks returns an upload in progress started by the user in the repository.
+func getUploadInChunks(uuid string, uploaderID, repoID int64) (*UploadInChunks, error) {
+	// the UUID makes the path of the chunks, it must not be anything else
+	if _, err := gouuid.FromString(uuid); err != nil {
+		return nil, ErrUploadNotExist{0, uuid}
+	}
+	upload := &UploadInChunks{UUID: uuid}
+	if has, err := x.Get(upload); err != nil {
+		return nil, err
+	} else if !has || upload.UploaderID != uploaderID || upload.RepoID != repoID {
+		return nil, ErrUploadNotExist{0, uuid}
+	}
+	return upload, nil
+}
+
+// GetUploadInChunksSize returns the size received of an upload in progress.
+func GetUploadInChunksSize(uuid string, uploaderID, repoID int64) (int64, error) {
+	_, fw, err := openUploadChunks(uuid, uploaderID, repoID)
+	if err != nil {
+		return 0, err
+	}
+	defer fw.Close()
+
+	fi, err := fw.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// WriteUploadChunk writes a chunk of an upload in progress at an offset and returns the size received.
+// The chunk replaces what was received after the offset, the offset can't be beyond the received size.
+func WriteUploadChunk(uuid string, uploaderID, repoID, offset int64, chunk io.Reader) (int64, error) {
+	upload, fw, err := openUploadChunks(uuid, uploaderID, repoID)
+	if err != nil {
+		return 0, err
+	}
+	defer fw.Close()
+
+	fi, err := fw.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 || offset > fi.Size() {
+		return 0, ErrUploadChunkOffset{UUID: uuid, Offset: offset, Size: fi.Size()}
+	}
+	if err := fw.Truncate(offset); err != nil {
+		return 0, fmt.Errorf("Truncate: %v", err)
+	}
+	if _, err := fw.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("Seek: %v", err)
+	}
+	n, err := io.Copy(fw, chunk)
+	if err != nil {
+		return 0, fmt.Errorf("Copy: %v", err)
+	}
+
+	// the upload expires once no chunk was received for too long
+	upload.UpdatedUnix = timeutil.TimeStampNow()
+	if _, err := x.ID(upload.ID).Cols("updated_unix").Update(upload); err != nil {
+		return 0, err
+	}
+	return offset + n, nil
+}
+
+// CompleteUploadInChunks turns the chunks received of an upload into an upload object.
+func CompleteUploadInChunks(uuid string, uploaderID, repoID int64, name string) (*Upload, error) {
+	if _, err := GetUploadInChunksSize(uuid, uploaderID, repoID); err != nil {
+		return nil, err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	upload := &Upload{
+		UUID: uuid,
+		Name: name,
+	}
+	if _, err := sess.Delete(&UploadInChunks{UUID: uuid}); err != nil {
+		return nil, err
+	}
+	if _, err := sess.Insert(upload); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(UploadChunksPath(uuid), upload.LocalPath()); err != nil {
+		return nil, fmt.Errorf("Rename: %v", err)
+	}
+	return upload, sess.Commit()
+}
+
+func deleteUploadInChunks(upload *UploadInChunks) error {
+	if _, err := x.ID(upload.ID).Delete(new(UploadInChunks)); err != nil {
+		return err
+	}
+	if err := os.Remove(UploadChunksPath(upload.UUID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeleteExpiredUploadsInChunks removes the uploads sent in chunks which never completed
+// and received no chunk for more than OLDER_THAN.
+func DeleteExpiredUploadsInChunks() {
+	log.Trace("Doing: UploadCleanup")
+
+	olderThan := time.Now().Add(-setting.Cron.UploadCleanup.OlderThan)
+	uploads := make([]*UploadInChunks, 0, 10)
+	if err := x.Where("updated_unix < ?", olderThan.Unix()).Find(&uploads); err != nil {
+		log.Error("UploadCleanup: %v", err)
+		return
+	}
+	for _, upload := range uploads {
+		if err := deleteUploadInChunks(upload); err != nil {
+			log.Error("Failed to delete upload %s: %v", upload.UUID, err)
+		}
+	}
+
+	// chunks left without upload, a chunk is never written before its upload is created
+	err := filepath.Walk(setting.Repository.Upload.TempPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".part") || !info.ModTime().Before(olderThan) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			log.Error("Failed to remove upload chunks %s: %v", p, err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("UploadCleanup: %v", err)
+	}
+}
+
 // GetUploadByUUID returns the Upload by UUID
 func GetUploadByUUID(uuid string) (*Upload, error) {
 	upload := &Upload{UUID: uuid}
@@ -137,12 +338,21 @@ func DeleteUploads(uploads ...*Upload) (err error) {
 	return nil
 }
 
-// DeleteUploadByUUID deletes a upload by UUID
-func DeleteUploadByUUID(uuid string) error {
+// DeleteUploadByUUID deletes a upload by UUID, an upload still in progress
+// is only deleted for the user who started it in the repository.
+func DeleteUploadByUUID(uuid string, uploaderID, repoID int64) error {
 	upload, err := GetUploadByUUID(uuid)
 	if err != nil {
 		if IsErrUploadNotExist(err) {
-			return nil
+			// the upload may still be in progress
+			inChunks, err := getUploadInChunks(uuid, uploaderID, repoID)
+			if err != nil {
+				if IsErrUploadNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			return deleteUploadInChunks(inChunks)
 		}
 		return fmt.Errorf("GetUploadByUUID: %v", err)
 	}
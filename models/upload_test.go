@@ -0,0 +1,134 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadInChunks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	tmpDir, err := ioutil.TempDir("", "uploads")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	defer func(tempPath string) {
+		setting.Repository.Upload.TempPath = tempPath
+	}(setting.Repository.Upload.TempPath)
+	setting.Repository.Upload.TempPath = tmpDir
+
+	uuid, err := NewUploadInChunks(2, 1)
+	assert.NoError(t, err)
+	AssertExistsAndLoadBean(t, &UploadInChunks{UUID: uuid, UploaderID: 2, RepoID: 1})
+
+	size, err := WriteUploadChunk(uuid, 2, 1, 0, strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, size)
+
+	// only the uploader can send chunks to the repository
+	_, err = WriteUploadChunk(uuid, 4, 1, 5, strings.NewReader("!"))
+	assert.True(t, IsErrUploadNotExist(err))
+	_, err = GetUploadInChunksSize(uuid, 2, 2)
+	assert.True(t, IsErrUploadNotExist(err))
+
+	_, err = WriteUploadChunk(uuid, 2, 1, 6, strings.NewReader("world"))
+	assert.True(t, IsErrUploadChunkOffset(err))
+	assert.EqualValues(t, 5, err.(ErrUploadChunkOffset).Size)
+
+	// a chunk sent again replaces what was received after its offset
+	size, err = WriteUploadChunk(uuid, 2, 1, 3, strings.NewReader("lo, world"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12, size)
+
+	size, err = GetUploadInChunksSize(uuid, 2, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12, size)
+
+	upload, err := CompleteUploadInChunks(uuid, 2, 1, "dir/hello.txt")
+	assert.NoError(t, err)
+	assert.EqualValues(t, uuid, upload.UUID)
+	content, err := ioutil.ReadFile(upload.LocalPath())
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello, world", string(content))
+	AssertExistsAndLoadBean(t, &Upload{UUID: uuid, Name: "dir/hello.txt"})
+	AssertNotExistsBean(t, &UploadInChunks{UUID: uuid})
+
+	_, err = GetUploadInChunksSize(uuid, 2, 1)
+	assert.True(t, IsErrUploadNotExist(err))
+	_, err = WriteUploadChunk("../../hello", 2, 1, 0, strings.NewReader("hello"))
+	assert.True(t, IsErrUploadNotExist(err))
+
+	// an upload in progress is deleted with its chunks
+	// only by the user who started it in the repository
+	uuid, err = NewUploadInChunks(2, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteUploadByUUID(uuid, 4, 1))
+	assert.NoError(t, DeleteUploadByUUID(uuid, 2, 2))
+	AssertExistsAndLoadBean(t, &UploadInChunks{UUID: uuid})
+	assert.FileExists(t, UploadChunksPath(uuid))
+	assert.NoError(t, DeleteUploadByUUID(uuid, 2, 1))
+	AssertNotExistsBean(t, &UploadInChunks{UUID: uuid})
+	_, err = os.Stat(UploadChunksPath(uuid))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDeleteExpiredUploadsInChunks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	tmpDir, err := ioutil.TempDir("", "uploads")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	defer func(tempPath string) {
+		setting.Repository.Upload.TempPath = tempPath
+	}(setting.Repository.Upload.TempPath)
+	setting.Repository.Upload.TempPath = tmpDir
+	setting.Cron.UploadCleanup.OlderThan = time.Hour
+
+	expired, err := NewUploadInChunks(2, 1)
+	assert.NoError(t, err)
+	old := time.Now().Add(-2 * time.Hour)
+	_, err = x.Table("upload_in_chunks").Where("uuid = ?", expired).
+		Update(map[string]interface{}{"created_unix": old.Unix(), "updated_unix": old.Unix()})
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chtimes(UploadChunksPath(expired), old, old))
+	recent, err := NewUploadInChunks(2, 1)
+	assert.NoError(t, err)
+
+	// an upload started long ago is kept while its chunks keep coming
+	active, err := NewUploadInChunks(2, 1)
+	assert.NoError(t, err)
+	_, err = x.Table("upload_in_chunks").Where("uuid = ?", active).
+		Update(map[string]interface{}{"created_unix": old.Unix(), "updated_unix": old.Unix()})
+	assert.NoError(t, err)
+	_, err = WriteUploadChunk(active, 2, 1, 0, strings.NewReader("hello"))
+	assert.NoError(t, err)
+
+	// chunks without upload are removed once old enough
+	orphan := UploadChunksPath("9e2c7d9c-94a7-4c4e-8a25-7fd8b5a8d6ad")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(orphan), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(orphan, []byte("chunk"), 0644))
+	assert.NoError(t, os.Chtimes(orphan, old, old))
+
+	DeleteExpiredUploadsInChunks()
+	AssertNotExistsBean(t, &UploadInChunks{UUID: expired})
+	_, err = os.Stat(UploadChunksPath(expired))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(orphan)
+	assert.True(t, os.IsNotExist(err))
+
+	AssertExistsAndLoadBean(t, &UploadInChunks{UUID: recent})
+	assert.FileExists(t, UploadChunksPath(recent))
+	AssertExistsAndLoadBean(t, &UploadInChunks{UUID: active})
+	assert.FileExists(t, UploadChunksPath(active))
+}
@@ -14,7 +14,6 @@ import (
 	"errors"
 	"fmt"
 	_ "image/jpeg" // Needed for jpeg support
-	"image/png"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -28,6 +27,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
@@ -329,9 +329,19 @@ func (u *User) GenerateActivateCode() string {
 	return u.GenerateEmailActivateCode(u.Email)
 }
 
-// CustomAvatarPath returns user custom avatar file path.
-func (u *User) CustomAvatarPath() string {
-	return filepath.Join(setting.AvatarUploadPath, u.Avatar)
+// CustomAvatarRelativePath returns the path of the user custom avatar in the avatar storage.
+func (u *User) CustomAvatarRelativePath() string {
+	return u.Avatar
+}
+
+// IterateUserAvatars iterates the users and organizations having an avatar in the avatar storage.
+func IterateUserAvatars(f func(u *User) error) error {
+	return x.
+		Where("avatar != ''").BufferSize(setting.Database.IterateBufferSize).
+		Iterate(new(User),
+			func(idx int, bean interface{}) error {
+				return f(bean.(*User))
+			})
 }
 
 // GenerateRandomAvatar generates a random avatar for user.
@@ -354,21 +364,12 @@ func (u *User) generateRandomAvatar(e Engine) error {
 	if u.Avatar == "" {
 		u.Avatar = fmt.Sprintf("%d", u.ID)
 	}
-	if err = os.MkdirAll(filepath.Dir(u.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
 	if _, err := e.ID(u.ID).Cols("avatar").Update(u); err != nil {
 		return err
 	}
 
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err = saveAvatar(storage.Avatars, u.CustomAvatarRelativePath(), img); err != nil {
+		return err
 	}
 
 	log.Info("New random avatar created: %d", u.ID)
@@ -395,12 +396,12 @@ func (u *User) RealSizedAvatarLink(size int) string {
 
 	switch {
 	case u.UseCustomAvatar:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !avatarExists(storage.Avatars, u.CustomAvatarRelativePath()) {
 			return base.DefaultAvatarLink()
 		}
 		return setting.AppSubURL + "/avatars/" + u.Avatar
 	case setting.DisableGravatar, setting.OfflineMode:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !avatarExists(storage.Avatars, u.CustomAvatarRelativePath()) {
 			if err := u.GenerateRandomAvatar(); err != nil {
 				log.Error("GenerateRandomAvatar: %v", err)
 			}
@@ -525,18 +526,8 @@ func (u *User) UploadAvatar(data []byte) error {
 		return fmt.Errorf("updateUser: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.AvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("Failed to create dir %s: %v", setting.AvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err = saveAvatar(storage.Avatars, u.CustomAvatarRelativePath(), *m); err != nil {
+		return err
 	}
 
 	return sess.Commit()
@@ -544,10 +535,10 @@ func (u *User) UploadAvatar(data []byte) error {
 
 // DeleteAvatar deletes the user's custom avatar.
 func (u *User) DeleteAvatar() error {
-	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarPath())
+	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarRelativePath())
 	if len(u.Avatar) > 0 {
-		if err := os.Remove(u.CustomAvatarPath()); err != nil {
-			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarPath(), err)
+		if err := deleteAvatar(storage.Avatars, u.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarRelativePath(), err)
 		}
 	}
 
@@ -1101,6 +1092,16 @@ func deleteUser(e *xorm.Session, u *User) error {
 	}
 	// ***** END: Star *****
 
+	// ***** START: VariantStar *****
+	starredVariantIDs := make([]int64, 0, 10)
+	if err = e.Table("variant_star").Cols("variant_star.variant_id").
+		Where("variant_star.uid = ?", u.ID).Find(&starredVariantIDs); err != nil {
+		return fmt.Errorf("get all variant stars: %v", err)
+	} else if _, err = e.Decr("num_stars").In("id", starredVariantIDs).NoAutoTime().Update(new(Variant)); err != nil {
+		return fmt.Errorf("decrease variant num_stars: %v", err)
+	}
+	// ***** END: VariantStar *****
+
 	// ***** START: Follow *****
 	followeeIDs := make([]int64, 0, 10)
 	if err = e.Table("follow").Cols("follow.follow_id").
@@ -1125,6 +1126,7 @@ func deleteUser(e *xorm.Session, u *User) error {
 		&Access{UserID: u.ID},
 		&Watch{UserID: u.ID},
 		&Star{UID: u.ID},
+		&VariantStar{UID: u.ID},
 		&Follow{UserID: u.ID},
 		&Follow{FollowID: u.ID},
 		&Action{UserID: u.ID},
@@ -1180,11 +1182,8 @@ func deleteUser(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		if err := deleteAvatar(storage.Avatars, u.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", u.Avatar, err)
 		}
 	}
 
@@ -1351,6 +1350,20 @@ func GetUserIDsByNames(names []string, ignoreNonExistent bool) ([]int64, error)
 	return ids, nil
 }
 
+// GetUserNamesByIDs returns the names of the users of the ids, sorted by name
+func GetUserNamesByIDs(ids []int64) ([]string, error) {
+	unames := make([]string, 0, len(ids))
+	if len(ids) == 0 {
+		return unames, nil
+	}
+	err := x.In("id", ids).
+		Table("user").
+		Asc("name").
+		Cols("name").
+		Find(&unames)
+	return unames, err
+}
+
 // UserCommit represents a commit with validation of user.
 type UserCommit struct {
 	User *User
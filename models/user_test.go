@@ -386,3 +386,15 @@ func TestGetUserIDsByNames(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, []int64(nil), IDs)
 }
+
+func TestGetUserNamesByIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	names, err := GetUserNamesByIDs([]int64{4, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user2", "user4"}, names)
+
+	names, err = GetUserNamesByIDs(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
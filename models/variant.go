@@ -0,0 +1,313 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// Variant represents a parallel version of a repository living on one of its branches,
+// with a title, a description and a cover image taken from the branch.
+type Variant struct {
+	ID              int64       `xorm:"pk autoincr"`
+	RepoID          int64       `xorm:"UNIQUE(s) NOT NULL"`
+	Repo            *Repository `xorm:"-"`
+	BranchName      string      `xorm:"UNIQUE(s) NOT NULL"`
+	OwnerID         int64       `xorm:"INDEX NOT NULL"`
+	Owner           *User       `xorm:"-"`
+	Title           string      `xorm:"NOT NULL"`
+	Description     string      `xorm:"TEXT"`
+	RenderedContent string      `xorm:"-"`
+	// CoverPath is the path of an image in the branch of the variant
+	CoverPath string
+	NumStars  int `xorm:"NOT NULL DEFAULT 0"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+func (v *Variant) loadRepo(e Engine) (err error) {
+	if v.Repo != nil {
+		return nil
+	}
+	v.Repo, err = getRepositoryByID(e, v.RepoID)
+	return err
+}
+
+// LoadRepo loads the repository of the variant
+func (v *Variant) LoadRepo() error {
+	return v.loadRepo(x)
+}
+
+func (v *Variant) loadOwner(e Engine) (err error) {
+	if v.Owner != nil {
+		return nil
+	}
+	v.Owner, err = getUserByID(e, v.OwnerID)
+	if IsErrUserNotExist(err) {
+		v.Owner = NewGhostUser()
+		return nil
+	}
+	return err
+}
+
+// LoadOwner loads the user who owns the variant
+func (v *Variant) LoadOwner() error {
+	return v.loadOwner(x)
+}
+
+// HTMLURL returns the URL of the page of the variant, the repository must be loaded
+func (v *Variant) HTMLURL() string {
+	return fmt.Sprintf("%s/variants/%d", v.Repo.HTMLURL(), v.ID)
+}
+
+// CoverLink returns the link to the raw cover image, or an empty string without cover.
+// The repository must be loaded.
+func (v *Variant) CoverLink() string {
+	if len(v.CoverPath) == 0 {
+		return ""
+	}
+	return v.Repo.Link() + v.coverRawPath()
+}
+
+func (v *Variant) coverRawPath() string {
+	return "/raw/branch/" + util.PathEscapeSegments(v.BranchName) + "/" + util.PathEscapeSegments(v.CoverPath)
+}
+
+// APIFormat returns this Variant in API format, the repository and the owner must be loaded.
+func (v *Variant) APIFormat() *api.Variant {
+	apiVariant := &api.Variant{
+		ID:          v.ID,
+		Branch:      v.BranchName,
+		Title:       v.Title,
+		Description: v.Description,
+		CoverPath:   v.CoverPath,
+		Stars:       v.NumStars,
+		HTMLURL:     v.HTMLURL(),
+		Created:     v.CreatedUnix.AsTime(),
+		Updated:     v.UpdatedUnix.AsTime(),
+	}
+	if len(v.CoverPath) > 0 {
+		apiVariant.CoverURL = v.Repo.HTMLURL() + v.coverRawPath()
+	}
+	if v.Owner != nil {
+		apiVariant.Owner = v.Owner.APIFormat()
+	}
+	return apiVariant
+}
+
+// VariantList is a list of variants
+type VariantList []*Variant
+
+// LoadAttributes loads the repositories and the owners of the variants
+func (variants VariantList) LoadAttributes() error {
+	for _, v := range variants {
+		if err := v.loadRepo(x); err != nil {
+			return err
+		}
+		if err := v.loadOwner(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewVariant creates a new variant on a branch, a branch can only hold one variant.
+func NewVariant(v *Variant) error {
+	has, err := x.Get(&Variant{RepoID: v.RepoID, BranchName: v.BranchName})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrVariantAlreadyExists{RepoID: v.RepoID, BranchName: v.BranchName}
+	}
+	_, err = x.Insert(v)
+	return err
+}
+
+// GetVariantByRepoID returns the variant in a repository.
+func GetVariantByRepoID(repoID, id int64) (*Variant, error) {
+	v := &Variant{
+		ID:     id,
+		RepoID: repoID,
+	}
+	has, err := x.Get(v)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrVariantNotExist{ID: id, RepoID: repoID}
+	}
+	return v, nil
+}
+
+// GetVariantByBranch returns the variant living on a branch of a repository.
+func GetVariantByBranch(repoID int64, branchName string) (*Variant, error) {
+	v := &Variant{
+		RepoID:     repoID,
+		BranchName: branchName,
+	}
+	has, err := x.Get(v)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrVariantNotExist{RepoID: repoID, BranchName: branchName}
+	}
+	return v, nil
+}
+
+// GetVariants returns the variants of a repository, sorted by newest, oldest,
+// recentupdate or moststars.
+func GetVariants(repoID int64, sortType string) (VariantList, error) {
+	variants := make(VariantList, 0, 10)
+	sess := x.Where("repo_id = ?", repoID)
+
+	switch sortType {
+	case "oldest":
+		sess.Asc("created_unix")
+	case "recentupdate":
+		sess.Desc("updated_unix")
+	case "moststars":
+		sess.Desc("num_stars").Desc("created_unix")
+	default:
+		sess.Desc("created_unix")
+	}
+	return variants, sess.Find(&variants)
+}
+
+// CountVariants returns the number of variants of a repository.
+func CountVariants(repoID int64) (int64, error) {
+	return x.Where("repo_id = ?", repoID).Count(new(Variant))
+}
+
+// UpdateVariant updates the title, the description and the cover of given variant.
+func UpdateVariant(v *Variant) error {
+	_, err := x.ID(v.ID).Cols("title", "description", "cover_path").Update(v)
+	return err
+}
+
+// DeleteVariantByRepoID deletes a variant with its stars from a repository,
+// the branch of the variant is left untouched.
+func DeleteVariantByRepoID(repoID, id int64) error {
+	v, err := GetVariantByRepoID(repoID, id)
+	if err != nil {
+		if IsErrVariantNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteVariant(v)
+}
+
+// DeleteVariantByBranch deletes the variant living on a branch, if any,
+// it is called when the branch is deleted.
+func DeleteVariantByBranch(repoID int64, branchName string) error {
+	v, err := GetVariantByBranch(repoID, branchName)
+	if err != nil {
+		if IsErrVariantNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteVariant(v)
+}
+
+func deleteVariant(v *Variant) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Delete(&VariantStar{VariantID: v.ID}); err != nil {
+		return err
+	}
+	if _, err := sess.ID(v.ID).Delete(new(Variant)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func deleteVariantsByRepoID(e Engine, repoID int64) error {
+	variantIDs := make([]int64, 0, 10)
+	if err := e.Table("variant").Cols("id").Where("repo_id = ?", repoID).Find(&variantIDs); err != nil {
+		return err
+	}
+	if len(variantIDs) == 0 {
+		return nil
+	}
+	if _, err := e.In("variant_id", variantIDs).Delete(new(VariantStar)); err != nil {
+		return err
+	}
+	_, err := e.In("id", variantIDs).Delete(new(Variant))
+	return err
+}
+
+// VariantStar represents a user backing a variant
+type VariantStar struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UID         int64              `xorm:"UNIQUE(s)"`
+	VariantID   int64              `xorm:"UNIQUE(s)"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// StarVariant or unstar variant.
+func StarVariant(userID, variantID int64, star bool) error {
+	sess := x.NewSession()
+	defer sess.Close()
+
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if star {
+		if isStaringVariant(sess, userID, variantID) {
+			return nil
+		}
+
+		if _, err := sess.Insert(&VariantStar{UID: userID, VariantID: variantID}); err != nil {
+			return err
+		}
+		if _, err := sess.Exec("UPDATE `variant` SET num_stars = num_stars + 1 WHERE id = ?", variantID); err != nil {
+			return err
+		}
+	} else {
+		if !isStaringVariant(sess, userID, variantID) {
+			return nil
+		}
+
+		if _, err := sess.Delete(&VariantStar{UID: userID, VariantID: variantID}); err != nil {
+			return err
+		}
+		if _, err := sess.Exec("UPDATE `variant` SET num_stars = num_stars - 1 WHERE id = ?", variantID); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
+
+// IsStaringVariant checks if user has starred given variant.
+func IsStaringVariant(userID, variantID int64) bool {
+	return isStaringVariant(x, userID, variantID)
+}
+
+func isStaringVariant(e Engine, userID, variantID int64) bool {
+	has, _ := e.Get(&VariantStar{UID: userID, VariantID: variantID})
+	return has
+}
+
+// GetStargazers returns the users that starred the variant.
+func (v *Variant) GetStargazers(page int) ([]*User, error) {
+	users := make([]*User, 0, ItemsPerPage)
+	sess := x.Where("variant_star.variant_id = ?", v.ID).
+		Join("LEFT", "variant_star", "`user`.id = variant_star.uid")
+	if page > 0 {
+		sess = sess.Limit(ItemsPerPage, (page-1)*ItemsPerPage)
+	}
+	return users, sess.Find(&users)
+}
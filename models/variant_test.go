@@ -0,0 +1,127 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVariant(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	variant := &Variant{
+		RepoID:     1,
+		BranchName: "master",
+		OwnerID:    2,
+		Title:      "Bright timeline",
+	}
+	assert.NoError(t, NewVariant(variant))
+	AssertExistsAndLoadBean(t, variant)
+
+	err := NewVariant(&Variant{RepoID: 1, BranchName: "develop", OwnerID: 2, Title: "Another"})
+	assert.True(t, IsErrVariantAlreadyExists(err))
+	CheckConsistencyFor(t, &Variant{})
+}
+
+func TestGetVariantByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	variant, err := GetVariantByRepoID(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "develop", variant.BranchName)
+	assert.Equal(t, "Dark timeline", variant.Title)
+
+	_, err = GetVariantByRepoID(2, 1)
+	assert.True(t, IsErrVariantNotExist(err))
+}
+
+func TestGetVariantByBranch(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	variant, err := GetVariantByBranch(1, "develop")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, variant.ID)
+
+	_, err = GetVariantByBranch(1, "master")
+	assert.True(t, IsErrVariantNotExist(err))
+}
+
+func TestGetVariants(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, NewVariant(&Variant{RepoID: 1, BranchName: "master", OwnerID: 2, Title: "Bright timeline"}))
+
+	variants, err := GetVariants(1, "moststars")
+	assert.NoError(t, err)
+	if assert.Len(t, variants, 2) {
+		assert.Equal(t, "develop", variants[0].BranchName)
+		assert.Equal(t, "master", variants[1].BranchName)
+	}
+	assert.NoError(t, variants.LoadAttributes())
+	assert.EqualValues(t, 1, variants[0].Repo.ID)
+	assert.EqualValues(t, 2, variants[0].Owner.ID)
+
+	count, err := CountVariants(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	variants, err = GetVariants(2, "")
+	assert.NoError(t, err)
+	assert.Len(t, variants, 0)
+}
+
+func TestVariant_CoverLink(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	variant := AssertExistsAndLoadBean(t, &Variant{ID: 1}).(*Variant)
+	assert.NoError(t, variant.LoadRepo())
+	assert.Empty(t, variant.CoverLink())
+
+	variant.CoverPath = "art/cover page.png"
+	assert.Equal(t, variant.Repo.Link()+"/raw/branch/develop/art/cover%20page.png", variant.CoverLink())
+}
+
+func TestStarVariant(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, StarVariant(2, 1, true))
+	assert.True(t, IsStaringVariant(2, 1))
+	// starring twice is a no-op
+	assert.NoError(t, StarVariant(2, 1, true))
+	variant := AssertExistsAndLoadBean(t, &Variant{ID: 1}).(*Variant)
+	assert.Equal(t, 2, variant.NumStars)
+
+	stargazers, err := variant.GetStargazers(0)
+	assert.NoError(t, err)
+	assert.Len(t, stargazers, 2)
+
+	assert.NoError(t, StarVariant(4, 1, false))
+	assert.False(t, IsStaringVariant(4, 1))
+	CheckConsistencyFor(t, &Variant{})
+}
+
+func TestDeleteVariantByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteVariantByRepoID(1, 1))
+	AssertNotExistsBean(t, &Variant{ID: 1})
+	AssertNotExistsBean(t, &VariantStar{VariantID: 1})
+
+	// deleting a missing variant is a no-op
+	assert.NoError(t, DeleteVariantByRepoID(1, 1))
+}
+
+func TestDeleteVariantByBranch(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteVariantByBranch(1, "master"))
+	AssertExistsAndLoadBean(t, &Variant{ID: 1})
+
+	assert.NoError(t, DeleteVariantByBranch(1, "develop"))
+	AssertNotExistsBean(t, &Variant{ID: 1})
+	AssertNotExistsBean(t, &VariantStar{VariantID: 1})
+}
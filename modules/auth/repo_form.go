@@ -133,6 +133,8 @@ type RepoSettingForm struct {
 	PullsAllowRebase                 bool
 	PullsAllowRebaseMerge            bool
 	PullsAllowSquash                 bool
+	EnableProjects                   bool
+	EnableKeystone                   bool
 	EnableTimetracker                bool
 	AllowOnlyContributorsToTrackTime bool
 	EnableIssueDependencies          bool
@@ -401,6 +403,93 @@ func (f *CreateMilestoneForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// __________                   __               __
+// \______   \_______  ____    |__| ____   _____/  |_
+//  |     ___/\_  __ \/  _ \   |  |/ __ \_/ ___\   __\
+//  |    |     |  | \(  <_> )  |  \  ___/\  \___|  |
+//  |____|     |__|   \____/\__|  |\___  >\___  >__|
+//                         \______|    \/     \/
+
+// CreateProjectForm form for creating a project
+type CreateProjectForm struct {
+	Title          string `binding:"Required;MaxSize(100)"`
+	Content        string
+	ColumnTemplate models.ProjectColumnTemplate
+}
+
+// Validate validates the fields
+func (f *CreateProjectForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// ProjectColumnForm form for adding or renaming a column of a project
+type ProjectColumnForm struct {
+	Title string `binding:"Required;MaxSize(100)"`
+}
+
+// Validate validates the fields
+func (f *ProjectColumnForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AddProjectCardForm form for adding an issue or a pull request to a column of a project
+type AddProjectCardForm struct {
+	// name of the repository of the issue, only used by projects of organizations
+	Repo       string
+	IssueIndex int64 `binding:"Required"`
+	ColumnID   int64
+}
+
+// Validate validates the fields
+func (f *AddProjectCardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// ____   ____            .__               __
+// \   \ /   /____ _______|__|____    _____/  |_
+//  \   Y   /\__  \\_  __ \  \__  \  /    \   __\
+//   \     /  / __ \|  | \/  |/ __ \|   |  \  |
+//    \___/  (____  /__|  |__(____  /___|  /__|
+//                \/              \/     \/
+
+// CreateVariantForm form for creating a variant
+type CreateVariantForm struct {
+	Branch     string `binding:"Required;GitRefName;MaxSize(100)" locale:"repo.variants.branch"`
+	BaseBranch string
+	Title      string `binding:"Required;MaxSize(100)" locale:"repo.variants.title"`
+	Content    string
+	CoverPath  string `binding:"MaxSize(500)" locale:"repo.variants.cover"`
+}
+
+// Validate validates the fields
+func (f *CreateVariantForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// EditVariantForm form for editing a variant
+type EditVariantForm struct {
+	Title     string `binding:"Required;MaxSize(100)" locale:"repo.variants.title"`
+	Content   string
+	CoverPath string `binding:"MaxSize(500)" locale:"repo.variants.cover"`
+}
+
+// Validate validates the fields
+func (f *EditVariantForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AssetTagsForm form for tagging a file or a folder of a branch
+type AssetTagsForm struct {
+	Branch   string `binding:"Required"`
+	TreePath string `binding:"Required"`
+	Tags     string
+}
+
+// Validate validates the fields
+func (f *AssetTagsForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -461,6 +550,13 @@ type CodeCommentForm struct {
 	TreePath string `form:"path" binding:"Required"`
 	IsReview bool   `form:"is_review"`
 	Reply    int64  `form:"reply"`
+
+	// Region of an image commented on instead of a line
+	IsRegion     bool    `form:"is_region"`
+	RegionX      float64 `form:"region_x"`
+	RegionY      float64 `form:"region_y"`
+	RegionWidth  float64 `form:"region_width"`
+	RegionHeight float64 `form:"region_height"`
 }
 
 // Validate validates the fields
@@ -581,6 +677,21 @@ func (f *EditRepoFileForm) Validate(ctx *macaron.Context, errs binding.Errors) b
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// EditKeystoneForm form for editing a document of the keystone
+type EditKeystoneForm struct {
+	Content       string
+	CommitSummary string `binding:"MaxSize(100)"`
+	CommitMessage string
+	CommitChoice  string `binding:"Required;MaxSize(50)"`
+	NewBranchName string `binding:"GitRefName;MaxSize(100)"`
+	LastCommit    string
+}
+
+// Validate validates the fields
+func (f *EditKeystoneForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // EditPreviewDiffForm form for changing preview diff
 type EditPreviewDiffForm struct {
 	Content string
@@ -23,6 +23,9 @@ type Organization struct {
 	OrgLink          string
 	CanCreateOrgRepo bool
 
+	// Access to the projects of the organization, granted by the teams with the projects unit
+	ProjectsAccessMode models.AccessMode
+
 	Team *models.Team
 }
 
@@ -170,3 +173,20 @@ func OrgAssignment(args ...bool) macaron.Handler {
 		HandleOrgAssignment(ctx, args...)
 	}
 }
+
+// RequireOrgProjectsAccess returns a macaron middleware that requires the signed user
+// to have at least the given access to the projects of the organization
+func RequireOrgProjectsAccess(mode models.AccessMode) macaron.Handler {
+	return func(ctx *Context) {
+		var err error
+		if ctx.Org.ProjectsAccessMode, err = ctx.Org.Organization.GetProjectsAccessMode(ctx.User); err != nil {
+			ctx.ServerError("GetProjectsAccessMode", err)
+			return
+		}
+		if ctx.Org.ProjectsAccessMode < mode {
+			ctx.NotFound("RequireOrgProjectsAccess", nil)
+			return
+		}
+		ctx.Data["CanWriteProjects"] = ctx.Org.ProjectsAccessMode >= models.AccessModeWrite
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"code.gitea.io/gitea/modules/migrations"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/sync"
+	lfs_service "code.gitea.io/gitea/services/lfs"
 	mirror_service "code.gitea.io/gitea/services/mirror"
+	"code.gitea.io/gitea/services/thumbnail"
 
 	"github.com/gogs/cron"
 )
@@ -26,6 +28,9 @@ const (
 	syncExternalUsers       = "sync_external_users"
 	deletedBranchesCleanup  = "deleted_branches_cleanup"
 	updateMigrationPosterID = "update_migration_post_id"
+	thumbnailCleanup        = "thumbnail_cleanup"
+	uploadCleanup           = "upload_cleanup"
+	lfsGarbageCollect       = "lfs_gc"
 )
 
 var c = cron.New()
@@ -120,6 +125,42 @@ func NewContext() {
 		}
 	}
 
+	if setting.Thumbnail.Enabled && setting.Cron.ThumbnailCleanup.Enabled {
+		entry, err = c.AddFunc("Remove unused thumbnails", setting.Cron.ThumbnailCleanup.Schedule, WithUnique(thumbnailCleanup, thumbnail.DeleteOldThumbnails))
+		if err != nil {
+			log.Fatal("Cron[Remove unused thumbnails]: %v", err)
+		}
+		if setting.Cron.ThumbnailCleanup.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(thumbnailCleanup, thumbnail.DeleteOldThumbnails)()
+		}
+	}
+
+	if setting.Cron.UploadCleanup.Enabled {
+		entry, err = c.AddFunc("Remove expired uploads", setting.Cron.UploadCleanup.Schedule, WithUnique(uploadCleanup, models.DeleteExpiredUploadsInChunks))
+		if err != nil {
+			log.Fatal("Cron[Remove expired uploads]: %v", err)
+		}
+		if setting.Cron.UploadCleanup.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(uploadCleanup, models.DeleteExpiredUploadsInChunks)()
+		}
+	}
+
+	if setting.LFS.StartServer && setting.Cron.LFSGarbageCollect.Enabled {
+		entry, err = c.AddFunc("Garbage collect LFS objects", setting.Cron.LFSGarbageCollect.Schedule, WithUnique(lfsGarbageCollect, lfs_service.GarbageCollectCron))
+		if err != nil {
+			log.Fatal("Cron[Garbage collect LFS objects]: %v", err)
+		}
+		if setting.Cron.LFSGarbageCollect.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(lfsGarbageCollect, lfs_service.GarbageCollectCron)()
+		}
+	}
+
 	entry, err = c.AddFunc("Update migrated repositories' issues and comments' posterid", setting.Cron.UpdateMigrationPosterID.Schedule, WithUnique(updateMigrationPosterID, migrations.UpdateMigrationPosterID))
 	if err != nil {
 		log.Fatal("Cron[Update migrated repositories]: %v", err)
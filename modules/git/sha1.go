@@ -16,6 +16,9 @@ import (
 // EmptySHA defines empty git SHA
 const EmptySHA = "0000000000000000000000000000000000000000"
 
+// EmptyTreeSHA is the SHA of the empty tree
+const EmptyTreeSHA = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
 // SHA1 a git commit name
 type SHA1 = plumbing.Hash
 
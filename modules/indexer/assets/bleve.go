@@ -0,0 +1,438 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package assets
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/index/upsidedown"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/registry"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/ethantkoenig/rupture"
+)
+
+const (
+	assetIndexerAnalyzer        = "assetIndexer"
+	assetIndexerKeywordAnalyzer = "assetIndexerKeyword"
+	assetIndexerExactAnalyzer   = "assetIndexerExact"
+	assetIndexerDocType         = "assetIndexerDocType"
+	assetIndexerLatestVersion   = 1
+)
+
+// indexerID a bleve-compatible unique identifier for a path of a branch,
+// a branch name never contains a colon
+func indexerID(repoID int64, branchName, treePath string) string {
+	return strconv.FormatInt(repoID, 36) + ":" + branchName + ":" + treePath
+}
+
+// numericEqualityQuery a numeric equality query for the given value and field
+func numericEqualityQuery(value int64, field string) *query.NumericRangeQuery {
+	f := float64(value)
+	tru := true
+	q := bleve.NewNumericRangeInclusiveQuery(&f, &f, &tru, &tru)
+	q.SetField(field)
+	return q
+}
+
+func newMatchPhraseQuery(matchPhrase, field, analyzer string) *query.MatchPhraseQuery {
+	q := bleve.NewMatchPhraseQuery(matchPhrase)
+	q.FieldVal = field
+	q.Analyzer = analyzer
+	return q
+}
+
+func newTermQuery(term, field string) *query.TermQuery {
+	q := bleve.NewTermQuery(term)
+	q.FieldVal = field
+	return q
+}
+
+const (
+	unicodeNormalizeName    = "unicodeNormalize"
+	singleTokenizerName     = "assetIndexerSingle"
+	separatorCharFilterName = "assetIndexerSeparator"
+)
+
+func addUnicodeNormalizeTokenFilter(m *mapping.IndexMappingImpl) error {
+	return m.AddCustomTokenFilter(unicodeNormalizeName, map[string]interface{}{
+		"type": unicodenorm.Name,
+		"form": unicodenorm.NFC,
+	})
+}
+
+// singleTokenizer emits the whole input as a single token, so that branch names,
+// tags, file types and authors are matched exactly
+type singleTokenizer struct{}
+
+func (singleTokenizer) Tokenize(input []byte) analysis.TokenStream {
+	return analysis.TokenStream{
+		&analysis.Token{
+			Term:     input,
+			Position: 1,
+			Start:    0,
+			End:      len(input),
+			Type:     analysis.AlphaNumeric,
+		},
+	}
+}
+
+// separatorCharFilter turns the separators of paths and tags into spaces,
+// so that "characters/hero.png" or "character:hero" are searchable word by word
+type separatorCharFilter struct{}
+
+func (separatorCharFilter) Filter(input []byte) []byte {
+	output := make([]byte, len(input))
+	for i, c := range input {
+		switch c {
+		case '/', '.', ':', '_', '-':
+			output[i] = ' '
+		default:
+			output[i] = c
+		}
+	}
+	return output
+}
+
+func init() {
+	registry.RegisterTokenizer(singleTokenizerName, func(config map[string]interface{}, cache *registry.Cache) (analysis.Tokenizer, error) {
+		return singleTokenizer{}, nil
+	})
+	registry.RegisterCharFilter(separatorCharFilterName, func(config map[string]interface{}, cache *registry.Cache) (analysis.CharFilter, error) {
+		return separatorCharFilter{}, nil
+	})
+}
+
+const maxBatchSize = 16
+
+// openIndexer open the index at the specified path, checking for metadata
+// updates and bleve version updates.  If index needs to be created (or
+// re-created), returns (nil, nil)
+func openIndexer(path string, latestVersion int) (bleve.Index, error) {
+	_, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	metadata, err := rupture.ReadIndexMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Version < latestVersion {
+		// the indexer is using a previous version, so we should delete it and
+		// re-populate
+		return nil, os.RemoveAll(path)
+	}
+
+	index, err := bleve.Open(path)
+	if err != nil && err == upsidedown.IncompatibleVersion {
+		// the indexer was built with a previous version of bleve, so we should
+		// delete it and re-populate
+		return nil, os.RemoveAll(path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// bleveIndexerData an asset as stored in the bleve index
+type bleveIndexerData struct {
+	RepoID     int64
+	BranchName string
+	TreePath   string
+	Name       string
+	IsDir      bool
+	Tags       []string
+	FileType   string
+	Author     string
+	Updated    time.Time
+}
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (d *bleveIndexerData) Type() string {
+	return assetIndexerDocType
+}
+
+// createAssetIndexer create an asset indexer if one does not already exist
+func createAssetIndexer(path string, latestVersion int) (bleve.Index, error) {
+	mapping := bleve.NewIndexMapping()
+	docMapping := bleve.NewDocumentMapping()
+
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("TreePath", textFieldMapping)
+	docMapping.AddFieldMappingsAt("Name", textFieldMapping)
+
+	keywordFieldMapping := bleve.NewTextFieldMapping()
+	keywordFieldMapping.Analyzer = assetIndexerKeywordAnalyzer
+	keywordFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("FileType", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("Author", keywordFieldMapping)
+
+	exactFieldMapping := bleve.NewTextFieldMapping()
+	exactFieldMapping.Analyzer = assetIndexerExactAnalyzer
+	exactFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("BranchName", exactFieldMapping)
+
+	// the tags are matched exactly by the filters and word by word by the keyword
+	tagWordsFieldMapping := bleve.NewTextFieldMapping()
+	tagWordsFieldMapping.Name = "TagWords"
+	tagWordsFieldMapping.Store = false
+	tagWordsFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Tags", keywordFieldMapping, tagWordsFieldMapping)
+
+	boolFieldMapping := bleve.NewBooleanFieldMapping()
+	boolFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("IsDir", boolFieldMapping)
+
+	dateFieldMapping := bleve.NewDateTimeFieldMapping()
+	dateFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Updated", dateFieldMapping)
+
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(assetIndexerAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{separatorCharFilterName},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(assetIndexerKeywordAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     singleTokenizerName,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(assetIndexerExactAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     singleTokenizerName,
+		"token_filters": []string{},
+	}); err != nil {
+		return nil, err
+	}
+
+	mapping.DefaultAnalyzer = assetIndexerAnalyzer
+	mapping.AddDocumentMapping(assetIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+
+	index, err := bleve.New(path, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
+		Version: latestVersion,
+	}); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+var (
+	_ Indexer = &BleveIndexer{}
+)
+
+// BleveIndexer implements Indexer interface
+type BleveIndexer struct {
+	indexDir string
+	indexer  bleve.Index
+}
+
+// NewBleveIndexer creates a new bleve local indexer
+func NewBleveIndexer(indexDir string) *BleveIndexer {
+	return &BleveIndexer{
+		indexDir: indexDir,
+	}
+}
+
+// Init will initial the indexer
+func (b *BleveIndexer) Init() (bool, error) {
+	var err error
+	b.indexer, err = openIndexer(b.indexDir, assetIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	if b.indexer != nil {
+		return true, nil
+	}
+
+	b.indexer, err = createAssetIndexer(b.indexDir, assetIndexerLatestVersion)
+	return false, err
+}
+
+// Index will save the index data
+func (b *BleveIndexer) Index(assets []*IndexerData) error {
+	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, asset := range assets {
+		if err := batch.Index(indexerID(asset.RepoID, asset.BranchName, asset.TreePath), &bleveIndexerData{
+			RepoID:     asset.RepoID,
+			BranchName: asset.BranchName,
+			TreePath:   asset.TreePath,
+			Name:       asset.Name(),
+			IsDir:      asset.IsDir,
+			Tags:       asset.Tags,
+			FileType:   asset.FileType,
+			Author:     asset.Author,
+			Updated:    asset.Updated,
+		}); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Delete deletes the indexes of assets
+func (b *BleveIndexer) Delete(assets ...*IndexerData) error {
+	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, asset := range assets {
+		if err := batch.Delete(indexerID(asset.RepoID, asset.BranchName, asset.TreePath)); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// DeleteByRepo deletes the indexes of the assets of a repository or of one of its branches
+func (b *BleveIndexer) DeleteByRepo(repoID int64, branchName string) error {
+	var indexerQuery query.Query = numericEqualityQuery(repoID, "RepoID")
+	if branchName != "" {
+		indexerQuery = bleve.NewConjunctionQuery(
+			indexerQuery,
+			newTermQuery(branchName, "BranchName"),
+		)
+	}
+	for {
+		result, err := b.indexer.Search(bleve.NewSearchRequestOptions(indexerQuery, 2*maxBatchSize, 0, false))
+		if err != nil {
+			return err
+		}
+		if len(result.Hits) == 0 {
+			return nil
+		}
+
+		batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+		for _, hit := range result.Hits {
+			if err = batch.Delete(hit.ID); err != nil {
+				return err
+			}
+		}
+		if err = batch.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// Search searches for assets by given conditions.
+func (b *BleveIndexer) Search(opts *SearchOptions) (*SearchResult, error) {
+	repoQueries := make([]query.Query, 0, len(opts.RepoIDs))
+	for _, repoID := range opts.RepoIDs {
+		repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+	}
+	queries := []query.Query{bleve.NewDisjunctionQuery(repoQueries...)}
+
+	if keyword := strings.TrimSpace(opts.Keyword); keyword != "" {
+		queries = append(queries, bleve.NewDisjunctionQuery(
+			newMatchPhraseQuery(keyword, "Name", assetIndexerAnalyzer),
+			newMatchPhraseQuery(keyword, "TreePath", assetIndexerAnalyzer),
+			newMatchPhraseQuery(keyword, "TagWords", assetIndexerAnalyzer),
+		))
+	}
+	for _, tag := range opts.Tags {
+		queries = append(queries, newTermQuery(strings.ToLower(tag), "Tags"))
+	}
+	if opts.FileType != "" {
+		queries = append(queries, newTermQuery(strings.ToLower(opts.FileType), "FileType"))
+	}
+	if opts.Author != "" {
+		queries = append(queries, newTermQuery(strings.ToLower(opts.Author), "Author"))
+	}
+	if !opts.Since.IsZero() || !opts.Before.IsZero() {
+		tru, fals := true, false
+		dateQuery := bleve.NewDateRangeInclusiveQuery(opts.Since, opts.Before, &tru, &fals)
+		dateQuery.SetField("Updated")
+		queries = append(queries, dateQuery)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	start := 0
+	if opts.Page > 1 {
+		start = (opts.Page - 1) * pageSize
+	}
+	search := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(queries...), pageSize, start, false)
+	search.Fields = []string{"*"}
+	search.SortBy([]string{"-Updated", "_id"})
+
+	result, err := b.indexer.Search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &SearchResult{
+		Total: int64(result.Total),
+		Hits:  make([]*IndexerData, 0, len(result.Hits)),
+	}
+	for _, hit := range result.Hits {
+		data := &IndexerData{
+			BranchName: fieldString(hit.Fields["BranchName"]),
+			TreePath:   fieldString(hit.Fields["TreePath"]),
+			FileType:   fieldString(hit.Fields["FileType"]),
+			Author:     fieldString(hit.Fields["Author"]),
+			Tags:       fieldStrings(hit.Fields["Tags"]),
+		}
+		if repoID, ok := hit.Fields["RepoID"].(float64); ok {
+			data.RepoID = int64(repoID)
+		}
+		if isDir, ok := hit.Fields["IsDir"].(bool); ok {
+			data.IsDir = isDir
+		}
+		if updated, err := time.Parse(time.RFC3339, fieldString(hit.Fields["Updated"])); err == nil {
+			data.Updated = updated
+		}
+		ret.Hits = append(ret.Hits, data)
+	}
+	return ret, nil
+}
+
+func fieldString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// fieldStrings returns the values of a stored array field, bleve returns a single value as is
+func fieldStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, s := range v {
+			values = append(values, fieldString(s))
+		}
+		return values
+	}
+	return []string{}
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package assets
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBleveIndexAndSearch(t *testing.T) {
+	dir := "./bleve.index"
+	indexer := NewBleveIndexer(dir)
+	defer os.RemoveAll(dir)
+
+	_, err := indexer.Init()
+	assert.NoError(t, err)
+
+	day := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, indexer.Index([]*IndexerData{
+		{
+			RepoID:     1,
+			BranchName: "master",
+			TreePath:   "characters/hero.png",
+			Tags:       []string{"character:hero", "palette"},
+			FileType:   FileTypeImage,
+			Author:     "Alice",
+			Updated:    day,
+		},
+		{
+			RepoID:     1,
+			BranchName: "Draft",
+			TreePath:   "characters/hero.png",
+			Tags:       []string{"character:hero"},
+			FileType:   FileTypeImage,
+			Author:     "Bob",
+			Updated:    day.AddDate(0, 0, 1),
+		},
+		{
+			RepoID:     1,
+			BranchName: "master",
+			TreePath:   "backgrounds",
+			IsDir:      true,
+			Tags:       []string{"background"},
+			FileType:   FileTypeFolder,
+			Author:     "Bob",
+			Updated:    day.AddDate(0, 0, 2),
+		},
+		{
+			RepoID:     2,
+			BranchName: "master",
+			TreePath:   "music/theme.ogg",
+			Tags:       []string{"character:villain"},
+			FileType:   FileTypeAudio,
+			Author:     "Alice",
+			Updated:    day.AddDate(0, 0, 3),
+		},
+	}))
+
+	for _, kase := range []struct {
+		Opts  SearchOptions
+		Paths []string
+	}{
+		{SearchOptions{RepoIDs: []int64{1}}, []string{"backgrounds", "characters/hero.png", "characters/hero.png"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, Keyword: "hero"}, []string{"characters/hero.png", "characters/hero.png"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, Keyword: "villain"}, []string{"music/theme.ogg"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, Tags: []string{"character:hero", "palette"}}, []string{"characters/hero.png"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, Tags: []string{"character"}}, []string{}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, FileType: FileTypeAudio}, []string{"music/theme.ogg"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, Author: "alice"}, []string{"music/theme.ogg", "characters/hero.png"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, Since: day.AddDate(0, 0, 1), Before: day.AddDate(0, 0, 3)}, []string{"backgrounds", "characters/hero.png"}},
+		{SearchOptions{RepoIDs: []int64{1, 2}, PageSize: 1, Page: 2}, []string{"backgrounds"}},
+	} {
+		result, err := indexer.Search(&kase.Opts)
+		assert.NoError(t, err)
+		paths := make([]string, 0, len(result.Hits))
+		for _, hit := range result.Hits {
+			paths = append(paths, hit.TreePath)
+		}
+		assert.EqualValues(t, kase.Paths, paths, "search: %+v", kase.Opts)
+	}
+
+	result, err := indexer.Search(&SearchOptions{RepoIDs: []int64{1}, FileType: FileTypeFolder})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Hits, 1) {
+		assert.EqualValues(t, &IndexerData{
+			RepoID:     1,
+			BranchName: "master",
+			TreePath:   "backgrounds",
+			IsDir:      true,
+			Tags:       []string{"background"},
+			FileType:   FileTypeFolder,
+			Author:     "Bob",
+			Updated:    day.AddDate(0, 0, 2),
+		}, result.Hits[0])
+	}
+
+	// the branch names are case sensitive
+	assert.NoError(t, indexer.DeleteByRepo(1, "draft"))
+	result, err = indexer.Search(&SearchOptions{RepoIDs: []int64{1}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, result.Total)
+
+	assert.NoError(t, indexer.DeleteByRepo(1, "Draft"))
+	result, err = indexer.Search(&SearchOptions{RepoIDs: []int64{1}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, result.Total)
+
+	assert.NoError(t, indexer.Delete(&IndexerData{RepoID: 1, BranchName: "master", TreePath: "backgrounds"}))
+	assert.NoError(t, indexer.DeleteByRepo(2, ""))
+	result, err = indexer.Search(&SearchOptions{RepoIDs: []int64{1, 2}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, result.Total)
+}
+
+func TestFileTypeOf(t *testing.T) {
+	assert.Equal(t, FileTypeImage, FileTypeOf("hero.PNG", false))
+	assert.Equal(t, FileTypeModel, FileTypeOf("ship.blend", false))
+	assert.Equal(t, FileTypeFolder, FileTypeOf("characters", true))
+	assert.Equal(t, FileTypeOther, FileTypeOf("Makefile", false))
+}
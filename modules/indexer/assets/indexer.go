@@ -0,0 +1,314 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package assets
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// IndexerData data stored in the asset indexer, a tagged file or folder of a branch
+type IndexerData struct {
+	RepoID     int64
+	BranchName string
+	TreePath   string
+	IsDir      bool
+	Tags       []string
+	FileType   string
+	// Author is the name of the author of the last commit changing the asset
+	Author  string
+	Updated time.Time
+}
+
+// Name returns the base name of the asset
+func (d *IndexerData) Name() string {
+	return path.Base(d.TreePath)
+}
+
+// SearchOptions represents the options of a search of the asset indexer
+type SearchOptions struct {
+	RepoIDs []int64
+	Keyword string
+	// Tags are the tags the assets must all have
+	Tags     []string
+	FileType string
+	Author   string
+	// Since and Before bound the date of the last change of the assets, a zero time is unbounded
+	Since    time.Time
+	Before   time.Time
+	Page     int
+	PageSize int
+}
+
+// SearchResult represents search results, the most recently changed assets first
+type SearchResult struct {
+	Total int64
+	Hits  []*IndexerData
+}
+
+// Indexer defines an interface to index the tagged assets
+type Indexer interface {
+	Init() (bool, error)
+	Index(assets []*IndexerData) error
+	Delete(assets ...*IndexerData) error
+	// DeleteByRepo deletes the assets of a branch of a repository, of all its branches if branchName is empty
+	DeleteByRepo(repoID int64, branchName string) error
+	Search(opts *SearchOptions) (*SearchResult, error)
+}
+
+// File types of the assets
+const (
+	FileTypeFolder   = "folder"
+	FileTypeImage    = "image"
+	FileTypeAudio    = "audio"
+	FileTypeVideo    = "video"
+	FileTypeModel    = "model"
+	FileTypeDocument = "document"
+	FileTypeArchive  = "archive"
+	FileTypeOther    = "other"
+)
+
+// FileTypes lists the file types of the assets
+var FileTypes = []string{
+	FileTypeImage,
+	FileTypeAudio,
+	FileTypeVideo,
+	FileTypeModel,
+	FileTypeDocument,
+	FileTypeArchive,
+	FileTypeFolder,
+	FileTypeOther,
+}
+
+var fileTypeExtensions = map[string][]string{
+	FileTypeImage:    {".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp", ".svg", ".ico", ".tif", ".tiff", ".tga", ".exr", ".psd", ".kra", ".ora", ".xcf"},
+	FileTypeAudio:    {".mp3", ".ogg", ".oga", ".wav", ".flac", ".m4a", ".aac", ".opus", ".mid", ".midi"},
+	FileTypeVideo:    {".mp4", ".m4v", ".webm", ".mkv", ".mov", ".avi", ".ogv"},
+	FileTypeModel:    {".obj", ".fbx", ".blend", ".gltf", ".glb", ".stl", ".dae", ".3ds", ".ply", ".usd", ".usdz"},
+	FileTypeDocument: {".md", ".markdown", ".txt", ".rst", ".org", ".pdf", ".odt", ".doc", ".docx", ".rtf", ".tex", ".fountain", ".csv", ".json", ".yml", ".yaml"},
+	FileTypeArchive:  {".zip", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".7z", ".rar"},
+}
+
+// FileTypeOf returns the file type of an asset by its name
+func FileTypeOf(name string, isDir bool) string {
+	if isDir {
+		return FileTypeFolder
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for fileType, exts := range fileTypeExtensions {
+		for _, e := range exts {
+			if e == ext {
+				return fileType
+			}
+		}
+	}
+	return FileTypeOther
+}
+
+type indexerHolder struct {
+	indexer Indexer
+	mutex   sync.RWMutex
+	cond    *sync.Cond
+}
+
+func newIndexerHolder() *indexerHolder {
+	h := &indexerHolder{}
+	h.cond = sync.NewCond(h.mutex.RLocker())
+	return h
+}
+
+func (h *indexerHolder) set(indexer Indexer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.indexer = indexer
+	h.cond.Broadcast()
+}
+
+func (h *indexerHolder) get() Indexer {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if h.indexer == nil {
+		h.cond.Wait()
+	}
+	return h.indexer
+}
+
+var holder = newIndexerHolder()
+
+// InitAssetIndexer initialize the asset indexer, syncReindex is true then reindex until
+// all the tagged assets are indexed.
+func InitAssetIndexer(syncReindex bool) {
+	waitChannel := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		log.Info("Initializing Asset Indexer")
+		assetIndexer := NewBleveIndexer(setting.Indexer.AssetPath)
+		exist, err := assetIndexer.Init()
+		if err != nil {
+			log.Fatal("Unable to initialize Bleve Asset Indexer: %v", err)
+		}
+		holder.set(assetIndexer)
+
+		if !exist {
+			if syncReindex {
+				populateAssetIndexer()
+			} else {
+				go populateAssetIndexer()
+			}
+		}
+		waitChannel <- time.Since(start)
+	}()
+	if syncReindex {
+		<-waitChannel
+	} else if setting.Indexer.StartupTimeout > 0 {
+		go func() {
+			timeout := setting.Indexer.StartupTimeout
+			if graceful.Manager.IsChild() && setting.GracefulHammerTime > 0 {
+				timeout += setting.GracefulHammerTime
+			}
+			select {
+			case duration := <-waitChannel:
+				log.Info("Asset Indexer Initialization took %v", duration)
+			case <-time.After(timeout):
+				log.Fatal("Asset Indexer Initialization timed-out after: %v", timeout)
+			}
+		}()
+	}
+}
+
+// populateAssetIndexer populate the asset indexer with the tagged assets of all repositories
+func populateAssetIndexer() {
+	assets, err := models.FindTaggedAssets(0, "")
+	if err != nil {
+		log.Error("FindTaggedAssets: %v", err)
+		return
+	}
+
+	for len(assets) > 0 {
+		n := 1
+		for n < len(assets) && assets[n].RepoID == assets[0].RepoID {
+			n++
+		}
+		repo, err := models.GetRepositoryByID(assets[0].RepoID)
+		if err != nil {
+			log.Error("GetRepositoryByID[%d]: %v", assets[0].RepoID, err)
+		} else if err = indexTaggedAssets(repo, assets[:n]); err != nil {
+			log.Error("indexTaggedAssets[%s]: %v", repo.FullName(), err)
+		}
+		assets = assets[n:]
+	}
+}
+
+// newIndexerData returns the data of a tagged asset, nil if the asset is not in its branch anymore
+func newIndexerData(gitRepo *git.Repository, asset *models.TaggedAsset) (*IndexerData, error) {
+	if !gitRepo.IsBranchExist(asset.BranchName) {
+		return nil, nil
+	}
+	commit, err := gitRepo.GetBranchCommit(asset.BranchName)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := commit.GetTreeEntryByPath(asset.TreePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lastCommit, err := commit.GetCommitByPath(asset.TreePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IndexerData{
+		RepoID:     asset.RepoID,
+		BranchName: asset.BranchName,
+		TreePath:   asset.TreePath,
+		IsDir:      entry.IsDir(),
+		Tags:       asset.Tags,
+		FileType:   FileTypeOf(entry.Name(), entry.IsDir()),
+		Author:     lastCommit.Author.Name,
+		Updated:    lastCommit.Author.When,
+	}, nil
+}
+
+// indexTaggedAssets indexes tagged assets of a repository, the ones missing from their branch are removed
+func indexTaggedAssets(repo *models.Repository, assets []*models.TaggedAsset) error {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	updates := make([]*IndexerData, 0, len(assets))
+	var deletes []*IndexerData
+	for _, asset := range assets {
+		data, err := newIndexerData(gitRepo, asset)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			deletes = append(deletes, &IndexerData{RepoID: asset.RepoID, BranchName: asset.BranchName, TreePath: asset.TreePath})
+			continue
+		}
+		updates = append(updates, data)
+	}
+
+	if err := holder.get().Index(updates); err != nil {
+		return err
+	}
+	return holder.get().Delete(deletes...)
+}
+
+// UpdateAssetIndexer add/update/remove a path of a branch in the asset indexer according to its tags
+func UpdateAssetIndexer(repo *models.Repository, branchName, treePath string) error {
+	tags, err := models.GetAssetTags(repo.ID, branchName, treePath)
+	if err != nil {
+		return err
+	}
+	return indexTaggedAssets(repo, []*models.TaggedAsset{{
+		RepoID:     repo.ID,
+		BranchName: branchName,
+		TreePath:   treePath,
+		Tags:       tags,
+	}})
+}
+
+// UpdateBranchAssetIndexer updates the tagged assets of a branch after a push
+func UpdateBranchAssetIndexer(repo *models.Repository, branchName string) error {
+	assets, err := models.FindTaggedAssets(repo.ID, branchName)
+	if err != nil {
+		return err
+	} else if len(assets) == 0 {
+		return nil
+	}
+	return indexTaggedAssets(repo, assets)
+}
+
+// DeleteBranchFromAssetIndexer removes the assets of a branch from the asset indexer
+func DeleteBranchFromAssetIndexer(repo *models.Repository, branchName string) error {
+	return holder.get().DeleteByRepo(repo.ID, branchName)
+}
+
+// DeleteRepoFromAssetIndexer removes the assets of a repository from the asset indexer
+func DeleteRepoFromAssetIndexer(repo *models.Repository) error {
+	return holder.get().DeleteByRepo(repo.ID, "")
+}
+
+// SearchAssets searches the tagged assets
+func SearchAssets(opts *SearchOptions) (*SearchResult, error) {
+	if len(opts.RepoIDs) == 0 {
+		return &SearchResult{}, nil
+	}
+	return holder.get().Search(opts)
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package layers decodes layered art documents (PSD, OpenRaster, ...)
+// into a flattened composite and their individual layers.
+package layers
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// ErrUnsupported is returned when a document uses a feature the decoder cannot handle
+var ErrUnsupported = errors.New("unsupported layered document")
+
+// maxDimension is the largest width or height of a document, the limit of PSB files
+const maxDimension = 300000
+
+// checkSize returns ErrUnsupported when an image of the given size must not be allocated,
+// documents are checked against the MAX_SOURCE_PIXELS setting of thumbnails
+func checkSize(width, height int) error {
+	if width > maxDimension || height > maxDimension || setting.IsThumbnailSourceTooLarge(width, height) {
+		return fmt.Errorf("%v: image of %dx%d pixels is too large", ErrUnsupported, width, height)
+	}
+	return nil
+}
+
+// pixelBudget limits the pixels of all the images allocated for a document to MAX_SOURCE_PIXELS,
+// so a small file can't declare many layers each within the limit
+type pixelBudget struct {
+	used int64
+}
+
+// charge returns ErrUnsupported when an image of the given size must not be allocated
+func (b *pixelBudget) charge(width, height int) error {
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	b.used += int64(width) * int64(height)
+	if max := setting.Thumbnail.MaxSourcePixels; max > 0 && b.used > max {
+		return fmt.Errorf("%v: images of the document exceed %d pixels", ErrUnsupported, max)
+	}
+	return nil
+}
+
+// Blend modes, named after the CSS mix-blend-mode values so they can be used by the web view
+const (
+	BlendNormal     = "normal"
+	BlendMultiply   = "multiply"
+	BlendScreen     = "screen"
+	BlendOverlay    = "overlay"
+	BlendDarken     = "darken"
+	BlendLighten    = "lighten"
+	BlendColorDodge = "color-dodge"
+	BlendColorBurn  = "color-burn"
+	BlendHardLight  = "hard-light"
+	BlendSoftLight  = "soft-light"
+	BlendDifference = "difference"
+	BlendExclusion  = "exclusion"
+	BlendHue        = "hue"
+	BlendSaturation = "saturation"
+	BlendColor      = "color"
+	BlendLuminosity = "luminosity"
+)
+
+// Layer is a single raster layer of a document
+type Layer struct {
+	Name      string
+	Visible   bool
+	Opacity   float64
+	BlendMode string
+	// Bounds is the position of Image inside the document
+	Bounds image.Rectangle
+	Image  image.Image
+}
+
+// PixelHash returns a hash of the layer position and pixels,
+// two layers with the same hash look the same in the document
+func (l *Layer) PixelHash() string {
+	h := sha1.New()
+	b := l.Bounds
+	_ = binary.Write(h, binary.BigEndian, []int64{int64(b.Min.X), int64(b.Min.Y), int64(b.Max.X), int64(b.Max.Y)})
+	if l.Image != nil {
+		r := l.Image.Bounds()
+		if img, ok := l.Image.(*image.NRGBA); ok {
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				h.Write(img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)])
+			}
+		} else {
+			row := make([]byte, 0, 4*r.Dx())
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				row = row[:0]
+				for x := r.Min.X; x < r.Max.X; x++ {
+					c := color.NRGBAModel.Convert(l.Image.At(x, y)).(color.NRGBA)
+					row = append(row, c.R, c.G, c.B, c.A)
+				}
+				h.Write(row)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Document is a decoded layered document, Layers are ordered from bottom to top
+type Document struct {
+	Width     int
+	Height    int
+	Composite image.Image
+	Layers    []*Layer
+}
+
+// Decoder decodes a layered document format
+type Decoder interface {
+	// Extensions returns the lower case file extensions handled by the decoder, including the dot
+	Extensions() []string
+	Decode(r io.ReaderAt, size int64) (*Document, error)
+}
+
+var decoders = map[string]Decoder{}
+
+// RegisterDecoder registers a decoder for all its extensions
+func RegisterDecoder(d Decoder) {
+	for _, ext := range d.Extensions() {
+		decoders[strings.ToLower(ext)] = d
+	}
+}
+
+// GetDecoder returns the decoder for a filename or nil if none is registered
+func GetDecoder(filename string) Decoder {
+	return decoders[strings.ToLower(filepath.Ext(filename))]
+}
+
+// IsLayeredFile returns whether a decoder is registered for the filename
+func IsLayeredFile(filename string) bool {
+	return GetDecoder(filename) != nil
+}
+
+// Decode decodes a document with the decoder registered for filename
+func Decode(filename string, r io.ReaderAt, size int64) (*Document, error) {
+	d := GetDecoder(filename)
+	if d == nil {
+		return nil, ErrUnsupported
+	}
+	return d.Decode(r, size)
+}
+
+func init() {
+	RegisterDecoder(oraDecoder{})
+	RegisterDecoder(psdDecoder{})
+}
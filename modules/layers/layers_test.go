@@ -0,0 +1,344 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package layers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDecoder(t *testing.T) {
+	assert.True(t, IsLayeredFile("art/cover.PSD"))
+	assert.True(t, IsLayeredFile("page.ora"))
+	assert.False(t, IsLayeredFile("page.png"))
+}
+
+func pngBytes(t *testing.T, img image.Image) []byte {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, png.Encode(buf, img))
+	return buf.Bytes()
+}
+
+func solid(w, h int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func buildORA(t *testing.T, files map[string][]byte) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeORA(t *testing.T) {
+	data := buildORA(t, map[string][]byte{
+		"stack.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<image w="4" h="3">
+  <stack>
+    <layer name="ink" src="data/ink.png" x="1" y="1" composite-op="svg:multiply"/>
+    <stack name="colors" visibility="hidden">
+      <layer name="flat" src="data/flat.png" opacity="0.5"/>
+    </stack>
+    <layer name="paper" src="data/paper.png"/>
+  </stack>
+</image>`),
+		"mergedimage.png": pngBytes(t, solid(4, 3, color.White)),
+		"data/ink.png":    pngBytes(t, solid(2, 2, color.Black)),
+		"data/flat.png":   pngBytes(t, solid(4, 3, color.NRGBA{R: 255, A: 255})),
+		"data/paper.png":  pngBytes(t, solid(4, 3, color.White)),
+	})
+
+	doc, err := Decode("page.ora", bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, doc.Width)
+	assert.Equal(t, 3, doc.Height)
+	assert.NotNil(t, doc.Composite)
+
+	if assert.Len(t, doc.Layers, 3) {
+		paper, flat, ink := doc.Layers[0], doc.Layers[1], doc.Layers[2]
+		assert.Equal(t, "paper", paper.Name)
+		assert.True(t, paper.Visible)
+
+		assert.Equal(t, "flat", flat.Name)
+		assert.False(t, flat.Visible)
+		assert.Equal(t, 0.5, flat.Opacity)
+
+		assert.Equal(t, "ink", ink.Name)
+		assert.Equal(t, BlendMultiply, ink.BlendMode)
+		assert.Equal(t, image.Rect(1, 1, 3, 3), ink.Bounds)
+	}
+}
+
+// psdWriter builds minimal uncompressed 8 bit RGB documents
+type psdWriter struct {
+	bytes.Buffer
+}
+
+func (w *psdWriter) put(data ...interface{}) {
+	for _, d := range data {
+		_ = binary.Write(w, binary.BigEndian, d)
+	}
+}
+
+type testPSDLayer struct {
+	name   string
+	bounds image.Rectangle
+	rgba   [4]byte
+	flags  byte
+	blend  string
+	// section is the "lsct" type, 0 for plain layers
+	section uint32
+}
+
+func buildPSD(width, height int, layers []testPSDLayer) []byte {
+	records := new(psdWriter)
+	channelData := new(psdWriter)
+	for _, l := range layers {
+		pixels := l.bounds.Dx() * l.bounds.Dy()
+		records.put(int32(l.bounds.Min.Y), int32(l.bounds.Min.X), int32(l.bounds.Max.Y), int32(l.bounds.Max.X))
+		records.put(uint16(4))
+		for _, id := range []int16{-1, 0, 1, 2} {
+			records.put(id, uint32(2+pixels))
+		}
+		records.put([]byte("8BIM"), []byte(l.blend), uint8(255), uint8(0), l.flags, uint8(0))
+
+		extra := new(psdWriter)
+		extra.put(uint32(0), uint32(0))
+		name := []byte(l.name)
+		extra.put(uint8(len(name)), name)
+		extra.Write(make([]byte, (4-(len(name)+1)%4)%4))
+		if l.section != 0 {
+			extra.put([]byte("8BIM"), []byte("lsct"), uint32(4), l.section)
+		}
+		records.put(uint32(extra.Len()), extra.Bytes())
+
+		for _, c := range []byte{l.rgba[3], l.rgba[0], l.rgba[1], l.rgba[2]} {
+			channelData.put(uint16(psdCompressionRaw), bytes.Repeat([]byte{c}, pixels))
+		}
+	}
+
+	layerInfo := new(psdWriter)
+	layerInfo.put(int16(len(layers)), records.Bytes(), channelData.Bytes())
+
+	w := new(psdWriter)
+	w.put([]byte("8BPS"), uint16(1), make([]byte, 6), uint16(3), uint32(height), uint32(width), uint16(8), uint16(psdColorModeRGB))
+	w.put(uint32(0), uint32(0))
+	w.put(uint32(4+layerInfo.Len()+4), uint32(layerInfo.Len()), layerInfo.Bytes(), uint32(0))
+	// merged image, PackBits compressed rows of a single repeated byte
+	w.put(uint16(psdCompressionRLE))
+	for i := 0; i < 3*height; i++ {
+		w.put(uint16(2))
+	}
+	for c := 0; c < 3; c++ {
+		for y := 0; y < height; y++ {
+			w.put(int8(1-width), byte(0x80))
+		}
+	}
+	return w.Bytes()
+}
+
+func TestDecodePSD(t *testing.T) {
+	data := buildPSD(4, 3, []testPSDLayer{
+		{name: "background", bounds: image.Rect(0, 0, 4, 3), rgba: [4]byte{255, 255, 255, 255}, blend: "norm"},
+		{bounds: image.Rect(0, 0, 0, 0), blend: "norm", section: psdSectionDivider, name: "</Layer group>"},
+		{name: "shadow", bounds: image.Rect(1, 1, 3, 2), rgba: [4]byte{0, 0, 0, 128}, blend: "mul "},
+		{name: "group", bounds: image.Rect(0, 0, 0, 0), blend: "pass", flags: 0x02, section: 1},
+		{name: "ink", bounds: image.Rect(0, 0, 2, 2), rgba: [4]byte{10, 20, 30, 255}, blend: "norm"},
+	})
+
+	doc, err := Decode("cover.psd", bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, doc.Width)
+	assert.Equal(t, 3, doc.Height)
+	assert.Equal(t, color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}, doc.Composite.At(2, 2))
+
+	if assert.Len(t, doc.Layers, 3) {
+		background, shadow, ink := doc.Layers[0], doc.Layers[1], doc.Layers[2]
+		assert.Equal(t, "background", background.Name)
+		assert.True(t, background.Visible)
+
+		assert.Equal(t, "shadow", shadow.Name)
+		assert.Equal(t, BlendMultiply, shadow.BlendMode)
+		assert.False(t, shadow.Visible, "layers of hidden groups are hidden")
+		assert.Equal(t, image.Rect(1, 1, 3, 2), shadow.Bounds)
+		assert.Equal(t, color.NRGBA{A: 128}, shadow.Image.At(2, 1))
+
+		assert.Equal(t, "ink", ink.Name)
+		assert.True(t, ink.Visible)
+		assert.Equal(t, color.NRGBA{R: 10, G: 20, B: 30, A: 255}, ink.Image.At(1, 1))
+	}
+}
+
+func TestDecodePSD_Invalid(t *testing.T) {
+	data := []byte("not a psd")
+	_, err := Decode("cover.psd", bytes.NewReader(data), int64(len(data)))
+	assert.Error(t, err)
+
+	data = buildPSD(4, 3, nil)
+	_, err = Decode("cover.psd", bytes.NewReader(data[:40]), 40)
+	assert.Error(t, err)
+}
+
+func TestDecode_TooLarge(t *testing.T) {
+	defer func(pixels int64) { setting.Thumbnail.MaxSourcePixels = pixels }(setting.Thumbnail.MaxSourcePixels)
+	setting.Thumbnail.MaxSourcePixels = 16
+
+	decode := func(filename string, data []byte) error {
+		_, err := Decode(filename, bytes.NewReader(data), int64(len(data)))
+		return err
+	}
+	assertTooLarge := func(err error) {
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "too large")
+		}
+	}
+
+	assert.NoError(t, decode("cover.psd", buildPSD(4, 3, nil)))
+	assertTooLarge(decode("cover.psd", buildPSD(5, 4, nil)))
+	err := decode("cover.psd", buildPSD(4, 3, []testPSDLayer{
+		{name: "huge", bounds: image.Rect(-1, -1, 4, 4), blend: "norm"},
+	}))
+	assertTooLarge(err)
+
+	stack := []byte(`<image w="4" h="3"><stack><layer name="ink" src="ink.png"/></stack></image>`)
+	assert.NoError(t, decode("page.ora", buildORA(t, map[string][]byte{
+		"stack.xml": stack,
+		"ink.png":   pngBytes(t, solid(4, 3, color.Black)),
+	})))
+	err = decode("page.ora", buildORA(t, map[string][]byte{
+		"stack.xml": stack,
+		"ink.png":   pngBytes(t, solid(5, 4, color.Black)),
+	}))
+	assertTooLarge(err)
+	err = decode("page.ora", buildORA(t, map[string][]byte{
+		"stack.xml":       stack,
+		"mergedimage.png": pngBytes(t, solid(5, 4, color.Black)),
+		"ink.png":         pngBytes(t, solid(4, 3, color.Black)),
+	}))
+	assertTooLarge(err)
+	err = decode("page.ora", buildORA(t, map[string][]byte{
+		"stack.xml": []byte(`<image w="400000" h="0"><stack/></image>`),
+	}))
+	assertTooLarge(err)
+}
+
+// buildCraftedPSD builds a small document declaring layers without any channel data
+func buildCraftedPSD(width, height, channels int, layers []image.Rectangle) []byte {
+	records := new(psdWriter)
+	for _, r := range layers {
+		records.put(int32(r.Min.Y), int32(r.Min.X), int32(r.Max.Y), int32(r.Max.X), uint16(0))
+		records.put([]byte("8BIM"), []byte("norm"), uint8(255), uint8(0), uint8(0), uint8(0))
+		// empty layer mask, blending ranges and padded name
+		records.put(uint32(12), uint32(0), uint32(0), make([]byte, 4))
+	}
+	layerInfo := new(psdWriter)
+	layerInfo.put(int16(len(layers)), records.Bytes())
+
+	w := new(psdWriter)
+	w.put([]byte("8BPS"), uint16(1), make([]byte, 6), uint16(channels), uint32(height), uint32(width), uint16(8), uint16(psdColorModeRGB))
+	w.put(uint32(0), uint32(0))
+	w.put(uint32(4+layerInfo.Len()+4), uint32(layerInfo.Len()), layerInfo.Bytes(), uint32(0))
+	w.put(uint16(psdCompressionRLE))
+	return w.Bytes()
+}
+
+func TestDecode_PixelBudget(t *testing.T) {
+	defer func(pixels int64) { setting.Thumbnail.MaxSourcePixels = pixels }(setting.Thumbnail.MaxSourcePixels)
+	setting.Thumbnail.MaxSourcePixels = 1024 * 1024
+
+	// decode returns the bytes allocated while decoding data and the error
+	decode := func(filename string, data []byte) (uint64, error) {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := Decode(filename, bytes.NewReader(data), int64(len(data)))
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc, err
+	}
+
+	// 16 layers each within the limit, 16 MB of layer images without the budget
+	layers := make([]image.Rectangle, 16)
+	for i := range layers {
+		layers[i] = image.Rect(0, 0, 512, 512)
+	}
+	data := buildCraftedPSD(1, 1, 3, layers)
+	assert.True(t, len(data) < 1024)
+	allocated, err := decode("cover.psd", data)
+	assert.Error(t, err)
+	assert.True(t, allocated < 8*1024*1024, "allocated %d bytes", allocated)
+
+	// the row lengths of the merged image are counted from the channels and the height,
+	// only the 1.2 MB merged image of the tall document is allocated
+	allocated, err = decode("cover.psd", buildCraftedPSD(1, 300000, 0xffff, nil))
+	assert.Error(t, err)
+	assert.True(t, allocated < 4*1024*1024, "allocated %d bytes", allocated)
+	allocated, err = decode("cover.psd", buildCraftedPSD(1, 300000, 3, nil))
+	assert.Error(t, err)
+	assert.True(t, allocated < 4*1024*1024, "allocated %d bytes", allocated)
+
+	// the layers of an OpenRaster document share the budget too
+	buildLayers := func(count int) []byte {
+		files := map[string][]byte{}
+		stack := `<image w="512" h="512"><stack>`
+		for i := 0; i < count; i++ {
+			name := fmt.Sprintf("layer%d.png", i)
+			files[name] = pngBytes(t, image.NewGray(image.Rect(0, 0, 512, 512)))
+			stack += `<layer src="` + name + `"/>`
+		}
+		files["stack.xml"] = []byte(stack + `</stack></image>`)
+		return buildORA(t, files)
+	}
+	_, err = decode("page.ora", buildLayers(4))
+	assert.NoError(t, err)
+	_, err = decode("page.ora", buildLayers(8))
+	assert.Error(t, err)
+}
+
+func TestUnpackBits(t *testing.T) {
+	row, err := unpackBits([]byte{0xfe, 0xaa, 0x02, 0x80, 0x00, 0x2a}, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xaa, 0xaa, 0xaa, 0x80, 0x00, 0x2a}, row)
+
+	_, err = unpackBits([]byte{0x05, 0x01}, 6)
+	assert.Error(t, err)
+}
+
+func TestPixelHash(t *testing.T) {
+	a := &Layer{Bounds: image.Rect(0, 0, 2, 2), Image: solid(2, 2, color.Black)}
+	b := &Layer{Bounds: image.Rect(0, 0, 2, 2), Image: solid(2, 2, color.Black)}
+	assert.Equal(t, a.PixelHash(), b.PixelHash())
+
+	b.Bounds = image.Rect(1, 0, 3, 2)
+	assert.NotEqual(t, a.PixelHash(), b.PixelHash(), "moved layer")
+
+	b = &Layer{Bounds: image.Rect(0, 0, 2, 2), Image: solid(2, 2, color.Black)}
+	b.Image.(*image.NRGBA).Set(1, 1, color.White)
+	assert.NotEqual(t, a.PixelHash(), b.PixelHash(), "edited pixel")
+
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	assert.Equal(t, a.PixelHash(), (&Layer{Bounds: a.Bounds, Image: gray}).PixelHash())
+}
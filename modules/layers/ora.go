@@ -0,0 +1,162 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package layers
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// oraDecoder decodes OpenRaster documents, a zip of PNG layers described by stack.xml
+type oraDecoder struct{}
+
+func (oraDecoder) Extensions() []string {
+	return []string{".ora"}
+}
+
+// oraNode is either a <layer> or a nested <stack> of stack.xml
+type oraNode struct {
+	XMLName     xml.Name
+	Name        string    `xml:"name,attr"`
+	Src         string    `xml:"src,attr"`
+	X           int       `xml:"x,attr"`
+	Y           int       `xml:"y,attr"`
+	Opacity     string    `xml:"opacity,attr"`
+	Visibility  string    `xml:"visibility,attr"`
+	CompositeOp string    `xml:"composite-op,attr"`
+	Children    []oraNode `xml:",any"`
+}
+
+type oraImage struct {
+	Width  int     `xml:"w,attr"`
+	Height int     `xml:"h,attr"`
+	Stack  oraNode `xml:"stack"`
+}
+
+func (oraDecoder) Decode(r io.ReaderAt, size int64) (*Document, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	stackFile, ok := files["stack.xml"]
+	if !ok {
+		return nil, fmt.Errorf("%v: missing stack.xml", ErrUnsupported)
+	}
+	var stack oraImage
+	if err := decodeZipFile(stackFile, func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(&stack)
+	}); err != nil {
+		return nil, fmt.Errorf("stack.xml: %v", err)
+	}
+
+	if err := checkSize(stack.Width, stack.Height); err != nil {
+		return nil, err
+	}
+	doc := &Document{
+		Width:  stack.Width,
+		Height: stack.Height,
+	}
+	budget := &pixelBudget{}
+	if f, ok := files["mergedimage.png"]; ok {
+		if doc.Composite, err = decodeZipPNG(f, budget); err != nil {
+			return nil, fmt.Errorf("mergedimage.png: %v", err)
+		}
+	}
+
+	if err := doc.addORAStack(files, budget, &stack.Stack, 0, 0, true); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// addORAStack appends the layers of a stack, stack.xml lists them from top to bottom
+func (doc *Document) addORAStack(files map[string]*zip.File, budget *pixelBudget, stack *oraNode, x, y int, visible bool) error {
+	x += stack.X
+	y += stack.Y
+	visible = visible && stack.Visibility != "hidden"
+	for i := len(stack.Children) - 1; i >= 0; i-- {
+		node := &stack.Children[i]
+		switch node.XMLName.Local {
+		case "stack":
+			if err := doc.addORAStack(files, budget, node, x, y, visible); err != nil {
+				return err
+			}
+		case "layer":
+			f, ok := files[node.Src]
+			if !ok {
+				return fmt.Errorf("missing layer source: %s", node.Src)
+			}
+			layer := &Layer{
+				Name:      node.Name,
+				Visible:   visible && node.Visibility != "hidden",
+				Opacity:   1,
+				BlendMode: oraBlendMode(node.CompositeOp),
+			}
+			if len(node.Opacity) > 0 {
+				if opacity, err := strconv.ParseFloat(node.Opacity, 64); err == nil {
+					layer.Opacity = opacity
+				}
+			}
+			var err error
+			if layer.Image, err = decodeZipPNG(f, budget); err != nil {
+				return fmt.Errorf("%s: %v", node.Src, err)
+			}
+			layer.Bounds = layer.Image.Bounds().Sub(layer.Image.Bounds().Min).Add(image.Pt(x+node.X, y+node.Y))
+			doc.Layers = append(doc.Layers, layer)
+		}
+	}
+	return nil
+}
+
+// decodeZipPNG decodes a PNG of the archive once its size is charged to the budget
+func decodeZipPNG(f *zip.File, budget *pixelBudget) (img image.Image, err error) {
+	var config image.Config
+	if err = decodeZipFile(f, func(r io.Reader) (err error) {
+		config, err = png.DecodeConfig(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err = budget.charge(config.Width, config.Height); err != nil {
+		return nil, err
+	}
+	err = decodeZipFile(f, func(r io.Reader) (err error) {
+		img, err = png.Decode(r)
+		return err
+	})
+	return img, err
+}
+
+func decodeZipFile(f *zip.File, decode func(io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return decode(rc)
+}
+
+// oraBlendMode maps an OpenRaster composite-op like "svg:multiply" to a blend mode
+func oraBlendMode(op string) string {
+	switch mode := strings.TrimPrefix(op, "svg:"); mode {
+	case BlendMultiply, BlendScreen, BlendOverlay, BlendDarken, BlendLighten,
+		BlendColorDodge, BlendColorBurn, BlendHardLight, BlendSoftLight,
+		BlendDifference, BlendExclusion, BlendHue, BlendSaturation, BlendColor, BlendLuminosity:
+		return mode
+	default:
+		return BlendNormal
+	}
+}
@@ -0,0 +1,541 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package layers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"unicode/utf16"
+)
+
+// psdDecoder decodes 8 bit RGB and grayscale Photoshop documents (PSD and PSB)
+type psdDecoder struct{}
+
+func (psdDecoder) Extensions() []string {
+	return []string{".psd", ".psb"}
+}
+
+const (
+	psdColorModeGrayscale = 1
+	psdColorModeRGB       = 3
+
+	psdCompressionRaw = 0
+	psdCompressionRLE = 1
+
+	psdChannelAlpha = -1
+	// psdMaxChannels is the largest number of channels of a document allowed by the format
+	psdMaxChannels = 56
+
+	// section types of the "lsct" additional layer information,
+	// a group is stored as a divider below its layers and a folder above them
+	psdSectionNone    = 0
+	psdSectionDivider = 3
+)
+
+var psdBlendModes = map[string]string{
+	"norm": BlendNormal,
+	"mul ": BlendMultiply,
+	"scrn": BlendScreen,
+	"over": BlendOverlay,
+	"dark": BlendDarken,
+	"lite": BlendLighten,
+	"div ": BlendColorDodge,
+	"idiv": BlendColorBurn,
+	"hLit": BlendHardLight,
+	"sLit": BlendSoftLight,
+	"diff": BlendDifference,
+	"smud": BlendExclusion,
+	"hue ": BlendHue,
+	"sat ": BlendSaturation,
+	"colr": BlendColor,
+	"lum ": BlendLuminosity,
+}
+
+type psdReader struct {
+	r   *bufio.Reader
+	psb bool
+	err error
+	// size bounds every length read from the file
+	size int64
+	// budget is charged for every image before it is allocated
+	budget pixelBudget
+}
+
+func (p *psdReader) read(data interface{}) {
+	if p.err == nil {
+		p.err = binary.Read(p.r, binary.BigEndian, data)
+	}
+}
+
+func (p *psdReader) uint16() uint16 {
+	var v uint16
+	p.read(&v)
+	return v
+}
+
+func (p *psdReader) int16() int16 {
+	var v int16
+	p.read(&v)
+	return v
+}
+
+func (p *psdReader) uint32() uint32 {
+	var v uint32
+	p.read(&v)
+	return v
+}
+
+func (p *psdReader) int32() int32 {
+	var v int32
+	p.read(&v)
+	return v
+}
+
+// length reads a section length, which is 8 bytes wide in PSB files
+func (p *psdReader) length() int64 {
+	if p.psb {
+		var v uint64
+		p.read(&v)
+		return int64(v)
+	}
+	return int64(p.uint32())
+}
+
+func (p *psdReader) bytes(n int64) []byte {
+	if p.err != nil {
+		return nil
+	}
+	if n < 0 || n > p.size {
+		p.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	buf := make([]byte, n)
+	_, p.err = io.ReadFull(p.r, buf)
+	return buf
+}
+
+func (p *psdReader) skip(n int64) {
+	if p.err == nil && n > 0 {
+		_, p.err = io.CopyN(ioutil.Discard, p.r, n)
+	}
+}
+
+type psdChannel struct {
+	id     int16
+	length int64
+}
+
+type psdLayerRecord struct {
+	layer    *Layer
+	channels []psdChannel
+	section  uint32
+}
+
+func (psdDecoder) Decode(r io.ReaderAt, size int64) (*Document, error) {
+	p := &psdReader{r: bufio.NewReader(io.NewSectionReader(r, 0, size)), size: size}
+
+	if sig := p.bytes(4); p.err != nil || string(sig) != "8BPS" {
+		return nil, fmt.Errorf("%v: not a PSD file", ErrUnsupported)
+	}
+	switch p.uint16() {
+	case 1:
+	case 2:
+		p.psb = true
+	default:
+		return nil, fmt.Errorf("%v: unknown PSD version", ErrUnsupported)
+	}
+	p.skip(6)
+	channels := int(p.uint16())
+	height := int(p.uint32())
+	width := int(p.uint32())
+	depth := p.uint16()
+	colorMode := p.uint16()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if depth != 8 || (colorMode != psdColorModeRGB && colorMode != psdColorModeGrayscale) {
+		return nil, fmt.Errorf("%v: only 8 bit RGB and grayscale documents are supported", ErrUnsupported)
+	}
+	if channels < 1 || channels > psdMaxChannels {
+		return nil, fmt.Errorf("%v: %d channels", ErrUnsupported, channels)
+	}
+	// the merged image is allocated with the document size
+	if err := p.budget.charge(width, height); err != nil {
+		return nil, err
+	}
+
+	// color mode data and image resources
+	p.skip(int64(p.uint32()))
+	p.skip(int64(p.uint32()))
+
+	doc := &Document{
+		Width:  width,
+		Height: height,
+	}
+
+	layerAndMaskLength := p.length()
+	if layerAndMaskLength > 0 {
+		layerInfoLength := p.length()
+		if layerInfoLength > 0 {
+			records, read := p.readLayerRecords(colorMode)
+			if p.err != nil {
+				return nil, p.err
+			}
+			doc.Layers = records
+			p.skip(layerInfoLength - read)
+		}
+		lengthSize := int64(4)
+		if p.psb {
+			lengthSize = 8
+		}
+		p.skip(layerAndMaskLength - layerInfoLength - lengthSize)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	composite, err := p.readMergedImage(width, height, channels, colorMode)
+	if err != nil {
+		return nil, err
+	}
+	doc.Composite = composite
+	return doc, nil
+}
+
+// readLayerRecords reads the layer records and their channel image data,
+// returning the layers and the number of bytes consumed.
+func (p *psdReader) readLayerRecords(colorMode uint16) ([]*Layer, int64) {
+	count := int(p.int16())
+	if count < 0 {
+		// a negative count means the first alpha channel holds the merged transparency
+		count = -count
+	}
+	read := int64(2)
+
+	records := make([]*psdLayerRecord, 0, count)
+	for i := 0; i < count && p.err == nil; i++ {
+		record, n := p.readLayerRecord()
+		read += n
+		records = append(records, record)
+	}
+
+	// records are stored bottom to top, walk them from the top to hide the layers of hidden groups
+	groupVisible := []bool{true}
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		parentVisible := groupVisible[len(groupVisible)-1]
+		switch record.section {
+		case psdSectionNone:
+			record.layer.Visible = record.layer.Visible && parentVisible
+		case psdSectionDivider:
+			if len(groupVisible) > 1 {
+				groupVisible = groupVisible[:len(groupVisible)-1]
+			}
+		default:
+			groupVisible = append(groupVisible, record.layer.Visible && parentVisible)
+		}
+	}
+
+	layers := make([]*Layer, 0, count)
+	for _, record := range records {
+		if p.err = p.budget.charge(record.layer.Bounds.Dx(), record.layer.Bounds.Dy()); p.err != nil {
+			return nil, read
+		}
+		img := newLayerImage(record.layer.Bounds)
+		for _, ch := range record.channels {
+			data := p.bytes(ch.length)
+			read += ch.length
+			if p.err != nil {
+				return nil, read
+			}
+			if img == nil || ch.id < psdChannelAlpha {
+				// empty layers and layer masks are not rendered
+				continue
+			}
+			pixels, err := decodeChannel(data, record.layer.Bounds.Dx(), record.layer.Bounds.Dy(), p.psb)
+			if err != nil {
+				// keep going without the channel, e.g. for zip compressed data
+				continue
+			}
+			setChannel(img, ch.id, colorMode, pixels)
+		}
+		if img == nil || record.section != psdSectionNone {
+			continue
+		}
+		record.layer.Image = img
+		layers = append(layers, record.layer)
+	}
+	return layers, read
+}
+
+func (p *psdReader) readLayerRecord() (*psdLayerRecord, int64) {
+	top, left, bottom, right := p.int32(), p.int32(), p.int32(), p.int32()
+	channelCount := int(p.uint16())
+	read := int64(18)
+
+	record := &psdLayerRecord{
+		layer: &Layer{
+			Bounds: image.Rect(int(left), int(top), int(right), int(bottom)),
+		},
+		channels: make([]psdChannel, channelCount),
+	}
+	if p.err == nil {
+		// a layer too large on its own is rejected before its channels are read
+		p.err = checkSize(record.layer.Bounds.Dx(), record.layer.Bounds.Dy())
+	}
+	for i := range record.channels {
+		record.channels[i].id = p.int16()
+		record.channels[i].length = p.length()
+		read += 2 + 4
+		if p.psb {
+			read += 4
+		}
+	}
+
+	p.skip(4) // blend mode signature
+	blendKey := string(p.bytes(4))
+	opacity := p.bytes(1)
+	p.skip(1) // clipping
+	flags := p.bytes(1)
+	p.skip(1) // filler
+	extraLength := int64(p.uint32())
+	read += 16 + extraLength
+	if p.err != nil {
+		return record, read
+	}
+
+	record.layer.BlendMode = BlendNormal
+	if mode, ok := psdBlendModes[blendKey]; ok {
+		record.layer.BlendMode = mode
+	}
+	record.layer.Opacity = float64(opacity[0]) / 255
+	record.layer.Visible = flags[0]&0x02 == 0
+
+	extra := &psdReader{r: bufio.NewReader(bytes.NewReader(p.bytes(extraLength))), psb: p.psb, size: extraLength}
+	extra.skip(int64(extra.uint32())) // layer mask data
+	extra.skip(int64(extra.uint32())) // blending ranges
+	var nameLength int64
+	if b := extra.bytes(1); len(b) == 1 {
+		nameLength = int64(b[0])
+	}
+	record.layer.Name = string(extra.bytes(nameLength))
+	// the pascal string is padded to a multiple of 4 bytes
+	extra.skip((4 - (nameLength+1)%4) % 4)
+
+	// additional layer information
+	for extra.err == nil {
+		if sig := extra.bytes(4); extra.err != nil || (string(sig) != "8BIM" && string(sig) != "8B64") {
+			break
+		}
+		key := string(extra.bytes(4))
+		length := int64(extra.uint32())
+		data := extra.bytes(length)
+		if extra.err != nil {
+			break
+		}
+		switch key {
+		case "luni":
+			if name, ok := decodeUnicodeString(data); ok {
+				record.layer.Name = name
+			}
+		case "lsct":
+			if len(data) >= 4 {
+				record.section = binary.BigEndian.Uint32(data)
+			}
+		}
+	}
+	return record, read
+}
+
+// readMergedImage reads the flattened image stored at the end of the file
+func (p *psdReader) readMergedImage(width, height, channels int, colorMode uint16) (image.Image, error) {
+	compression := p.uint16()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	colorChannels := 3
+	if colorMode == psdColorModeGrayscale {
+		colorChannels = 1
+	}
+	hasAlpha := channels > colorChannels
+
+	var rowLengths []int
+	if compression == psdCompressionRLE {
+		// every row length is stored in the file, which bounds their number
+		if int64(channels)*int64(height)*2 > p.size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		rowLengths = make([]int, channels*height)
+		for i := range rowLengths {
+			if p.psb {
+				rowLengths[i] = int(p.uint32())
+			} else {
+				rowLengths[i] = int(p.uint16())
+			}
+		}
+	} else if compression != psdCompressionRaw {
+		return nil, fmt.Errorf("%v: compressed merged image", ErrUnsupported)
+	}
+
+	for c := 0; c < channels && c <= colorChannels; c++ {
+		var pixels []byte
+		if compression == psdCompressionRaw {
+			pixels = p.bytes(int64(width * height))
+		} else {
+			pixels = make([]byte, 0, width*height)
+			for y := 0; y < height; y++ {
+				row, err := unpackBits(p.bytes(int64(rowLengths[c*height+y])), width)
+				if p.err != nil {
+					break
+				}
+				if err != nil {
+					return nil, err
+				}
+				pixels = append(pixels, row...)
+			}
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+
+		id := int16(c)
+		if c == colorChannels {
+			id = psdChannelAlpha
+		}
+		setChannel(img, id, colorMode, pixels)
+	}
+	if !hasAlpha {
+		setChannel(img, psdChannelAlpha, colorMode, bytes.Repeat([]byte{0xff}, width*height))
+	}
+	return img, nil
+}
+
+func newLayerImage(bounds image.Rectangle) *image.NRGBA {
+	if bounds.Empty() {
+		return nil
+	}
+	img := image.NewNRGBA(bounds)
+	// layers without an alpha channel are opaque
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+// setChannel copies the pixels of one channel into img
+func setChannel(img *image.NRGBA, id int16, colorMode uint16, pixels []byte) {
+	offsets := []int{}
+	switch {
+	case id == psdChannelAlpha:
+		offsets = []int{3}
+	case colorMode == psdColorModeGrayscale && id == 0:
+		offsets = []int{0, 1, 2}
+	case colorMode == psdColorModeRGB && id >= 0 && id <= 2:
+		offsets = []int{int(id)}
+	}
+	for i := 0; i < len(pixels) && i*4 < len(img.Pix); i++ {
+		for _, o := range offsets {
+			img.Pix[i*4+o] = pixels[i]
+		}
+	}
+}
+
+// decodeChannel decodes the image data of a layer channel including its compression marker
+func decodeChannel(data []byte, width, height int, psb bool) ([]byte, error) {
+	if len(data) < 2 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	compression := binary.BigEndian.Uint16(data)
+	data = data[2:]
+	switch compression {
+	case psdCompressionRaw:
+		if len(data) < width*height {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return data[:width*height], nil
+	case psdCompressionRLE:
+		countSize := 2
+		if psb {
+			countSize = 4
+		}
+		if len(data) < height*countSize {
+			return nil, io.ErrUnexpectedEOF
+		}
+		counts := data[:height*countSize]
+		data = data[height*countSize:]
+		pixels := make([]byte, 0, width*height)
+		for y := 0; y < height; y++ {
+			var n int
+			if psb {
+				n = int(binary.BigEndian.Uint32(counts[y*4:]))
+			} else {
+				n = int(binary.BigEndian.Uint16(counts[y*2:]))
+			}
+			if n > len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			row, err := unpackBits(data[:n], width)
+			if err != nil {
+				return nil, err
+			}
+			pixels = append(pixels, row...)
+			data = data[n:]
+		}
+		return pixels, nil
+	default:
+		return nil, fmt.Errorf("%v: channel compression %d", ErrUnsupported, compression)
+	}
+}
+
+// unpackBits decodes one PackBits compressed row of the given width
+func unpackBits(data []byte, width int) ([]byte, error) {
+	row := make([]byte, 0, width)
+	for i := 0; i < len(data) && len(row) < width; {
+		n := int(int8(data[i]))
+		i++
+		switch {
+		case n >= 0:
+			if i+n+1 > len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			row = append(row, data[i:i+n+1]...)
+			i += n + 1
+		case n > -128:
+			if i >= len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			row = append(row, bytes.Repeat(data[i:i+1], 1-n)...)
+			i++
+		}
+	}
+	if len(row) < width {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return row[:width], nil
+}
+
+// decodeUnicodeString decodes a length prefixed UTF-16 string
+func decodeUnicodeString(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	n := int(binary.BigEndian.Uint32(data))
+	data = data[4:]
+	if len(data) < n*2 {
+		return "", false
+	}
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(data[i*2:])
+	}
+	// names may be null terminated
+	for len(units) > 0 && units[len(units)-1] == 0 {
+		units = units[:len(units)-1]
+	}
+	return string(utf16.Decode(units)), true
+}
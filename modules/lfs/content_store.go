@@ -4,11 +4,11 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"hash"
 	"io"
-	"os"
-	"path/filepath"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/storage"
 )
 
 var (
@@ -16,79 +16,48 @@ var (
 	errSizeMismatch = errors.New("Content size does not match")
 )
 
-// ContentStore provides a simple file system based storage.
+// ContentStore provides access to the LFS content of a storage.
 type ContentStore struct {
-	BasePath string
+	storage.ObjectStorage
 }
 
 // Get takes a Meta object and retrieves the content from the store, returning
 // it as an io.Reader. If fromByte > 0, the reader starts from that byte
 func (s *ContentStore) Get(meta *models.LFSMetaObject, fromByte int64) (io.ReadCloser, error) {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-
-	f, err := os.Open(path)
+	f, err := s.Open(meta.RelativePath())
 	if err != nil {
 		return nil, err
 	}
 	if fromByte > 0 {
-		_, err = f.Seek(fromByte, os.SEEK_CUR)
+		if _, err = f.Seek(fromByte, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
 	}
-	return f, err
+	return f, nil
 }
 
 // Put takes a Meta object and an io.Reader and writes the content to the store.
+// The content is only stored if its size and hash match the Meta object.
 func (s *ContentStore) Put(meta *models.LFSMetaObject, r io.Reader) error {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-	tmpPath := path + ".tmp"
-
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0750); err != nil {
-		return err
-	}
-
-	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0640)
-	if err != nil {
-		return err
-	}
-	defer os.Remove(tmpPath)
-
-	hash := sha256.New()
-	hw := io.MultiWriter(hash, file)
-
-	written, err := io.Copy(hw, r)
-	if err != nil {
-		file.Close()
-		return err
-	}
-	file.Close()
-
-	if written != meta.Size {
-		return errSizeMismatch
-	}
-
-	shaStr := hex.EncodeToString(hash.Sum(nil))
-	if shaStr != meta.Oid {
-		return errHashMismatch
-	}
-
-	return os.Rename(tmpPath, path)
+	_, err := s.Save(meta.RelativePath(), &hashingReader{
+		reader: r,
+		hash:   sha256.New(),
+		meta:   meta,
+	}, meta.Size)
+	return err
 }
 
 // Exists returns true if the object exists in the content store.
 func (s *ContentStore) Exists(meta *models.LFSMetaObject) bool {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return false
-	}
-	return true
+	_, err := s.Stat(meta.RelativePath())
+	return err == nil
 }
 
 // Verify returns true if the object exists in the content store and size is correct.
 func (s *ContentStore) Verify(meta *models.LFSMetaObject) (bool, error) {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-
-	fi, err := os.Stat(path)
-	if os.IsNotExist(err) || err == nil && fi.Size() != meta.Size {
+	info, err := s.Stat(meta.RelativePath())
+	if storage.IsNotExist(err) || err == nil && info.Size != meta.Size {
 		return false, nil
 	} else if err != nil {
 		return false, err
@@ -97,10 +66,27 @@ func (s *ContentStore) Verify(meta *models.LFSMetaObject) (bool, error) {
 	return true, nil
 }
 
-func transformKey(key string) string {
-	if len(key) < 5 {
-		return key
-	}
+// hashingReader fails before returning the last bytes of the content if it does not
+// match the Meta object, so the storage never receives a complete invalid object
+type hashingReader struct {
+	reader  io.Reader
+	hash    hash.Hash
+	meta    *models.LFSMetaObject
+	written int64
+}
 
-	return filepath.Join(key[0:2], key[2:4], key[4:])
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.written += int64(n)
+	if r.written > r.meta.Size {
+		return 0, errSizeMismatch
+	}
+	if r.written == r.meta.Size && n > 0 && hex.EncodeToString(r.hash.Sum(nil)) != r.meta.Oid {
+		return 0, errHashMismatch
+	}
+	if err == io.EOF && r.written != r.meta.Size {
+		return n, errSizeMismatch
+	}
+	return n, err
 }
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 )
 
 // ReadPointerFile will return a partially filled LFSMetaObject if the provided reader is a pointer file
@@ -37,7 +38,23 @@ func IsPointerFile(buf *[]byte) *models.LFSMetaObject {
 		return nil
 	}
 
-	headString := string(*buf)
+	meta := ParsePointer(*buf)
+	if meta == nil {
+		return nil
+	}
+
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
+	if !contentStore.Exists(meta) {
+		return nil
+	}
+
+	return meta
+}
+
+// ParsePointer will return a partially filled LFSMetaObject if the provided byte slice is a pointer file,
+// unlike IsPointerFile the content is not required to be in the store
+func ParsePointer(buf []byte) *models.LFSMetaObject {
+	headString := string(buf)
 	if !strings.HasPrefix(headString, models.LFSMetaFileIdentifier) {
 		return nil
 	}
@@ -53,17 +70,11 @@ func IsPointerFile(buf *[]byte) *models.LFSMetaObject {
 		return nil
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
-	meta := &models.LFSMetaObject{Oid: oid, Size: size}
-	if !contentStore.Exists(meta) {
-		return nil
-	}
-
-	return meta
+	return &models.LFSMetaObject{Oid: oid, Size: size}
 }
 
 // ReadMetaObject will read a models.LFSMetaObject and return a reader
 func ReadMetaObject(meta *models.LFSMetaObject) (io.ReadCloser, error) {
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	return contentStore.Get(meta, 0)
 }
@@ -0,0 +1,147 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+)
+
+// maxPointerSize is the size above which a blob cannot be an LFS pointer
+const maxPointerSize = 1024
+
+// ReachablePointers returns the LFS pointers reachable from any reference of the repository, by oid.
+//
+// Unlike the pipelines used to list the pointers of a repository any failure of
+// one of the git commands is returned, so callers deleting unreferenced objects
+// never act on a partial result.
+func ReachablePointers(repoPath string) (map[string]*models.LFSMetaObject, error) {
+	revListReader, revListWriter := io.Pipe()
+	catFileCheckReader, catFileCheckWriter := io.Pipe()
+	shasToBatchReader, shasToBatchWriter := io.Pipe()
+	catFileBatchReader, catFileBatchWriter := io.Pipe()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	wg.Add(len(errs))
+
+	// 1. List all objects reachable from any reference
+	go func() {
+		defer wg.Done()
+		errs[0] = runPipelineCommand(repoPath, nil, revListWriter, "rev-list", "--objects", "--all")
+	}()
+
+	// 2. Get the type and size of each object, %(rest) makes git ignore the paths listed by rev-list
+	go func() {
+		defer wg.Done()
+		errs[1] = runPipelineCommand(repoPath, revListReader, catFileCheckWriter, "cat-file", "--batch-check=%(objectname) %(objecttype) %(objectsize) %(rest)")
+	}()
+
+	// 3. Restrict to the blobs small enough to be pointers
+	go func() {
+		defer wg.Done()
+		err := filterSmallBlobs(catFileCheckReader, shasToBatchWriter)
+		_ = catFileCheckReader.CloseWithError(err)
+		_ = shasToBatchWriter.CloseWithError(err)
+		errs[2] = err
+	}()
+
+	// 4. Read their content
+	go func() {
+		defer wg.Done()
+		errs[3] = runPipelineCommand(repoPath, shasToBatchReader, catFileBatchWriter, "cat-file", "--batch")
+	}()
+
+	// 5. Keep the blobs which are pointers
+	pointers, err := readPointersFromCatFileBatch(catFileBatchReader)
+	_ = catFileBatchReader.CloseWithError(err)
+	wg.Wait()
+
+	for _, stageErr := range errs {
+		if stageErr != nil {
+			return nil, stageErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pointers, nil
+}
+
+// runPipelineCommand runs a git command of a pipeline, closing both ends with its error
+// so the previous and next stages stop as well
+func runPipelineCommand(repoPath string, stdin *io.PipeReader, stdout *io.PipeWriter, args ...string) error {
+	stderr := new(bytes.Buffer)
+	var err error
+	if stdin == nil {
+		err = git.NewCommand(args...).RunInDirPipeline(repoPath, stdout, stderr)
+	} else {
+		err = git.NewCommand(args...).RunInDirFullPipeline(repoPath, stdout, stderr, stdin)
+	}
+	if err != nil {
+		err = fmt.Errorf("git %s [%s]: %v - %s", strings.Join(args, " "), repoPath, err, stderr.String())
+	}
+	if stdin != nil {
+		_ = stdin.CloseWithError(err)
+	}
+	_ = stdout.CloseWithError(err)
+	return err
+}
+
+func filterSmallBlobs(catFileCheckReader io.Reader, shasToBatchWriter io.Writer) error {
+	scanner := bufio.NewScanner(catFileCheckReader)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), " ")
+		if len(fields) < 3 || fields[1] != "blob" {
+			continue
+		}
+		if size, _ := strconv.Atoi(fields[2]); size > maxPointerSize {
+			continue
+		}
+		if _, err := io.WriteString(shasToBatchWriter, fields[0]+"\n"); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func readPointersFromCatFileBatch(catFileBatchReader io.Reader) (map[string]*models.LFSMetaObject, error) {
+	pointers := make(map[string]*models.LFSMetaObject)
+	bufferedReader := bufio.NewReader(catFileBatchReader)
+	buf := make([]byte, maxPointerSize+1)
+	for {
+		// Header line: sha type size
+		header, err := bufferedReader.ReadString('\n')
+		if err == io.EOF && len(header) == 0 {
+			return pointers, nil
+		} else if err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected cat-file header %q", header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size > maxPointerSize {
+			return nil, fmt.Errorf("unexpected cat-file header %q", header)
+		}
+		// the content is followed by a newline
+		content := buf[:size+1]
+		if _, err := io.ReadFull(bufferedReader, content); err != nil {
+			return nil, err
+		}
+		if pointer := ParsePointer(content[:size]); pointer != nil {
+			pointers[pointer.Oid] = pointer
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"gitea.com/macaron/macaron"
 	"github.com/dgrijalva/jwt-go"
@@ -146,39 +147,65 @@ func getContentHandler(ctx *context.Context) {
 		return
 	}
 
+	var filename string
+	if encodedFilename := ctx.Params("filename"); len(encodedFilename) > 0 {
+		if decodedFilename, err := base64.RawURLEncoding.DecodeString(encodedFilename); err == nil {
+			filename = string(decodedFilename)
+		}
+	}
+
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
+	if setting.LFS.Storage.ServeDirect {
+		// redirect the download to the object storage, the range is handled there
+		u, err := contentStore.URL(meta.RelativePath(), filename, setting.LFS.Storage.ServeDirectExpiry)
+		if err == nil {
+			ctx.Redirect(u.String())
+			logRequest(ctx.Req, http.StatusFound)
+			return
+		} else if err != storage.ErrURLNotSupported {
+			log.Error("Unable to get direct URL of LFS object %s: %v", meta.Oid, err)
+		}
+	}
+
 	// Support resume download using Range header
-	var fromByte int64
-	statusCode := 200
+	fromByte, toByte := int64(0), meta.Size-1
+	statusCode := http.StatusOK
 	if rangeHdr := ctx.Req.Header.Get("Range"); rangeHdr != "" {
-		regex := regexp.MustCompile(`bytes=(\d+)\-.*`)
+		regex := regexp.MustCompile(`bytes=(\d+)\-(\d*).*`)
 		match := regex.FindStringSubmatch(rangeHdr)
 		if len(match) > 1 {
-			statusCode = 206
-			fromByte, _ = strconv.ParseInt(match[1], 10, 32)
-			ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", fromByte, meta.Size-1, meta.Size-fromByte))
+			statusCode = http.StatusPartialContent
+			fromByte, _ = strconv.ParseInt(match[1], 10, 64)
+			if fromByte >= meta.Size {
+				ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", meta.Size))
+				writeStatus(ctx, http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			if len(match[2]) > 0 {
+				if end, err := strconv.ParseInt(match[2], 10, 64); err == nil && end >= fromByte && end < toByte {
+					toByte = end
+				}
+			}
+			ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", fromByte, toByte, meta.Size))
+			ctx.Resp.Header().Set("Access-Control-Expose-Headers", "Content-Range")
 		}
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
 	content, err := contentStore.Get(meta, fromByte)
 	if err != nil {
 		writeStatus(ctx, 404)
 		return
 	}
 
-	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(meta.Size-fromByte, 10))
+	contentLength := toByte + 1 - fromByte
+	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(contentLength, 10))
 	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
-
-	filename := ctx.Params("filename")
 	if len(filename) > 0 {
-		decodedFilename, err := base64.RawURLEncoding.DecodeString(filename)
-		if err == nil {
-			ctx.Resp.Header().Set("Content-Disposition", "attachment; filename=\""+string(decodedFilename)+"\"")
-		}
+		ctx.Resp.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
 	}
 
 	ctx.Resp.WriteHeader(statusCode)
-	_, _ = io.Copy(ctx.Resp, content)
+	_, _ = io.CopyN(ctx.Resp, content, contentLength)
 	_ = content.Close()
 	logRequest(ctx.Req, statusCode)
 }
@@ -242,7 +269,7 @@ func PostHandler(ctx *context.Context) {
 	ctx.Resp.Header().Set("Content-Type", metaMediaType)
 
 	sentStatus := 202
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	if meta.Existing && contentStore.Exists(meta) {
 		sentStatus = 200
 	}
@@ -293,7 +320,7 @@ func BatchHandler(ctx *context.Context) {
 			return
 		}
 
-		contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+		contentStore := &ContentStore{ObjectStorage: storage.LFS}
 
 		meta, err := repository.GetLFSMetaObjectByOid(object.Oid)
 		if err == nil && contentStore.Exists(meta) { // Object is found and exists
@@ -326,7 +353,7 @@ func PutHandler(ctx *context.Context) {
 		return
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	bodyReader := ctx.Req.Body().ReadCloser()
 	defer bodyReader.Close()
 	if err := contentStore.Put(meta, bodyReader); err != nil {
@@ -360,7 +387,7 @@ func VerifyHandler(ctx *context.Context) {
 		return
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	ok, err := contentStore.Verify(meta)
 	if err != nil {
 		ctx.Resp.WriteHeader(500)
@@ -532,6 +559,23 @@ func authenticate(ctx *context.Context, repository *models.Repository, authoriza
 	return false
 }
 
+// NewToken signs a token granting the user the operation on the LFS objects of the repository,
+// valid for LFS_HTTP_AUTH_EXPIRY
+func NewToken(repoID, userID int64, operation string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"repo": repoID,
+		"op":   operation,
+		"exp":  now.Add(setting.LFS.HTTPAuthExpiry).Unix(),
+		"nbf":  now.Unix(),
+		"user": userID,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// Sign and get the complete encoded token as a string using the secret
+	return token.SignedString(setting.LFS.JWTSecretBytes)
+}
+
 func parseToken(authorization string) (*models.User, *models.Repository, string, error) {
 	if authorization == "" {
 		return nil, nil, "unknown", fmt.Errorf("No token")
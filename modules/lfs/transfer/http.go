@@ -0,0 +1,251 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package transfer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+const metaMediaType = "application/vnd.git-lfs+json"
+
+// HTTPBackend forwards the requests to the LFS server of Gitea,
+// so permissions, storage and locks are handled in a single place
+type HTTPBackend struct {
+	endpoint string
+	// authorization returns the Authorization header of each request, tokens
+	// are short-lived while a connection may be kept open much longer
+	authorization func() (string, error)
+	client        *http.Client
+}
+
+// NewHTTPBackend returns a backend for the LFS server of a repository reachable on the local URL
+func NewHTTPBackend(ownerName, repoName string, authorization func() (string, error)) *HTTPBackend {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         setting.Domain,
+		},
+	}
+	if setting.Protocol == setting.UnixSocket {
+		transport.Dial = func(_, _ string) (net.Conn, error) {
+			return net.Dial("unix", setting.HTTPAddr)
+		}
+	}
+	return &HTTPBackend{
+		endpoint:      fmt.Sprintf("%s%s/%s.git/info/lfs/", setting.LocalURL, url.PathEscape(ownerName), url.PathEscape(repoName)),
+		authorization: authorization,
+		client:        &http.Client{Transport: transport},
+	}
+}
+
+// do sends a request to the LFS server, any response with another status than expected is returned as an ErrStatus
+func (b *HTTPBackend) do(method, path string, body io.Reader, size int64, expected ...int) (*http.Response, error) {
+	req, err := http.NewRequest(method, b.endpoint+path, body)
+	if err != nil {
+		return nil, ErrStatus{http.StatusInternalServerError, err.Error()}
+	}
+	req.ContentLength = size
+	authorization, err := b.authorization()
+	if err != nil {
+		return nil, ErrStatus{http.StatusInternalServerError, err.Error()}
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", metaMediaType)
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", metaMediaType)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, ErrStatus{http.StatusBadGateway, err.Error()}
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+
+	message := http.StatusText(resp.StatusCode)
+	var lockErr api.LFSLockError
+	if err := json.NewDecoder(resp.Body).Decode(&lockErr); err == nil && lockErr.Message != "" {
+		message = lockErr.Message
+	}
+	return nil, ErrStatus{resp.StatusCode, message}
+}
+
+// doJSON posts the request as JSON and decodes the response
+func (b *HTTPBackend) doJSON(method, path string, request, response interface{}, expected ...int) error {
+	var body io.Reader
+	var size int64
+	if request != nil {
+		buf, err := json.Marshal(request)
+		if err != nil {
+			return ErrStatus{http.StatusInternalServerError, err.Error()}
+		}
+		body, size = bytes.NewReader(buf), int64(len(buf))
+	}
+	resp, err := b.do(method, path, body, size, expected...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return ErrStatus{http.StatusBadGateway, err.Error()}
+	}
+	return nil
+}
+
+type batchObject struct {
+	Oid     string              `json:"oid"`
+	Size    int64               `json:"size"`
+	Actions map[string]struct{} `json:"actions,omitempty"`
+}
+
+// Batch implements Backend
+func (b *HTTPBackend) Batch(operation string, objects []*Object) error {
+	request := struct {
+		Operation string         `json:"operation"`
+		Objects   []*batchObject `json:"objects"`
+	}{Operation: operation}
+	for _, object := range objects {
+		request.Objects = append(request.Objects, &batchObject{Oid: object.Oid, Size: object.Size})
+	}
+	var response struct {
+		Objects []*batchObject `json:"objects"`
+	}
+	if err := b.doJSON(http.MethodPost, "objects/batch", request, &response, http.StatusOK); err != nil {
+		return err
+	}
+
+	actions := make(map[string]map[string]struct{}, len(response.Objects))
+	for _, object := range response.Objects {
+		actions[object.Oid] = object.Actions
+	}
+	for _, object := range objects {
+		object.Action = ActionNoop
+		if _, ok := actions[object.Oid][operation]; ok {
+			object.Action = operation
+		}
+	}
+	return nil
+}
+
+// Upload implements Backend
+func (b *HTTPBackend) Upload(oid string, size int64, content io.Reader) error {
+	resp, err := b.do(http.MethodPut, "objects/"+url.PathEscape(oid), ioutil.NopCloser(content), size, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Verify implements Backend
+func (b *HTTPBackend) Verify(oid string, size int64) error {
+	buf, _ := json.Marshal(&batchObject{Oid: oid, Size: size})
+	resp, err := b.do(http.MethodPost, "verify", bytes.NewReader(buf), int64(len(buf)), http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Download implements Backend
+func (b *HTTPBackend) Download(oid string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequest(http.MethodGet, b.endpoint+"objects/"+url.PathEscape(oid), nil)
+	if err != nil {
+		return nil, 0, ErrStatus{http.StatusInternalServerError, err.Error()}
+	}
+	authorization, err := b.authorization()
+	if err != nil {
+		return nil, 0, ErrStatus{http.StatusInternalServerError, err.Error()}
+	}
+	// without the LFS media type the content is returned instead of the meta data
+	req.Header.Set("Authorization", authorization)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, ErrStatus{http.StatusBadGateway, err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, ErrStatus{resp.StatusCode, http.StatusText(resp.StatusCode)}
+	}
+	if resp.ContentLength < 0 {
+		resp.Body.Close()
+		return nil, 0, ErrStatus{http.StatusBadGateway, "unknown object size"}
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// Lock implements Backend
+func (b *HTTPBackend) Lock(path string) (*api.LFSLock, error) {
+	buf, _ := json.Marshal(&api.LFSLockRequest{Path: path})
+	resp, err := b.do(http.MethodPost, "locks", bytes.NewReader(buf), int64(len(buf)), http.StatusCreated, http.StatusConflict)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		var lockErr api.LFSLockError
+		if err := json.NewDecoder(resp.Body).Decode(&lockErr); err != nil || lockErr.Lock == nil {
+			return nil, ErrStatus{http.StatusConflict, "lock already exists"}
+		}
+		return nil, ErrLockConflict{lockErr.Lock}
+	}
+	var lockResp api.LFSLockResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lockResp); err != nil || lockResp.Lock == nil {
+		return nil, ErrStatus{http.StatusBadGateway, "invalid lock response"}
+	}
+	return lockResp.Lock, nil
+}
+
+// Locks implements Backend
+func (b *HTTPBackend) Locks(path, id string) ([]*api.LFSLock, error) {
+	query := url.Values{}
+	if path != "" {
+		query.Set("path", path)
+	}
+	if id != "" {
+		query.Set("id", id)
+	}
+	var list api.LFSLockList
+	if err := b.doJSON(http.MethodGet, "locks?"+query.Encode(), nil, &list, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return list.Locks, nil
+}
+
+// VerifyLocks implements Backend
+func (b *HTTPBackend) VerifyLocks() (ours, theirs []*api.LFSLock, err error) {
+	var list api.LFSLockListVerify
+	if err := b.doJSON(http.MethodPost, "locks/verify", struct{}{}, &list, http.StatusOK); err != nil {
+		return nil, nil, err
+	}
+	return list.Ours, list.Theirs, nil
+}
+
+// Unlock implements Backend
+func (b *HTTPBackend) Unlock(id string, force bool) (*api.LFSLock, error) {
+	var lockResp api.LFSLockResponse
+	if err := b.doJSON(http.MethodPost, "locks/"+url.PathEscape(id)+"/unlock", &api.LFSLockDeleteRequest{Force: force}, &lockResp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if lockResp.Lock == nil {
+		return nil, ErrStatus{http.StatusBadGateway, "invalid unlock response"}
+	}
+	return lockResp.Lock, nil
+}
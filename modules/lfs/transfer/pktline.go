@@ -0,0 +1,172 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package transfer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxPacketSize is the largest packet allowed by the pkt-line format, including the length
+	maxPacketSize = 65520
+	maxPacketData = maxPacketSize - 4
+)
+
+type packetKind int
+
+const (
+	dataPacket packetKind = iota
+	flushPacket
+	delimPacket
+)
+
+// packetReader reads the pkt-line packets sent by the client
+type packetReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func newPacketReader(r io.Reader) *packetReader {
+	return &packetReader{r: r, buf: make([]byte, maxPacketData)}
+}
+
+// readPacket returns the next packet, the data is only valid until the next call
+func (p *packetReader) readPacket() ([]byte, packetKind, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(p.r, header[:]); err != nil {
+		return nil, dataPacket, err
+	}
+	length, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return nil, dataPacket, fmt.Errorf("invalid packet length %q", header)
+	}
+	switch {
+	case length == 0:
+		return nil, flushPacket, nil
+	case length == 1:
+		return nil, delimPacket, nil
+	case length < 4 || length > maxPacketSize:
+		return nil, dataPacket, fmt.Errorf("invalid packet length %q", header)
+	}
+	data := p.buf[:length-4]
+	if _, err := io.ReadFull(p.r, data); err != nil {
+		return nil, dataPacket, err
+	}
+	return data, dataPacket, nil
+}
+
+// readLine returns the next packet as text without its trailing newline
+func (p *packetReader) readLine() (string, packetKind, error) {
+	data, kind, err := p.readPacket()
+	return strings.TrimSuffix(string(data), "\n"), kind, err
+}
+
+// readLines returns the text packets up to the next flush packet
+func (p *packetReader) readLines() ([]string, error) {
+	var lines []string
+	for {
+		line, kind, err := p.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if kind == flushPacket {
+			return lines, nil
+		} else if kind == delimPacket {
+			return nil, fmt.Errorf("unexpected delimiter packet")
+		}
+		lines = append(lines, line)
+	}
+}
+
+// dataReader returns the content of the data packets up to the next flush packet
+func (p *packetReader) dataReader() io.Reader {
+	return &packetDataReader{p: p}
+}
+
+type packetDataReader struct {
+	p       *packetReader
+	pending []byte
+	done    bool
+}
+
+func (r *packetDataReader) Read(b []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		data, kind, err := r.p.readPacket()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case flushPacket:
+			r.done = true
+		case delimPacket:
+			return 0, fmt.Errorf("unexpected delimiter packet")
+		default:
+			r.pending = data
+		}
+	}
+	n := copy(b, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// packetWriter writes pkt-line packets to the client, they are sent at each flush packet
+type packetWriter struct {
+	w *bufio.Writer
+}
+
+func newPacketWriter(w io.Writer) *packetWriter {
+	return &packetWriter{w: bufio.NewWriterSize(w, maxPacketSize)}
+}
+
+func (p *packetWriter) writePacket(data []byte) error {
+	if _, err := fmt.Fprintf(p.w, "%04x", len(data)+4); err != nil {
+		return err
+	}
+	_, err := p.w.Write(data)
+	return err
+}
+
+func (p *packetWriter) writeLine(line string) error {
+	return p.writePacket([]byte(line + "\n"))
+}
+
+func (p *packetWriter) writeDelim() error {
+	_, err := p.w.WriteString("0001")
+	return err
+}
+
+func (p *packetWriter) writeFlush() error {
+	if _, err := p.w.WriteString("0000"); err != nil {
+		return err
+	}
+	return p.w.Flush()
+}
+
+// Write writes b as data packets
+func (p *packetWriter) Write(b []byte) (int, error) {
+	var written int
+	for len(b) > 0 {
+		n := len(b)
+		if n > maxPacketData {
+			n = maxPacketData
+		}
+		if err := p.writePacket(b[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		b = b[n:]
+	}
+	return written, nil
+}
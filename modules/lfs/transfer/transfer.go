@@ -0,0 +1,471 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package transfer implements the server side of the git-lfs-transfer SSH protocol,
+// https://github.com/git-lfs/git-lfs/blob/master/docs/proposals/ssh_adapter.md
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Operations a connection is opened for
+const (
+	OperationUpload   = "upload"
+	OperationDownload = "download"
+)
+
+// Actions returned for the objects of a batch request
+const (
+	ActionUpload   = "upload"
+	ActionDownload = "download"
+	ActionNoop     = "noop"
+)
+
+// Object is an LFS object of a batch request
+type Object struct {
+	Oid    string
+	Size   int64
+	Action string
+}
+
+// Backend stores the objects and locks of a repository
+type Backend interface {
+	// Batch sets the action the client has to perform for each object
+	Batch(operation string, objects []*Object) error
+	Upload(oid string, size int64, content io.Reader) error
+	Verify(oid string, size int64) error
+	Download(oid string) (io.ReadCloser, int64, error)
+
+	// Lock returns an ErrLockConflict if the path is already locked
+	Lock(path string) (*api.LFSLock, error)
+	Locks(path, id string) ([]*api.LFSLock, error)
+	// VerifyLocks returns the locks of the repository split by whether the user owns them
+	VerifyLocks() (ours, theirs []*api.LFSLock, err error)
+	Unlock(id string, force bool) (*api.LFSLock, error)
+}
+
+// ErrStatus is an error sent to the client with its status code
+type ErrStatus struct {
+	Code    int
+	Message string
+}
+
+func (err ErrStatus) Error() string {
+	return fmt.Sprintf("status %d: %s", err.Code, err.Message)
+}
+
+// ErrLockConflict represents a "LockConflict" kind of error.
+type ErrLockConflict struct {
+	Lock *api.LFSLock
+}
+
+func (err ErrLockConflict) Error() string {
+	return fmt.Sprintf("lock already exists [id: %s, path: %s]", err.Lock.ID, err.Lock.Path)
+}
+
+// server handles the requests of one connection
+type server struct {
+	operation string
+	backend   Backend
+	r         *packetReader
+	w         *packetWriter
+}
+
+// Serve handles the git-lfs-transfer requests read from r until the client quits
+func Serve(r io.Reader, w io.Writer, operation string, backend Backend) error {
+	if operation != OperationUpload && operation != OperationDownload {
+		return fmt.Errorf("unknown operation %q", operation)
+	}
+	s := &server{
+		operation: operation,
+		backend:   backend,
+		r:         newPacketReader(r),
+		w:         newPacketWriter(w),
+	}
+
+	// capability advertisement
+	if err := s.w.writeLine("version=1"); err != nil {
+		return err
+	}
+	if err := s.w.writeFlush(); err != nil {
+		return err
+	}
+
+	for {
+		command, args, hasData, err := s.readRequest()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if command == "quit" {
+			return s.writeStatus(http.StatusOK)
+		}
+		if err := s.handle(command, args, hasData); err != nil {
+			if _, ok := err.(ErrStatus); !ok {
+				return err
+			}
+			if err := s.writeError(err.(ErrStatus)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readRequest reads the command and the arguments of a request,
+// hasData is set if the arguments are followed by a delimiter packet
+func (s *server) readRequest() (command string, args map[string]string, hasData bool, err error) {
+	command, kind, err := s.r.readLine()
+	if err != nil {
+		return "", nil, false, err
+	}
+	if kind != dataPacket {
+		return "", nil, false, fmt.Errorf("expected a command")
+	}
+	args = make(map[string]string)
+	for {
+		line, kind, err := s.r.readLine()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return "", nil, false, err
+		}
+		switch kind {
+		case flushPacket:
+			return command, args, false, nil
+		case delimPacket:
+			return command, args, true, nil
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return "", nil, false, fmt.Errorf("invalid argument %q", line)
+		}
+		args[kv[0]] = kv[1]
+	}
+}
+
+func (s *server) handle(command string, args map[string]string, hasData bool) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "empty command"})
+	}
+
+	switch fields[0] {
+	case "version":
+		if len(fields) != 2 || fields[1] != "1" {
+			return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "unsupported version"})
+		}
+		if err := s.skipData(hasData, nil); err != nil {
+			return err
+		}
+		return s.writeStatus(http.StatusOK)
+	case "batch":
+		return s.batch(args, hasData)
+	case "put-object":
+		if len(fields) != 2 {
+			return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "missing object id"})
+		}
+		return s.putObject(fields[1], args, hasData)
+	case "verify-object":
+		if len(fields) != 2 {
+			return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "missing object id"})
+		}
+		return s.verifyObject(fields[1], args, hasData)
+	case "get-object":
+		if len(fields) != 2 {
+			return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "missing object id"})
+		}
+		return s.getObject(fields[1], hasData)
+	case "lock":
+		return s.lock(args, hasData)
+	case "list-lock":
+		return s.listLocks(args, hasData)
+	case "unlock":
+		if len(fields) != 2 {
+			return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "missing lock id"})
+		}
+		return s.unlock(fields[1], args, hasData)
+	}
+	return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "unknown command " + fields[0]})
+}
+
+// skipData discards the data of a request which is not expected or not handled
+// to stay in sync with the client, and returns err
+func (s *server) skipData(hasData bool, err error) error {
+	if hasData {
+		if _, copyErr := io.Copy(ioutil.Discard, s.r.dataReader()); copyErr != nil {
+			return copyErr
+		}
+	}
+	return err
+}
+
+func (s *server) requireUpload() error {
+	if s.operation != OperationUpload {
+		return ErrStatus{http.StatusForbidden, "the connection is read only"}
+	}
+	return nil
+}
+
+func (s *server) batch(args map[string]string, hasData bool) error {
+	if !hasData {
+		return ErrStatus{http.StatusBadRequest, "missing objects"}
+	}
+	lines, err := s.r.readLines()
+	if err != nil {
+		return err
+	}
+	if algo, ok := args["hash-algo"]; ok && algo != "sha256" {
+		return ErrStatus{http.StatusConflict, "unsupported hash algorithm " + algo}
+	}
+	if transfer, ok := args["transfer"]; ok && transfer != "basic" {
+		return ErrStatus{http.StatusConflict, "unsupported transfer " + transfer}
+	}
+
+	objects := make([]*Object, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return ErrStatus{http.StatusBadRequest, "invalid object " + line}
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || size < 0 {
+			return ErrStatus{http.StatusBadRequest, "invalid object " + line}
+		}
+		objects = append(objects, &Object{Oid: fields[0], Size: size, Action: ActionNoop})
+	}
+	if err := s.backend.Batch(s.operation, objects); err != nil {
+		return err
+	}
+
+	if err := s.w.writeLine(fmt.Sprintf("status %d", http.StatusOK)); err != nil {
+		return err
+	}
+	if err := s.w.writeDelim(); err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := s.w.writeLine(fmt.Sprintf("%s %d %s", object.Oid, object.Size, object.Action)); err != nil {
+			return err
+		}
+	}
+	return s.w.writeFlush()
+}
+
+func (s *server) putObject(oid string, args map[string]string, hasData bool) error {
+	if !hasData {
+		return ErrStatus{http.StatusBadRequest, "missing object content"}
+	}
+	if err := s.requireUpload(); err != nil {
+		return s.skipData(hasData, err)
+	}
+	size, err := strconv.ParseInt(args["size"], 10, 64)
+	if err != nil || size < 0 {
+		return s.skipData(hasData, ErrStatus{http.StatusBadRequest, "invalid size"})
+	}
+
+	content := s.r.dataReader()
+	err = s.backend.Upload(oid, size, content)
+	// the backend may stop reading on failure
+	if err := s.skipData(true, nil); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return s.writeStatus(http.StatusOK)
+}
+
+func (s *server) verifyObject(oid string, args map[string]string, hasData bool) error {
+	if err := s.skipData(hasData, s.requireUpload()); err != nil {
+		return err
+	}
+	size, err := strconv.ParseInt(args["size"], 10, 64)
+	if err != nil || size < 0 {
+		return ErrStatus{http.StatusBadRequest, "invalid size"}
+	}
+	if err := s.backend.Verify(oid, size); err != nil {
+		return err
+	}
+	return s.writeStatus(http.StatusOK)
+}
+
+func (s *server) getObject(oid string, hasData bool) error {
+	if err := s.skipData(hasData, nil); err != nil {
+		return err
+	}
+	content, size, err := s.backend.Download(oid)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := s.w.writeLine(fmt.Sprintf("status %d", http.StatusOK)); err != nil {
+		return err
+	}
+	if err := s.w.writeLine(fmt.Sprintf("size=%d", size)); err != nil {
+		return err
+	}
+	if err := s.w.writeDelim(); err != nil {
+		return err
+	}
+	// the status has been sent already, a failure can only close the connection
+	if n, err := io.Copy(s.w, content); err != nil {
+		return fmt.Errorf("send %s: %v", oid, err)
+	} else if n != size {
+		return fmt.Errorf("send %s: %d bytes instead of %d", oid, n, size)
+	}
+	return s.w.writeFlush()
+}
+
+func (s *server) lock(args map[string]string, hasData bool) error {
+	if err := s.skipData(hasData, s.requireUpload()); err != nil {
+		return err
+	}
+	path := args["path"]
+	if path == "" {
+		return ErrStatus{http.StatusBadRequest, "missing path"}
+	}
+
+	lock, err := s.backend.Lock(path)
+	if conflict, ok := err.(ErrLockConflict); ok {
+		lines := append([]string{fmt.Sprintf("status %d", http.StatusConflict)}, lockArgs(conflict.Lock)...)
+		lines = append(lines, "")
+		return s.writeLines(lines, "lock already exists")
+	} else if err != nil {
+		return err
+	}
+	return s.writeStatus(http.StatusCreated, lockArgs(lock)...)
+}
+
+func (s *server) listLocks(args map[string]string, hasData bool) error {
+	if err := s.skipData(hasData, nil); err != nil {
+		return err
+	}
+	limit := 100
+	if v, ok := args["limit"]; ok {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return ErrStatus{http.StatusBadRequest, "invalid limit"}
+		}
+	}
+
+	// the locks are listed for verification before a push
+	var locks []*api.LFSLock
+	ours := make(map[string]bool)
+	if s.operation == OperationUpload {
+		ourLocks, theirLocks, err := s.backend.VerifyLocks()
+		if err != nil {
+			return err
+		}
+		for _, lock := range ourLocks {
+			ours[lock.ID] = true
+		}
+		for _, lock := range append(ourLocks, theirLocks...) {
+			if (args["path"] == "" || lock.Path == args["path"]) && (args["id"] == "" || lock.ID == args["id"]) {
+				locks = append(locks, lock)
+			}
+		}
+	} else {
+		var err error
+		if locks, err = s.backend.Locks(args["path"], args["id"]); err != nil {
+			return err
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Path < locks[j].Path })
+
+	// the cursor is the index of the first lock of the page
+	start := 0
+	if v, ok := args["cursor"]; ok {
+		var err error
+		if start, err = strconv.Atoi(v); err != nil || start < 0 || start > len(locks) {
+			return ErrStatus{http.StatusBadRequest, "invalid cursor"}
+		}
+	}
+	end := start + limit
+	lines := []string{fmt.Sprintf("status %d", http.StatusOK)}
+	if end < len(locks) {
+		lines = append(lines, "next-cursor="+strconv.Itoa(end))
+	} else {
+		end = len(locks)
+	}
+	lines = append(lines, "")
+	for _, lock := range locks[start:end] {
+		lines = append(lines,
+			"lock "+lock.ID,
+			fmt.Sprintf("path %s %s", lock.ID, lock.Path),
+			fmt.Sprintf("locked-at %s %s", lock.ID, lock.LockedAt.UTC().Format(time.RFC3339)),
+			fmt.Sprintf("ownername %s %s", lock.ID, lockOwner(lock)))
+		if s.operation == OperationUpload {
+			owner := "theirs"
+			if ours[lock.ID] {
+				owner = "ours"
+			}
+			lines = append(lines, fmt.Sprintf("owner %s %s", lock.ID, owner))
+		}
+	}
+	return s.writeLines(lines)
+}
+
+func (s *server) unlock(id string, args map[string]string, hasData bool) error {
+	if err := s.skipData(hasData, s.requireUpload()); err != nil {
+		return err
+	}
+	lock, err := s.backend.Unlock(id, args["force"] == "true")
+	if err != nil {
+		return err
+	}
+	return s.writeStatus(http.StatusOK, lockArgs(lock)...)
+}
+
+func lockOwner(lock *api.LFSLock) string {
+	if lock.Owner == nil {
+		return ""
+	}
+	return lock.Owner.Name
+}
+
+func lockArgs(lock *api.LFSLock) []string {
+	return []string{
+		"id=" + lock.ID,
+		"path=" + lock.Path,
+		"locked-at=" + lock.LockedAt.UTC().Format(time.RFC3339),
+		"ownername=" + lockOwner(lock),
+	}
+}
+
+// writeLines sends a response, an empty line stands for a delimiter packet
+func (s *server) writeLines(lines []string, message ...string) error {
+	for _, line := range append(lines, message...) {
+		var err error
+		if line == "" {
+			err = s.w.writeDelim()
+		} else {
+			err = s.w.writeLine(line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.w.writeFlush()
+}
+
+func (s *server) writeStatus(code int, args ...string) error {
+	return s.writeLines(append([]string{fmt.Sprintf("status %d", code)}, args...))
+}
+
+func (s *server) writeError(err ErrStatus) error {
+	return s.writeLines([]string{fmt.Sprintf("status %d", err.Code), ""}, err.Message)
+}
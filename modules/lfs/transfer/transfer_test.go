@@ -0,0 +1,223 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryBackend struct {
+	objects map[string][]byte
+	locks   []*api.LFSLock
+}
+
+func (b *memoryBackend) Batch(operation string, objects []*Object) error {
+	for _, object := range objects {
+		_, exists := b.objects[object.Oid]
+		if operation == OperationUpload && !exists {
+			object.Action = ActionUpload
+		} else if operation == OperationDownload && exists {
+			object.Action = ActionDownload
+		}
+	}
+	return nil
+}
+
+func (b *memoryBackend) Upload(oid string, size int64, content io.Reader) error {
+	buf, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if int64(len(buf)) != size {
+		return ErrStatus{http.StatusBadRequest, "size mismatch"}
+	}
+	b.objects[oid] = buf
+	return nil
+}
+
+func (b *memoryBackend) Verify(oid string, size int64) error {
+	if buf, ok := b.objects[oid]; !ok || int64(len(buf)) != size {
+		return ErrStatus{http.StatusNotFound, "Not Found"}
+	}
+	return nil
+}
+
+func (b *memoryBackend) Download(oid string) (io.ReadCloser, int64, error) {
+	buf, ok := b.objects[oid]
+	if !ok {
+		return nil, 0, ErrStatus{http.StatusNotFound, "Not Found"}
+	}
+	return ioutil.NopCloser(bytes.NewReader(buf)), int64(len(buf)), nil
+}
+
+func (b *memoryBackend) Lock(path string) (*api.LFSLock, error) {
+	for _, lock := range b.locks {
+		if lock.Path == path {
+			return nil, ErrLockConflict{lock}
+		}
+	}
+	lock := &api.LFSLock{
+		ID:       strconv.Itoa(len(b.locks) + 1),
+		Path:     path,
+		LockedAt: time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC),
+		Owner:    &api.LFSLockOwner{Name: "user2"},
+	}
+	b.locks = append(b.locks, lock)
+	return lock, nil
+}
+
+func (b *memoryBackend) Locks(path, id string) ([]*api.LFSLock, error) {
+	return b.locks, nil
+}
+
+func (b *memoryBackend) VerifyLocks() (ours, theirs []*api.LFSLock, err error) {
+	for _, lock := range b.locks {
+		if lock.Owner.Name == "user2" {
+			ours = append(ours, lock)
+		} else {
+			theirs = append(theirs, lock)
+		}
+	}
+	return ours, theirs, nil
+}
+
+func (b *memoryBackend) Unlock(id string, force bool) (*api.LFSLock, error) {
+	for i, lock := range b.locks {
+		if lock.ID == id {
+			if lock.Owner.Name != "user2" && !force {
+				return nil, ErrStatus{http.StatusForbidden, "not the owner"}
+			}
+			b.locks = append(b.locks[:i], b.locks[i+1:]...)
+			return lock, nil
+		}
+	}
+	return nil, ErrStatus{http.StatusNotFound, "Not Found"}
+}
+
+// packets encodes lines as pkt-lines, "0000" and "0001" are kept as flush and delimiter packets
+func packets(lines ...string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		if line == "0000" || line == "0001" {
+			b.WriteString(line)
+			continue
+		}
+		fmt.Fprintf(&b, "%04x%s", len(line)+4, line)
+	}
+	return b.String()
+}
+
+func serve(t *testing.T, operation string, backend Backend, requests ...string) string {
+	var out bytes.Buffer
+	assert.NoError(t, Serve(strings.NewReader(packets(requests...)), &out, operation, backend))
+	return out.String()
+}
+
+const oid = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func TestServe_Upload(t *testing.T) {
+	backend := &memoryBackend{objects: map[string][]byte{}}
+
+	out := serve(t, OperationUpload, backend,
+		"version 1\n", "0000",
+		"batch\n", "transfer=basic\n", "refname=refs/heads/master\n", "0001", oid+" 3\n", "0000",
+		"put-object "+oid+"\n", "size=3\n", "0001", "f", "oo", "0000",
+		"verify-object "+oid+"\n", "size=3\n", "0000",
+		"batch\n", "0001", oid+" 3\n", "0000",
+		"quit\n", "0000")
+	assert.Equal(t, packets(
+		"version=1\n", "0000",
+		"status 200\n", "0000",
+		"status 200\n", "0001", oid+" 3 upload\n", "0000",
+		"status 200\n", "0000",
+		"status 200\n", "0001", oid+" 3 noop\n", "0000",
+		"status 200\n", "0000"), out)
+	assert.Equal(t, []byte("foo"), backend.objects[oid])
+}
+
+func TestServe_Download(t *testing.T) {
+	backend := &memoryBackend{objects: map[string][]byte{oid: []byte("foo")}}
+
+	out := serve(t, OperationDownload, backend,
+		"version 1\n", "0000",
+		"batch\n", "hash-algo=sha256\n", "0001", oid+" 3\n", "0000",
+		"get-object "+oid+"\n", "0000",
+		"get-object "+strings.Repeat("0", 64)+"\n", "0000",
+		// the data of a refused upload is skipped
+		"put-object "+oid+"\n", "size=3\n", "0001", "bar", "0000",
+		"lock\n", "path=foo.psd\n", "0000")
+	assert.Equal(t, packets(
+		"version=1\n", "0000",
+		"status 200\n", "0000",
+		"status 200\n", "0001", oid+" 3 download\n", "0000",
+		"status 200\n", "size=3\n", "0001", "foo", "0000",
+		"status 404\n", "0001", "Not Found\n", "0000",
+		"status 403\n", "0001", "the connection is read only\n", "0000",
+		"status 403\n", "0001", "the connection is read only\n", "0000"), out)
+	assert.Equal(t, []byte("foo"), backend.objects[oid])
+}
+
+func TestServe_Locks(t *testing.T) {
+	backend := &memoryBackend{
+		locks: []*api.LFSLock{{
+			ID:       "7",
+			Path:     "b.psd",
+			LockedAt: time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC),
+			Owner:    &api.LFSLockOwner{Name: "user5"},
+		}},
+	}
+
+	out := serve(t, OperationUpload, backend,
+		"lock\n", "path=a.psd\n", "refname=refs/heads/master\n", "0000",
+		"lock\n", "path=b.psd\n", "0000",
+		"list-lock\n", "limit=1\n", "0000",
+		"list-lock\n", "cursor=1\n", "0000",
+		"unlock 7\n", "0000",
+		"unlock 7\n", "force=true\n", "0000")
+	assert.Equal(t, packets(
+		"version=1\n", "0000",
+		"status 201\n", "id=2\n", "path=a.psd\n", "locked-at=2020-05-01T12:00:00Z\n", "ownername=user2\n", "0000",
+		"status 409\n", "id=7\n", "path=b.psd\n", "locked-at=2020-04-01T12:00:00Z\n", "ownername=user5\n", "0001", "lock already exists\n", "0000",
+		"status 200\n", "next-cursor=1\n", "0001",
+		"lock 2\n", "path 2 a.psd\n", "locked-at 2 2020-05-01T12:00:00Z\n", "ownername 2 user2\n", "owner 2 ours\n", "0000",
+		"status 200\n", "0001",
+		"lock 7\n", "path 7 b.psd\n", "locked-at 7 2020-04-01T12:00:00Z\n", "ownername 7 user5\n", "owner 7 theirs\n", "0000",
+		"status 403\n", "0001", "not the owner\n", "0000",
+		"status 200\n", "id=7\n", "path=b.psd\n", "locked-at=2020-04-01T12:00:00Z\n", "ownername=user5\n", "0000"), out)
+	assert.Len(t, backend.locks, 1)
+}
+
+func TestServe_InvalidPacket(t *testing.T) {
+	var out bytes.Buffer
+	assert.Error(t, Serve(strings.NewReader("zzzz"), &out, OperationUpload, &memoryBackend{}))
+	assert.Error(t, Serve(strings.NewReader(""), &out, "push", &memoryBackend{}))
+}
+
+func TestPacketWriter_Write(t *testing.T) {
+	var out bytes.Buffer
+	w := newPacketWriter(&out)
+	data := bytes.Repeat([]byte("a"), maxPacketData+10)
+	n, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	assert.NoError(t, w.writeFlush())
+
+	r := newPacketReader(&out)
+	content, err := ioutil.ReadAll(r.dataReader())
+	assert.NoError(t, err)
+	assert.Equal(t, data, content)
+}
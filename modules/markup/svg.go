@@ -0,0 +1,204 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SVGSanitizer is a whitelist of the SVG elements and attributes which are
+// safe to render. Unlike the HTML sanitizer it works on XML tokens, so the
+// case of names like viewBox or linearGradient is kept.
+type SVGSanitizer struct {
+	elements   map[string]bool
+	attributes map[string]bool
+	init       sync.Once
+}
+
+var svgSanitizer = &SVGSanitizer{}
+
+var errNotSVG = errors.New("not an SVG document")
+
+var (
+	// local references ("#gradient") and embedded raster images are the only links allowed
+	svgSafeHref = regexp.MustCompile(`^(#[\w.:-]*|data:image/(png|gif|jpeg|webp);base64,[A-Za-z0-9+/=\s]*)$`)
+	// url() in styles and presentation attributes must reference the document itself
+	svgURLFunc   = regexp.MustCompile(`(?i)url\(\s*['"]?\s*([^'")\s]*)`)
+	svgUnsafeCSS = regexp.MustCompile(`(?i)(@import|expression\s*\(|javascript:|behavior\s*:|-moz-binding)`)
+)
+
+// NewSVGSanitizer initializes the SVG whitelist, only once during the entire application lifecycle.
+func NewSVGSanitizer() {
+	svgSanitizer.init.Do(func() {
+		svgSanitizer.elements = make(map[string]bool)
+		for _, name := range strings.Fields(`svg g defs symbol use title desc switch
+			path rect circle ellipse line polyline polygon
+			text tspan textPath
+			linearGradient radialGradient stop pattern clipPath mask marker image
+			filter feBlend feColorMatrix feComponentTransfer feComposite feConvolveMatrix
+			feDiffuseLighting feDisplacementMap feDistantLight feDropShadow feFlood feFuncA
+			feFuncB feFuncG feFuncR feGaussianBlur feImage feMerge feMergeNode feMorphology
+			feOffset fePointLight feSpecularLighting feSpotLight feTile feTurbulence`) {
+			svgSanitizer.elements[name] = true
+		}
+
+		svgSanitizer.attributes = make(map[string]bool)
+		for _, name := range strings.Fields(`id class style lang xml:lang xml:space
+			xmlns xmlns:xlink xmlns:svg version baseProfile
+			x y x1 y1 x2 y2 cx cy r rx ry fx fy fr width height d points pathLength
+			viewBox preserveAspectRatio transform gradientTransform gradientUnits spreadMethod
+			patternTransform patternUnits patternContentUnits clipPathUnits maskUnits maskContentUnits
+			markerUnits markerWidth markerHeight refX refY orient offset
+			fill fill-opacity fill-rule stroke stroke-dasharray stroke-dashoffset stroke-linecap
+			stroke-linejoin stroke-miterlimit stroke-opacity stroke-width
+			opacity visibility display color clip-path clip-rule mask filter
+			marker-start marker-mid marker-end stop-color stop-opacity
+			mix-blend-mode isolation overflow paint-order vector-effect shape-rendering
+			image-rendering color-interpolation color-interpolation-filters
+			font-family font-size font-style font-variant font-weight font-stretch
+			text-anchor text-decoration text-rendering dominant-baseline alignment-baseline
+			baseline-shift letter-spacing word-spacing writing-mode dx dy rotate textLength
+			lengthAdjust startOffset method spacing side
+			href xlink:href xlink:title
+			in in2 result mode type values operator k1 k2 k3 k4 stdDeviation edgeMode
+			dx dy scale xChannelSelector yChannelSelector order kernelMatrix divisor bias
+			targetX targetY preserveAlpha surfaceScale diffuseConstant specularConstant
+			specularExponent kernelUnitLength azimuth elevation pointsAtX pointsAtY pointsAtZ
+			limitingConeAngle flood-color flood-opacity lighting-color baseFrequency numOctaves
+			seed stitchTiles radius tableValues slope intercept amplitude exponent
+			filterUnits primitiveUnits requiredFeatures requiredExtensions systemLanguage`) {
+			svgSanitizer.attributes[name] = true
+		}
+	})
+}
+
+// IsSVGFile returns whether the filename has the extension of an SVG image.
+func IsSVGFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".svg")
+}
+
+func svgName(name xml.Name) string {
+	if len(name.Space) == 0 {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func isSafeSVGAttr(attr xml.Attr) bool {
+	name := svgName(attr.Name)
+	if !svgSanitizer.attributes[name] {
+		return false
+	}
+	switch name {
+	case "href", "xlink:href":
+		return svgSafeHref.MatchString(strings.TrimSpace(attr.Value))
+	}
+	if svgUnsafeCSS.MatchString(attr.Value) {
+		return false
+	}
+	for _, match := range svgURLFunc.FindAllStringSubmatch(attr.Value, -1) {
+		if !strings.HasPrefix(match[1], "#") {
+			return false
+		}
+	}
+	return true
+}
+
+func hasXMLNS(attrs []xml.Attr) bool {
+	for _, attr := range attrs {
+		if len(attr.Name.Space) == 0 && attr.Name.Local == "xmlns" {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeSVG takes an SVG document and returns it with all elements and attributes which
+// are not whitelisted removed. Scripts, event handlers, foreign objects, style sheets and
+// external references are dropped. An error is returned if the document is not well formed.
+func SanitizeSVG(data []byte) ([]byte, error) {
+	NewSVGSanitizer()
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	out := new(bytes.Buffer)
+	// depth of the element being skipped with its children, 0 when not skipping
+	skipping := 0
+	hasRoot := false
+	var open []string
+	for {
+		// RawToken keeps namespace prefixes like "xlink" as they are written
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if hasRoot && len(open) == 0 {
+				return nil, errNotSVG
+			}
+			open = append(open, svgName(t.Name))
+			if skipping > 0 {
+				skipping++
+				continue
+			}
+			name := svgName(t.Name)
+			if !hasRoot && name != "svg" {
+				return nil, errNotSVG
+			}
+			hasRoot = true
+			if !svgSanitizer.elements[name] {
+				skipping = 1
+				continue
+			}
+			out.WriteString("<" + name)
+			if name == "svg" && !hasXMLNS(t.Attr) {
+				// browsers only render SVG images in their namespace
+				out.WriteString(` xmlns="http://www.w3.org/2000/svg"`)
+			}
+			for _, attr := range t.Attr {
+				if isSafeSVGAttr(attr) {
+					out.WriteString(" " + svgName(attr.Name) + `="`)
+					_ = xml.EscapeText(out, []byte(attr.Value))
+					out.WriteByte('"')
+				}
+			}
+			out.WriteByte('>')
+		case xml.EndElement:
+			// RawToken does not check that elements are balanced
+			if len(open) == 0 || open[len(open)-1] != svgName(t.Name) {
+				return nil, fmt.Errorf("unexpected end element </%s>", svgName(t.Name))
+			}
+			open = open[:len(open)-1]
+			if skipping > 0 {
+				skipping--
+				continue
+			}
+			out.WriteString("</" + svgName(t.Name) + ">")
+		case xml.CharData:
+			if skipping == 0 && hasRoot {
+				_ = xml.EscapeText(out, t)
+			}
+		}
+		// comments, processing instructions and directives (DOCTYPE, ENTITY) are dropped
+	}
+	if !hasRoot {
+		return nil, errNotSVG
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("unclosed element <%s>", open[len(open)-1])
+	}
+	return out.Bytes(), nil
+}
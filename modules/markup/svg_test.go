@@ -0,0 +1,59 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SanitizeSVG(t *testing.T) {
+	testCases := []string{
+		// Case of SVG names is kept
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><linearGradient id="g"><stop offset="0" stop-color="red"></stop></linearGradient><rect width="10" height="10" fill="url(#g)"></rect></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><linearGradient id="g"><stop offset="0" stop-color="red"></stop></linearGradient><rect width="10" height="10" fill="url(#g)"></rect></svg>`,
+
+		// Missing namespace
+		`<svg><circle r="1"/></svg>`, `<svg xmlns="http://www.w3.org/2000/svg"><circle r="1"></circle></svg>`,
+
+		// Scripts and event handlers
+		`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"><script>alert(2)</script><g onclick="alert(3)"><path d="M0 0"/></g></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"><g><path d="M0 0"></path></g></svg>`,
+
+		// Foreign objects and style sheets with their children
+		`<svg xmlns="http://www.w3.org/2000/svg"><foreignObject><div xmlns="http://www.w3.org/1999/xhtml">x</div></foreignObject><style>@import url(http://example.com/x.css);</style><text>ok</text></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"><text>ok</text></svg>`,
+
+		// Links
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"/><use href="http://example.com/a.svg#b"/><image xlink:href="javascript:alert(1)"/></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"></use><use></use><image></image></svg>`,
+
+		// External resources in styles
+		`<svg xmlns="http://www.w3.org/2000/svg"><rect style="fill: url('http://example.com/track')" fill="url(#ok)"/></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"><rect fill="url(#ok)"></rect></svg>`,
+
+		// Comments and directives
+		`<?xml version="1.0"?><!DOCTYPE svg><!-- note --><svg xmlns="http://www.w3.org/2000/svg">a &amp; b</svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg">a &amp; b</svg>`,
+	}
+
+	for i := 0; i < len(testCases); i += 2 {
+		sanitized, err := SanitizeSVG([]byte(testCases[i]))
+		assert.NoError(t, err)
+		assert.Equal(t, testCases[i+1], string(sanitized))
+	}
+
+	for _, invalid := range []string{
+		`<html><svg/></html>`,
+		`<svg><rect></svg>`,
+		`<svg></svg><svg></svg>`,
+		`<!DOCTYPE svg [<!ENTITY x "y">]><svg>&x;</svg>`,
+		``,
+	} {
+		_, err := SanitizeSVG([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
@@ -256,3 +256,43 @@ func (a *actionNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Rep
 		log.Error("notifyWatchers: %v", err)
 	}
 }
+
+func (a *actionNotifier) NotifyCreateVariant(doer *models.User, variant *models.Variant) {
+	if err := variant.LoadRepo(); err != nil {
+		log.Error("variant.LoadRepo: %v", err)
+		return
+	}
+
+	if err := models.NotifyWatchers(&models.Action{
+		ActUserID: doer.ID,
+		ActUser:   doer,
+		OpType:    models.ActionCreateVariant,
+		Content:   fmt.Sprintf("%d|%s", variant.ID, variant.Title),
+		RepoID:    variant.Repo.ID,
+		Repo:      variant.Repo,
+		IsPrivate: variant.Repo.IsPrivate,
+		RefName:   variant.BranchName,
+	}); err != nil {
+		log.Error("NotifyWatchers: %v", err)
+	}
+}
+
+func (a *actionNotifier) NotifyImportAssets(doer *models.User, repo, sourceRepo *models.Repository, usages models.AssetUsageList) {
+	// The action belongs to the source repository so that its watchers learn where its assets are used,
+	// it must not reveal a private repository to the watchers of a public one.
+	if len(usages) == 0 || (repo.IsPrivate && !sourceRepo.IsPrivate) {
+		return
+	}
+
+	if err := models.NotifyWatchers(&models.Action{
+		ActUserID: doer.ID,
+		ActUser:   doer,
+		OpType:    models.ActionImportAssets,
+		Content:   fmt.Sprintf("%s|%d", repo.FullName(), len(usages)),
+		RepoID:    sourceRepo.ID,
+		Repo:      sourceRepo,
+		IsPrivate: sourceRepo.IsPrivate,
+	}); err != nil {
+		log.Error("NotifyWatchers: %v", err)
+	}
+}
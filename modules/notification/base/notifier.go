@@ -29,6 +29,8 @@ type Notifier interface {
 	NotifyIssueChangeTitle(doer *models.User, issue *models.Issue, oldTitle string)
 	NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
 		addedLabels []*models.Label, removedLabels []*models.Label)
+	NotifyIssueChangeProject(doer *models.User, issue *models.Issue, project *models.Project, removed bool)
+	NotifyMoveProjectCard(doer *models.User, card *models.ProjectCard, oldColumnID int64)
 
 	NotifyNewPullRequest(*models.PullRequest)
 	NotifyMergePullRequest(*models.PullRequest, *models.User, *git.Repository)
@@ -48,6 +50,9 @@ type Notifier interface {
 	NotifyCreateRef(doer *models.User, repo *models.Repository, refType, refFullName string)
 	NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string)
 
+	NotifyCreateVariant(doer *models.User, variant *models.Variant)
+	NotifyImportAssets(doer *models.User, repo, sourceRepo *models.Repository, usages models.AssetUsageList)
+
 	NotifySyncPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *models.PushCommits)
 	NotifySyncCreateRef(doer *models.User, repo *models.Repository, refType, refFullName string)
 	NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string)
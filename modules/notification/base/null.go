@@ -74,6 +74,14 @@ func (*NullNotifier) NotifyDeleteRelease(doer *models.User, rel *models.Release)
 func (*NullNotifier) NotifyIssueChangeMilestone(doer *models.User, issue *models.Issue, oldMilestoneID int64) {
 }
 
+// NotifyIssueChangeProject places a place holder function
+func (*NullNotifier) NotifyIssueChangeProject(doer *models.User, issue *models.Issue, project *models.Project, removed bool) {
+}
+
+// NotifyMoveProjectCard places a place holder function
+func (*NullNotifier) NotifyMoveProjectCard(doer *models.User, card *models.ProjectCard, oldColumnID int64) {
+}
+
 // NotifyIssueChangeContent places a place holder function
 func (*NullNotifier) NotifyIssueChangeContent(doer *models.User, issue *models.Issue, oldContent string) {
 }
@@ -123,6 +131,14 @@ func (*NullNotifier) NotifyCreateRef(doer *models.User, repo *models.Repository,
 func (*NullNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
 }
 
+// NotifyCreateVariant places a place holder function
+func (*NullNotifier) NotifyCreateVariant(doer *models.User, variant *models.Variant) {
+}
+
+// NotifyImportAssets places a place holder function
+func (*NullNotifier) NotifyImportAssets(doer *models.User, repo, sourceRepo *models.Repository, usages models.AssetUsageList) {
+}
+
 // NotifyRenameRepository places a place holder function
 func (*NullNotifier) NotifyRenameRepository(doer *models.User, repo *models.Repository, oldRepoName string) {
 }
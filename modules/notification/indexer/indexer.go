@@ -5,7 +5,11 @@
 package indexer
 
 import (
+	"strings"
+
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	asset_indexer "code.gitea.io/gitea/modules/indexer/assets"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/notification/base"
@@ -104,6 +108,28 @@ func (r *indexerNotifier) NotifyDeleteComment(doer *models.User, comment *models
 func (r *indexerNotifier) NotifyDeleteRepository(doer *models.User, repo *models.Repository) {
 	issue_indexer.DeleteRepoIssueIndexer(repo)
 	models.DeleteRepoFromIndexer(repo)
+	if err := asset_indexer.DeleteRepoFromAssetIndexer(repo); err != nil {
+		log.Error("DeleteRepoFromAssetIndexer: %v", err)
+	}
+}
+
+func (r *indexerNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *models.PushCommits) {
+	if !strings.HasPrefix(refName, git.BranchPrefix) {
+		return
+	}
+	// the last change of the tagged assets of the branch may have moved
+	if err := asset_indexer.UpdateBranchAssetIndexer(repo, strings.TrimPrefix(refName, git.BranchPrefix)); err != nil {
+		log.Error("UpdateBranchAssetIndexer: %v", err)
+	}
+}
+
+func (r *indexerNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	if refType != "branch" {
+		return
+	}
+	if err := asset_indexer.DeleteBranchFromAssetIndexer(repo, strings.TrimPrefix(refFullName, git.BranchPrefix)); err != nil {
+		log.Error("DeleteBranchFromAssetIndexer: %v", err)
+	}
 }
 
 func (r *indexerNotifier) NotifyIssueChangeContent(doer *models.User, issue *models.Issue, oldContent string) {
@@ -129,6 +129,20 @@ func NotifyIssueChangeMilestone(doer *models.User, issue *models.Issue, oldMiles
 	}
 }
 
+// NotifyIssueChangeProject notifies adding an issue to or removing it from a project to notifiers
+func NotifyIssueChangeProject(doer *models.User, issue *models.Issue, project *models.Project, removed bool) {
+	for _, notifier := range notifiers {
+		notifier.NotifyIssueChangeProject(doer, issue, project, removed)
+	}
+}
+
+// NotifyMoveProjectCard notifies moving a card to another column of its project to notifiers
+func NotifyMoveProjectCard(doer *models.User, card *models.ProjectCard, oldColumnID int64) {
+	for _, notifier := range notifiers {
+		notifier.NotifyMoveProjectCard(doer, card, oldColumnID)
+	}
+}
+
 // NotifyIssueChangeContent notifies change content to notifiers
 func NotifyIssueChangeContent(doer *models.User, issue *models.Issue, oldContent string) {
 	for _, notifier := range notifiers {
@@ -228,6 +242,20 @@ func NotifyDeleteRef(pusher *models.User, repo *models.Repository, refType, refF
 	}
 }
 
+// NotifyCreateVariant notifies the creation of a variant to notifiers
+func NotifyCreateVariant(doer *models.User, variant *models.Variant) {
+	for _, notifier := range notifiers {
+		notifier.NotifyCreateVariant(doer, variant)
+	}
+}
+
+// NotifyImportAssets notifies the import of assets of sourceRepo into repo to notifiers
+func NotifyImportAssets(doer *models.User, repo, sourceRepo *models.Repository, usages models.AssetUsageList) {
+	for _, notifier := range notifiers {
+		notifier.NotifyImportAssets(doer, repo, sourceRepo, usages)
+	}
+}
+
 // NotifySyncPushCommits notifies commits pushed to notifiers
 func NotifySyncPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *models.PushCommits) {
 	for _, notifier := range notifiers {
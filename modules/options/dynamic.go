@@ -82,6 +82,11 @@ func Labels(name string) ([]byte, error) {
 	return fileFromDir(path.Join("label", name))
 }
 
+// Keystone reads the content of a specific keystone template from static or custom path.
+func Keystone(name string) ([]byte, error) {
+	return fileFromDir(path.Join("keystone", name))
+}
+
 // fileFromDir is a helper to read files from static or custom path.
 func fileFromDir(name string) ([]byte, error) {
 	customPath := path.Join(setting.CustomPath, "options", name)
@@ -0,0 +1,328 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// ImportAssetsOptions holds the options for importing assets from another repository
+type ImportAssetsOptions struct {
+	SourceRepo *models.Repository
+	// SourceRef is a branch, a tag or a commit of the source repository, defaults to its default branch
+	SourceRef string
+	// SourcePaths are the files and the directories to import
+	SourcePaths []string
+	// TreePath is the directory the assets are copied to
+	TreePath  string
+	OldBranch string
+	NewBranch string
+	Message   string
+}
+
+// importedAsset is a file of the source repository to be copied
+type importedAsset struct {
+	entry      *git.TreeEntry
+	sourcePath string
+	treePath   string
+	lfsMeta    *models.LFSMetaObject
+}
+
+// getSourceCommit returns the commit of the source repository a reference points to
+func getSourceCommit(gitRepo *git.Repository, ref string) (*git.Commit, error) {
+	if gitRepo.IsBranchExist(ref) {
+		return gitRepo.GetBranchCommit(ref)
+	} else if gitRepo.IsTagExist(ref) {
+		return gitRepo.GetTagCommit(ref)
+	}
+	return gitRepo.GetCommit(ref)
+}
+
+// collectAssets lists the files below a path of the source commit, submodules are skipped.
+// The files keep their path relative to the parent directory of sourcePath.
+func collectAssets(commit *git.Commit, sourcePath, treePath string) ([]*importedAsset, error) {
+	entry, err := commit.GetTreeEntryByPath(sourcePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, models.ErrRepoFileDoesNotExist{Path: sourcePath}
+		}
+		return nil, err
+	}
+	if entry.IsSubModule() {
+		return nil, models.ErrRepoFileDoesNotExist{Path: sourcePath}
+	}
+	if !entry.IsDir() {
+		return []*importedAsset{{
+			entry:      entry,
+			sourcePath: sourcePath,
+			treePath:   path.Join(treePath, entry.Name()),
+		}}, nil
+	}
+
+	tree, err := commit.SubTree(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]*importedAsset, 0, len(entries))
+	for _, e := range entries {
+		if e.IsSubModule() {
+			continue
+		}
+		children, err := collectAssets(commit, path.Join(sourcePath, e.Name()), path.Join(treePath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, children...)
+	}
+	return assets, nil
+}
+
+// ImportAssets copies files from a commit of a repository the doer can read into a branch of repo
+// and records their provenance. LFS objects are shared with the source repository instead of being
+// uploaded again.
+func ImportAssets(repo *models.Repository, doer *models.User, opts *ImportAssetsOptions) (models.AssetUsageList, error) {
+	if len(opts.SourcePaths) == 0 {
+		return nil, nil
+	}
+
+	perm, err := models.GetUserRepoPermission(opts.SourceRepo, doer)
+	if err != nil {
+		return nil, err
+	}
+	if !perm.CanRead(models.UnitTypeCode) {
+		return nil, models.ErrRepoNotExist{ID: opts.SourceRepo.ID}
+	}
+
+	if opts.OldBranch == "" {
+		opts.OldBranch = repo.DefaultBranch
+	}
+	if opts.NewBranch == "" {
+		opts.NewBranch = opts.OldBranch
+	}
+	if _, err := repo.GetBranch(opts.OldBranch); err != nil {
+		return nil, err
+	}
+	if opts.NewBranch != opts.OldBranch {
+		existingBranch, err := repo.GetBranch(opts.NewBranch)
+		if existingBranch != nil {
+			return nil, models.ErrBranchAlreadyExists{
+				BranchName: opts.NewBranch,
+			}
+		}
+		if err != nil && !git.IsErrBranchNotExist(err) {
+			return nil, err
+		}
+	} else if protected, _ := repo.IsProtectedBranchForPush(opts.OldBranch, doer); protected {
+		return nil, models.ErrUserCannotCommit{UserName: doer.LowerName}
+	}
+
+	treePath := CleanUploadFileName(opts.TreePath)
+	if treePath == "" && strings.Trim(opts.TreePath, "/") != "" {
+		return nil, models.ErrFilenameInvalid{Path: opts.TreePath}
+	}
+
+	sourceRepo, err := git.OpenRepository(opts.SourceRepo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer sourceRepo.Close()
+
+	sourceRef := opts.SourceRef
+	if sourceRef == "" {
+		sourceRef = opts.SourceRepo.DefaultBranch
+	}
+	sourceCommit, err := getSourceCommit(sourceRepo, sourceRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*importedAsset
+	for _, sourcePath := range opts.SourcePaths {
+		cleanPath := CleanUploadFileName(sourcePath)
+		if cleanPath == "" {
+			return nil, models.ErrFilenameInvalid{Path: sourcePath}
+		}
+		collected, err := collectAssets(sourceCommit, cleanPath, treePath)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, collected...)
+	}
+
+	treePaths := make([]string, len(assets))
+	for i, asset := range assets {
+		// Check file is not lfs locked, will return nil if lock setting not enabled
+		lfsLock, err := repo.GetTreePathLock(asset.treePath)
+		if err != nil {
+			return nil, err
+		}
+		if lfsLock != nil && lfsLock.OwnerID != doer.ID {
+			// The owner isn't loaded when the user was deleted
+			ownerName := models.NewGhostUser().Name
+			if lfsLock.Owner != nil {
+				ownerName = lfsLock.Owner.Name
+			}
+			return nil, models.ErrLFSFileLocked{RepoID: repo.ID, Path: asset.treePath, UserName: ownerName}
+		}
+		treePaths[i] = asset.treePath
+	}
+
+	t, err := NewTemporaryUploadRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+	if err := t.Clone(opts.OldBranch); err != nil {
+		return nil, err
+	}
+	if err := t.SetDefaultIndex(); err != nil {
+		return nil, err
+	}
+
+	// An import never overwrites a file of the repository
+	existing, err := t.LsFiles(treePaths...)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range existing {
+		if file != "" {
+			return nil, models.ErrRepoFileAlreadyExists{Path: file}
+		}
+	}
+
+	for _, asset := range assets {
+		if err := copyAsset(t, opts.SourceRepo, asset); err != nil {
+			return nil, err
+		}
+	}
+
+	treeHash, err := t.WriteTree()
+	if err != nil {
+		return nil, err
+	}
+
+	message := strings.TrimSpace(opts.Message)
+	if message == "" {
+		message = fmt.Sprintf("Import assets from %s@%s", opts.SourceRepo.FullName(), base.ShortSha(sourceCommit.ID.String()))
+	}
+	commitHash, err := t.CommitTree(doer, doer, treeHash, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// The LFS objects are already in the store, they only need to be associated with the repository
+	for i, asset := range assets {
+		if asset.lfsMeta == nil {
+			continue
+		}
+		if asset.lfsMeta, err = models.NewLFSMetaObject(asset.lfsMeta); err != nil {
+			if rollbackErr := removeNewLFSMetaObjects(repo, assets[:i]); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+			return nil, err
+		}
+	}
+
+	if err := t.Push(doer, commitHash, opts.NewBranch); err != nil {
+		if rollbackErr := removeNewLFSMetaObjects(repo, assets); rollbackErr != nil {
+			log.Error("Unable to remove the LFS meta objects of the failed import into %-v: %v", repo, rollbackErr)
+		}
+		return nil, err
+	}
+
+	usages := make(models.AssetUsageList, len(assets))
+	for i, asset := range assets {
+		usages[i] = &models.AssetUsage{
+			RepoID:         repo.ID,
+			Repo:           repo,
+			TreePath:       asset.treePath,
+			CommitID:       commitHash,
+			SourceRepoID:   opts.SourceRepo.ID,
+			SourceRepo:     opts.SourceRepo,
+			SourceTreePath: asset.sourcePath,
+			SourceCommitID: sourceCommit.ID.String(),
+			IsLFS:          asset.lfsMeta != nil,
+			DoerID:         doer.ID,
+			Doer:           doer,
+		}
+	}
+	if err := models.NewAssetUsages(usages); err != nil {
+		return nil, err
+	}
+
+	notification.NotifyImportAssets(doer, repo, opts.SourceRepo, usages)
+
+	return usages, nil
+}
+
+// removeNewLFSMetaObjects removes the LFS meta objects created for the assets, the ones which already
+// existed in the repository are kept
+func removeNewLFSMetaObjects(repo *models.Repository, assets []*importedAsset) error {
+	for _, asset := range assets {
+		if asset.lfsMeta == nil || asset.lfsMeta.Existing {
+			continue
+		}
+		if _, err := repo.RemoveLFSMetaObjectByOid(asset.lfsMeta.Oid); err != nil {
+			return fmt.Errorf("RemoveLFSMetaObjectByOid: %v", err)
+		}
+	}
+	return nil
+}
+
+// copyAsset adds the blob of an asset to the index of the temporary repository.
+// An LFS pointer is copied as is when the object belongs to the source repository.
+func copyAsset(t *TemporaryUploadRepository, sourceRepo *models.Repository, asset *importedAsset) error {
+	dataRc, err := asset.entry.Blob().DataAsync()
+	if err != nil {
+		return err
+	}
+	defer dataRc.Close()
+
+	buf := make([]byte, 1024)
+	n, err := io.ReadFull(dataRc, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	buf = buf[:n]
+
+	if setting.LFS.StartServer {
+		if meta := lfs.ParsePointer(buf); meta != nil {
+			if _, err := sourceRepo.GetLFSMetaObjectByOid(meta.Oid); err == nil {
+				asset.lfsMeta = &models.LFSMetaObject{Oid: meta.Oid, Size: meta.Size, RepositoryID: t.repo.ID}
+			} else if err != models.ErrLFSObjectNotExist {
+				return err
+			}
+		}
+	}
+
+	objectHash, err := t.HashObject(io.MultiReader(bytes.NewReader(buf), dataRc))
+	if err != nil {
+		return err
+	}
+	mode := "100644"
+	switch asset.entry.Mode() {
+	case git.EntryModeExec:
+		mode = "100755"
+	case git.EntryModeSymlink:
+		mode = "120000"
+	}
+	return t.AddObjectToIndex(mode, objectHash, asset.treePath)
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveNewLFSMetaObjects(t *testing.T) {
+	models.PrepareTestEnv(t)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	const existingOid = "2eccdb43825d2a49d99d542daa20075cff1d97d9d2349a8977efe9c03661737c"
+	const newOid = "7b6b2c88dba9f760a1a58469b67fee2b698ef7e9399c4ca4f34a14ccbe39f623"
+	_, err := models.NewLFSMetaObject(&models.LFSMetaObject{Oid: existingOid, Size: 10, RepositoryID: repo.ID})
+	assert.NoError(t, err)
+
+	existing, err := models.NewLFSMetaObject(&models.LFSMetaObject{Oid: existingOid, Size: 10, RepositoryID: repo.ID})
+	assert.NoError(t, err)
+	assert.True(t, existing.Existing)
+	created, err := models.NewLFSMetaObject(&models.LFSMetaObject{Oid: newOid, Size: 20, RepositoryID: repo.ID})
+	assert.NoError(t, err)
+
+	assets := []*importedAsset{{lfsMeta: existing}, {lfsMeta: created}, {}}
+	assert.NoError(t, removeNewLFSMetaObjects(repo, assets))
+	models.AssertExistsAndLoadBean(t, &models.LFSMetaObject{Oid: existingOid, RepositoryID: repo.ID})
+	models.AssertNotExistsBean(t, &models.LFSMetaObject{Oid: newOid, RepositoryID: repo.ID})
+}
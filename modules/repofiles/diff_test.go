@@ -35,6 +35,8 @@ func TestGetDiffPreview(t *testing.T) {
 			{
 				Name:        "README.md",
 				OldName:     "README.md",
+				OldBlobID:   "4b4851a",
+				NewBlobID:   "972d593",
 				Index:       1,
 				Addition:    2,
 				Deletion:    1,
@@ -0,0 +1,256 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/options"
+	"code.gitea.io/gitea/modules/structs"
+	pull_service "code.gitea.io/gitea/services/pull"
+
+	"github.com/unknwon/com"
+)
+
+// KeystoneRefName is the reference holding the KEYSTONE and the BLUEPRINT of a repository,
+// it does not share history with the branches and is kept out of refs/heads so it can't
+// collide with a branch nor show up in the branch list.
+const KeystoneRefName = "refs/keystone/head"
+
+// KeystonePage is a document of the keystone
+type KeystonePage struct {
+	Name     string
+	FileName string
+}
+
+// KeystonePages contains the documents of the keystone in display order
+var KeystonePages = []KeystonePage{
+	{Name: "keystone", FileName: "KEYSTONE.md"},
+	{Name: "blueprint", FileName: "BLUEPRINT.md"},
+}
+
+// GetKeystonePage returns the document of the keystone with the given name
+func GetKeystonePage(name string) (KeystonePage, bool) {
+	for _, page := range KeystonePages {
+		if strings.EqualFold(name, page.Name) || strings.EqualFold(name, page.FileName) {
+			return page, true
+		}
+	}
+	return KeystonePage{}, false
+}
+
+// GetKeystoneCommit returns the last commit of the keystone, git.ErrNotExist
+// is returned when the repository has no keystone yet
+func GetKeystoneCommit(gitRepo *git.Repository) (*git.Commit, error) {
+	if !git.IsReferenceExist(gitRepo.Path, KeystoneRefName) {
+		return nil, git.ErrNotExist{ID: KeystoneRefName}
+	}
+	commitID, err := gitRepo.GetRefCommitID(KeystoneRefName)
+	if err != nil {
+		return nil, err
+	}
+	return gitRepo.GetCommit(commitID)
+}
+
+// GetKeystonePageContent returns the content of a document of the keystone at the given commit,
+// a document missing from the branch has no content.
+func GetKeystonePageContent(commit *git.Commit, page KeystonePage) ([]byte, error) {
+	entry, err := commit.GetTreeEntryByPath(page.FileName)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	reader, err := entry.Blob().DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// InitKeystone creates the keystone of a repository with the templates of all documents,
+// an existing keystone is left untouched.
+func InitKeystone(repo *models.Repository, doer *models.User) error {
+	if git.IsReferenceExist(repo.RepoPath(), KeystoneRefName) {
+		return nil
+	}
+
+	t, err := NewTemporaryUploadRepository(repo)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	if err := t.Init(); err != nil {
+		return err
+	}
+
+	match := map[string]string{
+		"Name":        repo.Name,
+		"Description": repo.Description,
+	}
+	for _, page := range KeystonePages {
+		data, err := options.Keystone(page.FileName)
+		if err != nil {
+			return fmt.Errorf("Keystone[%s]: %v", page.FileName, err)
+		}
+		objectHash, err := t.HashObject(strings.NewReader(com.Expand(string(data), match)))
+		if err != nil {
+			return err
+		}
+		if err := t.AddObjectToIndex("100644", objectHash, page.FileName); err != nil {
+			return err
+		}
+	}
+
+	treeHash, err := t.WriteTree()
+	if err != nil {
+		return err
+	}
+	commitHash, err := t.CommitOrphanTree(doer, doer, treeHash, "Create keystone")
+	if err != nil {
+		return err
+	}
+	return t.PushRef(doer, commitHash, KeystoneRefName)
+}
+
+// UpdateKeystonePageOptions holds the options for updating a document of the keystone
+type UpdateKeystonePageOptions struct {
+	LastCommitID string
+	// NewBranch is branched from the keystone to propose the change instead of committing it directly
+	NewBranch string
+	Message   string
+	Content   string
+}
+
+// UpdateKeystonePage commits a new content of a document to the keystone,
+// or to a new branch for a proposal.
+func UpdateKeystonePage(repo *models.Repository, doer *models.User, page KeystonePage, opts *UpdateKeystonePageOptions) (*structs.FileResponse, error) {
+	if opts.NewBranch != "" {
+		if git.IsBranchExist(repo.RepoPath(), opts.NewBranch) {
+			return nil, models.ErrBranchAlreadyExists{BranchName: opts.NewBranch}
+		}
+	}
+
+	t, err := NewTemporaryUploadRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+	if err := t.CloneRef(KeystoneRefName); err != nil {
+		return nil, err
+	}
+	if err := t.SetDefaultIndex(); err != nil {
+		return nil, err
+	}
+
+	lastCommitID, err := t.GetLastCommit()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := t.gitRepo.GetCommit(lastCommitID)
+	if err != nil {
+		return nil, err
+	}
+	// a direct commit must not overwrite a change of the document made while editing it
+	if opts.NewBranch == "" && opts.LastCommitID != "" && opts.LastCommitID != commit.ID.String() {
+		givenCommitID, err := t.gitRepo.ConvertToSHA1(opts.LastCommitID)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateKeystonePage: Invalid last commit ID: %v", err)
+		}
+		if changed, err := commit.FileChangedSinceCommit(page.FileName, givenCommitID.String()); err != nil {
+			return nil, err
+		} else if changed {
+			return nil, models.ErrCommitIDDoesNotMatch{
+				GivenCommitID:   opts.LastCommitID,
+				CurrentCommitID: commit.ID.String(),
+			}
+		}
+	}
+
+	objectHash, err := t.HashObject(strings.NewReader(strings.Replace(opts.Content, "\r", "", -1)))
+	if err != nil {
+		return nil, err
+	}
+	if err := t.AddObjectToIndex("100644", objectHash, page.FileName); err != nil {
+		return nil, err
+	}
+	treeHash, err := t.WriteTree()
+	if err != nil {
+		return nil, err
+	}
+
+	message := strings.TrimSpace(opts.Message)
+	if message == "" {
+		message = "Update " + page.FileName
+	}
+	commitHash, err := t.CommitTree(doer, doer, treeHash, message)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NewBranch != "" {
+		err = t.Push(doer, commitHash, opts.NewBranch)
+	} else {
+		err = t.PushRef(doer, commitHash, KeystoneRefName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err = t.gitRepo.GetCommit(commitHash)
+	if err != nil {
+		return nil, err
+	}
+	return GetFileResponseFromCommit(repo, commit, commitHash, page.FileName)
+}
+
+// NewKeystoneProposal opens a pull request proposing the changes of a branch to the keystone
+func NewKeystoneProposal(repo *models.Repository, doer *models.User, branch, title, content string) (*models.PullRequest, error) {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	compareInfo, err := gitRepo.GetCompareInfo(repo.RepoPath(), KeystoneRefName, branch)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := gitRepo.GetPatch(compareInfo.MergeBase, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	pullIssue := &models.Issue{
+		RepoID:   repo.ID,
+		Title:    title,
+		PosterID: doer.ID,
+		Poster:   doer,
+		IsPull:   true,
+		Content:  content,
+	}
+	pr := &models.PullRequest{
+		HeadRepoID: repo.ID,
+		BaseRepoID: repo.ID,
+		HeadBranch: branch,
+		BaseBranch: KeystoneRefName,
+		HeadRepo:   repo,
+		BaseRepo:   repo,
+		MergeBase:  compareInfo.MergeBase,
+		Type:       models.PullRequestGitea,
+	}
+	if err := pull_service.NewPullRequest(repo, pullIssue, nil, nil, pr, patch, nil); err != nil {
+		return nil, err
+	}
+	if err := pr.PushToBaseRepo(); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
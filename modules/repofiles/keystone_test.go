@@ -0,0 +1,24 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeystonePage(t *testing.T) {
+	page, ok := GetKeystonePage("blueprint")
+	assert.True(t, ok)
+	assert.Equal(t, "BLUEPRINT.md", page.FileName)
+
+	page, ok = GetKeystonePage("KEYSTONE.md")
+	assert.True(t, ok)
+	assert.Equal(t, "keystone", page.Name)
+
+	_, ok = GetKeystonePage("README.md")
+	assert.False(t, ok)
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ReaderManifest is the file of a folder listing the pages read in the reader
+const ReaderManifest = "pages.yml"
+
+// readerImageExtensions are the extensions of the images browsers can display as pages
+var readerImageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".bmp", ".avif"}
+
+// IsReaderImage returns true if a file can be shown as a page of the reader
+func IsReaderImage(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range readerImageExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidReaderManifest represents an invalid pages.yml manifest
+type ErrInvalidReaderManifest struct {
+	Path string
+	Err  error
+}
+
+// IsErrInvalidReaderManifest checks if an error is a ErrInvalidReaderManifest
+func IsErrInvalidReaderManifest(err error) bool {
+	_, ok := err.(ErrInvalidReaderManifest)
+	return ok
+}
+
+func (err ErrInvalidReaderManifest) Error() string {
+	return fmt.Sprintf("invalid reader manifest [path: %s]: %v", err.Path, err.Err)
+}
+
+// readerManifest is the content of a pages.yml manifest:
+//
+//	title: Chapter 1
+//	direction: rtl
+//	pages:
+//	  - cover.png
+//	  - file: 02-03.png
+//	    title: The bridge
+//	    wide: true
+//
+// The paths of the pages are relative to the folder of the manifest, a wide page is a double-page
+// spread drawn as one image.
+type readerManifest struct {
+	Title     string               `yaml:"title"`
+	Direction string               `yaml:"direction"`
+	Pages     []readerManifestPage `yaml:"pages"`
+}
+
+type readerManifestPage struct {
+	File  string `yaml:"file"`
+	Title string `yaml:"title"`
+	Wide  bool   `yaml:"wide"`
+}
+
+// UnmarshalYAML reads a page given by its path only or with its options
+func (p *readerManifestPage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&p.File); err == nil {
+		return nil
+	}
+	type plain readerManifestPage
+	return unmarshal((*plain)(p))
+}
+
+// ReaderPage is an image shown as a page of the reader
+type ReaderPage struct {
+	TreePath string
+	Title    string
+	Wide     bool
+	Blob     *git.Blob
+}
+
+// ReaderBook is the sequence of pages of a folder or of a manifest
+type ReaderBook struct {
+	Title string
+	// TreePath is the folder of the pages
+	TreePath string
+	// ManifestPath is the path of the manifest listing the pages, empty if the folder is listed
+	ManifestPath string
+	RightToLeft  bool
+	Pages        []*ReaderPage
+	// MissingPages are the pages of the manifest which aren't images of the commit
+	MissingPages []string
+}
+
+// GetReaderBook returns the pages to read of a folder or of a manifest at a commit. The pages
+// of a folder are listed by its pages.yml manifest if it has one, otherwise they are the images
+// of the folder in natural order.
+func GetReaderBook(commit *git.Commit, treePath string) (*ReaderBook, error) {
+	treePath = strings.Trim(treePath, "/")
+	if treePath != "" {
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		if err != nil {
+			return nil, err
+		}
+		if !entry.IsDir() {
+			ext := strings.ToLower(path.Ext(treePath))
+			if ext != ".yml" && ext != ".yaml" {
+				return nil, git.ErrNotExist{ID: commit.ID.String(), RelPath: treePath}
+			}
+			return readManifest(commit, treePath, entry.Blob())
+		}
+	}
+
+	tree, err := commit.SubTree(treePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	book := &ReaderBook{TreePath: treePath}
+	if treePath != "" {
+		book.Title = path.Base(treePath)
+	}
+	pages := make([]*ReaderPage, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsRegular() && !entry.IsExecutable() {
+			continue
+		}
+		if entry.Name() == ReaderManifest {
+			return readManifest(commit, path.Join(treePath, entry.Name()), entry.Blob())
+		}
+		if IsReaderImage(entry.Name()) {
+			pages = append(pages, &ReaderPage{
+				TreePath: path.Join(treePath, entry.Name()),
+				Title:    entry.Name(),
+				Blob:     entry.Blob(),
+			})
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return base.NaturalSortLess(path.Base(pages[i].TreePath), path.Base(pages[j].TreePath))
+	})
+	book.Pages = pages
+	return book, nil
+}
+
+// readManifest returns the pages listed by a manifest
+func readManifest(commit *git.Commit, manifestPath string, blob *git.Blob) (*ReaderBook, error) {
+	reader, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest readerManifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, ErrInvalidReaderManifest{Path: manifestPath, Err: err}
+	}
+	switch strings.ToLower(manifest.Direction) {
+	case "", "ltr", "rtl":
+	default:
+		return nil, ErrInvalidReaderManifest{Path: manifestPath, Err: fmt.Errorf("unknown direction %q", manifest.Direction)}
+	}
+
+	dir := path.Dir(manifestPath)
+	if dir == "." {
+		dir = ""
+	}
+	book := &ReaderBook{
+		Title:        manifest.Title,
+		TreePath:     dir,
+		ManifestPath: manifestPath,
+		RightToLeft:  strings.EqualFold(manifest.Direction, "rtl"),
+		Pages:        make([]*ReaderPage, 0, len(manifest.Pages)),
+	}
+	if book.Title == "" && dir != "" {
+		book.Title = path.Base(dir)
+	}
+
+	for _, p := range manifest.Pages {
+		// the pages are relative to the folder of the manifest, or to the root when they start with a slash
+		treePath := path.Clean(p.File)
+		if !strings.HasPrefix(p.File, "/") {
+			treePath = path.Clean(path.Join(dir, p.File))
+		}
+		treePath = strings.TrimPrefix(treePath, "/")
+		if p.File == "" || treePath == ".." || strings.HasPrefix(treePath, "../") || !IsReaderImage(treePath) {
+			book.MissingPages = append(book.MissingPages, p.File)
+			continue
+		}
+
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				book.MissingPages = append(book.MissingPages, p.File)
+				continue
+			}
+			return nil, err
+		}
+		if !entry.IsRegular() && !entry.IsExecutable() {
+			book.MissingPages = append(book.MissingPages, p.File)
+			continue
+		}
+
+		page := &ReaderPage{
+			TreePath: treePath,
+			Title:    p.Title,
+			Wide:     p.Wide,
+			Blob:     entry.Blob(),
+		}
+		if page.Title == "" {
+			page.Title = path.Base(treePath)
+		}
+		book.Pages = append(book.Pages, page)
+	}
+	return book, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestReaderManifest(t *testing.T) {
+	var manifest readerManifest
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+title: Chapter 1
+direction: rtl
+pages:
+  - cover.png
+  - file: 02-03.png
+    title: The bridge
+    wide: true
+`), &manifest))
+	assert.EqualValues(t, readerManifest{
+		Title:     "Chapter 1",
+		Direction: "rtl",
+		Pages: []readerManifestPage{
+			{File: "cover.png"},
+			{File: "02-03.png", Title: "The bridge", Wide: true},
+		},
+	}, manifest)
+
+	assert.Error(t, yaml.Unmarshal([]byte("pages:\n  - [cover.png]\n"), &manifest))
+}
+
+func TestIsReaderImage(t *testing.T) {
+	assert.True(t, IsReaderImage("pages/01.PNG"))
+	assert.True(t, IsReaderImage("cover.webp"))
+	assert.False(t, IsReaderImage("cover.psd"))
+	assert.False(t, IsReaderImage("png"))
+}
@@ -75,6 +75,34 @@ func (t *TemporaryUploadRepository) Clone(branch string) error {
 	return nil
 }
 
+// CloneRef fetches a reference of the base repository to our path and sets it as the HEAD,
+// references outside of refs/heads can't be cloned
+func (t *TemporaryUploadRepository) CloneRef(ref string) error {
+	if err := t.Init(); err != nil {
+		return err
+	}
+	if _, err := git.NewCommand("fetch", "--no-tags", t.repo.RepoPath(), "+"+ref+":"+ref).RunInDir(t.basePath); err != nil {
+		return fmt.Errorf("CloneRef: %v", err)
+	}
+	if _, err := git.NewCommand("symbolic-ref", "HEAD", ref).RunInDir(t.basePath); err != nil {
+		return fmt.Errorf("CloneRef: %v", err)
+	}
+	return nil
+}
+
+// Init the repository as an empty bare repository
+func (t *TemporaryUploadRepository) Init() error {
+	if err := git.InitRepository(t.basePath, true); err != nil {
+		return err
+	}
+	gitRepo, err := git.OpenRepository(t.basePath)
+	if err != nil {
+		return err
+	}
+	t.gitRepo = gitRepo
+	return nil
+}
+
 // SetDefaultIndex sets the git index to our HEAD
 func (t *TemporaryUploadRepository) SetDefaultIndex() error {
 	if _, err := git.NewCommand("read-tree", "HEAD").RunInDir(t.basePath); err != nil {
@@ -109,6 +137,28 @@ func (t *TemporaryUploadRepository) LsFiles(filenames ...string) ([]string, erro
 	return filelist, nil
 }
 
+// GetIndexFileContent returns the content of a file of the index or nil if the index has no such file
+func (t *TemporaryUploadRepository) GetIndexFileContent(treePath string) ([]byte, error) {
+	stdOut := new(bytes.Buffer)
+	stdErr := new(bytes.Buffer)
+
+	if err := git.NewCommand("ls-files", "-z", "--", treePath).RunInDirPipeline(t.basePath, stdOut, stdErr); err != nil {
+		log.Error("Unable to run git ls-files for temporary repo: %s (%s) Error: %v\nstdout: %s\nstderr: %s", t.repo.FullName(), t.basePath, err, stdOut.String(), stdErr.String())
+		return nil, fmt.Errorf("Unable to run git ls-files for temporary repo of: %s Error: %v\nstdout: %s\nstderr: %s", t.repo.FullName(), err, stdOut.String(), stdErr.String())
+	}
+	if string(bytes.TrimRight(stdOut.Bytes(), "\000")) != treePath {
+		return nil, nil
+	}
+
+	stdOut.Reset()
+	stdErr.Reset()
+	if err := git.NewCommand("cat-file", "blob", ":"+treePath).RunInDirPipeline(t.basePath, stdOut, stdErr); err != nil {
+		log.Error("Unable to run git cat-file for temporary repo: %s (%s) Error: %v\nstderr: %s", t.repo.FullName(), t.basePath, err, stdErr.String())
+		return nil, fmt.Errorf("Unable to run git cat-file for temporary repo of: %s Error: %v\nstderr: %s", t.repo.FullName(), err, stdErr.String())
+	}
+	return stdOut.Bytes(), nil
+}
+
 // RemoveFilesFromIndex removes the given files from the index
 func (t *TemporaryUploadRepository) RemoveFilesFromIndex(filenames ...string) error {
 	stdOut := new(bytes.Buffer)
@@ -188,6 +238,15 @@ func (t *TemporaryUploadRepository) GetLastCommitByRef(ref string) (string, erro
 
 // CommitTree creates a commit from a given tree for the user with provided message
 func (t *TemporaryUploadRepository) CommitTree(author, committer *models.User, treeHash string, message string) (string, error) {
+	return t.commitTree(author, committer, treeHash, message, "HEAD")
+}
+
+// CommitOrphanTree creates a commit without parent from a given tree for the user with provided message
+func (t *TemporaryUploadRepository) CommitOrphanTree(author, committer *models.User, treeHash string, message string) (string, error) {
+	return t.commitTree(author, committer, treeHash, message, "")
+}
+
+func (t *TemporaryUploadRepository) commitTree(author, committer *models.User, treeHash, message, parent string) (string, error) {
 	commitTimeStr := time.Now().Format(time.RFC3339)
 	authorSig := author.NewGitSig()
 	committerSig := committer.NewGitSig()
@@ -211,7 +270,10 @@ func (t *TemporaryUploadRepository) CommitTree(author, committer *models.User, t
 	_, _ = messageBytes.WriteString(message)
 	_, _ = messageBytes.WriteString("\n")
 
-	args := []string{"commit-tree", treeHash, "-p", "HEAD"}
+	args := []string{"commit-tree", treeHash}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
 
 	// Determine if we should sign
 	if version.Compare(binVersion, "1.7.9", ">=") {
@@ -236,10 +298,15 @@ func (t *TemporaryUploadRepository) CommitTree(author, committer *models.User, t
 
 // Push the provided commitHash to the repository branch by the provided user
 func (t *TemporaryUploadRepository) Push(doer *models.User, commitHash string, branch string) error {
+	return t.PushRef(doer, commitHash, git.BranchPrefix+strings.TrimSpace(branch))
+}
+
+// PushRef pushes the provided commitHash to the repository reference by the provided user
+func (t *TemporaryUploadRepository) PushRef(doer *models.User, commitHash string, ref string) error {
 	// Because calls hooks we need to pass in the environment
 	env := models.PushingEnvironment(doer, t.repo)
 
-	if _, err := git.NewCommand("push", t.repo.RepoPath(), strings.TrimSpace(commitHash)+":refs/heads/"+strings.TrimSpace(branch)).RunInDirWithEnv(t.basePath, env); err != nil {
+	if _, err := git.NewCommand("push", t.repo.RepoPath(), strings.TrimSpace(commitHash)+":"+ref).RunInDirWithEnv(t.basePath, env); err != nil {
 		log.Error("Unable to push back to repo from temporary repo: %s (%s) Error: %v",
 			t.repo.FullName(), t.basePath, err)
 		return fmt.Errorf("Unable to push back to repo from temporary repo: %s (%s) Error: %v",
@@ -18,6 +18,7 @@ import (
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	pull_service "code.gitea.io/gitea/services/pull"
 
@@ -382,7 +383,7 @@ func CreateOrUpdateRepoFile(repo *models.Repository, doer *models.User, opts *Up
 		if err != nil {
 			return nil, err
 		}
-		contentStore := &lfs.ContentStore{BasePath: setting.LFS.ContentPath}
+		contentStore := &lfs.ContentStore{ObjectStorage: storage.LFS}
 		if !contentStore.Exists(lfsMetaObject) {
 			if err := contentStore.Put(lfsMetaObject, strings.NewReader(opts.Content)); err != nil {
 				if _, err2 := repo.RemoveLFSMetaObjectByOid(lfsMetaObject.Oid); err2 != nil {
@@ -464,7 +465,15 @@ func PushUpdate(repo *models.Repository, branch string, opts PushUpdateOptions)
 				return fmt.Errorf("PushUpdateAddTag: %v", err)
 			}
 		}
-	} else if !isDelRef {
+	} else if isDelRef {
+		// Neither a variant nor the asset tags outlive their branch
+		if err = models.DeleteVariantByBranch(repo.ID, opts.RefFullName[len(git.BranchPrefix):]); err != nil {
+			return fmt.Errorf("DeleteVariantByBranch: %v", err)
+		}
+		if err = models.DeleteAssetTagsByBranch(repo.ID, opts.RefFullName[len(git.BranchPrefix):]); err != nil {
+			return fmt.Errorf("DeleteAssetTagsByBranch: %v", err)
+		}
+	} else {
 		// If is branch reference
 
 		// Clear cache for branch commit count
@@ -5,6 +5,7 @@
 package repofiles
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path"
@@ -13,6 +14,8 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/util"
 )
 
 // UploadRepoFileOptions contains the uploaded repository file options
@@ -44,6 +47,57 @@ func cleanUpAfterFailure(infos *[]uploadInfo, t *TemporaryUploadRepository, orig
 	return original
 }
 
+// lfsAutoTrackPattern returns the .gitattributes pattern of an uploaded file stored in LFS
+// because of its extension or its size, or "" if the file is stored as a regular git blob
+func lfsAutoTrackPattern(treePath string, size int64) string {
+	ext := path.Ext(treePath)
+	if ext != "" && util.IsStringInSlice(strings.ToLower(ext), setting.Repository.Upload.LFSAutoTrackExtensions) {
+		return "*" + escapeGitAttributesPattern(ext)
+	}
+	if minSize := setting.Repository.Upload.LFSAutoTrackMinSize; minSize > 0 && size >= minSize*1024*1024 {
+		return "/" + escapeGitAttributesPattern(treePath)
+	}
+	return ""
+}
+
+// escapeGitAttributesPattern escapes the wildcards and the spaces of a path used as a .gitattributes pattern
+func escapeGitAttributesPattern(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case ' ', '\t':
+			b.WriteString("[[:space:]]")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// addLFSPatternsToGitAttributes appends the LFS rules of some patterns to the .gitattributes file of the index
+func addLFSPatternsToGitAttributes(t *TemporaryUploadRepository, patterns []string) error {
+	content, err := t.GetIndexFileContent(".gitattributes")
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	for _, pattern := range patterns {
+		buf.WriteString(pattern + " filter=lfs diff=lfs merge=lfs -text\n")
+	}
+
+	objectHash, err := t.HashObject(buf)
+	if err != nil {
+		return err
+	}
+	return t.AddObjectToIndex("100644", objectHash, ".gitattributes")
+}
+
 // UploadRepoFiles uploads files to the given repository
 func UploadRepoFiles(repo *models.Repository, doer *models.User, opts *UploadRepoFileOptions) error {
 	if len(opts.Files) == 0 {
@@ -68,7 +122,7 @@ func UploadRepoFiles(repo *models.Repository, doer *models.User, opts *UploadRep
 			return models.ErrLFSFileLocked{RepoID: repo.ID, Path: filepath, UserName: lfsLock.Owner.Name}
 		}
 
-		names[i] = upload.Name
+		names[i] = filepath
 		infos[i] = uploadInfo{upload: upload}
 	}
 
@@ -93,25 +147,39 @@ func UploadRepoFiles(repo *models.Repository, doer *models.User, opts *UploadRep
 	}
 
 	// Copy uploaded files into repository.
+	var lfsPatterns []string
 	for i, uploadInfo := range infos {
 		file, err := os.Open(uploadInfo.upload.LocalPath())
 		if err != nil {
 			return err
 		}
 		defer file.Close()
+		fileInfo, err := file.Stat()
+		if err != nil {
+			return err
+		}
+
+		treePath := path.Join(opts.TreePath, uploadInfo.upload.Name)
+		useLFS := false
+		if setting.LFS.StartServer {
+			if filename2attribute2info[treePath] != nil && filename2attribute2info[treePath]["filter"] == "lfs" {
+				useLFS = true
+			} else if pattern := lfsAutoTrackPattern(treePath, fileInfo.Size()); pattern != "" {
+				useLFS = true
+				if !util.IsStringInSlice(pattern, lfsPatterns) {
+					lfsPatterns = append(lfsPatterns, pattern)
+				}
+			}
+		}
 
 		var objectHash string
-		if setting.LFS.StartServer && filename2attribute2info[uploadInfo.upload.Name] != nil && filename2attribute2info[uploadInfo.upload.Name]["filter"] == "lfs" {
+		if useLFS {
 			// Handle LFS
 			// FIXME: Inefficient! this should probably happen in models.Upload
 			oid, err := models.GenerateLFSOid(file)
 			if err != nil {
 				return err
 			}
-			fileInfo, err := file.Stat()
-			if err != nil {
-				return err
-			}
 
 			uploadInfo.lfsMetaObject = &models.LFSMetaObject{Oid: oid, Size: fileInfo.Size(), RepositoryID: t.repo.ID}
 
@@ -125,12 +193,19 @@ func UploadRepoFiles(repo *models.Repository, doer *models.User, opts *UploadRep
 		}
 
 		// Add the object to the index
-		if err := t.AddObjectToIndex("100644", objectHash, path.Join(opts.TreePath, uploadInfo.upload.Name)); err != nil {
+		if err := t.AddObjectToIndex("100644", objectHash, treePath); err != nil {
 			return err
 
 		}
 	}
 
+	// Track the files stored in LFS automatically in the same commit
+	if len(lfsPatterns) > 0 {
+		if err := addLFSPatternsToGitAttributes(t, lfsPatterns); err != nil {
+			return err
+		}
+	}
+
 	// Now write the tree
 	treeHash, err := t.WriteTree()
 	if err != nil {
@@ -163,7 +238,7 @@ func UploadRepoFiles(repo *models.Repository, doer *models.User, opts *UploadRep
 
 	// OK now we can insert the data into the store - there's no way to clean up the store
 	// once it's in there, it's in there.
-	contentStore := &lfs.ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &lfs.ContentStore{ObjectStorage: storage.LFS}
 	for _, uploadInfo := range infos {
 		if uploadInfo.lfsMetaObject == nil {
 			continue
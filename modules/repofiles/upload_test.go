@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLFSAutoTrackPattern(t *testing.T) {
+	defer func(minSize int64, extensions []string) {
+		setting.Repository.Upload.LFSAutoTrackMinSize = minSize
+		setting.Repository.Upload.LFSAutoTrackExtensions = extensions
+	}(setting.Repository.Upload.LFSAutoTrackMinSize, setting.Repository.Upload.LFSAutoTrackExtensions)
+	setting.Repository.Upload.LFSAutoTrackMinSize = 2
+	setting.Repository.Upload.LFSAutoTrackExtensions = []string{".psd", ".png"}
+
+	assert.EqualValues(t, "*.psd", lfsAutoTrackPattern("art/cover.psd", 10))
+	assert.EqualValues(t, "*.PNG", lfsAutoTrackPattern("art/cover.PNG", 10))
+	assert.EqualValues(t, "", lfsAutoTrackPattern("art/notes.txt", 10))
+	assert.EqualValues(t, "", lfsAutoTrackPattern("art/psd", 10))
+	assert.EqualValues(t, "/art/big[[:space:]]file\\[1].txt", lfsAutoTrackPattern("art/big file[1].txt", 2*1024*1024))
+
+	setting.Repository.Upload.LFSAutoTrackMinSize = 0
+	assert.EqualValues(t, "", lfsAutoTrackPattern("art/big file.txt", 2*1024*1024))
+}
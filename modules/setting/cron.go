@@ -52,6 +52,25 @@ var (
 		UpdateMigrationPosterID struct {
 			Schedule string
 		} `ini:"cron.update_migration_poster_id"`
+		ThumbnailCleanup struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		} `ini:"cron.thumbnail_cleanup"`
+		UploadCleanup struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		} `ini:"cron.upload_cleanup"`
+		LFSGarbageCollect struct {
+			Enabled     bool
+			RunAtStart  bool
+			Schedule    string
+			GracePeriod time.Duration
+			Delete      bool
+		} `ini:"cron.lfs_gc"`
 	}{
 		UpdateMirror: struct {
 			Enabled    bool
@@ -122,6 +141,41 @@ var (
 		}{
 			Schedule: "@every 24h",
 		},
+		ThumbnailCleanup: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+			OlderThan:  30 * 24 * time.Hour,
+		},
+		UploadCleanup: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		}{
+			Enabled:    true,
+			RunAtStart: true,
+			Schedule:   "@every 24h",
+			OlderThan:  24 * time.Hour,
+		},
+		LFSGarbageCollect: struct {
+			Enabled     bool
+			RunAtStart  bool
+			Schedule    string
+			GracePeriod time.Duration
+			Delete      bool
+		}{
+			Enabled:     false,
+			RunAtStart:  false,
+			Schedule:    "@every 168h",
+			GracePeriod: 7 * 24 * time.Hour,
+			Delete:      false,
+		},
 	}
 )
 
@@ -29,6 +29,7 @@ var (
 		IssuePath             string
 		RepoIndexerEnabled    bool
 		RepoPath              string
+		AssetPath             string
 		UpdateQueueLength     int
 		MaxIndexerFileSize    int64
 		IssueQueueType        string
@@ -41,6 +42,7 @@ var (
 	}{
 		IssueType:             "bleve",
 		IssuePath:             "indexers/issues.bleve",
+		AssetPath:             "indexers/assets.bleve",
 		IssueQueueType:        LevelQueueType,
 		IssueQueueDir:         "indexers/issues.queue",
 		IssueQueueConnStr:     "",
@@ -60,6 +62,10 @@ func newIndexerService() {
 	if !filepath.IsAbs(Indexer.RepoPath) {
 		Indexer.RepoPath = path.Join(AppWorkPath, Indexer.RepoPath)
 	}
+	Indexer.AssetPath = sec.Key("ASSET_INDEXER_PATH").MustString(path.Join(AppDataPath, "indexers/assets.bleve"))
+	if !filepath.IsAbs(Indexer.AssetPath) {
+		Indexer.AssetPath = path.Join(AppWorkPath, Indexer.AssetPath)
+	}
 	Indexer.IncludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_INCLUDE").MustString(""))
 	Indexer.ExcludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_EXCLUDE").MustString(""))
 
@@ -44,11 +44,14 @@ var (
 
 		// Repository upload settings
 		Upload struct {
-			Enabled      bool
-			TempPath     string
-			AllowedTypes []string `delim:"|"`
-			FileMaxSize  int64
-			MaxFiles     int
+			Enabled                bool
+			TempPath               string
+			AllowedTypes           []string `delim:"|"`
+			FileMaxSize            int64
+			MaxFiles               int
+			ChunkSize              int64
+			LFSAutoTrackMinSize    int64    `ini:"LFS_AUTO_TRACK_MIN_SIZE"`
+			LFSAutoTrackExtensions []string `ini:"LFS_AUTO_TRACK_EXTENSIONS"`
 		} `ini:"-"`
 
 		// Repository local settings
@@ -101,17 +104,23 @@ var (
 
 		// Repository upload settings
 		Upload: struct {
-			Enabled      bool
-			TempPath     string
-			AllowedTypes []string `delim:"|"`
-			FileMaxSize  int64
-			MaxFiles     int
+			Enabled                bool
+			TempPath               string
+			AllowedTypes           []string `delim:"|"`
+			FileMaxSize            int64
+			MaxFiles               int
+			ChunkSize              int64
+			LFSAutoTrackMinSize    int64    `ini:"LFS_AUTO_TRACK_MIN_SIZE"`
+			LFSAutoTrackExtensions []string `ini:"LFS_AUTO_TRACK_EXTENSIONS"`
 		}{
-			Enabled:      true,
-			TempPath:     "data/tmp/uploads",
-			AllowedTypes: []string{},
-			FileMaxSize:  3,
-			MaxFiles:     5,
+			Enabled:                true,
+			TempPath:               "data/tmp/uploads",
+			AllowedTypes:           []string{},
+			FileMaxSize:            3,
+			MaxFiles:               5,
+			ChunkSize:              10,
+			LFSAutoTrackMinSize:    0,
+			LFSAutoTrackExtensions: []string{},
 		},
 
 		// Repository local settings
@@ -200,4 +209,18 @@ func newRepository() {
 	if !filepath.IsAbs(Repository.Upload.TempPath) {
 		Repository.Upload.TempPath = path.Join(AppWorkPath, Repository.Upload.TempPath)
 	}
+
+	// the extensions stored in LFS are compared case insensitively and with their leading dot
+	extensions := make([]string, 0, len(Repository.Upload.LFSAutoTrackExtensions))
+	for _, ext := range Repository.Upload.LFSAutoTrackExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" || ext == "." {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions = append(extensions, ext)
+	}
+	Repository.Upload.LFSAutoTrackExtensions = extensions
 }
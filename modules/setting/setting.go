@@ -137,6 +137,8 @@ var (
 		JWTSecretBase64 string        `ini:"LFS_JWT_SECRET"`
 		JWTSecretBytes  []byte        `ini:"-"`
 		HTTPAuthExpiry  time.Duration `ini:"LFS_HTTP_AUTH_EXPIRY"`
+		AllowPureSSH    bool          `ini:"LFS_ALLOW_PURE_SSH"`
+		Storage         Storage       `ini:"-"`
 	}
 
 	// Security settings
@@ -258,6 +260,8 @@ var (
 	RepositoryAvatarUploadPath    string
 	RepositoryAvatarFallback      string
 	RepositoryAvatarFallbackImage string
+	AvatarStorage                 Storage
+	RepositoryAvatarStorage       Storage
 
 	// Log settings
 	LogLevel           string
@@ -278,6 +282,7 @@ var (
 	AttachmentMaxSize      int64
 	AttachmentMaxFiles     int
 	AttachmentEnabled      bool
+	AttachmentStorage      Storage
 
 	// Time settings
 	TimeFormat string
@@ -700,9 +705,16 @@ func NewContext() {
 
 	LFS.HTTPAuthExpiry = sec.Key("LFS_HTTP_AUTH_EXPIRY").MustDuration(20 * time.Minute)
 
+	// the [lfs] section selects where the content is stored, the local path
+	// defaults to LFS_CONTENT_PATH of [server]
+	LFS.Storage = getStorage(Cfg.Section("lfs"), LFS.ContentPath, "lfs/")
+	LFS.ContentPath = LFS.Storage.Path
+
 	if LFS.StartServer {
-		if err := os.MkdirAll(LFS.ContentPath, 0700); err != nil {
-			log.Fatal("Failed to create '%s': %v", LFS.ContentPath, err)
+		if LFS.Storage.Type == LocalStorageType {
+			if err := os.MkdirAll(LFS.ContentPath, 0700); err != nil {
+				log.Fatal("Failed to create '%s': %v", LFS.ContentPath, err)
+			}
 		}
 
 		LFS.JWTSecretBytes = make([]byte, 32)
@@ -805,6 +817,8 @@ func NewContext() {
 	if !filepath.IsAbs(AttachmentPath) {
 		AttachmentPath = path.Join(AppWorkPath, AttachmentPath)
 	}
+	AttachmentStorage = getStorage(sec, AttachmentPath, "attachments/")
+	AttachmentPath = AttachmentStorage.Path
 	AttachmentAllowedTypes = strings.Replace(sec.Key("ALLOWED_TYPES").MustString("image/jpeg,image/png,application/zip,application/gzip"), "|", ",", -1)
 	AttachmentMaxSize = sec.Key("MAX_SIZE").MustInt64(4)
 	AttachmentMaxFiles = sec.Key("MAX_FILES").MustInt(5)
@@ -877,6 +891,11 @@ func NewContext() {
 	if !filepath.IsAbs(RepositoryAvatarUploadPath) {
 		RepositoryAvatarUploadPath = path.Join(AppWorkPath, RepositoryAvatarUploadPath)
 	}
+	// avatars have their own sections as [picture] holds two storages
+	AvatarStorage = getStorage(Cfg.Section("avatar"), AvatarUploadPath, "avatars/")
+	AvatarUploadPath = AvatarStorage.Path
+	RepositoryAvatarStorage = getStorage(Cfg.Section("repo-avatar"), RepositoryAvatarUploadPath, "repo-avatars/")
+	RepositoryAvatarUploadPath = RepositoryAvatarStorage.Path
 	RepositoryAvatarFallback = sec.Key("REPOSITORY_AVATAR_FALLBACK").MustString("none")
 	RepositoryAvatarFallbackImage = sec.Key("REPOSITORY_AVATAR_FALLBACK_IMAGE").MustString("/img/repo_default.png")
 	AvatarMaxWidth = sec.Key("AVATAR_MAX_WIDTH").MustInt(4096)
@@ -1072,4 +1091,5 @@ func NewServices() {
 	newMigrationsService()
 	newIndexerService()
 	newTaskService()
+	newThumbnailService()
 }
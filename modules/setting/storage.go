@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+	"time"
+
+	ini "gopkg.in/ini.v1"
+)
+
+// enumerates all storage types
+const (
+	LocalStorageType = "local"
+	MinioStorageType = "minio"
+)
+
+// Storage represents the configuration of a storage backend
+type Storage struct {
+	Type string
+	// Path is the root directory of local storage
+	Path string
+	// ServeDirect redirects downloads to a pre-signed URL of the object storage
+	ServeDirect bool
+	// ServeDirectExpiry is the validity of pre-signed URLs
+	ServeDirectExpiry time.Duration
+	Minio             struct {
+		Endpoint        string
+		AccessKeyID     string
+		SecretAccessKey string
+		Bucket          string
+		Location        string
+		BasePath        string
+		UseSSL          bool
+	}
+}
+
+// storageKey returns the key of the section, falling back to the shared [storage]
+// section so the connection to an object storage is only configured once
+func storageKey(sec *ini.Section, name string) *ini.Key {
+	if sec.HasKey(name) {
+		return sec.Key(name)
+	}
+	return Cfg.Section("storage").Key(name)
+}
+
+// getStorage reads the storage settings of a section, defaultPath is the
+// local storage path when none is configured
+func getStorage(sec *ini.Section, defaultPath, defaultBasePath string) Storage {
+	var storage Storage
+	storage.Type = storageKey(sec, "STORAGE_TYPE").In(LocalStorageType, []string{LocalStorageType, MinioStorageType})
+	storage.Path = sec.Key("PATH").MustString(defaultPath)
+	if !filepath.IsAbs(storage.Path) {
+		storage.Path = filepath.Join(AppWorkPath, storage.Path)
+	}
+	storage.ServeDirect = storageKey(sec, "SERVE_DIRECT").MustBool(false)
+	storage.ServeDirectExpiry = storageKey(sec, "SERVE_DIRECT_EXPIRY").MustDuration(5 * time.Minute)
+
+	storage.Minio.Endpoint = storageKey(sec, "MINIO_ENDPOINT").MustString("localhost:9000")
+	storage.Minio.AccessKeyID = storageKey(sec, "MINIO_ACCESS_KEY_ID").String()
+	storage.Minio.SecretAccessKey = storageKey(sec, "MINIO_SECRET_ACCESS_KEY").String()
+	storage.Minio.Bucket = storageKey(sec, "MINIO_BUCKET").MustString("gitea")
+	storage.Minio.Location = storageKey(sec, "MINIO_LOCATION").MustString("us-east-1")
+	storage.Minio.BasePath = sec.Key("MINIO_BASE_PATH").MustString(defaultBasePath)
+	storage.Minio.UseSSL = storageKey(sec, "MINIO_USE_SSL").MustBool(false)
+	return storage
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ini "gopkg.in/ini.v1"
+)
+
+func Test_getStorage(t *testing.T) {
+	oldCfg := Cfg
+	defer func() {
+		Cfg = oldCfg
+	}()
+
+	var err error
+	Cfg, err = ini.Load([]byte(`
+[storage]
+STORAGE_TYPE = minio
+MINIO_BUCKET = shared
+
+[attachment]
+MINIO_BUCKET = attachments
+
+[lfs]
+STORAGE_TYPE = local
+PATH = /data/lfs
+`))
+	assert.NoError(t, err)
+
+	attachment := getStorage(Cfg.Section("attachment"), "/data/attachments", "attachments/")
+	assert.Equal(t, MinioStorageType, attachment.Type)
+	assert.Equal(t, "attachments", attachment.Minio.Bucket)
+	assert.Equal(t, "attachments/", attachment.Minio.BasePath)
+
+	avatar := getStorage(Cfg.Section("avatar"), "/data/avatars", "avatars/")
+	assert.Equal(t, MinioStorageType, avatar.Type)
+	assert.Equal(t, "shared", avatar.Minio.Bucket)
+	assert.Equal(t, "/data/avatars", avatar.Path)
+
+	lfs := getStorage(Cfg.Section("lfs"), "/data/default", "lfs/")
+	assert.Equal(t, LocalStorageType, lfs.Type)
+	assert.Equal(t, "/data/lfs", lfs.Path)
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+var (
+	// Thumbnail settings
+	Thumbnail = struct {
+		Enabled         bool
+		Path            string
+		Sizes           []int
+		MaxSourceSize   int64
+		MaxSourcePixels int64
+	}{
+		Enabled:         true,
+		Path:            "thumbnails",
+		Sizes:           []int{64, 256, 1024},
+		MaxSourceSize:   256 * 1024 * 1024,
+		MaxSourcePixels: 64 * 1024 * 1024,
+	}
+)
+
+func newThumbnailService() {
+	sec := Cfg.Section("thumbnail")
+	Thumbnail.Enabled = sec.Key("ENABLED").MustBool(true)
+	Thumbnail.Path = sec.Key("PATH").MustString(path.Join(AppDataPath, "thumbnails"))
+	if !filepath.IsAbs(Thumbnail.Path) {
+		Thumbnail.Path = path.Join(AppWorkPath, Thumbnail.Path)
+	}
+	Thumbnail.Sizes = sec.Key("SIZES").Ints(",")
+	if len(Thumbnail.Sizes) == 0 {
+		Thumbnail.Sizes = []int{64, 256, 1024}
+	}
+	sort.Ints(Thumbnail.Sizes)
+	Thumbnail.MaxSourceSize = sec.Key("MAX_SOURCE_SIZE").MustInt64(256 * 1024 * 1024)
+	Thumbnail.MaxSourcePixels = sec.Key("MAX_SOURCE_PIXELS").MustInt64(64 * 1024 * 1024)
+}
+
+// IsValidThumbnailSize returns whether thumbnails of the given size may be generated
+func IsValidThumbnailSize(size int) bool {
+	for _, s := range Thumbnail.Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// IsThumbnailSourceTooLarge returns whether an image of the given dimensions exceeds MAX_SOURCE_PIXELS
+func IsThumbnailSourceTooLarge(width, height int) bool {
+	if width < 0 || height < 0 {
+		return true
+	}
+	return Thumbnail.MaxSourcePixels > 0 && int64(width)*int64(height) > Thumbnail.MaxSourcePixels
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var _ ObjectStorage = &LocalStorage{}
+
+// LocalStorage represents a local files storage
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage returns a local files storage rooted at dir
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (l *LocalStorage) path(p string) string {
+	// paths are always relative to the root of the storage
+	return filepath.Join(l.dir, filepath.Clean("/"+filepath.FromSlash(p)))
+}
+
+// Open returns a file
+func (l *LocalStorage) Open(path string) (Object, error) {
+	return os.Open(l.path(path))
+}
+
+// Save writes the content to a temporary file which is moved in place once complete
+func (l *LocalStorage) Save(path string, r io.Reader, size int64) (int64, error) {
+	p := l.path(path)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpRemove := func() {
+		tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmpRemove()
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		tmpRemove()
+		return 0, err
+	}
+	if err = os.Rename(tmp.Name(), p); err != nil {
+		tmpRemove()
+		return 0, err
+	}
+	return written, nil
+}
+
+// Stat returns the info of the file, directories are not objects
+func (l *LocalStorage) Stat(path string) (*ObjectInfo, error) {
+	fi, err := os.Stat(l.path(path))
+	if err != nil {
+		return nil, err
+	} else if fi.IsDir() {
+		return nil, ErrObjectNotExist
+	}
+	return &ObjectInfo{
+		Path:    path,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}, nil
+}
+
+// Delete deletes a file
+func (l *LocalStorage) Delete(path string) error {
+	err := os.Remove(l.path(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// URL gets the redirect URL to a file, local files are served by Gitea itself
+func (l *LocalStorage) URL(path, name string, expiry time.Duration) (*url.URL, error) {
+	return nil, ErrURLNotSupported
+}
+
+// IterateObjects iterates across the files, temporary files of pending saves are skipped
+func (l *LocalStorage) IterateObjects(fn func(info *ObjectInfo) error) error {
+	return filepath.Walk(l.dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+		return fn(&ObjectInfo{
+			Path:    filepath.ToSlash(rel),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+		})
+	})
+}
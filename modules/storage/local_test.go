@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := NewLocalStorage(dir)
+	assert.NoError(t, err)
+
+	n, err := l.Save("ab/cd/object", strings.NewReader("hello world"), -1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+
+	// paths cannot escape the root
+	_, err = l.Save("../outside", strings.NewReader("x"), 1)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "outside"))
+
+	obj, err := l.Open("ab/cd/object")
+	assert.NoError(t, err)
+	_, err = obj.Seek(6, io.SeekStart)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+	obj.Close()
+
+	_, err = l.Save("ab/cd/broken", io.MultiReader(strings.NewReader("partial"), failingReader{}), 20)
+	assert.Error(t, err)
+	_, err = l.Stat("ab/cd/broken")
+	assert.True(t, IsNotExist(err))
+	_, err = l.Stat("ab/cd")
+	assert.True(t, IsNotExist(err))
+
+	var paths []string
+	assert.NoError(t, l.IterateObjects(func(info *ObjectInfo) error {
+		paths = append(paths, info.Path)
+		return nil
+	}))
+	assert.Equal(t, []string{"ab/cd/object", "outside"}, paths)
+
+	_, err = l.URL("ab/cd/object", "object", 0)
+	assert.Equal(t, ErrURLNotSupported, err)
+
+	assert.NoError(t, l.Delete("ab/cd/object"))
+	assert.NoError(t, l.Delete("ab/cd/object"))
+	_, err = l.Open("ab/cd/object")
+	assert.True(t, IsNotExist(err))
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// ErrVerificationFailed is returned when a migrated object does not match its source
+type ErrVerificationFailed struct {
+	Path   string
+	Reason string
+}
+
+func (err ErrVerificationFailed) Error() string {
+	return fmt.Sprintf("verification of %s failed: %s", err.Path, err.Reason)
+}
+
+// IsErrVerificationFailed checks if an error is a ErrVerificationFailed.
+func IsErrVerificationFailed(err error) bool {
+	_, ok := err.(ErrVerificationFailed)
+	return ok
+}
+
+// MigrateOptions represents the options of a storage migration
+type MigrateOptions struct {
+	// Verify compares the content of every copy with its source, else only the sizes are compared
+	Verify bool
+	// Resume skips the objects which already exist at the destination
+	// with the same size, or the same content when verifying
+	Resume bool
+}
+
+// Migrate copies the object at path from src to dst and checks the copy.
+// It returns whether the object was copied, false if it was skipped when resuming.
+func Migrate(dst, src ObjectStorage, path string, opts MigrateOptions) (bool, error) {
+	info, err := src.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	if opts.Resume {
+		if dstInfo, err := dst.Stat(path); err == nil && dstInfo.Size == info.Size {
+			if !opts.Verify {
+				return false, nil
+			}
+			srcHash, err := objectHash(src, path)
+			if err != nil {
+				return false, err
+			}
+			if dstHash, err := objectHash(dst, path); err == nil && dstHash == srcHash {
+				return false, nil
+			}
+		} else if err != nil && !IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	f, err := src.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	written, err := dst.Save(path, io.TeeReader(f, hash), info.Size)
+	if err != nil {
+		return false, err
+	}
+	if written != info.Size {
+		return true, ErrVerificationFailed{Path: path, Reason: fmt.Sprintf("copied %d of %d bytes", written, info.Size)}
+	}
+
+	dstInfo, err := dst.Stat(path)
+	if err != nil {
+		return true, err
+	}
+	if dstInfo.Size != info.Size {
+		return true, ErrVerificationFailed{Path: path, Reason: fmt.Sprintf("size %d, expected %d", dstInfo.Size, info.Size)}
+	}
+	if opts.Verify {
+		dstHash, err := objectHash(dst, path)
+		if err != nil {
+			return true, err
+		}
+		if srcHash := hex.EncodeToString(hash.Sum(nil)); dstHash != srcHash {
+			return true, ErrVerificationFailed{Path: path, Reason: fmt.Sprintf("sha256 %s, expected %s", dstHash, srcHash)}
+		}
+	}
+	return true, nil
+}
+
+// objectHash returns the hex encoded SHA256 of the content of an object
+func objectHash(s ObjectStorage, path string) (string, error) {
+	f, err := s.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src, err := NewLocalStorage(dir)
+	assert.NoError(t, err)
+	dst, fake, server := newTestMinioStorage(t)
+	defer server.Close()
+
+	_, err = src.Save("a/b/object", strings.NewReader("content"), -1)
+	assert.NoError(t, err)
+
+	copied, err := Migrate(dst, src, "a/b/object", MigrateOptions{Verify: true})
+	assert.NoError(t, err)
+	assert.True(t, copied)
+	assert.Equal(t, []byte("content"), fake.buckets["gitea"]["lfs/a/b/object"])
+
+	_, err = Migrate(dst, src, "missing", MigrateOptions{})
+	assert.True(t, IsNotExist(err))
+
+	// resuming skips the objects already present
+	copied, err = Migrate(dst, src, "a/b/object", MigrateOptions{Resume: true})
+	assert.NoError(t, err)
+	assert.False(t, copied)
+
+	// a corrupted copy of the same size is only noticed when verifying
+	fake.buckets["gitea"]["lfs/a/b/object"] = []byte("CONTENT")
+	copied, err = Migrate(dst, src, "a/b/object", MigrateOptions{Resume: true})
+	assert.NoError(t, err)
+	assert.False(t, copied)
+	copied, err = Migrate(dst, src, "a/b/object", MigrateOptions{Resume: true, Verify: true})
+	assert.NoError(t, err)
+	assert.True(t, copied)
+	assert.Equal(t, []byte("content"), fake.buckets["gitea"]["lfs/a/b/object"])
+}
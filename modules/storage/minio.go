@@ -0,0 +1,589 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ ObjectStorage = &MinioStorage{}
+
+const (
+	amzDateFormat    = "20060102T150405Z"
+	amzShortFormat   = "20060102"
+	amzAlgorithm     = "AWS4-HMAC-SHA256"
+	amzUnsignedBody  = "UNSIGNED-PAYLOAD"
+	amzEmptyBodyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var (
+	// minioPartSize is the part size of multipart uploads, objects up to this size are uploaded at once
+	minioPartSize int64 = 64 * 1024 * 1024
+	// minioMaxParts is the largest number of parts of a multipart upload allowed by S3
+	minioMaxParts int64 = 10000
+	// minioRequestTimeout bounds every request but the download of object content
+	minioRequestTimeout = 10 * time.Minute
+
+	// minioTransport is shared by all storages so connections to the endpoint are reused
+	minioTransport = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		ExpectContinueTimeout: time.Second,
+	}
+)
+
+// MinioStorageConfig represents the configuration of an S3 compatible object storage
+type MinioStorageConfig struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	Location        string
+	BasePath        string
+	UseSSL          bool
+}
+
+// MinioStorage stores objects in a bucket of an S3 compatible object storage
+// (AWS S3, MinIO, ...), requests are signed with AWS signature version 4
+type MinioStorage struct {
+	cfg    MinioStorageConfig
+	client *http.Client
+	// downloadClient has no overall timeout, object content may take long to be read
+	downloadClient *http.Client
+	// now is replaced by tests
+	now func() time.Time
+}
+
+// NewMinioStorage returns an S3 compatible storage, the bucket is created if it does not exist
+func NewMinioStorage(cfg MinioStorageConfig) (*MinioStorage, error) {
+	if len(cfg.Endpoint) == 0 || len(cfg.Bucket) == 0 {
+		return nil, errors.New("object storage endpoint and bucket are required")
+	}
+	m := &MinioStorage{
+		cfg:            cfg,
+		client:         &http.Client{Transport: minioTransport, Timeout: minioRequestTimeout},
+		downloadClient: &http.Client{Transport: minioTransport},
+		now:            time.Now,
+	}
+
+	resp, err := m.do(http.MethodHead, "", nil, nil, nil, -1)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return m, nil
+	case http.StatusNotFound:
+	default:
+		return nil, fmt.Errorf("bucket %s: %s", cfg.Bucket, resp.Status)
+	}
+
+	var body []byte
+	if len(cfg.Location) > 0 && cfg.Location != "us-east-1" {
+		body = []byte(`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><LocationConstraint>` +
+			cfg.Location + `</LocationConstraint></CreateBucketConfiguration>`)
+	}
+	resp, err = m.do(http.MethodPut, "", nil, nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err = checkResponse(resp); err != nil {
+		return nil, fmt.Errorf("create bucket %s: %v", cfg.Bucket, err)
+	}
+	return m, nil
+}
+
+func (m *MinioStorage) key(path string) string {
+	return strings.TrimPrefix(m.cfg.BasePath+strings.TrimPrefix(path, "/"), "/")
+}
+
+func (m *MinioStorage) url(key string, query url.Values) *url.URL {
+	scheme := "http"
+	if m.cfg.UseSSL {
+		scheme = "https"
+	}
+	// path style addressing works with every S3 compatible storage
+	p := "/" + m.cfg.Bucket
+	if len(key) > 0 {
+		p += "/" + key
+	}
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     m.cfg.Endpoint,
+		Path:     p,
+		RawPath:  awsEscape(p, false),
+		RawQuery: canonicalQuery(query),
+	}
+}
+
+// do sends a signed request, body is streamed with an unsigned payload
+func (m *MinioStorage) do(method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	req, err := m.newRequest(method, key, query, header, body, size)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.Do(req)
+}
+
+func (m *MinioStorage) newRequest(method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Request, error) {
+	req, err := http.NewRequest(method, m.url(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	payloadHash := amzEmptyBodyHash
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = amzUnsignedBody
+	}
+	m.sign(req, payloadHash, m.now().UTC())
+	return req, nil
+}
+
+func (m *MinioStorage) scope(t time.Time) string {
+	return t.Format(amzShortFormat) + "/" + m.cfg.Location + "/s3/aws4_request"
+}
+
+func (m *MinioStorage) signature(t time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := amzAlgorithm + "\n" + t.Format(amzDateFormat) + "\n" + m.scope(t) + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + m.cfg.SecretAccessKey)
+	for _, part := range []string{t.Format(amzShortFormat), m.cfg.Location, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	return hex.EncodeToString(key)
+}
+
+// sign adds the authorization headers of AWS signature version 4
+func (m *MinioStorage) sign(req *http.Request, payloadHash string, t time.Time) {
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + t.Format(amzDateFormat) + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		amzAlgorithm, m.cfg.AccessKeyID, m.scope(t), signedHeaders, m.signature(t, canonicalRequest)))
+}
+
+// presign returns a URL which grants a GET request to anyone until it expires
+func (m *MinioStorage) presign(key string, query url.Values, expiry time.Duration) *url.URL {
+	t := m.now().UTC()
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("X-Amz-Algorithm", amzAlgorithm)
+	query.Set("X-Amz-Credential", m.cfg.AccessKeyID+"/"+m.scope(t))
+	query.Set("X-Amz-Date", t.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiry/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	u := m.url(key, query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		amzUnsignedBody,
+	}, "\n")
+	query.Set("X-Amz-Signature", m.signature(t, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u
+}
+
+// awsEscape encodes every byte but the unreserved characters, as required for signing
+func awsEscape(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k, true)+"="+awsEscape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// checkResponse returns the error of an unsuccessful response
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrObjectNotExist
+	}
+	var e s3Error
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if xml.Unmarshal(data, &e) == nil && len(e.Code) > 0 {
+		return fmt.Errorf("%s: %s: %s", resp.Status, e.Code, e.Message)
+	}
+	return errors.New(resp.Status)
+}
+
+// Open returns the object, its content is only requested on the first read
+// so seeking to resume a download does not transfer the skipped bytes
+func (m *MinioStorage) Open(path string) (Object, error) {
+	info, err := m.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &minioObject{storage: m, key: m.key(path), size: info.Size}, nil
+}
+
+// Save uploads the object, content of unknown size is buffered to a temporary
+// file first as S3 requires the length of uploads
+func (m *MinioStorage) Save(path string, r io.Reader, size int64) (int64, error) {
+	if size < 0 {
+		tmp, err := ioutil.TempFile("", "gitea-storage")
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}()
+		if size, err = io.Copy(tmp, r); err != nil {
+			return 0, err
+		}
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r = tmp
+	}
+	if size == 0 {
+		// the content is still read, r may fail on EOF
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			return 0, err
+		}
+		r = bytes.NewReader(nil)
+	}
+	if size > minioPartSize {
+		if err := m.saveMultipart(m.key(path), r, size); err != nil {
+			return 0, err
+		}
+		return size, nil
+	}
+
+	// a reader failing midway aborts the request, so no partial object is stored
+	resp, err := m.do(http.MethodPut, m.key(path), nil, nil, r, size)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err = checkResponse(resp); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+type s3InitiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+// saveMultipart uploads the object in parts as S3 rejects single uploads above 5 GiB,
+// the upload is aborted on failure so no partial object is stored
+func (m *MinioStorage) saveMultipart(key string, r io.Reader, size int64) error {
+	resp, err := m.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	var result s3InitiateMultipartUploadResult
+	if err = checkResponse(resp); err == nil {
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+	}
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("initiate multipart upload: %v", err)
+	}
+
+	if err = m.uploadParts(key, result.UploadID, r, size); err != nil {
+		resp, abortErr := m.do(http.MethodDelete, key, url.Values{"uploadId": {result.UploadID}}, nil, nil, -1)
+		if abortErr == nil {
+			abortErr = checkResponse(resp)
+			resp.Body.Close()
+		}
+		if abortErr != nil && !IsNotExist(abortErr) {
+			return fmt.Errorf("%v, abort multipart upload: %v", err, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *MinioStorage) uploadParts(key, uploadID string, r io.Reader, size int64) error {
+	partSize := minioPartSize
+	if min := (size + minioMaxParts - 1) / minioMaxParts; min > partSize {
+		partSize = min
+	}
+
+	complete := s3CompleteMultipartUpload{}
+	for offset, number := int64(0), 1; offset < size; offset, number = offset+partSize, number+1 {
+		length := partSize
+		if size-offset < length {
+			length = size - offset
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := m.do(http.MethodPut, key, query, nil, io.LimitReader(r, length), length)
+		if err != nil {
+			return err
+		}
+		err = checkResponse(resp)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("upload part %d: %v", number, err)
+		}
+		complete.Parts = append(complete.Parts, s3CompletedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err := m.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err = checkResponse(resp); err != nil {
+		return fmt.Errorf("complete multipart upload: %v", err)
+	}
+	// the completion may fail after the response status was sent, the error is then in the body
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+	var e s3Error
+	if xml.Unmarshal(data, &e) == nil && len(e.Code) > 0 {
+		return fmt.Errorf("complete multipart upload: %s: %s", e.Code, e.Message)
+	}
+	return nil
+}
+
+// Stat returns the size and modification time of the object
+func (m *MinioStorage) Stat(path string) (*ObjectInfo, error) {
+	resp, err := m.do(http.MethodHead, m.key(path), nil, nil, nil, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err = checkResponse(resp); err != nil {
+		return nil, err
+	}
+	info := &ObjectInfo{
+		Path: path,
+		Size: resp.ContentLength,
+	}
+	info.ModTime, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return info, nil
+}
+
+// Delete deletes the object, deleting a missing object is not an error
+func (m *MinioStorage) Delete(path string) error {
+	resp, err := m.do(http.MethodDelete, m.key(path), nil, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err = checkResponse(resp); err != nil && !IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// URL returns a pre-signed URL to download the object as name
+func (m *MinioStorage) URL(path, name string, expiry time.Duration) (*url.URL, error) {
+	query := url.Values{}
+	if len(name) > 0 {
+		query.Set("response-content-disposition", fmt.Sprintf(`attachment; filename="%s"`, quoteEscaper.Replace(name)))
+	}
+	return m.presign(m.key(path), query, expiry), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// IterateObjects lists the objects under the base path
+func (m *MinioStorage) IterateObjects(fn func(info *ObjectInfo) error) error {
+	prefix := m.key("")
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if len(token) > 0 {
+			query.Set("continuation-token", token)
+		}
+		resp, err := m.do(http.MethodGet, "", query, nil, nil, -1)
+		if err != nil {
+			return err
+		}
+		var result s3ListResult
+		if err = checkResponse(resp); err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range result.Contents {
+			if err = fn(&ObjectInfo{
+				Path:    strings.TrimPrefix(obj.Key, prefix),
+				Size:    obj.Size,
+				ModTime: obj.LastModified,
+			}); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// minioObject reads an object with ranged GET requests
+type minioObject struct {
+	storage *MinioStorage
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *minioObject) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		header := http.Header{}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+		req, err := o.storage.newRequest(http.MethodGet, o.key, nil, header, nil, -1)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := o.storage.downloadClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		if err = checkResponse(resp); err != nil {
+			resp.Body.Close()
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *minioObject) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *minioObject) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	return o.body.Close()
+}
@@ -0,0 +1,360 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal stand-in for MinIO: path style buckets, signed requests,
+// ranged reads, multipart uploads and ListObjectsV2
+type fakeS3 struct {
+	sync.Mutex
+	signer  *MinioStorage
+	buckets map[string]map[string][]byte
+	// uploads holds the parts of the multipart uploads in progress by upload ID
+	uploads map[string]map[int][]byte
+	// partCount is the number of uploaded parts
+	partCount int
+}
+
+var authorizationRegexp = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/[^,]+, SignedHeaders=([^,]+), Signature=([0-9a-f]{64})$`)
+
+func (s *fakeS3) checkSignature(r *http.Request) error {
+	if signature := r.URL.Query().Get("X-Amz-Signature"); len(signature) > 0 {
+		query := r.URL.Query()
+		query.Del("X-Amz-Signature")
+		t, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+		if err != nil {
+			return err
+		}
+		expires, _ := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if s.signer.now().After(t.Add(time.Duration(expires) * time.Second)) {
+			return errors.New("expired")
+		}
+		canonicalRequest := strings.Join([]string{r.Method, r.URL.EscapedPath(), canonicalQuery(query),
+			"host:" + r.Host + "\n", "host", amzUnsignedBody}, "\n")
+		if s.signer.signature(t, canonicalRequest) != signature {
+			return errors.New("invalid pre-signed signature")
+		}
+		return nil
+	}
+
+	match := authorizationRegexp.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil || match[1] != "access" {
+		return errors.New("invalid authorization")
+	}
+	t, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return err
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	canonicalRequest := strings.Join([]string{r.Method, r.URL.EscapedPath(), canonicalQuery(r.URL.Query()),
+		"host:" + r.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + r.Header.Get("X-Amz-Date") + "\n",
+		match[3], payloadHash}, "\n")
+	if s.signer.signature(t, canonicalRequest) != match[4] {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.checkSignature(r); err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, "<Error><Code>SignatureDoesNotMatch</Code><Message>%v</Message></Error>", err)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket, ok := s.buckets[parts[0]]
+	if len(parts) == 1 {
+		switch {
+		case r.Method == http.MethodPut:
+			s.buckets[parts[0]] = map[string][]byte{}
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			s.list(w, r, bucket)
+		}
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	key := parts[1]
+	if _, ok := r.URL.Query()["uploads"]; ok || len(r.URL.Query().Get("uploadId")) > 0 {
+		s.multipart(w, r, bucket, key)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bucket[key] = data
+	case http.MethodHead, http.MethodGet:
+		data, ok := bucket[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Last-Modified", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		var from int64
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &from); err == nil {
+			data = data[from:]
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *fakeS3) multipart(w http.ResponseWriter, r *http.Request, bucket map[string][]byte, key string) {
+	uploadID := r.URL.Query().Get("uploadId")
+	if len(uploadID) == 0 {
+		uploadID = fmt.Sprintf("upload-%d", len(s.uploads)+1)
+		s.uploads[uploadID] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+		return
+	}
+	upload, ok := s.uploads[uploadID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		number, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		upload[number] = data
+		s.partCount++
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, number))
+	case http.MethodPost:
+		var complete s3CompleteMultipartUpload
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var data []byte
+		for i, part := range complete.Parts {
+			if part.PartNumber != i+1 || part.ETag != fmt.Sprintf(`"part-%d"`, part.PartNumber) {
+				fmt.Fprint(w, "<Error><Code>InvalidPart</Code></Error>")
+				return
+			}
+			data = append(data, upload[part.PartNumber]...)
+		}
+		bucket[key] = data
+		delete(s.uploads, uploadID)
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Key>%s</Key></CompleteMultipartUploadResult>", key)
+	case http.MethodDelete:
+		delete(s.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *fakeS3) list(w http.ResponseWriter, r *http.Request, bucket map[string][]byte) {
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for key := range bucket {
+		if strings.HasPrefix(key, prefix) && key > r.URL.Query().Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key  string
+		Size int64
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}{}
+	// two keys per page to exercise continuation
+	if len(keys) > 2 {
+		keys = keys[:2]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, content{Key: key, Size: int64(len(bucket[key]))})
+	}
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func newTestMinioStorage(t *testing.T) (*MinioStorage, *fakeS3, *httptest.Server) {
+	fake := &fakeS3{buckets: map[string]map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+	server := httptest.NewServer(fake)
+
+	u, _ := url.Parse(server.URL)
+	cfg := MinioStorageConfig{
+		Endpoint:        u.Host,
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		Bucket:          "gitea",
+		Location:        "us-east-1",
+		BasePath:        "lfs/",
+	}
+	fake.signer = &MinioStorage{cfg: cfg, now: time.Now}
+
+	m, err := NewMinioStorage(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, fake.buckets, "gitea")
+	return m, fake, server
+}
+
+func TestMinioStorage(t *testing.T) {
+	m, fake, server := newTestMinioStorage(t)
+	defer server.Close()
+
+	n, err := m.Save("ab/cd/ef gh", strings.NewReader("hello world"), 11)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+	assert.Equal(t, []byte("hello world"), fake.buckets["gitea"]["lfs/ab/cd/ef gh"])
+
+	// unknown size
+	_, err = m.Save("ab/cd/unknown", strings.NewReader("abc"), -1)
+	assert.NoError(t, err)
+
+	info, err := m.Stat("ab/cd/ef gh")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, info.Size)
+
+	_, err = m.Stat("missing")
+	assert.True(t, IsNotExist(err))
+	_, err = m.Open("missing")
+	assert.True(t, IsNotExist(err))
+
+	obj, err := m.Open("ab/cd/ef gh")
+	assert.NoError(t, err)
+	_, err = obj.Seek(6, io.SeekStart)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+	assert.NoError(t, obj.Close())
+
+	var paths []string
+	assert.NoError(t, m.IterateObjects(func(info *ObjectInfo) error {
+		paths = append(paths, info.Path)
+		return nil
+	}))
+	assert.Equal(t, []string{"ab/cd/ef gh", "ab/cd/unknown"}, paths)
+
+	assert.NoError(t, m.Delete("ab/cd/unknown"))
+	assert.NoError(t, m.Delete("ab/cd/unknown"))
+	assert.NotContains(t, fake.buckets["gitea"], "lfs/ab/cd/unknown")
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("hash mismatch")
+}
+
+func TestMinioStorage_AbortedSave(t *testing.T) {
+	m, fake, server := newTestMinioStorage(t)
+	defer server.Close()
+
+	_, err := m.Save("broken", io.MultiReader(strings.NewReader("partial"), failingReader{}), 20)
+	assert.Error(t, err)
+	assert.NotContains(t, fake.buckets["gitea"], "lfs/broken")
+}
+
+func TestMinioStorage_Multipart(t *testing.T) {
+	m, fake, server := newTestMinioStorage(t)
+	defer server.Close()
+	defer func(size int64) { minioPartSize = size }(minioPartSize)
+	minioPartSize = 4
+
+	n, err := m.Save("large", strings.NewReader("hello world"), 11)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+	assert.Equal(t, []byte("hello world"), fake.buckets["gitea"]["lfs/large"])
+	assert.Equal(t, 3, fake.partCount)
+
+	// objects up to the part size are uploaded at once
+	_, err = m.Save("small", strings.NewReader("abcd"), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fake.partCount)
+
+	// at most minioMaxParts parts are uploaded
+	defer func(parts int64) { minioMaxParts = parts }(minioMaxParts)
+	minioMaxParts = 2
+	_, err = m.Save("larger parts", strings.NewReader("hello world"), 11)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello world"), fake.buckets["gitea"]["lfs/larger parts"])
+	assert.Equal(t, 5, fake.partCount)
+
+	// a failing reader aborts the upload
+	_, err = m.Save("broken", io.MultiReader(strings.NewReader("partial"), failingReader{}), 20)
+	assert.Error(t, err)
+	assert.NotContains(t, fake.buckets["gitea"], "lfs/broken")
+	assert.Empty(t, fake.uploads)
+}
+
+func TestMinioStorage_URL(t *testing.T) {
+	m, _, server := newTestMinioStorage(t)
+	defer server.Close()
+	_, err := m.Save("object", strings.NewReader("content"), 7)
+	assert.NoError(t, err)
+
+	u, err := m.URL("object", `cover "final".psd`, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, `attachment; filename="cover \"final\".psd"`, u.Query().Get("response-content-disposition"))
+
+	resp, err := http.Get(u.String())
+	assert.NoError(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "content", string(data))
+
+	// the signature covers the object path
+	tampered := *u
+	tampered.Path = strings.Replace(u.Path, "object", "other", 1)
+	tampered.RawPath = ""
+	resp, err = http.Get(tampered.String())
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
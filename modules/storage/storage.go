@@ -0,0 +1,122 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package storage provides the backends storing large content like LFS objects
+// either on the local file system or on an S3 compatible object storage.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	// ErrURLNotSupported is returned when a storage cannot provide direct download URLs
+	ErrURLNotSupported = errors.New("url method not supported")
+	// ErrObjectNotExist is returned when an object does not exist in a storage
+	ErrObjectNotExist = os.ErrNotExist
+)
+
+// Object represents the content of a stored object, reads can start at any offset
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// ObjectStorage represents an object storage to handle a bucket and files
+type ObjectStorage interface {
+	// Open returns the content of an object, ErrObjectNotExist if it is missing
+	Open(path string) (Object, error)
+	// Save stores the content of r, size is -1 when unknown.
+	// When r returns an error the object is not stored.
+	Save(path string, r io.Reader, size int64) (int64, error)
+	// Stat returns the information of an object, ErrObjectNotExist if it is missing
+	Stat(path string) (*ObjectInfo, error)
+	Delete(path string) error
+	// URL returns a pre-signed URL to download an object as name, or ErrURLNotSupported
+	URL(path, name string, expiry time.Duration) (*url.URL, error)
+	// IterateObjects calls fn for every stored object
+	IterateObjects(fn func(info *ObjectInfo) error) error
+}
+
+// Copy copies an object from one storage to another
+func Copy(dst ObjectStorage, dstPath string, src ObjectStorage, srcPath string) (int64, error) {
+	info, err := src.Stat(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	f, err := src.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return dst.Save(dstPath, f, info.Size)
+}
+
+// IsNotExist returns whether the error reports a missing object
+func IsNotExist(err error) bool {
+	return os.IsNotExist(err)
+}
+
+var (
+	// LFS represents the storage of LFS content
+	LFS ObjectStorage
+	// Attachments represents the storage of issue and release attachments
+	Attachments ObjectStorage
+	// Avatars represents the storage of user avatars
+	Avatars ObjectStorage
+	// RepoAvatars represents the storage of repository avatars
+	RepoAvatars ObjectStorage
+)
+
+// NewStorage creates the storage described by the settings
+func NewStorage(cfg setting.Storage) (ObjectStorage, error) {
+	switch cfg.Type {
+	case setting.LocalStorageType:
+		return NewLocalStorage(cfg.Path)
+	case setting.MinioStorageType:
+		return NewMinioStorage(MinioStorageConfig{
+			Endpoint:        cfg.Minio.Endpoint,
+			AccessKeyID:     cfg.Minio.AccessKeyID,
+			SecretAccessKey: cfg.Minio.SecretAccessKey,
+			Bucket:          cfg.Minio.Bucket,
+			Location:        cfg.Minio.Location,
+			BasePath:        cfg.Minio.BasePath,
+			UseSSL:          cfg.Minio.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// Init initializes the storages
+func Init() error {
+	var err error
+	if LFS, err = NewStorage(setting.LFS.Storage); err != nil {
+		return fmt.Errorf("LFS storage: %v", err)
+	}
+	if Attachments, err = NewStorage(setting.AttachmentStorage); err != nil {
+		return fmt.Errorf("attachment storage: %v", err)
+	}
+	if Avatars, err = NewStorage(setting.AvatarStorage); err != nil {
+		return fmt.Errorf("avatar storage: %v", err)
+	}
+	if RepoAvatars, err = NewStorage(setting.RepositoryAvatarStorage); err != nil {
+		return fmt.Errorf("repository avatar storage: %v", err)
+	}
+	return nil
+}
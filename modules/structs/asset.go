@@ -0,0 +1,85 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// AssetUsage represents an asset a repository imported from another repository
+type AssetUsage struct {
+	ID int64 `json:"id"`
+	// repository the asset was imported into
+	Repository *RepositoryMeta `json:"repository"`
+	Path       string          `json:"path"`
+	// commit importing the asset
+	CommitID string `json:"commit_id"`
+	// repository the asset was imported from
+	SourceRepository *RepositoryMeta `json:"source_repository"`
+	SourcePath       string          `json:"source_path"`
+	// commit of the source repository the asset was copied from
+	SourceCommitID string `json:"source_commit_id"`
+	SourceURL      string `json:"source_url"`
+	IsLFS          bool   `json:"is_lfs"`
+	Importer       *User  `json:"importer"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// ImportAssetsOption options for importing assets from another repository
+type ImportAssetsOption struct {
+	// owner of the source repository
+	// required: true
+	SourceOwner string `json:"source_owner" binding:"Required"`
+	// name of the source repository
+	// required: true
+	SourceRepo string `json:"source_repo" binding:"Required"`
+	// branch, tag or commit of the source repository, defaults to its default branch
+	SourceRef string `json:"source_ref"`
+	// files and directories of the source repository to import
+	// required: true
+	Paths []string `json:"paths" binding:"Required"`
+	// directory the assets are copied to, defaults to the root of the repository
+	TreePath string `json:"tree_path"`
+	// message (optional) for the commit of this import, if not supplied a default message will be used
+	Message string `json:"message"`
+	// branch (optional) to base this import from, if not given, the default branch is used
+	BranchName string `json:"branch"`
+	// new_branch (optional) will make a new branch from `branch` before importing the assets
+	NewBranchName string `json:"new_branch"`
+}
+
+// Asset represents a tagged file or folder of a branch listed by an asset library
+type Asset struct {
+	Repository *RepositoryMeta `json:"repository"`
+	Branch     string          `json:"branch"`
+	Path       string          `json:"path"`
+	Name       string          `json:"name"`
+	IsDir      bool            `json:"is_dir"`
+	// type of the asset: image, audio, video, model, document, archive, folder or other
+	Type string   `json:"type"`
+	Tags []string `json:"tags"`
+	// name of the author of the last commit changing the asset
+	Author string `json:"author"`
+	// date of the last commit changing the asset
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	HTMLURL string    `json:"html_url"`
+}
+
+// AssetTags represents the tags of a file or a folder of a branch
+type AssetTags struct {
+	Branch string   `json:"branch"`
+	Path   string   `json:"path"`
+	Tags   []string `json:"tags"`
+}
+
+// SetAssetTagsOption options for replacing the tags of a file or a folder
+type SetAssetTagsOption struct {
+	// branch of the file or the folder, defaults to the default branch
+	Branch string `json:"branch"`
+	// the new tags, an empty list removes all the tags
+	Tags []string `json:"tags"`
+}
@@ -65,6 +65,7 @@ type Issue struct {
 
 	PullRequest *PullRequestMeta `json:"pull_request"`
 	Repo        *RepositoryMeta  `json:"repository"`
+	Reactions   *ReactionSummary `json:"reactions"`
 }
 
 // ListIssueOption list issue options
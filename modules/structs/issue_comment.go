@@ -21,7 +21,8 @@ type Comment struct {
 	// swagger:strfmt date-time
 	Created time.Time `json:"created_at"`
 	// swagger:strfmt date-time
-	Updated time.Time `json:"updated_at"`
+	Updated   time.Time        `json:"updated_at"`
+	Reactions *ReactionSummary `json:"reactions"`
 }
 
 // CreateIssueCommentOption options for creating a comment on an issue
@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Reaction represents a reaction of a user on an issue, a pull request or a comment
+type Reaction struct {
+	User     *User  `json:"user"`
+	Reaction string `json:"content"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// EditReactionOption is the reaction to add or to remove
+type EditReactionOption struct {
+	// one of the allowed reactions, like +1 or heart
+	// required: true
+	Reaction string `json:"content" binding:"Required"`
+}
+
+// ReactionSummary is the number of reactions on an issue, a pull request or a comment
+type ReactionSummary struct {
+	TotalCount int `json:"total_count"`
+	// the number of reactions by content
+	Counts map[string]int `json:"counts"`
+}
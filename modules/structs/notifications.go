@@ -0,0 +1,46 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// NotificationThread represents a notification of a user about an issue or a pull request
+type NotificationThread struct {
+	ID         int64                `json:"id"`
+	Repository *Repository          `json:"repository"`
+	Subject    *NotificationSubject `json:"subject"`
+	Unread     bool                 `json:"unread"`
+	Pinned     bool                 `json:"pinned"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url"`
+}
+
+// NotificationSubject is the issue or the pull request a notification is about
+type NotificationSubject struct {
+	Title string `json:"title"`
+	// Type is "Issue" or "Pull"
+	Type string `json:"type"`
+	// State is "open" or "closed"
+	State                StateType `json:"state"`
+	URL                  string    `json:"url"`
+	HTMLURL              string    `json:"html_url"`
+	LatestCommentURL     string    `json:"latest_comment_url"`
+	LatestCommentHTMLURL string    `json:"latest_comment_html_url"`
+}
+
+// NotificationCount is the number of unread notifications of a user
+type NotificationCount struct {
+	New int64 `json:"new"`
+}
+
+const (
+	// NotificationSubjectIssue is the type of the subject of a notification about an issue
+	NotificationSubjectIssue = "Issue"
+	// NotificationSubjectPull is the type of the subject of a notification about a pull request
+	NotificationSubjectPull = "Pull"
+)
@@ -0,0 +1,103 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project is a kanban board whose columns hold cards of issues and pull requests
+type Project struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	State       StateType `json:"state"`
+	Creator     *User     `json:"creator"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required: true
+	Title       string `json:"title" binding:"Required;MaxSize(100)"`
+	Description string `json:"description"`
+	// the columns the project starts with, the first one is the default column
+	// enum: none,basic_kanban,bug_triage
+	ColumnTemplate string `json:"column_template"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title" binding:"OmitEmpty;MaxSize(100)"`
+	Description *string `json:"description"`
+	// enum: open,closed
+	State *string `json:"state"`
+}
+
+// ProjectColumn is a column of a project
+type ProjectColumn struct {
+	ID        int64  `json:"id"`
+	ProjectID int64  `json:"project_id"`
+	Title     string `json:"title"`
+	// whether new cards are added to this column
+	Default bool `json:"default"`
+	Sorting int  `json:"sorting"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateProjectColumnOption options for adding a column to a project
+type CreateProjectColumnOption struct {
+	// required: true
+	Title string `json:"title" binding:"Required;MaxSize(100)"`
+}
+
+// EditProjectColumnOption options for editing a column of a project
+type EditProjectColumnOption struct {
+	Title *string `json:"title" binding:"OmitEmpty;MaxSize(100)"`
+	// set to `true` to add new cards to this column
+	Default *bool `json:"default"`
+	// zero based position of the column among the columns of the project
+	Position *int `json:"position"`
+}
+
+// ProjectCard places an issue or a pull request in a column of a project
+type ProjectCard struct {
+	ID        int64  `json:"id"`
+	ProjectID int64  `json:"project_id"`
+	ColumnID  int64  `json:"column_id"`
+	Sorting   int    `json:"sorting"`
+	Issue     *Issue `json:"issue"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateProjectCardOption options for adding an issue or a pull request to a project
+type CreateProjectCardOption struct {
+	// name of the repository of the issue, required for projects of organizations
+	Repo string `json:"repo"`
+	// index of the issue or pull request in the repository
+	// required: true
+	IssueIndex int64 `json:"issue_index" binding:"Required"`
+	// the column to add the card to, the default column if omitted
+	ColumnID int64 `json:"column_id"`
+}
+
+// MoveProjectCardOption options for moving a card of a project
+type MoveProjectCardOption struct {
+	// required: true
+	ColumnID int64 `json:"column_id" binding:"Required"`
+	// zero based position of the card in the column, the end of the column if omitted
+	Position *int `json:"position"`
+}
@@ -91,3 +91,21 @@ type EditPullRequestOption struct {
 	Deadline       *time.Time `json:"due_date"`
 	RemoveDeadline *bool      `json:"unset_due_date"`
 }
+
+// BinaryCollision is a binary file of a pull request colliding with a file of another open pull request
+type BinaryCollision struct {
+	// same_path when both pull requests change the file, name when the names only differ by case
+	// or Unicode normalization
+	// enum: same_path,name
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	OtherPath string `json:"other_path"`
+}
+
+// CompetingPullRequest is an open pull request changing the same binary files as a pull request
+type CompetingPullRequest struct {
+	// null for the files of the base branch whose names only differ by case or Unicode normalization
+	// from the files added by the pull request
+	PullRequest *PullRequest       `json:"pull_request"`
+	Collisions  []*BinaryCollision `json:"collisions"`
+}
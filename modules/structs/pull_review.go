@@ -0,0 +1,153 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// ImageRegion represents a region of an image, normalized to its size:
+// 0 is the left or top edge and 1 the right or bottom edge. A point has no width and height.
+type ImageRegion struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// PullReviewImageComment represents a comment on a region of an image of a pull request
+type PullReviewImageComment struct {
+	ID       int64        `json:"id"`
+	ReviewID int64        `json:"pull_request_review_id"`
+	HTMLURL  string       `json:"html_url"`
+	PRURL    string       `json:"pull_request_url"`
+	Poster   *User        `json:"user"`
+	Body     string       `json:"body"`
+	Path     string       `json:"path"`
+	BlobSHA  string       `json:"blob_sha"`
+	Region   *ImageRegion `json:"region"`
+	// the image has been changed since the comment
+	Outdated bool `json:"outdated"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreatePullReviewImageCommentOption options for commenting on a region of an image of a pull request
+type CreatePullReviewImageCommentOption struct {
+	// required: true
+	Body string `json:"body" binding:"Required"`
+	// path of the image in the head of the pull request
+	// required: true
+	Path string `json:"path" binding:"Required"`
+	// required: true
+	Region *ImageRegion `json:"region" binding:"Required"`
+}
+
+// ReviewStateType is the state of a review of a pull request
+type ReviewStateType string
+
+const (
+	// ReviewStateApproved approves the changes
+	ReviewStateApproved ReviewStateType = "APPROVED"
+	// ReviewStatePending is a review which isn't submitted yet
+	ReviewStatePending ReviewStateType = "PENDING"
+	// ReviewStateComment gives general feedback
+	ReviewStateComment ReviewStateType = "COMMENT"
+	// ReviewStateRequestChanges gives feedback blocking the merge
+	ReviewStateRequestChanges ReviewStateType = "REQUEST_CHANGES"
+	// ReviewStateRequestReview is a review requested from a reviewer
+	ReviewStateRequestReview ReviewStateType = "REQUEST_REVIEW"
+	// ReviewStateUnknown is an unknown state
+	ReviewStateUnknown ReviewStateType = ""
+)
+
+// PullReview represents a review of a pull request
+type PullReview struct {
+	ID       int64           `json:"id"`
+	Reviewer *User           `json:"user"`
+	State    ReviewStateType `json:"state"`
+	Body     string          `json:"body"`
+	// the head commit of the pull request reviewed
+	CommitID string `json:"commit_id"`
+	// the head of the pull request changed since the review
+	Stale bool `json:"stale"`
+	// the review is made by an approver of the protected branch and counts towards the required approvals
+	Official          bool `json:"official"`
+	Dismissed         bool `json:"dismissed"`
+	CodeCommentsCount int  `json:"comments_count"`
+	// swagger:strfmt date-time
+	Submitted   time.Time `json:"submitted_at"`
+	HTMLURL     string    `json:"html_url"`
+	HTMLPullURL string    `json:"pull_request_url"`
+}
+
+// PullReviewComment represents a comment on a line of a file of a pull request
+type PullReviewComment struct {
+	ID       int64  `json:"id"`
+	Body     string `json:"body"`
+	Poster   *User  `json:"user"`
+	ReviewID int64  `json:"pull_request_review_id"`
+	Path     string `json:"path"`
+	// the last commit changing the line commented on
+	CommitID string `json:"commit_id"`
+	DiffHunk string `json:"diff_hunk"`
+	// the line of the new version of the file commented on, 0 if the line is removed
+	LineNum uint64 `json:"position"`
+	// the line of the old version of the file commented on, 0 if the line is added or unchanged
+	OldLineNum uint64 `json:"original_position"`
+	// the line has been changed since the comment
+	Outdated bool `json:"outdated"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated     time.Time `json:"updated_at"`
+	HTMLURL     string    `json:"html_url"`
+	HTMLPullURL string    `json:"pull_request_url"`
+}
+
+// CreatePullReviewOptions are options to create a review of a pull request
+type CreatePullReviewOptions struct {
+	// APPROVED, REQUEST_CHANGES or COMMENT submits the review, it is pending if PENDING or empty
+	Event ReviewStateType `json:"event"`
+	Body  string          `json:"body"`
+	// the head commit of the pull request reviewed, defaults to the current head
+	CommitID string                    `json:"commit_id"`
+	Comments []CreatePullReviewComment `json:"comments"`
+}
+
+// CreatePullReviewComment is a comment on a line of a file of a pull request, either on a line of the new version
+// of the file or on a removed line of the old version
+type CreatePullReviewComment struct {
+	// the path of the file in the pull request
+	Path string `json:"path"`
+	Body string `json:"body"`
+	// the line of the old version of the file, if the comment is on a removed line
+	OldLineNum int64 `json:"old_position"`
+	// the line of the new version of the file
+	NewLineNum int64 `json:"new_position"`
+}
+
+// SubmitPullReviewOptions are options to submit a pending review
+type SubmitPullReviewOptions struct {
+	// APPROVED, REQUEST_CHANGES or COMMENT
+	// required: true
+	Event ReviewStateType `json:"event" binding:"Required"`
+	// defaults to the body of the pending review
+	Body string `json:"body"`
+}
+
+// DismissPullReviewOptions are options to dismiss a review
+type DismissPullReviewOptions struct {
+	Message string `json:"message"`
+}
+
+// PullReviewRequestOptions are options to request reviews from users, or to remove the requests
+type PullReviewRequestOptions struct {
+	// the names of the reviewers
+	// required: true
+	Reviewers []string `json:"reviewers" binding:"Required"`
+}
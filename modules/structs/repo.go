@@ -87,6 +87,8 @@ type Repository struct {
 	AllowRebase               bool             `json:"allow_rebase"`
 	AllowRebaseMerge          bool             `json:"allow_rebase_explicit"`
 	AllowSquash               bool             `json:"allow_squash_merge"`
+	HasProjects               bool             `json:"has_projects"`
+	HasKeystone               bool             `json:"has_keystone"`
 	AvatarURL                 string           `json:"avatar_url"`
 }
 
@@ -154,6 +156,10 @@ type EditRepoOption struct {
 	AllowRebaseMerge *bool `json:"allow_rebase_explicit,omitempty"`
 	// either `true` to allow squash-merging pull requests, or `false` to prevent squash-merging. `has_pull_requests` must be `true`.
 	AllowSquash *bool `json:"allow_squash_merge,omitempty"`
+	// either `true` to enable project boards, or `false` to disable them.
+	HasProjects *bool `json:"has_projects,omitempty"`
+	// either `true` to enable the keystone, or `false` to disable it.
+	HasKeystone *bool `json:"has_keystone,omitempty"`
 	// set to `true` to archive this repository.
 	Archived *bool `json:"archived,omitempty"`
 }
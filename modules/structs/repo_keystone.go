@@ -0,0 +1,36 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Keystone represents the KEYSTONE and the BLUEPRINT of a repository,
+// both are kept on a dedicated ref with their own history
+type Keystone struct {
+	// ref holding the documents
+	Ref string `json:"ref"`
+	// SHA of the last commit of the ref
+	SHA string `json:"sha"`
+	// markdown content of the KEYSTONE
+	Keystone string `json:"keystone"`
+	// markdown content of the BLUEPRINT
+	Blueprint string `json:"blueprint"`
+	HTMLURL   string `json:"html_url"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// EditKeystonePageOption options for editing a document of the keystone
+type EditKeystonePageOption struct {
+	// markdown content of the document
+	Content string `json:"content"`
+	// message (optional) for the commit, if not supplied a default message is used
+	Message string `json:"message"`
+	// new_branch (optional) will make a new branch from the keystone to propose the change instead of committing it,
+	// a pull request is opened for it when the repository has pull requests enabled
+	NewBranchName string `json:"new_branch" binding:"GitRefName;MaxSize(100)"`
+}
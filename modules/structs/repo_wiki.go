@@ -0,0 +1,47 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// WikiCommit a revision of a wiki page
+type WikiCommit struct {
+	ID        string      `json:"sha"`
+	Author    *CommitUser `json:"author"`
+	Committer *CommitUser `json:"committer"`
+	Message   string      `json:"message"`
+}
+
+// WikiPageMetaData wiki page meta information
+type WikiPageMetaData struct {
+	Title      string      `json:"title"`
+	HTMLURL    string      `json:"html_url"`
+	SubURL     string      `json:"sub_url"`
+	LastCommit *WikiCommit `json:"last_commit"`
+}
+
+// WikiPage a wiki page with its raw and rendered content
+type WikiPage struct {
+	*WikiPageMetaData
+	// raw markdown of the page
+	Content string `json:"content"`
+	// page rendered to HTML
+	HTML        string `json:"html"`
+	CommitCount int64  `json:"commit_count"`
+}
+
+// WikiCommitList the revisions of a wiki page
+type WikiCommitList struct {
+	WikiCommits []*WikiCommit `json:"commits"`
+	Count       int64         `json:"count"`
+}
+
+// CreateWikiPageOptions options for creating or editing a wiki page
+type CreateWikiPageOptions struct {
+	// page title, required to create a page. When editing, an empty title keeps the page name
+	Title string `json:"title"`
+	// raw markdown of the page. When editing, an empty content keeps the page content
+	Content string `json:"content"`
+	// message of the commit, a default one is used if empty
+	Message string `json:"message"`
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Variant is a parallel version of a repository living on one of its branches
+type Variant struct {
+	ID          int64  `json:"id"`
+	Branch      string `json:"branch"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// path of the cover image in the branch
+	CoverPath string `json:"cover_path"`
+	CoverURL  string `json:"cover_url"`
+	Owner     *User  `json:"owner"`
+	Stars     int    `json:"stars_count"`
+	// number of commits of the variant missing from the default branch
+	CommitsAhead int `json:"commits_ahead"`
+	// number of commits of the default branch missing from the variant
+	CommitsBehind int              `json:"commits_behind"`
+	Activity      *VariantActivity `json:"activity,omitempty"`
+	HTMLURL       string           `json:"html_url"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// VariantActivity represents the activity on the branch of a variant over a period
+type VariantActivity struct {
+	// swagger:strfmt date-time
+	Since        time.Time `json:"since"`
+	Contributors int64     `json:"contributors_count"`
+	Commits      int64     `json:"commits_count"`
+	ChangedFiles int64     `json:"changed_files"`
+	Additions    int64     `json:"additions"`
+	Deletions    int64     `json:"deletions"`
+}
+
+// CreateVariantOption options for creating a variant
+type CreateVariantOption struct {
+	// branch holding the variant, it is created from the base branch if it does not exist
+	// required: true
+	Branch string `json:"branch" binding:"Required;GitRefName;MaxSize(100)"`
+	// branch the new branch is created from, defaults to the default branch of the repository
+	BaseBranch string `json:"base_branch"`
+	// required: true
+	Title       string `json:"title" binding:"Required;MaxSize(100)"`
+	Description string `json:"description"`
+	// path of the cover image in the branch
+	CoverPath string `json:"cover_path"`
+}
+
+// EditVariantOption options for editing a variant
+type EditVariantOption struct {
+	Title       *string `json:"title" binding:"OmitEmpty;MaxSize(100)"`
+	Description *string `json:"description"`
+	// path of the cover image in the branch, empty to remove the cover
+	CoverPath *string `json:"cover_path"`
+}
@@ -249,11 +249,12 @@ func NewFuncMap() []template.FuncMap {
 			}
 			return float32(n) * 100 / float32(sum)
 		},
-		"CommentMustAsDiff": gitdiff.CommentMustAsDiff,
-		"MirrorAddress":     mirror_service.Address,
-		"MirrorFullAddress": mirror_service.AddressNoCredentials,
-		"MirrorUserName":    mirror_service.Username,
-		"MirrorPassword":    mirror_service.Password,
+		"CommentMustAsDiff":    gitdiff.CommentMustAsDiff,
+		"CommentRegionThreads": models.GroupRegionComments,
+		"MirrorAddress":        mirror_service.Address,
+		"MirrorFullAddress":    mirror_service.AddressNoCredentials,
+		"MirrorUserName":       mirror_service.Username,
+		"MirrorPassword":       mirror_service.Password,
 		"CommitType": func(commit interface{}) string {
 			switch commit.(type) {
 			case models.SignCommitWithStatuses:
@@ -562,6 +563,10 @@ func ActionIcon(opType models.ActionType) string {
 		return "eye"
 	case models.ActionRejectPullRequest:
 		return "x"
+	case models.ActionCreateVariant:
+		return "versions"
+	case models.ActionImportAssets:
+		return "file-symlink-file"
 	default:
 		return "invalid type"
 	}
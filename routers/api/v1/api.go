@@ -69,6 +69,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/admin"
 	"code.gitea.io/gitea/routers/api/v1/misc"
+	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
@@ -356,6 +357,25 @@ func reqOrgMembership() macaron.Handler {
 	}
 }
 
+// reqOrgProjectsAccess user should have the given access to the projects of the organization
+func reqOrgProjectsAccess(mode models.AccessMode) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		accessMode, err := ctx.Org.Organization.GetProjectsAccessMode(ctx.User)
+		if err != nil {
+			ctx.Error(500, "GetProjectsAccessMode", err)
+			return
+		}
+		if accessMode < mode {
+			if accessMode >= models.AccessModeRead {
+				ctx.Error(403, "", "Must have write access to the projects of the organization")
+			} else {
+				ctx.NotFound()
+			}
+			return
+		}
+	}
+}
+
 func reqGitHook() macaron.Handler {
 	return func(ctx *context.APIContext) {
 		if !ctx.User.CanEditGitHook() {
@@ -511,6 +531,17 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
 		m.Post("/markdown/raw", misc.MarkdownRaw)
 
+		// Notifications
+		m.Group("/notifications", func() {
+			m.Combo("").
+				Get(notify.ListNotifications).
+				Put(notify.ReadNotifications)
+			m.Get("/new", notify.NewAvailable)
+			m.Combo("/threads/:id").
+				Get(notify.GetThread).
+				Patch(notify.ReadThread)
+		}, reqToken())
+
 		// Users
 		m.Group("/users", func() {
 			m.Get("/search", user.Search)
@@ -633,12 +664,20 @@ func RegisterRoutes(m *macaron.Macaron) {
 				}, reqToken(), reqAdmin())
 				m.Get("/raw/*", context.RepoRefByType(context.RepoRefAny), reqRepoReader(models.UnitTypeCode), repo.GetRawFile)
 				m.Get("/archive/*", reqRepoReader(models.UnitTypeCode), repo.GetArchive)
+				m.Get("/thumbnails/:sha", context.RepoRef(), reqRepoReader(models.UnitTypeCode), repo.GetThumbnail)
 				m.Combo("/forks").Get(repo.ListForks).
 					Post(reqToken(), reqRepoReader(models.UnitTypeCode), bind(api.CreateForkOption{}), repo.CreateFork)
 				m.Group("/branches", func() {
 					m.Get("", repo.ListBranches)
 					m.Get("/*", context.RepoRefByType(context.RepoRefBranch), repo.GetBranch)
 				}, reqRepoReader(models.UnitTypeCode))
+				m.Group("/branch_protections", func() {
+					m.Combo("").Get(repo.ListBranchProtections).
+						Post(mustNotBeArchived, bind(api.CreateBranchProtectionOption{}), repo.CreateBranchProtection)
+					m.Combo("/*").Get(repo.GetBranchProtection).
+						Patch(mustNotBeArchived, bind(api.EditBranchProtectionOption{}), repo.EditBranchProtection).
+						Delete(repo.DeleteBranchProtection)
+				}, reqToken(), reqAdmin())
 				m.Group("/tags", func() {
 					m.Get("", repo.ListTags)
 				}, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(true))
@@ -657,9 +696,14 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
 					m.Group("/comments", func() {
 						m.Get("", repo.ListRepoIssueComments)
-						m.Combo("/:id", reqToken()).
-							Patch(mustNotBeArchived, bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
-							Delete(repo.DeleteIssueComment)
+						m.Group("/:id", func() {
+							m.Combo("", reqToken()).
+								Patch(mustNotBeArchived, bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
+								Delete(repo.DeleteIssueComment)
+							m.Combo("/reactions").Get(repo.GetIssueCommentReactions).
+								Post(reqToken(), mustNotBeArchived, bind(api.EditReactionOption{}), repo.PostIssueCommentReaction).
+								Delete(reqToken(), mustNotBeArchived, bind(api.EditReactionOption{}), repo.DeleteIssueCommentReaction)
+						})
 					})
 					m.Group("/:index", func() {
 						m.Combo("").Get(repo.GetIssue).
@@ -686,6 +730,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 						})
 
 						m.Combo("/deadline").Post(reqToken(), bind(api.EditDeadlineOption{}), repo.UpdateIssueDeadline)
+						m.Combo("/reactions").Get(repo.GetIssueReactions).
+							Post(reqToken(), mustNotBeArchived, bind(api.EditReactionOption{}), repo.PostIssueReaction).
+							Delete(reqToken(), mustNotBeArchived, bind(api.EditReactionOption{}), repo.DeleteIssueReaction)
 						m.Group("/stopwatch", func() {
 							m.Post("/start", reqToken(), repo.StartIssueStopwatch)
 							m.Post("/stop", reqToken(), repo.StopIssueStopwatch)
@@ -720,6 +767,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Put("", reqToken(), user.Watch)
 					m.Delete("", reqToken(), user.Unwatch)
 				})
+				m.Combo("/notifications", reqToken()).
+					Get(notify.ListRepoNotifications).
+					Put(notify.ReadRepoNotifications)
 				m.Group("/releases", func() {
 					m.Combo("").Get(repo.ListReleases).
 						Post(reqToken(), reqRepoWriter(models.UnitTypeReleases), context.ReferencesGitRepo(false), bind(api.CreateReleaseOption{}), repo.CreateRelease)
@@ -736,6 +786,61 @@ func RegisterRoutes(m *macaron.Macaron) {
 						})
 					})
 				}, reqRepoReader(models.UnitTypeReleases))
+				m.Group("/projects", func() {
+					m.Combo("").Get(repo.ListProjects).
+						Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectOption{}), repo.CreateProject)
+					m.Group("/:id", func() {
+						m.Combo("").Get(repo.GetProject).
+							Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectOption{}), repo.EditProject).
+							Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), repo.DeleteProject)
+						m.Group("/columns", func() {
+							m.Combo("").Get(repo.ListProjectColumns).
+								Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectColumnOption{}), repo.CreateProjectColumn)
+							m.Combo("/:column").
+								Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectColumnOption{}), repo.EditProjectColumn).
+								Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectColumn)
+						})
+						m.Group("/cards", func() {
+							m.Combo("").Get(repo.ListProjectCards).
+								Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectCardOption{}), repo.CreateProjectCard)
+							m.Combo("/:card").
+								Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.MoveProjectCardOption{}), repo.MoveProjectCard).
+								Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectCard)
+						})
+					})
+				}, reqRepoReader(models.UnitTypeProjects))
+				m.Group("/keystone", func() {
+					m.Get("", repo.GetKeystone)
+					m.Put("/:page", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeKeystone), bind(api.EditKeystonePageOption{}), repo.EditKeystonePage)
+				}, reqRepoReader(models.UnitTypeKeystone))
+				m.Group("/variants", func() {
+					m.Combo("").Get(repo.ListVariants).
+						Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeCode), bind(api.CreateVariantOption{}), repo.CreateVariant)
+					m.Group("/:id", func() {
+						m.Combo("").Get(repo.GetVariant).
+							Patch(reqToken(), mustNotBeArchived, bind(api.EditVariantOption{}), repo.EditVariant).
+							Delete(reqToken(), mustNotBeArchived, repo.DeleteVariant)
+						m.Combo("/star", reqToken()).
+							Put(repo.StarVariant).
+							Delete(repo.UnstarVariant)
+					})
+				}, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
+				m.Group("/assets", func() {
+					m.Post("/import", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeCode), bind(api.ImportAssetsOption{}), repo.ImportAssets)
+					m.Get("/upstream", repo.ListUpstreamAssets)
+					m.Get("/downstream", repo.ListDownstreamAssets)
+					m.Combo("/tags/*").Get(repo.GetAssetTags).
+						Put(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeCode), bind(api.SetAssetTagsOption{}), repo.SetAssetTags)
+					m.Get("/library", repo.SearchAssetLibrary)
+				}, reqRepoReader(models.UnitTypeCode))
+				m.Group("/wiki", func() {
+					m.Get("/pages", repo.ListWikiPages)
+					m.Combo("/page/:pageName").Get(repo.GetWikiPage).
+						Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeWiki), bind(api.CreateWikiPageOptions{}), repo.EditWikiPage).
+						Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeWiki), repo.DeleteWikiPage)
+					m.Get("/revisions/:pageName", repo.ListWikiPageRevisions)
+					m.Post("/new", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeWiki), bind(api.CreateWikiPageOptions{}), repo.CreateWikiPage)
+				}, reqRepoReader(models.UnitTypeWiki))
 				m.Post("/mirror-sync", reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
 				m.Get("/editorconfig/:filename", context.RepoRef(), reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
@@ -746,6 +851,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
 							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+						m.Get("/competing", repo.ListCompetingPullRequests)
+						m.Combo("/image_comments").Get(repo.ListPullImageComments).
+							Post(reqToken(), mustNotBeArchived, bind(api.CreatePullReviewImageCommentOption{}), repo.CreatePullImageComment)
+						m.Group("/reviews", func() {
+							m.Combo("").Get(repo.ListPullReviews).
+								Post(reqToken(), mustNotBeArchived, bind(api.CreatePullReviewOptions{}), repo.CreatePullReview)
+							m.Group("/:id", func() {
+								m.Combo("").Get(repo.GetPullReview).
+									Post(reqToken(), mustNotBeArchived, bind(api.SubmitPullReviewOptions{}), repo.SubmitPullReview).
+									Delete(reqToken(), mustNotBeArchived, repo.DeletePullReview)
+								m.Get("/comments", repo.ListPullReviewComments)
+								m.Post("/dismissals", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests),
+									bind(api.DismissPullReviewOptions{}), repo.DismissPullReview)
+							})
+						})
+						m.Combo("/requested_reviewers", reqToken(), mustNotBeArchived).
+							Post(bind(api.PullReviewRequestOptions{}), repo.CreateReviewRequests).
+							Delete(bind(api.PullReviewRequestOptions{}), repo.DeleteReviewRequests)
 					})
 				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
 				m.Group("/statuses", func() {
@@ -816,6 +939,30 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Post(reqOrgOwnership(), bind(api.CreateTeamOption{}), org.CreateTeam)
 				m.Get("/search", org.SearchTeam)
 			}, reqOrgMembership())
+			m.Get("/assets/library", org.SearchAssetLibrary)
+			m.Group("/projects", func() {
+				m.Combo("").Get(org.ListProjects).
+					Post(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), bind(api.CreateProjectOption{}), org.CreateProject)
+				m.Group("/:id", func() {
+					m.Combo("").Get(org.GetProject).
+						Patch(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), bind(api.EditProjectOption{}), org.EditProject).
+						Delete(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), org.DeleteProject)
+					m.Group("/columns", func() {
+						m.Combo("").Get(org.ListProjectColumns).
+							Post(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), bind(api.CreateProjectColumnOption{}), org.CreateProjectColumn)
+						m.Combo("/:column").
+							Patch(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), bind(api.EditProjectColumnOption{}), org.EditProjectColumn).
+							Delete(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), org.DeleteProjectColumn)
+					})
+					m.Group("/cards", func() {
+						m.Combo("").Get(org.ListProjectCards).
+							Post(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), bind(api.CreateProjectCardOption{}), org.CreateProjectCard)
+						m.Combo("/:card").
+							Patch(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), bind(api.MoveProjectCardOption{}), org.MoveProjectCard).
+							Delete(reqToken(), reqOrgProjectsAccess(models.AccessModeWrite), org.DeleteProjectCard)
+					})
+				})
+			}, reqOrgProjectsAccess(models.AccessModeRead))
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
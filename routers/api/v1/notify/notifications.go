@@ -0,0 +1,175 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// NewAvailable returns the number of unread notifications of the authenticated user
+func NewAvailable(ctx *context.APIContext) {
+	// swagger:operation GET /notifications/new notification notifyNewAvailable
+	// ---
+	// summary: Check if unread notifications exist
+	// produces:
+	// - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/NotificationCount"
+	count, err := models.GetNotificationCount(ctx.User, models.NotificationStatusUnread)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetNotificationCount", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, api.NotificationCount{New: count})
+}
+
+// parseNotificationStatus returns the status of a notification named "unread", "read" or "pinned"
+func parseNotificationStatus(name string) (models.NotificationStatus, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "unread":
+		return models.NotificationStatusUnread, nil
+	case "read":
+		return models.NotificationStatusRead, nil
+	case "pinned":
+		return models.NotificationStatusPinned, nil
+	}
+	return 0, fmt.Errorf("unknown notification status %q", name)
+}
+
+// parseStatusTypes returns the statuses given by the "status-types" query parameter, or the default ones
+func parseStatusTypes(ctx *context.APIContext, defaults ...models.NotificationStatus) ([]models.NotificationStatus, error) {
+	names := ctx.QueryStrings("status-types")
+	if len(names) == 0 {
+		return defaults, nil
+	}
+	statuses := make([]models.NotificationStatus, 0, len(names))
+	for _, name := range names {
+		status, err := parseNotificationStatus(name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// parseTime returns the time in RFC 3339 format of a query parameter, or the zero time if it isn't given
+func parseTime(ctx *context.APIContext, param string) (time.Time, error) {
+	value := ctx.Query(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %v", param, err)
+	}
+	return t, nil
+}
+
+// listNotifications writes the notifications of the authenticated user, in a repository if repoID isn't 0
+func listNotifications(ctx *context.APIContext, repoID int64) {
+	// the unread and pinned notifications are listed by default, all of them with "all"
+	statuses, err := parseStatusTypes(ctx, models.NotificationStatusUnread, models.NotificationStatusPinned)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	if ctx.QueryBool("all") {
+		statuses = nil
+	}
+
+	page, perPage := utils.GetPagesInfo(ctx)
+	opts := &models.FindNotificationOptions{
+		UserID:   ctx.User.ID,
+		RepoID:   repoID,
+		Status:   statuses,
+		Page:     page,
+		PageSize: perPage,
+	}
+	since, err := parseTime(ctx, "since")
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	before, err := parseTime(ctx, "before")
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	if !since.IsZero() {
+		opts.UpdatedAfterUnix = since.Unix()
+	}
+	if !before.IsZero() {
+		opts.UpdatedBeforeUnix = before.Unix()
+	}
+
+	total, err := models.CountNotifications(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CountNotifications", err)
+		return
+	}
+	notifications, err := models.FindNotifications(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindNotifications", err)
+		return
+	}
+	if err := notifications.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return
+	}
+
+	ctx.SetLinkHeader(int(total), perPage)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.JSON(http.StatusOK, notifications.APIFormat())
+}
+
+// readNotifications changes the status of the notifications of the authenticated user, in a
+// repository if repoID isn't 0
+func readNotifications(ctx *context.APIContext, repoID int64) {
+	// the unread notifications are marked as read by default
+	statuses, err := parseStatusTypes(ctx, models.NotificationStatusUnread)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	if ctx.QueryBool("all") {
+		statuses = nil
+	}
+	toStatus := models.NotificationStatusRead
+	if ctx.Query("to-status") != "" {
+		if toStatus, err = parseNotificationStatus(ctx.Query("to-status")); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+	}
+	lastReadAt, err := parseTime(ctx, "last_read_at")
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	if lastReadAt.IsZero() {
+		lastReadAt = time.Now()
+	}
+
+	if _, err := models.SetNotificationsStatus(&models.FindNotificationOptions{
+		UserID:            ctx.User.ID,
+		RepoID:            repoID,
+		Status:            statuses,
+		UpdatedBeforeUnix: lastReadAt.Unix(),
+	}, toStatus); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetNotificationsStatus", err)
+		return
+	}
+	ctx.Status(http.StatusResetContent)
+}
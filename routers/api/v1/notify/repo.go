@@ -0,0 +1,117 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"code.gitea.io/gitea/modules/context"
+)
+
+// ListRepoNotifications lists the notifications of the authenticated user in a repository
+func ListRepoNotifications(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/notifications notification notifyGetRepoList
+	// ---
+	// summary: List the notifications of the authenticated user in a repository, the most recently updated first
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: all
+	//   in: query
+	//   description: If true, show the notifications of any status
+	//   type: boolean
+	// - name: status-types
+	//   in: query
+	//   description: "Show the notifications of these statuses: unread, read or pinned. Defaults to unread and pinned"
+	//   type: array
+	//   collectionFormat: multi
+	//   items:
+	//     type: string
+	//     enum: [unread, read, pinned]
+	// - name: since
+	//   in: query
+	//   description: Only show notifications updated at or after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show notifications updated at or before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: per_page
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/NotificationThreadList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	listNotifications(ctx, ctx.Repo.Repository.ID)
+}
+
+// ReadRepoNotifications marks the notifications of the authenticated user in a repository as read
+func ReadRepoNotifications(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/notifications notification notifyReadRepoList
+	// ---
+	// summary: Mark the notifications of the authenticated user in a repository as read, or change them to another status
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: last_read_at
+	//   in: query
+	//   description: Only change the notifications updated at or before the given time. This is a timestamp in RFC 3339 format, defaults to now
+	//   type: string
+	//   format: date-time
+	// - name: all
+	//   in: query
+	//   description: If true, change the notifications of any status
+	//   type: boolean
+	// - name: status-types
+	//   in: query
+	//   description: "Change the notifications of these statuses: unread, read or pinned. Defaults to unread"
+	//   type: array
+	//   collectionFormat: multi
+	//   items:
+	//     type: string
+	//     enum: [unread, read, pinned]
+	// - name: to-status
+	//   in: query
+	//   description: Status to change the notifications to, defaults to read
+	//   type: string
+	//   enum: [unread, read, pinned]
+	// responses:
+	//   "205":
+	//     "$ref": "#/responses/empty"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	readNotifications(ctx, ctx.Repo.Repository.ID)
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+)
+
+// GetThread gets a notification thread of the authenticated user
+func GetThread(ctx *context.APIContext) {
+	// swagger:operation GET /notifications/threads/{id} notification notifyGetThread
+	// ---
+	// summary: Get a notification thread
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the notification thread
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/NotificationThread"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	notification := getThread(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := notification.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, notification.APIFormat())
+}
+
+// ReadThread marks a notification thread of the authenticated user as read
+func ReadThread(ctx *context.APIContext) {
+	// swagger:operation PATCH /notifications/threads/{id} notification notifyReadThread
+	// ---
+	// summary: Mark a notification thread as read, or change it to another status
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the notification thread
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: to-status
+	//   in: query
+	//   description: Status to change the notification to, defaults to read
+	//   type: string
+	//   enum: [unread, read, pinned]
+	// responses:
+	//   "205":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	notification := getThread(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	status := models.NotificationStatusRead
+	if ctx.Query("to-status") != "" {
+		var err error
+		if status, err = parseNotificationStatus(ctx.Query("to-status")); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+	}
+
+	if err := models.SetNotificationStatus(notification.ID, ctx.User, status); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetNotificationStatus", err)
+		return
+	}
+	ctx.Status(http.StatusResetContent)
+}
+
+// getThread returns the notification of the ":id" parameter, the notifications of other users aren't found
+func getThread(ctx *context.APIContext) *models.Notification {
+	notification, err := models.GetNotificationByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetNotificationByID", err)
+		}
+		return nil
+	}
+	if notification.UserID != ctx.User.ID {
+		ctx.NotFound()
+		return nil
+	}
+	return notification
+}
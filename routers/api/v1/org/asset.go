@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/repo"
+)
+
+// SearchAssetLibrary search the tagged assets of the repositories of an organization
+func SearchAssetLibrary(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/assets/library organization orgSearchAssetLibrary
+	// ---
+	// summary: Search the tagged files and folders of the repositories of an organization, the most recently changed first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: q
+	//   in: query
+	//   description: keyword searched in the names, the paths and the tags
+	//   type: string
+	// - name: tags
+	//   in: query
+	//   description: comma-separated tags the assets must all have
+	//   type: string
+	// - name: type
+	//   in: query
+	//   description: "type of the assets: image, audio, video, model, document, archive, folder or other"
+	//   type: string
+	// - name: author
+	//   in: query
+	//   description: name of the author of the last change of the assets
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: Only show assets changed at or after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show assets changed before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: per_page
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AssetList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo.SearchAssetLibrary(ctx)
+}
@@ -0,0 +1,416 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/repo"
+)
+
+// ListProjects list the projects of an organization
+func ListProjects(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects organization orgListProjects
+	// ---
+	// summary: List an organization's projects
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open and closed. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+	repo.ListProjects(ctx)
+}
+
+// GetProject get a project of an organization
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects/{id} organization orgGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo.GetProject(ctx)
+}
+
+// CreateProject create a project for an organization
+func CreateProject(ctx *context.APIContext, form api.CreateProjectOption) {
+	// swagger:operation POST /orgs/{org}/projects organization orgCreateProject
+	// ---
+	// summary: Create a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo.CreateProject(ctx, form)
+}
+
+// EditProject modify a project of an organization
+func EditProject(ctx *context.APIContext, form api.EditProjectOption) {
+	// swagger:operation PATCH /orgs/{org}/projects/{id} organization orgEditProject
+	// ---
+	// summary: Update a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo.EditProject(ctx, form)
+}
+
+// DeleteProject delete a project of an organization
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/projects/{id} organization orgDeleteProject
+	// ---
+	// summary: Delete a project with its columns and cards
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	repo.DeleteProject(ctx)
+}
+
+// ListProjectColumns list the columns of a project
+func ListProjectColumns(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects/{id}/columns organization orgListProjectColumns
+	// ---
+	// summary: List the columns of a project in order
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumnList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo.ListProjectColumns(ctx)
+}
+
+// CreateProjectColumn add a column to a project
+func CreateProjectColumn(ctx *context.APIContext, form api.CreateProjectColumnOption) {
+	// swagger:operation POST /orgs/{org}/projects/{id}/columns organization orgCreateProjectColumn
+	// ---
+	// summary: Add a column after the existing columns of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectColumnOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo.CreateProjectColumn(ctx, form)
+}
+
+// EditProjectColumn modify a column of a project
+func EditProjectColumn(ctx *context.APIContext, form api.EditProjectColumnOption) {
+	// swagger:operation PATCH /orgs/{org}/projects/{id}/columns/{column} organization orgEditProjectColumn
+	// ---
+	// summary: Rename, move or make a column the default column of its project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectColumnOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo.EditProjectColumn(ctx, form)
+}
+
+// DeleteProjectColumn delete a column of a project
+func DeleteProjectColumn(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/projects/{id}/columns/{column} organization orgDeleteProjectColumn
+	// ---
+	// summary: Delete a column, its cards are moved to the default column
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo.DeleteProjectColumn(ctx)
+}
+
+// ListProjectCards list the cards of a project
+func ListProjectCards(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects/{id}/cards organization orgListProjectCards
+	// ---
+	// summary: List the cards of a project ordered by their position in the columns
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo.ListProjectCards(ctx)
+}
+
+// CreateProjectCard add an issue or a pull request to a project
+func CreateProjectCard(ctx *context.APIContext, form api.CreateProjectCardOption) {
+	// swagger:operation POST /orgs/{org}/projects/{id}/cards organization orgCreateProjectCard
+	// ---
+	// summary: Add an issue or a pull request to the end of a column of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectCardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	repo.CreateProjectCard(ctx, form)
+}
+
+// MoveProjectCard move a card of a project
+func MoveProjectCard(ctx *context.APIContext, form api.MoveProjectCardOption) {
+	// swagger:operation PATCH /orgs/{org}/projects/{id}/cards/{card} organization orgMoveProjectCard
+	// ---
+	// summary: Move a card to a position in a column of its project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: card
+	//   in: path
+	//   description: id of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo.MoveProjectCard(ctx, form)
+}
+
+// DeleteProjectCard remove an issue or a pull request from a project
+func DeleteProjectCard(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/projects/{id}/cards/{card} organization orgDeleteProjectCard
+	// ---
+	// summary: Remove an issue or a pull request from a project
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: card
+	//   in: path
+	//   description: id of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo.DeleteProjectCard(ctx)
+}
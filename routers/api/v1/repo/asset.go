@@ -0,0 +1,451 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	asset_indexer "code.gitea.io/gitea/modules/indexer/assets"
+	"code.gitea.io/gitea/modules/repofiles"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	asset_service "code.gitea.io/gitea/services/asset"
+)
+
+// ImportAssets import assets from another repository
+func ImportAssets(ctx *context.APIContext, form api.ImportAssetsOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/assets/import repository repoImportAssets
+	// ---
+	// summary: Import files of another repository, recording where they were borrowed from
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/ImportAssetsOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/AssetUsageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	sourceRepo, err := models.GetRepositoryByOwnerAndName(form.SourceOwner, form.SourceRepo)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByOwnerAndName", err)
+		}
+		return
+	}
+	if sourceRepo.ID == ctx.Repo.Repository.ID {
+		ctx.Error(http.StatusUnprocessableEntity, "", "assets can only be imported from another repository")
+		return
+	}
+
+	usages, err := repofiles.ImportAssets(ctx.Repo.Repository, ctx.User, &repofiles.ImportAssetsOptions{
+		SourceRepo:  sourceRepo,
+		SourceRef:   form.SourceRef,
+		SourcePaths: form.Paths,
+		TreePath:    form.TreePath,
+		OldBranch:   form.BranchName,
+		NewBranch:   form.NewBranchName,
+		Message:     form.Message,
+	})
+	if err != nil {
+		switch {
+		case models.IsErrRepoNotExist(err),
+			models.IsErrRepoFileDoesNotExist(err),
+			git.IsErrBranchNotExist(err),
+			git.IsErrNotExist(err):
+			ctx.NotFound(err)
+		case models.IsErrUserCannotCommit(err),
+			models.IsErrLFSFileLocked(err):
+			ctx.Error(http.StatusForbidden, "", err)
+		case models.IsErrRepoFileAlreadyExists(err),
+			models.IsErrBranchAlreadyExists(err):
+			ctx.Error(http.StatusConflict, "", err)
+		case models.IsErrFilenameInvalid(err):
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "ImportAssets", err)
+		}
+		return
+	}
+
+	apiUsages := make([]*api.AssetUsage, len(usages))
+	for i := range usages {
+		apiUsages[i] = usages[i].APIFormat()
+	}
+	ctx.JSON(http.StatusCreated, &apiUsages)
+}
+
+// ListUpstreamAssets list the assets a repository imported from other repositories
+func ListUpstreamAssets(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/assets/upstream repository repoListUpstreamAssets
+	// ---
+	// summary: List the assets a repository borrowed from other repositories
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: per_page
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AssetUsageList"
+
+	page, perPage := utils.GetPagesInfo(ctx)
+	usages, err := models.GetUpstreamAssetUsages(ctx.Repo.Repository.ID, page, perPage)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUpstreamAssetUsages", err)
+		return
+	}
+	listAssetUsages(ctx, usages, func(u *models.AssetUsage) *models.Repository { return u.SourceRepo })
+}
+
+// ListDownstreamAssets list the usages of the assets of a repository by other repositories
+func ListDownstreamAssets(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/assets/downstream repository repoListDownstreamAssets
+	// ---
+	// summary: List the assets of a repository borrowed by other repositories
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: per_page
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AssetUsageList"
+
+	page, perPage := utils.GetPagesInfo(ctx)
+	usages, err := models.GetDownstreamAssetUsages(ctx.Repo.Repository.ID, page, perPage)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetDownstreamAssetUsages", err)
+		return
+	}
+	listAssetUsages(ctx, usages, func(u *models.AssetUsage) *models.Repository { return u.Repo })
+}
+
+// listAssetUsages responds with the asset usages whose other repository the doer can read
+func listAssetUsages(ctx *context.APIContext, usages models.AssetUsageList, otherRepo func(*models.AssetUsage) *models.Repository) {
+	if err := usages.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return
+	}
+
+	apiUsages := make([]*api.AssetUsage, 0, len(usages))
+	for _, u := range usages {
+		perm, err := models.GetUserRepoPermission(otherRepo(u), ctx.User)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+			return
+		}
+		if !perm.CanRead(models.UnitTypeCode) {
+			continue
+		}
+		apiUsages = append(apiUsages, u.APIFormat())
+	}
+	ctx.JSON(http.StatusOK, &apiUsages)
+}
+
+// GetAssetTags get the tags of a file or a folder
+func GetAssetTags(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/assets/tags/{filepath} repository repoGetAssetTags
+	// ---
+	// summary: Get the tags of a file or a folder of a branch
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: filepath
+	//   in: path
+	//   description: path of the file or the folder
+	//   type: string
+	//   required: true
+	// - name: branch
+	//   in: query
+	//   description: branch of the file or the folder, defaults to the default branch
+	//   type: string
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AssetTags"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	branchName := ctx.QueryTrim("branch")
+	if branchName == "" {
+		branchName = ctx.Repo.Repository.DefaultBranch
+	}
+	if _, err := ctx.Repo.Repository.GetBranch(branchName); err != nil {
+		if git.IsErrBranchNotExist(err) {
+			ctx.NotFound(err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetBranch", err)
+		}
+		return
+	}
+
+	treePath := strings.Trim(ctx.Params("*"), "/")
+	tags, err := models.GetAssetTags(ctx.Repo.Repository.ID, branchName, treePath)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetAssetTags", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, &api.AssetTags{
+		Branch: branchName,
+		Path:   treePath,
+		Tags:   tags,
+	})
+}
+
+// SetAssetTags replace the tags of a file or a folder
+func SetAssetTags(ctx *context.APIContext, form api.SetAssetTagsOption) {
+	// swagger:operation PUT /repos/{owner}/{repo}/assets/tags/{filepath} repository repoSetAssetTags
+	// ---
+	// summary: Replace the tags of a file or a folder of a branch
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: filepath
+	//   in: path
+	//   description: path of the file or the folder
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/SetAssetTagsOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AssetTags"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	branchName := strings.TrimSpace(form.Branch)
+	if branchName == "" {
+		branchName = ctx.Repo.Repository.DefaultBranch
+	}
+	treePath := strings.Trim(ctx.Params("*"), "/")
+
+	tags, err := asset_service.SetAssetTags(ctx.User, ctx.Repo.Repository, branchName, treePath, form.Tags)
+	if err != nil {
+		switch {
+		case models.IsErrInvalidAssetTags(err):
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		case git.IsErrBranchNotExist(err), models.IsErrRepoFileDoesNotExist(err):
+			ctx.NotFound(err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "SetAssetTags", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, &api.AssetTags{
+		Branch: branchName,
+		Path:   treePath,
+		Tags:   tags,
+	})
+}
+
+// SearchAssetLibrary search the tagged assets of a repository
+func SearchAssetLibrary(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/assets/library repository repoSearchAssetLibrary
+	// ---
+	// summary: Search the tagged files and folders of a repository, the most recently changed first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: q
+	//   in: query
+	//   description: keyword searched in the names, the paths and the tags
+	//   type: string
+	// - name: tags
+	//   in: query
+	//   description: comma-separated tags the assets must all have
+	//   type: string
+	// - name: type
+	//   in: query
+	//   description: "type of the assets: image, audio, video, model, document, archive, folder or other"
+	//   type: string
+	// - name: author
+	//   in: query
+	//   description: name of the author of the last change of the assets
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: Only show assets changed at or after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show assets changed before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: per_page
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AssetList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	var repos []*models.Repository
+	if ctx.Repo.Repository != nil {
+		repos = []*models.Repository{ctx.Repo.Repository}
+	} else {
+		var err error
+		if repos, err = asset_service.LibraryRepositories(ctx.Org.Organization, ctx.User); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LibraryRepositories", err)
+			return
+		}
+	}
+
+	page, perPage := utils.GetPagesInfo(ctx)
+	opts := &asset_indexer.SearchOptions{
+		Keyword:  ctx.QueryTrim("q"),
+		FileType: ctx.QueryTrim("type"),
+		Author:   ctx.QueryTrim("author"),
+		Page:     page,
+		PageSize: perPage,
+	}
+	for _, tag := range strings.Split(ctx.Query("tags"), ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	for param, t := range map[string]*time.Time{"since": &opts.Since, "before": &opts.Before} {
+		if value := ctx.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid %s: %v", param, err))
+				return
+			}
+			*t = parsed
+		}
+	}
+
+	assets, total, err := asset_service.SearchLibrary(repos, opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchLibrary", err)
+		return
+	}
+
+	apiAssets := make([]*api.Asset, len(assets))
+	for i, a := range assets {
+		apiAssets[i] = &api.Asset{
+			Repository: &api.RepositoryMeta{
+				ID:       a.Repo.ID,
+				Name:     a.Repo.Name,
+				FullName: a.Repo.FullName(),
+			},
+			Branch:  a.BranchName,
+			Path:    a.TreePath,
+			Name:    a.Name(),
+			IsDir:   a.IsDir,
+			Type:    a.FileType,
+			Tags:    a.Tags,
+			Author:  a.Author,
+			Updated: a.Updated,
+			HTMLURL: a.HTMLURL(),
+		}
+	}
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.JSON(http.StatusOK, &apiAssets)
+}
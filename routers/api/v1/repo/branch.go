@@ -6,6 +6,10 @@
 package repo
 
 import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
@@ -114,3 +118,372 @@ func ListBranches(ctx *context.APIContext) {
 
 	ctx.JSON(200, &apiBranches)
 }
+
+// ListBranchProtections lists the branch protections of a repository
+func ListBranchProtections(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/branch_protections repository repoListBranchProtection
+	// ---
+	// summary: List the branch protections of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtectionList"
+	bps, err := ctx.Repo.Repository.GetProtectedBranches()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranches", err)
+		return
+	}
+	apiBps := make([]*api.BranchProtection, len(bps))
+	for i := range bps {
+		apiBps[i] = convert.ToBranchProtection(bps[i])
+	}
+
+	ctx.JSON(http.StatusOK, apiBps)
+}
+
+// GetBranchProtection gets the protection of a branch
+func GetBranchProtection(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/branch_protections/{name} repository repoGetBranchProtection
+	// ---
+	// summary: Get the protection of a branch
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the protected branch
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	bp := getBranchProtection(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToBranchProtection(bp))
+}
+
+// CreateBranchProtection protects a branch
+func CreateBranchProtection(ctx *context.APIContext, form api.CreateBranchProtectionOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/branch_protections repository repoCreateBranchProtection
+	// ---
+	// summary: Protect a branch
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateBranchProtectionOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo := ctx.Repo.Repository
+	if _, err := repo.GetBranch(form.BranchName); err != nil {
+		if git.IsErrBranchNotExist(err) {
+			ctx.NotFound(err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetBranch", err)
+		}
+		return
+	}
+
+	bp, err := models.GetProtectedBranchBy(repo.ID, form.BranchName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchBy", err)
+		return
+	} else if bp != nil {
+		ctx.Error(http.StatusConflict, "", fmt.Errorf("the branch %s is already protected", form.BranchName))
+		return
+	}
+
+	bp = &models.ProtectedBranch{
+		RepoID:     repo.ID,
+		BranchName: form.BranchName,
+	}
+	updateBranchProtection(ctx, bp, api.EditBranchProtectionOption{
+		EnablePush:                  &form.EnablePush,
+		EnablePushWhitelist:         &form.EnablePushWhitelist,
+		PushWhitelistUsernames:      form.PushWhitelistUsernames,
+		PushWhitelistTeams:          form.PushWhitelistTeams,
+		PushWhitelistDeployKeys:     &form.PushWhitelistDeployKeys,
+		EnableMergeWhitelist:        &form.EnableMergeWhitelist,
+		MergeWhitelistUsernames:     form.MergeWhitelistUsernames,
+		MergeWhitelistTeams:         form.MergeWhitelistTeams,
+		EnableStatusCheck:           &form.EnableStatusCheck,
+		StatusCheckContexts:         form.StatusCheckContexts,
+		RequiredApprovals:           &form.RequiredApprovals,
+		EnableApprovalsWhitelist:    &form.EnableApprovalsWhitelist,
+		ApprovalsWhitelistUsernames: form.ApprovalsWhitelistUsernames,
+		ApprovalsWhitelistTeams:     form.ApprovalsWhitelistTeams,
+	})
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToBranchProtection(bp))
+}
+
+// EditBranchProtection edits the protection of a branch
+func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtectionOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/branch_protections/{name} repository repoEditBranchProtection
+	// ---
+	// summary: Edit the protection of a branch, only the fields given are changed
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the protected branch
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditBranchProtectionOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	bp := getBranchProtection(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	updateBranchProtection(ctx, bp, form)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToBranchProtection(bp))
+}
+
+// DeleteBranchProtection removes the protection of a branch
+func DeleteBranchProtection(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/branch_protections/{name} repository repoDeleteBranchProtection
+	// ---
+	// summary: Remove the protection of a branch
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the protected branch
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	bp := getBranchProtection(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := ctx.Repo.Repository.DeleteProtectedBranch(bp.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProtectedBranch", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// getBranchProtection returns the protection of the branch named in the route
+func getBranchProtection(ctx *context.APIContext) *models.ProtectedBranch {
+	bp, err := models.GetProtectedBranchBy(ctx.Repo.Repository.ID, ctx.Params("*"))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchBy", err)
+		return nil
+	} else if bp == nil {
+		ctx.NotFound()
+		return nil
+	}
+	return bp
+}
+
+// updateBranchProtection applies the options given to the branch protection and saves it, the whitelists
+// and the status check contexts are cleared when they are disabled like in the settings of the repository
+func updateBranchProtection(ctx *context.APIContext, bp *models.ProtectedBranch, form api.EditBranchProtectionOption) {
+	repo := ctx.Repo.Repository
+
+	if form.EnablePush != nil {
+		bp.CanPush = *form.EnablePush
+	}
+	if form.EnablePushWhitelist != nil {
+		bp.EnableWhitelist = *form.EnablePushWhitelist
+	}
+	if form.PushWhitelistDeployKeys != nil {
+		bp.WhitelistDeployKeys = *form.PushWhitelistDeployKeys
+	}
+	if form.EnableMergeWhitelist != nil {
+		bp.EnableMergeWhitelist = *form.EnableMergeWhitelist
+	}
+	if form.EnableStatusCheck != nil {
+		bp.EnableStatusCheck = *form.EnableStatusCheck
+	}
+	if form.StatusCheckContexts != nil {
+		bp.StatusCheckContexts = form.StatusCheckContexts
+	}
+	if form.RequiredApprovals != nil {
+		if *form.RequiredApprovals < 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", "required_approvals can't be negative")
+			return
+		}
+		bp.RequiredApprovals = *form.RequiredApprovals
+	}
+	if form.EnableApprovalsWhitelist != nil {
+		bp.EnableApprovalsWhitelist = *form.EnableApprovalsWhitelist
+	}
+
+	opts := models.WhitelistOptions{
+		UserIDs:          bp.WhitelistUserIDs,
+		TeamIDs:          bp.WhitelistTeamIDs,
+		MergeUserIDs:     bp.MergeWhitelistUserIDs,
+		MergeTeamIDs:     bp.MergeWhitelistTeamIDs,
+		ApprovalsUserIDs: bp.ApprovalsWhitelistUserIDs,
+		ApprovalsTeamIDs: bp.ApprovalsWhitelistTeamIDs,
+	}
+	for _, whitelist := range []struct {
+		names []string
+		ids   *[]int64
+	}{
+		{form.PushWhitelistUsernames, &opts.UserIDs},
+		{form.MergeWhitelistUsernames, &opts.MergeUserIDs},
+		{form.ApprovalsWhitelistUsernames, &opts.ApprovalsUserIDs},
+	} {
+		if whitelist.names == nil {
+			continue
+		}
+		ids, err := models.GetUserIDsByNames(whitelist.names, false)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "GetUserIDsByNames", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserIDsByNames", err)
+			}
+			return
+		}
+		*whitelist.ids = ids
+	}
+	for _, whitelist := range []struct {
+		names []string
+		ids   *[]int64
+	}{
+		{form.PushWhitelistTeams, &opts.TeamIDs},
+		{form.MergeWhitelistTeams, &opts.MergeTeamIDs},
+		{form.ApprovalsWhitelistTeams, &opts.ApprovalsTeamIDs},
+	} {
+		if whitelist.names == nil {
+			continue
+		}
+		if len(whitelist.names) > 0 && !ctx.Repo.Owner.IsOrganization() {
+			ctx.Error(http.StatusUnprocessableEntity, "", "only the repositories of organizations have teams")
+			return
+		}
+		ids, err := models.GetTeamIDsByNames(repo.OwnerID, whitelist.names, false)
+		if err != nil {
+			if models.IsErrTeamNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "GetTeamIDsByNames", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetTeamIDsByNames", err)
+			}
+			return
+		}
+		*whitelist.ids = ids
+	}
+
+	// the options of the disabled protections are cleared
+	if !bp.CanPush {
+		bp.EnableWhitelist = false
+	}
+	if !bp.EnableWhitelist {
+		bp.WhitelistDeployKeys = false
+		opts.UserIDs, opts.TeamIDs = nil, nil
+	}
+	if !bp.EnableMergeWhitelist {
+		opts.MergeUserIDs, opts.MergeTeamIDs = nil, nil
+	}
+	if !bp.EnableStatusCheck {
+		bp.StatusCheckContexts = nil
+	}
+	if !bp.EnableApprovalsWhitelist {
+		opts.ApprovalsUserIDs, opts.ApprovalsTeamIDs = nil, nil
+	}
+
+	if err := models.UpdateProtectBranch(repo, bp, opts); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
+		return
+	}
+}
@@ -143,6 +143,10 @@ func SearchIssues(ctx *context.APIContext) {
 		ctx.Error(500, "Issues", err)
 		return
 	}
+	if err := models.IssueList(issues).LoadReactions(); err != nil {
+		ctx.Error(500, "LoadReactions", err)
+		return
+	}
 
 	apiIssues := make([]*api.Issue, len(issues))
 	for i := range issues {
@@ -238,6 +242,10 @@ func ListIssues(ctx *context.APIContext) {
 		ctx.Error(500, "Issues", err)
 		return
 	}
+	if err := models.IssueList(issues).LoadReactions(); err != nil {
+		ctx.Error(500, "LoadReactions", err)
+		return
+	}
 
 	apiIssues := make([]*api.Issue, len(issues))
 	for i := range issues {
@@ -527,6 +535,10 @@ func EditIssue(ctx *context.APIContext, form api.EditIssueOption) {
 		ctx.Error(500, "GetIssueByID", err)
 		return
 	}
+	if err = issue.LoadReactions(); err != nil {
+		ctx.Error(500, "LoadReactions", err)
+		return
+	}
 	ctx.JSON(201, issue.APIFormat())
 }
 
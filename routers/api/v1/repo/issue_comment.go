@@ -72,6 +72,10 @@ func ListIssueComments(ctx *context.APIContext) {
 		ctx.Error(500, "LoadPosters", err)
 		return
 	}
+	if err := models.CommentList(comments).LoadReactions(); err != nil {
+		ctx.Error(500, "LoadReactions", err)
+		return
+	}
 
 	apiComments := make([]*api.Comment, len(comments))
 	for i, comment := range comments {
@@ -139,6 +143,10 @@ func ListRepoIssueComments(ctx *context.APIContext) {
 		ctx.Error(500, "LoadRepositories", err)
 		return
 	}
+	if err := models.CommentList(comments).LoadReactions(); err != nil {
+		ctx.Error(500, "LoadReactions", err)
+		return
+	}
 	for i := range comments {
 		apiComments[i] = comments[i].APIFormat()
 	}
@@ -301,6 +309,10 @@ func editIssueComment(ctx *context.APIContext, form api.EditIssueCommentOption)
 		ctx.Error(500, "UpdateComment", err)
 		return
 	}
+	if err := comment.LoadReactions(); err != nil {
+		ctx.Error(500, "LoadReactions", err)
+		return
+	}
 
 	ctx.JSON(200, comment.APIFormat())
 }
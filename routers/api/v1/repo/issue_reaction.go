@@ -0,0 +1,381 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetIssueReactions lists the reactions on an issue
+func GetIssueReactions(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issues/{index}/reactions issue issueGetIssueReactions
+	// ---
+	// summary: List the reactions on an issue or a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ReactionList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	issue := getReactionIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reactions, err := models.FindIssueReactions(issue)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindIssueReactions", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, reactions.APIFormat())
+}
+
+// PostIssueReaction adds a reaction to an issue
+func PostIssueReaction(ctx *context.APIContext, form api.EditReactionOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/issues/{index}/reactions issue issuePostIssueReaction
+	// ---
+	// summary: Add a reaction to an issue or a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: content
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditReactionOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Reaction"
+	//   "201":
+	//     "$ref": "#/responses/Reaction"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue := getReactionIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reaction, err := models.CreateIssueReaction(ctx.User, issue, form.Reaction)
+	writeCreatedReaction(ctx, reaction, err, func() (models.ReactionList, error) {
+		return models.FindIssueReactions(issue)
+	})
+}
+
+// DeleteIssueReaction removes a reaction from an issue
+func DeleteIssueReaction(ctx *context.APIContext, form api.EditReactionOption) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/issues/{index}/reactions issue issueDeleteIssueReaction
+	// ---
+	// summary: Remove a reaction from an issue or a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: content
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditReactionOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	issue := getReactionIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteIssueReaction(ctx.User, issue, form.Reaction); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteIssueReaction", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetIssueCommentReactions lists the reactions on a comment
+func GetIssueCommentReactions(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issues/comments/{id}/reactions issue issueGetCommentReactions
+	// ---
+	// summary: List the reactions on a comment of an issue or a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the comment
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ReactionList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	comment := getReactionComment(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reactions, err := models.FindCommentReactions(comment)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindCommentReactions", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, reactions.APIFormat())
+}
+
+// PostIssueCommentReaction adds a reaction to a comment
+func PostIssueCommentReaction(ctx *context.APIContext, form api.EditReactionOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/issues/comments/{id}/reactions issue issuePostCommentReaction
+	// ---
+	// summary: Add a reaction to a comment of an issue or a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the comment
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: content
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditReactionOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Reaction"
+	//   "201":
+	//     "$ref": "#/responses/Reaction"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	comment := getReactionComment(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reaction, err := models.CreateCommentReaction(ctx.User, comment.Issue, comment, form.Reaction)
+	writeCreatedReaction(ctx, reaction, err, func() (models.ReactionList, error) {
+		return models.FindCommentReactions(comment)
+	})
+}
+
+// DeleteIssueCommentReaction removes a reaction from a comment
+func DeleteIssueCommentReaction(ctx *context.APIContext, form api.EditReactionOption) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/issues/comments/{id}/reactions issue issueDeleteCommentReaction
+	// ---
+	// summary: Remove a reaction from a comment of an issue or a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the comment
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: content
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditReactionOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	comment := getReactionComment(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteCommentReaction(ctx.User, comment.Issue, comment, form.Reaction); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteCommentReaction", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// getReactionIssue returns the issue of the index in the route if the user can read it
+func getReactionIssue(ctx *context.APIContext) *models.Issue {
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return nil
+	}
+	if !ctx.Repo.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.Error(http.StatusForbidden, "GetIssueByIndex", "no permission to read the issue")
+		return nil
+	}
+	return issue
+}
+
+// getReactionComment returns the comment of the id in the route, with its issue, if the user can read it
+func getReactionComment(ctx *context.APIContext) *models.Comment {
+	comment, err := models.GetCommentByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCommentNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCommentByID", err)
+		}
+		return nil
+	}
+	if err := comment.LoadIssue(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssue", err)
+		return nil
+	}
+	if comment.Issue.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound()
+		return nil
+	}
+	if !ctx.Repo.CanReadIssuesOrPulls(comment.Issue.IsPull) {
+		ctx.Error(http.StatusForbidden, "GetCommentByID", "no permission to read the comment")
+		return nil
+	}
+	return comment
+}
+
+// writeCreatedReaction writes the reaction created, or the existing one found in the reactions if the user
+// already reacted the same way
+func writeCreatedReaction(ctx *context.APIContext, reaction *models.Reaction, err error, reactions func() (models.ReactionList, error)) {
+	if err == nil {
+		if err := reaction.LoadUser(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadUser", err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, reaction.APIFormat())
+		return
+	}
+
+	switch {
+	case models.IsErrForbiddenIssueReaction(err):
+		ctx.Error(http.StatusUnprocessableEntity, "CreateReaction", err)
+	case models.IsErrReactionAlreadyExist(err):
+		reactionType := err.(models.ErrReactionAlreadyExist).Reaction
+		list, err := reactions()
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "FindReactions", err)
+			return
+		}
+		for _, existing := range list {
+			if existing.UserID == ctx.User.ID && existing.Type == reactionType {
+				ctx.JSON(http.StatusOK, existing.APIFormat())
+				return
+			}
+		}
+		ctx.NotFound()
+	default:
+		ctx.Error(http.StatusInternalServerError, "CreateReaction", err)
+	}
+}
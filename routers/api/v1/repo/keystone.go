@@ -0,0 +1,165 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetKeystone get the keystone of a repository
+func GetKeystone(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/keystone repository repoGetKeystone
+	// ---
+	// summary: Get the KEYSTONE and the BLUEPRINT of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Keystone"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	gitRepo, err := git.OpenRepository(ctx.Repo.Repository.RepoPath())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OpenRepository", err)
+		return
+	}
+	defer gitRepo.Close()
+
+	commit, err := repofiles.GetKeystoneCommit(gitRepo)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetKeystoneCommit", err)
+		}
+		return
+	}
+
+	contents := make([]string, len(repofiles.KeystonePages))
+	for i, page := range repofiles.KeystonePages {
+		data, err := repofiles.GetKeystonePageContent(commit, page)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetKeystonePageContent", err)
+			return
+		}
+		contents[i] = string(data)
+	}
+
+	ctx.JSON(http.StatusOK, &api.Keystone{
+		Ref:       repofiles.KeystoneRefName,
+		SHA:       commit.ID.String(),
+		Keystone:  contents[0],
+		Blueprint: contents[1],
+		HTMLURL:   ctx.Repo.Repository.HTMLURL() + "/keystone",
+		Updated:   commit.Committer.When,
+	})
+}
+
+// EditKeystonePage edit a document of the keystone of a repository
+func EditKeystonePage(ctx *context.APIContext, form api.EditKeystonePageOption) {
+	// swagger:operation PUT /repos/{owner}/{repo}/keystone/{page} repository repoEditKeystonePage
+	// ---
+	// summary: Edit a document of the keystone of a repository, the keystone is created if it does not exist yet
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: path
+	//   description: document of the keystone
+	//   type: string
+	//   enum: [keystone, blueprint]
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditKeystonePageOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/FileResponse"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	page, ok := repofiles.GetKeystonePage(ctx.Params(":page"))
+	if !ok {
+		ctx.NotFound()
+		return
+	}
+
+	if ctx.Repo.Repository.IsEmpty {
+		ctx.Error(http.StatusUnprocessableEntity, "", "the keystone can not be created in an empty repository")
+		return
+	}
+	if form.NewBranchName != "" && !ctx.Repo.CanWrite(models.UnitTypeCode) {
+		ctx.Error(http.StatusForbidden, "", "proposing a change on a new branch requires write access to the code")
+		return
+	}
+	if err := repofiles.InitKeystone(ctx.Repo.Repository, ctx.User); err != nil {
+		ctx.Error(http.StatusInternalServerError, "InitKeystone", err)
+		return
+	}
+
+	fileResponse, err := repofiles.UpdateKeystonePage(ctx.Repo.Repository, ctx.User, page, &repofiles.UpdateKeystonePageOptions{
+		NewBranch: form.NewBranchName,
+		Message:   form.Message,
+		Content:   form.Content,
+	})
+	if err != nil {
+		if models.IsErrBranchAlreadyExists(err) {
+			ctx.Error(http.StatusConflict, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "UpdateKeystonePage", err)
+		}
+		return
+	}
+
+	if form.NewBranchName != "" && ctx.Repo.Repository.UnitEnabled(models.UnitTypePullRequests) {
+		title := strings.SplitN(strings.TrimSpace(form.Message), "\n", 2)[0]
+		if title == "" {
+			title = "Update " + page.FileName
+		}
+		if _, err := repofiles.NewKeystoneProposal(ctx.Repo.Repository, ctx.User, form.NewBranchName, title, ""); err != nil {
+			ctx.Error(http.StatusInternalServerError, "NewKeystoneProposal", err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, fileResponse)
+}
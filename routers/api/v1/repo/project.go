@@ -0,0 +1,894 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// ListProjects list the projects of a repository
+func ListProjects(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects repository repoListProjects
+	// ---
+	// summary: List a repository's projects
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open and closed. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+	var projects []*models.Project
+	var err error
+	isClosed := ctx.Query("state") == string(api.StateClosed)
+	if repoID, orgID := getProjectsOwner(ctx); orgID > 0 {
+		projects, err = models.GetOrgProjects(orgID, ctx.QueryInt("page"), isClosed, ctx.Query("sort"))
+	} else {
+		projects, err = models.GetProjects(repoID, ctx.QueryInt("page"), isClosed, ctx.Query("sort"))
+	}
+	if err != nil {
+		ctx.Error(500, "GetProjects", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, len(projects))
+	for i := range projects {
+		if err = projects[i].LoadCreator(); err != nil {
+			ctx.Error(500, "LoadCreator", err)
+			return
+		}
+		apiProjects[i] = projects[i].APIFormat()
+	}
+	ctx.JSON(200, &apiProjects)
+}
+
+// GetProject get a project of a repository
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id} repository repoGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(200, project.APIFormat())
+}
+
+// CreateProject create a project for a repository
+func CreateProject(ctx *context.APIContext, form api.CreateProjectOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects repository repoCreateProject
+	// ---
+	// summary: Create a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	template := models.ProjectColumnTemplateNone
+	if len(form.ColumnTemplate) > 0 {
+		var ok bool
+		if template, ok = models.ProjectColumnTemplateFromName(form.ColumnTemplate); !ok {
+			ctx.Error(422, "", "unknown column template: "+form.ColumnTemplate)
+			return
+		}
+	}
+
+	repoID, orgID := getProjectsOwner(ctx)
+	project := &models.Project{
+		RepoID:      repoID,
+		OwnerID:     orgID,
+		CreatorID:   ctx.User.ID,
+		Creator:     ctx.User,
+		Title:       form.Title,
+		Description: form.Description,
+	}
+	if err := models.NewProject(project, template); err != nil {
+		ctx.Error(500, "NewProject", err)
+		return
+	}
+	ctx.JSON(201, project.APIFormat())
+}
+
+// EditProject modify a project of a repository
+func EditProject(ctx *context.APIContext, form api.EditProjectOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id} repository repoEditProject
+	// ---
+	// summary: Update a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		project.Title = *form.Title
+	}
+	if form.Description != nil {
+		project.Description = *form.Description
+	}
+	if err := models.UpdateProject(project); err != nil {
+		ctx.Error(500, "UpdateProject", err)
+		return
+	}
+
+	if form.State != nil {
+		isClosed := *form.State == string(api.StateClosed)
+		if isClosed != project.IsClosed {
+			if err := models.ChangeProjectStatus(project, isClosed); err != nil {
+				ctx.Error(500, "ChangeProjectStatus", err)
+				return
+			}
+		}
+	}
+	ctx.JSON(200, project.APIFormat())
+}
+
+// DeleteProject delete a project of a repository
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id} repository repoDeleteProject
+	// ---
+	// summary: Delete a project with its columns and cards
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	var err error
+	if repoID, orgID := getProjectsOwner(ctx); orgID > 0 {
+		err = models.DeleteProjectByOwnerID(orgID, ctx.ParamsInt64(":id"))
+	} else {
+		err = models.DeleteProjectByRepoID(repoID, ctx.ParamsInt64(":id"))
+	}
+	if err != nil {
+		ctx.Error(500, "DeleteProject", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListProjectColumns list the columns of a project
+func ListProjectColumns(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id}/columns repository repoListProjectColumns
+	// ---
+	// summary: List the columns of a project in order
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumnList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	columns, err := models.GetProjectColumns(project.ID)
+	if err != nil {
+		ctx.Error(500, "GetProjectColumns", err)
+		return
+	}
+	apiColumns := make([]*api.ProjectColumn, len(columns))
+	for i := range columns {
+		apiColumns[i] = columns[i].APIFormat()
+	}
+	ctx.JSON(200, &apiColumns)
+}
+
+// CreateProjectColumn add a column to a project
+func CreateProjectColumn(ctx *context.APIContext, form api.CreateProjectColumnOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/columns repository repoCreateProjectColumn
+	// ---
+	// summary: Add a column after the existing columns of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectColumnOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	column := &models.ProjectColumn{
+		ProjectID: project.ID,
+		CreatorID: ctx.User.ID,
+		Title:     form.Title,
+	}
+	if err := models.NewProjectColumn(column); err != nil {
+		ctx.Error(500, "NewProjectColumn", err)
+		return
+	}
+	ctx.JSON(201, column.APIFormat())
+}
+
+// EditProjectColumn modify a column of a project
+func EditProjectColumn(ctx *context.APIContext, form api.EditProjectColumnOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id}/columns/{column} repository repoEditProjectColumn
+	// ---
+	// summary: Rename, move or make a column the default column of its project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectColumnOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	column := getProjectColumnByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		column.Title = *form.Title
+		if err := models.UpdateProjectColumn(column); err != nil {
+			ctx.Error(500, "UpdateProjectColumn", err)
+			return
+		}
+	}
+
+	if form.Default != nil && *form.Default && !column.IsDefault {
+		if err := models.SetDefaultProjectColumn(column); err != nil {
+			ctx.Error(500, "SetDefaultProjectColumn", err)
+			return
+		}
+	}
+
+	if form.Position != nil {
+		columns, err := models.GetProjectColumns(column.ProjectID)
+		if err != nil {
+			ctx.Error(500, "GetProjectColumns", err)
+			return
+		}
+		ids := make([]int64, 0, len(columns))
+		for _, c := range columns {
+			if c.ID != column.ID {
+				ids = append(ids, c.ID)
+			}
+		}
+		ids, err = insertProjectID(ids, column.ID, *form.Position)
+		if err != nil {
+			ctx.Error(422, "", err)
+			return
+		}
+		if err = models.SortProjectColumns(column.ProjectID, ids); err != nil {
+			ctx.Error(500, "SortProjectColumns", err)
+			return
+		}
+		if column, err = models.GetProjectColumn(column.ProjectID, column.ID); err != nil {
+			ctx.Error(500, "GetProjectColumn", err)
+			return
+		}
+	}
+	ctx.JSON(200, column.APIFormat())
+}
+
+// DeleteProjectColumn delete a column of a project
+func DeleteProjectColumn(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id}/columns/{column} repository repoDeleteProjectColumn
+	// ---
+	// summary: Delete a column, its cards are moved to the default column
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	column := getProjectColumnByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectColumn(column); err != nil {
+		if models.IsErrProjectColumnIsDefault(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "DeleteProjectColumn", err)
+		}
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListProjectCards list the cards of a project
+func ListProjectCards(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id}/cards repository repoListProjectCards
+	// ---
+	// summary: List the cards of a project ordered by their position in the columns
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	cards, err := models.GetProjectCards(project.ID)
+	if err != nil {
+		ctx.Error(500, "GetProjectCards", err)
+		return
+	}
+	if err = cards.LoadIssues(); err != nil {
+		ctx.Error(500, "LoadIssues", err)
+		return
+	}
+	perms := make(map[int64]models.Permission)
+	apiCards := make([]*api.ProjectCard, 0, len(cards))
+	for _, card := range cards {
+		if card.Issue == nil {
+			continue
+		}
+		perm, ok := perms[card.Issue.RepoID]
+		if !ok {
+			if perm, err = getProjectIssuePermission(ctx, card.Issue); err != nil {
+				ctx.Error(500, "GetUserRepoPermission", err)
+				return
+			}
+			perms[card.Issue.RepoID] = perm
+		}
+		if perm.CanReadIssuesOrPulls(card.Issue.IsPull) {
+			apiCards = append(apiCards, card.APIFormat())
+		}
+	}
+	ctx.JSON(200, &apiCards)
+}
+
+// CreateProjectCard add an issue or a pull request to a project
+func CreateProjectCard(ctx *context.APIContext, form api.CreateProjectCardOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/cards repository repoCreateProjectCard
+	// ---
+	// summary: Add an issue or a pull request to the end of a column of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectCardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := getProjectIssue(ctx, project, form.Repo, form.IssueIndex)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "getProjectIssue", err)
+		}
+		return
+	}
+
+	card, err := issue_service.AddToProject(ctx.User, project, issue, form.ColumnID)
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.NotFound()
+		} else if models.IsErrProjectCardAlreadyExist(err) {
+			ctx.Error(409, "", err)
+		} else {
+			ctx.Error(500, "AddToProject", err)
+		}
+		return
+	}
+	if err = issue.LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(201, card.APIFormat())
+}
+
+// MoveProjectCard move a card of a project
+func MoveProjectCard(ctx *context.APIContext, form api.MoveProjectCardOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id}/cards/{card} repository repoMoveProjectCard
+	// ---
+	// summary: Move a card to a position in a column of its project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: card
+	//   in: path
+	//   description: id of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	card := getProjectCardByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	column, err := models.GetProjectColumn(card.ProjectID, form.ColumnID)
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "GetProjectColumn", err)
+		}
+		return
+	}
+
+	cards, err := models.GetProjectColumnCards(column.ID)
+	if err != nil {
+		ctx.Error(500, "GetProjectColumnCards", err)
+		return
+	}
+	ids := make([]int64, 0, len(cards)+1)
+	for _, c := range cards {
+		if c.ID != card.ID {
+			ids = append(ids, c.ID)
+		}
+	}
+	position := len(ids)
+	if form.Position != nil {
+		position = *form.Position
+	}
+	if ids, err = insertProjectID(ids, card.ID, position); err != nil {
+		ctx.Error(422, "", err)
+		return
+	}
+
+	if err = issue_service.MoveProjectCards(ctx.User, column, ids); err != nil {
+		ctx.Error(500, "MoveProjectCards", err)
+		return
+	}
+
+	if card, err = models.GetProjectCard(card.ProjectID, card.ID); err != nil {
+		ctx.Error(500, "GetProjectCard", err)
+		return
+	}
+	if err = models.ProjectCardList([]*models.ProjectCard{card}).LoadIssues(); err != nil {
+		ctx.Error(500, "LoadIssues", err)
+		return
+	}
+	ctx.JSON(200, card.APIFormat())
+}
+
+// DeleteProjectCard remove an issue or a pull request from a project
+func DeleteProjectCard(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id}/cards/{card} repository repoDeleteProjectCard
+	// ---
+	// summary: Remove an issue or a pull request from a project
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: card
+	//   in: path
+	//   description: id of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	card := getProjectCardByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	project, err := models.GetProjectByID(card.ProjectID)
+	if err != nil {
+		ctx.Error(500, "GetProjectByID", err)
+		return
+	}
+	if err = issue_service.RemoveFromProject(ctx.User, project, card); err != nil {
+		ctx.Error(500, "RemoveFromProject", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// getProjectsOwner returns the repository or the organization whose projects are handled,
+// the project endpoints of organizations share the handlers of repositories.
+func getProjectsOwner(ctx *context.APIContext) (repoID, orgID int64) {
+	if ctx.Repo.Repository != nil {
+		return ctx.Repo.Repository.ID, 0
+	}
+	return 0, ctx.Org.Organization.ID
+}
+
+func getProjectByParams(ctx *context.APIContext) *models.Project {
+	var project *models.Project
+	var err error
+	if repoID, orgID := getProjectsOwner(ctx); orgID > 0 {
+		project, err = models.GetProjectByOwnerID(orgID, ctx.ParamsInt64(":id"))
+	} else {
+		project, err = models.GetProjectByRepoID(repoID, ctx.ParamsInt64(":id"))
+	}
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetProject", err)
+		}
+		return nil
+	}
+	if err = project.LoadCreator(); err != nil {
+		ctx.Error(500, "LoadCreator", err)
+		return nil
+	}
+	return project
+}
+
+func getProjectColumnByParams(ctx *context.APIContext) *models.ProjectColumn {
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return nil
+	}
+	column, err := models.GetProjectColumn(project.ID, ctx.ParamsInt64(":column"))
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetProjectColumn", err)
+		}
+		return nil
+	}
+	return column
+}
+
+func getProjectCardByParams(ctx *context.APIContext) *models.ProjectCard {
+	project := getProjectByParams(ctx)
+	if ctx.Written() {
+		return nil
+	}
+	card, err := models.GetProjectCard(project.ID, ctx.ParamsInt64(":card"))
+	if err != nil {
+		if models.IsErrProjectCardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetProjectCard", err)
+		}
+		return nil
+	}
+	return card
+}
+
+// getProjectIssuePermission returns the permission of the user in the repository of the issue
+func getProjectIssuePermission(ctx *context.APIContext, issue *models.Issue) (models.Permission, error) {
+	if ctx.Repo.Repository != nil && issue.RepoID == ctx.Repo.Repository.ID {
+		return ctx.Repo.Permission, nil
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return models.Permission{}, err
+	}
+	return models.GetUserRepoPermission(issue.Repo, ctx.User)
+}
+
+// getProjectIssue returns the issue or pull request which can be added to the project,
+// repoName selects the repository of the issue for projects of organizations.
+func getProjectIssue(ctx *context.APIContext, project *models.Project, repoName string, index int64) (*models.Issue, error) {
+	repoID := project.RepoID
+	if project.IsOrganizationProject() {
+		repo, err := models.GetRepositoryByName(project.OwnerID, repoName)
+		if err != nil {
+			if models.IsErrRepoNotExist(err) {
+				return nil, models.ErrIssueNotExist{Index: index}
+			}
+			return nil, err
+		}
+		repoID = repo.ID
+	}
+
+	issue, err := models.GetIssueByIndex(repoID, index)
+	if err != nil {
+		return nil, err
+	}
+	perm, err := getProjectIssuePermission(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		return nil, models.ErrIssueNotExist{RepoID: repoID, Index: index}
+	}
+	return issue, nil
+}
+
+// insertProjectID inserts id into ids at the zero based position
+func insertProjectID(ids []int64, id int64, position int) ([]int64, error) {
+	if position < 0 || position > len(ids) {
+		return nil, fmt.Errorf("position %d is out of range [0, %d]", position, len(ids))
+	}
+	ids = append(ids, 0)
+	copy(ids[position+1:], ids[position:])
+	ids[position] = id
+	return ids, nil
+}
@@ -520,6 +520,71 @@ func IsPullRequestMerged(ctx *context.APIContext) {
 	ctx.NotFound()
 }
 
+// ListCompetingPullRequests lists the open pull requests changing the same binary files as a pull request,
+// and the files of the base branch colliding with the ones it adds
+func ListCompetingPullRequests(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/competing repository repoListCompetingPullRequests
+	// ---
+	// summary: List the open pull requests changing the same binary files as a pull request or colliding with their names
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CompetingPullRequestList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPullRequestByIndex", err)
+		}
+		return
+	}
+
+	competing, err := pull_service.FindCompetingPullRequests(pr)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindCompetingPullRequests", err)
+		return
+	}
+
+	apiCompeting := make([]*api.CompetingPullRequest, 0, len(competing))
+	for _, c := range competing {
+		collisions := make([]*api.BinaryCollision, 0, len(c.Collisions))
+		for _, collision := range c.Collisions {
+			collisions = append(collisions, &api.BinaryCollision{
+				Type:      string(collision.Type),
+				Path:      collision.Path,
+				OtherPath: collision.OtherPath,
+			})
+		}
+		apiPull := &api.CompetingPullRequest{Collisions: collisions}
+		if c.PullRequest != nil {
+			apiPull.PullRequest = c.PullRequest.APIFormat()
+		}
+		apiCompeting = append(apiCompeting, apiPull)
+	}
+	ctx.JSON(http.StatusOK, apiCompeting)
+}
+
 // MergePullRequest merges a PR given an index
 func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/merge repository repoMergePullRequest
@@ -0,0 +1,927 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// ListPullImageComments lists the comments on regions of images of a pull request
+func ListPullImageComments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/image_comments repository repoListPullImageComments
+	// ---
+	// summary: List the comments on regions of images of a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewImageCommentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	issue := getPullIssueByIndex(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	comments, err := models.FindComments(models.FindCommentsOptions{
+		IssueID: issue.ID,
+		Type:    models.CommentTypeCode,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindComments", err)
+		return
+	}
+
+	apiComments := make([]*api.PullReviewImageComment, 0, len(comments))
+	for _, comment := range comments {
+		if !comment.IsRegionComment() {
+			continue
+		}
+		if comment.ReviewID != 0 {
+			if err := comment.LoadReview(); err != nil {
+				ctx.Error(http.StatusInternalServerError, "LoadReview", err)
+				return
+			}
+			// only the reviewer can see the comments of a pending review
+			if comment.Review.Type == models.ReviewTypePending && (ctx.User == nil || ctx.User.ID != comment.Review.ReviewerID) {
+				continue
+			}
+		}
+		if err := comment.LoadPoster(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadPoster", err)
+			return
+		}
+		apiComments = append(apiComments, comment.APIRegionFormat())
+	}
+	ctx.JSON(http.StatusOK, &apiComments)
+}
+
+// CreatePullImageComment comments on a region of an image of a pull request
+func CreatePullImageComment(ctx *context.APIContext, form api.CreatePullReviewImageCommentOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/image_comments repository repoCreatePullImageComment
+	// ---
+	// summary: Comment on a region of an image in the head of a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreatePullReviewImageCommentOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PullReviewImageComment"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue := getPullIssueByIndex(ctx)
+	if ctx.Written() {
+		return
+	}
+	if issue.IsLocked && !ctx.Repo.CanWrite(models.UnitTypePullRequests) && !ctx.User.IsAdmin {
+		ctx.Error(http.StatusForbidden, "CreatePullImageComment", "pull request is locked")
+		return
+	}
+
+	region := models.CommentRegion{
+		X:      form.Region.X,
+		Y:      form.Region.Y,
+		Width:  form.Region.Width,
+		Height: form.Region.Height,
+	}
+	if !region.IsValid() {
+		ctx.Error(http.StatusUnprocessableEntity, "CreatePullImageComment", "the region must be inside of the image")
+		return
+	}
+
+	comment, err := pull_service.CreateImageComment(ctx.User, issue, region, form.Body, form.Path, false, 0)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateImageComment", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateImageComment", err)
+		}
+		return
+	}
+	if err := comment.LoadPoster(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadPoster", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, comment.APIRegionFormat())
+}
+
+// getPullIssueByIndex returns the issue of the pull request of the index in the route
+func getPullIssueByIndex(ctx *context.APIContext) *models.Issue {
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPullRequestByIndex", err)
+		}
+		return nil
+	}
+	if err := pr.LoadIssue(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssue", err)
+		return nil
+	}
+	pr.Issue.Repo = ctx.Repo.Repository
+	return pr.Issue
+}
+
+// ListPullReviews lists the reviews of a pull request
+func ListPullReviews(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews repository repoListPullReviews
+	// ---
+	// summary: List the reviews of a pull request, the requested reviews included
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	issue, headCommitID := getPullIssueAndHead(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reviews, err := models.FindReviews(models.FindReviewOptions{
+		Type:    models.ReviewTypeUnknown,
+		IssueID: issue.ID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindReviews", err)
+		return
+	}
+
+	apiReviews := make([]*api.PullReview, 0, len(reviews))
+	for _, review := range reviews {
+		if !canSeePullReview(ctx, review) {
+			continue
+		}
+		apiReview := toAPIPullReview(ctx, issue, review, headCommitID)
+		if ctx.Written() {
+			return
+		}
+		apiReviews = append(apiReviews, apiReview)
+	}
+	ctx.JSON(http.StatusOK, &apiReviews)
+}
+
+// GetPullReview gets a review of a pull request
+func GetPullReview(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoGetPullReview
+	// ---
+	// summary: Get a review of a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	issue, review, headCommitID := getPullReview(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiReview := toAPIPullReview(ctx, issue, review, headCommitID)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// ListPullReviewComments lists the comments on lines of a review of a pull request
+func ListPullReviewComments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/comments repository repoListPullReviewComments
+	// ---
+	// summary: List the comments on lines of a review of a pull request, the comments on images are listed with the image comments
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewCommentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	issue, review, _ := getPullReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	comments, err := models.FindComments(models.FindCommentsOptions{
+		IssueID:  issue.ID,
+		ReviewID: review.ID,
+		Type:     models.CommentTypeCode,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindComments", err)
+		return
+	}
+
+	apiComments := make([]*api.PullReviewComment, 0, len(comments))
+	for _, comment := range comments {
+		if comment.IsRegionComment() {
+			continue
+		}
+		if err := comment.LoadPoster(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadPoster", err)
+			return
+		}
+		comment.Issue = issue
+		apiComments = append(apiComments, comment.APIReviewFormat())
+	}
+	ctx.JSON(http.StatusOK, &apiComments)
+}
+
+// CreatePullReview creates a review of a pull request
+func CreatePullReview(ctx *context.APIContext, form api.CreatePullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews repository repoCreatePullReview
+	// ---
+	// summary: Create a review of a pull request with comments on lines, the review is submitted unless its event is PENDING
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreatePullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue, headCommitID := getPullIssueAndHead(ctx)
+	if ctx.Written() {
+		return
+	}
+	if issue.IsLocked && !ctx.Repo.CanWrite(models.UnitTypePullRequests) && !ctx.User.IsAdmin {
+		ctx.Error(http.StatusForbidden, "CreatePullReview", "pull request is locked")
+		return
+	}
+
+	reviewType := models.ReviewTypePending
+	if form.Event != api.ReviewStateUnknown {
+		reviewType = models.ToReviewType(form.Event)
+	}
+	if !checkPullReviewType(ctx, issue, reviewType, true) {
+		return
+	}
+	if reviewType != models.ReviewTypePending && reviewType != models.ReviewTypeApprove &&
+		len(strings.TrimSpace(form.Body)) == 0 && len(form.Comments) == 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "CreatePullReview", "the review needs a body or comments")
+		return
+	}
+	for _, c := range form.Comments {
+		if c.Path == "" || len(strings.TrimSpace(c.Body)) == 0 || (c.NewLineNum > 0) == (c.OldLineNum > 0) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreatePullReview", "a comment needs a path, a body and either a new or an old line")
+			return
+		}
+	}
+
+	commitID := headCommitID
+	if form.CommitID != "" {
+		commit, err := ctx.Repo.GitRepo.GetCommit(form.CommitID)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "GetCommit", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetCommit", err)
+			}
+			return
+		}
+		commitID = commit.ID.String()
+	}
+
+	// a reviewer has a single pending review
+	if _, err := models.GetCurrentReview(ctx.User, issue); err == nil {
+		ctx.Error(http.StatusUnprocessableEntity, "CreatePullReview", "a pending review already exists")
+		return
+	} else if !models.IsErrReviewNotExist(err) {
+		ctx.Error(http.StatusInternalServerError, "GetCurrentReview", err)
+		return
+	}
+
+	review, err := models.CreateReview(models.CreateReviewOptions{
+		Type:     models.ReviewTypePending,
+		Content:  form.Body,
+		Issue:    issue,
+		Reviewer: ctx.User,
+		CommitID: commitID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CreateReview", err)
+		return
+	}
+	for _, c := range form.Comments {
+		line := c.NewLineNum
+		if c.OldLineNum > 0 {
+			line = -c.OldLineNum
+		}
+		if _, err := pull_service.CreateCodeComment(ctx.User, issue, line, c.Body, c.Path, true, 0); err != nil {
+			ctx.Error(http.StatusInternalServerError, "CreateCodeComment", err)
+			return
+		}
+	}
+
+	if reviewType != models.ReviewTypePending {
+		if review, _, err = pull_service.SubmitReview(ctx.User, issue, reviewType, form.Body, commitID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SubmitReview", err)
+			return
+		}
+	}
+
+	apiReview := toAPIPullReview(ctx, issue, review, headCommitID)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// SubmitPullReview submits a pending review of a pull request
+func SubmitPullReview(ctx *context.APIContext, form api.SubmitPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoSubmitPullReview
+	// ---
+	// summary: Submit a pending review of a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SubmitPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue, review, headCommitID := getPullReview(ctx)
+	if ctx.Written() {
+		return
+	}
+	if review.Type != models.ReviewTypePending || review.ReviewerID != ctx.User.ID {
+		ctx.Error(http.StatusUnprocessableEntity, "SubmitPullReview", "only your pending reviews can be submitted")
+		return
+	}
+
+	reviewType := models.ToReviewType(form.Event)
+	if !checkPullReviewType(ctx, issue, reviewType, false) {
+		return
+	}
+	body := form.Body
+	if body == "" {
+		body = review.Content
+	}
+
+	review, _, err := pull_service.SubmitReview(ctx.User, issue, reviewType, body, "")
+	if err != nil {
+		if models.IsContentEmptyErr(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "SubmitReview", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "SubmitReview", err)
+		}
+		return
+	}
+
+	apiReview := toAPIPullReview(ctx, issue, review, headCommitID)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// DeletePullReview deletes a pending review of a pull request
+func DeletePullReview(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoDeletePullReview
+	// ---
+	// summary: Delete a pending review of a pull request with its comments
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	_, review, _ := getPullReview(ctx)
+	if ctx.Written() {
+		return
+	}
+	if review.Type != models.ReviewTypePending || review.ReviewerID != ctx.User.ID {
+		ctx.Error(http.StatusUnprocessableEntity, "DeletePullReview", "only your pending reviews can be deleted")
+		return
+	}
+
+	if err := models.DeletePendingReview(review); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeletePendingReview", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// DismissPullReview dismisses an approval or a rejection of a pull request
+func DismissPullReview(ctx *context.APIContext, form api.DismissPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/dismissals repository repoDismissPullReview
+	// ---
+	// summary: Dismiss an approval or a rejection of a pull request, it doesn't count anymore
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/DismissPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue, review, headCommitID := getPullReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if _, err := models.DismissReview(ctx.User, review, form.Message); err != nil {
+		if models.IsErrReviewNotDismissable(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "DismissReview", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DismissReview", err)
+		}
+		return
+	}
+
+	apiReview := toAPIPullReview(ctx, issue, review, headCommitID)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// CreateReviewRequests requests reviews of a pull request from users
+func CreateReviewRequests(ctx *context.APIContext, form api.PullReviewRequestOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoCreatePullReviewRequests
+	// ---
+	// summary: Request reviews of a pull request from users
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/PullReviewRequestOptions"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PullReviewList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue, reviewers := getPullReviewers(ctx, form)
+	if ctx.Written() {
+		return
+	}
+
+	for _, reviewer := range reviewers {
+		if reviewer.ID == issue.PosterID {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateReviewRequests", fmt.Errorf("the poster of the pull request can't review it: %s", reviewer.Name))
+			return
+		}
+		perm, err := models.GetUserRepoPermission(ctx.Repo.Repository, reviewer)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+			return
+		}
+		if !perm.CanRead(models.UnitTypePullRequests) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateReviewRequests", fmt.Errorf("reviewer can't read the pull request: %s", reviewer.Name))
+			return
+		}
+	}
+
+	for _, reviewer := range reviewers {
+		if _, err := pull_service.RequestReview(ctx.User, reviewer, issue); err != nil {
+			ctx.Error(http.StatusInternalServerError, "RequestReview", err)
+			return
+		}
+	}
+
+	reviews, err := models.FindReviews(models.FindReviewOptions{
+		Type:    models.ReviewTypeRequest,
+		IssueID: issue.ID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindReviews", err)
+		return
+	}
+	apiReviews := make([]*api.PullReview, 0, len(reviews))
+	for _, review := range reviews {
+		apiReview := toAPIPullReview(ctx, issue, review, "")
+		if ctx.Written() {
+			return
+		}
+		apiReviews = append(apiReviews, apiReview)
+	}
+	ctx.JSON(http.StatusCreated, &apiReviews)
+}
+
+// DeleteReviewRequests removes the reviews of a pull request requested from users
+func DeleteReviewRequests(ctx *context.APIContext, form api.PullReviewRequestOptions) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoDeletePullReviewRequests
+	// ---
+	// summary: Remove the reviews of a pull request requested from users
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/PullReviewRequestOptions"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	issue, reviewers := getPullReviewers(ctx, form)
+	if ctx.Written() {
+		return
+	}
+
+	for _, reviewer := range reviewers {
+		if _, err := models.RemoveReviewRequest(ctx.User, reviewer, issue); err != nil {
+			ctx.Error(http.StatusInternalServerError, "RemoveReviewRequest", err)
+			return
+		}
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// getPullIssueAndHead returns the issue and the head commit of the pull request of the index in the route
+func getPullIssueAndHead(ctx *context.APIContext) (*models.Issue, string) {
+	issue := getPullIssueByIndex(ctx)
+	if ctx.Written() {
+		return nil, ""
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadPullRequest", err)
+		return nil, ""
+	}
+	headCommitID, err := pull_service.GetHeadCommitID(ctx.Repo.GitRepo, issue.PullRequest)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetHeadCommitID", err)
+		return nil, ""
+	}
+	return issue, headCommitID
+}
+
+// getPullReview returns the issue of the pull request, the review of the id in the route and the head commit
+// of the pull request, the pending reviews of other users aren't found
+func getPullReview(ctx *context.APIContext) (*models.Issue, *models.Review, string) {
+	issue, headCommitID := getPullIssueAndHead(ctx)
+	if ctx.Written() {
+		return nil, nil, ""
+	}
+
+	review, err := models.GetReviewByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrReviewNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetReviewByID", err)
+		}
+		return nil, nil, ""
+	}
+	if review.IssueID != issue.ID || !canSeePullReview(ctx, review) {
+		ctx.NotFound()
+		return nil, nil, ""
+	}
+	return issue, review, headCommitID
+}
+
+// getPullReviewers returns the issue of the pull request and the reviewers of the form, only the poster of the
+// pull request and its writers can change the requested reviews
+func getPullReviewers(ctx *context.APIContext, form api.PullReviewRequestOptions) (*models.Issue, []*models.User) {
+	issue := getPullIssueByIndex(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+	if issue.PosterID != ctx.User.ID && !ctx.Repo.CanWrite(models.UnitTypePullRequests) {
+		ctx.Error(http.StatusForbidden, "ReviewRequests", "only the poster and the writers can request reviews")
+		return nil, nil
+	}
+
+	reviewers := make([]*models.User, 0, len(form.Reviewers))
+	for _, name := range form.Reviewers {
+		reviewer, err := models.GetUserByName(name)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "GetUserByName", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return nil, nil
+		}
+		reviewers = append(reviewers, reviewer)
+	}
+	return issue, reviewers
+}
+
+// canSeePullReview returns true if the review can be seen by the user, a pending review is only seen by its reviewer
+func canSeePullReview(ctx *context.APIContext, review *models.Review) bool {
+	return review.Type != models.ReviewTypePending || (ctx.User != nil && ctx.User.ID == review.ReviewerID)
+}
+
+// checkPullReviewType writes an error if a review of the type can't be made, the poster of the pull request
+// can't approve it or request changes
+func checkPullReviewType(ctx *context.APIContext, issue *models.Issue, reviewType models.ReviewType, allowPending bool) bool {
+	switch reviewType {
+	case models.ReviewTypeComment:
+		return true
+	case models.ReviewTypePending:
+		if allowPending {
+			return true
+		}
+	case models.ReviewTypeApprove, models.ReviewTypeReject:
+		if issue.PosterID == ctx.User.ID {
+			ctx.Error(http.StatusUnprocessableEntity, "ReviewType", "you can't approve your own pull request or request changes on it")
+			return false
+		}
+		return true
+	}
+	ctx.Error(http.StatusUnprocessableEntity, "ReviewType", "invalid review event")
+	return false
+}
+
+// toAPIPullReview converts a review of the pull request to api.PullReview, it is stale if headCommitID isn't
+// the reviewed commit
+func toAPIPullReview(ctx *context.APIContext, issue *models.Issue, review *models.Review, headCommitID string) *api.PullReview {
+	review.Issue = issue
+	if err := review.LoadReviewer(); err != nil {
+		if !models.IsErrUserNotExist(err) {
+			ctx.Error(http.StatusInternalServerError, "LoadReviewer", err)
+			return nil
+		}
+		review.Reviewer = models.NewGhostUser()
+	}
+	apiReview := review.APIFormat()
+	apiReview.Stale = review.CommitID != "" && headCommitID != "" && review.CommitID != headCommitID
+	return apiReview
+}
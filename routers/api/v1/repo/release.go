@@ -7,8 +7,8 @@ package repo
 import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
-	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
 	releaseservice "code.gitea.io/gitea/services/release"
 )
 
@@ -56,20 +56,6 @@ func GetRelease(ctx *context.APIContext) {
 	ctx.JSON(200, release.APIFormat())
 }
 
-func getPagesInfo(ctx *context.APIContext) (int, int) {
-	page := ctx.QueryInt("page")
-	if page == 0 {
-		page = 1
-	}
-	perPage := ctx.QueryInt("per_page")
-	if perPage == 0 {
-		perPage = setting.API.DefaultPagingNum
-	} else if perPage > setting.API.MaxResponseItems {
-		perPage = setting.API.MaxResponseItems
-	}
-	return page, perPage
-}
-
 // ListReleases list a repository's releases
 func ListReleases(ctx *context.APIContext) {
 	// swagger:operation GET /repos/{owner}/{repo}/releases repository repoListReleases
@@ -99,7 +85,7 @@ func ListReleases(ctx *context.APIContext) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/ReleaseList"
-	page, limit := getPagesInfo(ctx)
+	page, limit := utils.GetPagesInfo(ctx)
 	releases, err := models.GetReleasesByRepoID(ctx.Repo.Repository.ID, models.FindReleasesOptions{
 		IncludeDrafts: ctx.Repo.AccessMode >= models.AccessModeWrite,
 		IncludeTags:   false,
@@ -708,7 +708,7 @@ func updateBasicProperties(ctx *context.APIContext, opts api.EditRepoOption) err
 	return nil
 }
 
-// updateRepoUnits updates repo units: Issue settings, Wiki settings, PR settings
+// updateRepoUnits updates repo units: Issue settings, Wiki settings, PR settings, Projects settings, Keystone settings
 func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 	owner := ctx.Repo.Owner
 	repo := ctx.Repo.Repository
@@ -864,6 +864,32 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 		})
 	}
 
+	if opts.HasProjects == nil {
+		// If HasProjects setting not touched, rewrite existing repo unit
+		if unit, err := repo.GetUnit(models.UnitTypeProjects); err == nil {
+			units = append(units, *unit)
+		}
+	} else if *opts.HasProjects {
+		units = append(units, models.RepoUnit{
+			RepoID: repo.ID,
+			Type:   models.UnitTypeProjects,
+			Config: new(models.UnitConfig),
+		})
+	}
+
+	if opts.HasKeystone == nil {
+		// If HasKeystone setting not touched, rewrite existing repo unit
+		if unit, err := repo.GetUnit(models.UnitTypeKeystone); err == nil {
+			units = append(units, *unit)
+		}
+	} else if *opts.HasKeystone {
+		units = append(units, models.RepoUnit{
+			RepoID: repo.ID,
+			Type:   models.UnitTypeKeystone,
+			Config: new(models.UnitConfig),
+		})
+	}
+
 	if err := models.UpdateRepositoryUnits(repo, units); err != nil {
 		ctx.Error(http.StatusInternalServerError, "UpdateRepositoryUnits", err)
 		return err
@@ -0,0 +1,87 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/thumbnail"
+)
+
+// GetThumbnail get a resized preview of an image blob
+func GetThumbnail(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/thumbnails/{sha} repository repoGetThumbnail
+	// ---
+	// summary: Get a PNG thumbnail of an image blob, LFS pointers are resolved
+	// produces:
+	// - image/png
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: sha
+	//   in: path
+	//   description: sha of the blob
+	//   type: string
+	//   required: true
+	// - name: size
+	//   in: query
+	//   description: bounding box of the thumbnail in pixels, must be one of the configured sizes
+	//   type: integer
+	// responses:
+	//   "200":
+	//     description: success
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	if !setting.Thumbnail.Enabled {
+		ctx.NotFound()
+		return
+	}
+
+	blob, err := ctx.Repo.GitRepo.GetBlob(ctx.Params("sha"))
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetBlob", err)
+		}
+		return
+	}
+
+	size := ctx.QueryInt("size")
+	if size == 0 && len(setting.Thumbnail.Sizes) > 0 {
+		size = setting.Thumbnail.Sizes[0]
+	}
+	f, err := thumbnail.OpenForBlob(ctx.Repo.Repository, blob, size)
+	if err != nil {
+		switch err {
+		case thumbnail.ErrInvalidSize, thumbnail.ErrNotImage, thumbnail.ErrSourceTooLarge:
+			ctx.Error(http.StatusUnprocessableEntity, "OpenForBlob", err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "OpenForBlob", err)
+		}
+		return
+	}
+	defer f.Close()
+
+	ctx.Resp.Header().Set("Cache-Control", thumbnail.CacheControl(ctx.Repo.Repository))
+	ctx.Resp.Header().Set("Content-Type", "image/png")
+	ctx.Resp.Header().Set("ETag", fmt.Sprintf(`"%s-%d"`, blob.ID, size))
+	http.ServeContent(ctx.Resp, ctx.Req.Request, "", time.Time{}, f)
+}
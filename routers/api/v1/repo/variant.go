@@ -0,0 +1,433 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+	api "code.gitea.io/gitea/modules/structs"
+	variant_service "code.gitea.io/gitea/services/variant"
+)
+
+// toAPIVariants converts the variants to API format with their activity since timeFrom
+// and their divergence from the default branch
+func toAPIVariants(ctx *context.APIContext, variants models.VariantList, timeFrom time.Time) []*api.Variant {
+	for _, v := range variants {
+		v.Repo = ctx.Repo.Repository
+	}
+	if err := variants.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return nil
+	}
+	stats, err := models.GetVariantsActivityStats(ctx.Repo.Repository, variants, timeFrom)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetVariantsActivityStats", err)
+		return nil
+	}
+
+	apiVariants := make([]*api.Variant, len(variants))
+	for i, v := range variants {
+		apiVariants[i] = v.APIFormat()
+		if s := stats[v.ID]; s != nil {
+			apiVariants[i].Activity = &api.VariantActivity{
+				Since:        timeFrom,
+				Contributors: s.AuthorCount,
+				Commits:      s.CommitCount,
+				ChangedFiles: s.ChangedFiles,
+				Additions:    s.Additions,
+				Deletions:    s.Deletions,
+			}
+		}
+		if ctx.Repo.GitRepo.IsBranchExist(v.BranchName) {
+			divergence, err := repofiles.CountDivergingCommits(ctx.Repo.Repository, v.BranchName)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "CountDivergingCommits", err)
+				return nil
+			}
+			apiVariants[i].CommitsAhead = divergence.Ahead
+			apiVariants[i].CommitsBehind = divergence.Behind
+		}
+	}
+	return apiVariants
+}
+
+// variantActivitySince returns the start of the activity period requested by the since query parameter, one week ago by default
+func variantActivitySince(ctx *context.APIContext) time.Time {
+	if len(ctx.Query("since")) > 0 {
+		if since, err := time.Parse(time.RFC3339, ctx.Query("since")); err == nil {
+			return since
+		}
+	}
+	return time.Now().Add(-time.Hour * 168)
+}
+
+// getVariantByParams returns the variant of the repository with the id in the path
+func getVariantByParams(ctx *context.APIContext) *models.Variant {
+	v, err := models.GetVariantByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrVariantNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetVariantByRepoID", err)
+		}
+		return nil
+	}
+	v.Repo = ctx.Repo.Repository
+	return v
+}
+
+// getEditableVariantByParams returns the variant with the id in the path if the doer owns it or administrates the repository
+func getEditableVariantByParams(ctx *context.APIContext) *models.Variant {
+	v := getVariantByParams(ctx)
+	if ctx.Written() {
+		return nil
+	}
+	if ctx.User.ID != v.OwnerID && !ctx.Repo.IsAdmin() {
+		ctx.Error(http.StatusForbidden, "", "only the owner of the variant or an administrator of the repository can change it")
+		return nil
+	}
+	return v
+}
+
+// ListVariants list the variants of a repository
+func ListVariants(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/variants repository repoListVariants
+	// ---
+	// summary: List a repository's variants
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: sort
+	//   in: query
+	//   description: sort order, Recognised values are newest, oldest, recentupdate and moststars. Defaults to "newest"
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: start of the period the activity is computed over, defaults to one week ago
+	//   type: string
+	//   format: date-time
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/VariantList"
+
+	variants, err := models.GetVariants(ctx.Repo.Repository.ID, ctx.Query("sort"))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetVariants", err)
+		return
+	}
+	apiVariants := toAPIVariants(ctx, variants, variantActivitySince(ctx))
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, &apiVariants)
+}
+
+// GetVariant get a variant of a repository
+func GetVariant(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/variants/{id} repository repoGetVariant
+	// ---
+	// summary: Get a variant
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the variant
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: since
+	//   in: query
+	//   description: start of the period the activity is computed over, defaults to one week ago
+	//   type: string
+	//   format: date-time
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Variant"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	v := getVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiVariants := toAPIVariants(ctx, models.VariantList{v}, variantActivitySince(ctx))
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, apiVariants[0])
+}
+
+// CreateVariant create a variant for a repository
+func CreateVariant(ctx *context.APIContext, form api.CreateVariantOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/variants repository repoCreateVariant
+	// ---
+	// summary: Create a variant, its branch is created from the base branch if it does not exist
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateVariantOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Variant"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	v := &models.Variant{
+		BranchName:  form.Branch,
+		Title:       form.Title,
+		Description: form.Description,
+		CoverPath:   form.CoverPath,
+	}
+	if err := variant_service.CreateVariant(ctx.User, ctx.Repo.Repository, v, form.BaseBranch); err != nil {
+		switch {
+		case models.IsErrVariantAlreadyExists(err),
+			models.IsErrTagAlreadyExists(err),
+			models.IsErrBranchNameConflict(err):
+			ctx.Error(http.StatusConflict, "", err)
+		case models.IsErrVariantInvalidCover(err):
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		case git.IsErrBranchNotExist(err):
+			ctx.NotFound(err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "CreateVariant", err)
+		}
+		return
+	}
+
+	apiVariants := toAPIVariants(ctx, models.VariantList{v}, variantActivitySince(ctx))
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusCreated, apiVariants[0])
+}
+
+// EditVariant edit a variant of a repository
+func EditVariant(ctx *context.APIContext, form api.EditVariantOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/variants/{id} repository repoEditVariant
+	// ---
+	// summary: Edit a variant, only its owner and the administrators of the repository can edit it
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the variant
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditVariantOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Variant"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	v := getEditableVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if form.Title != nil && len(*form.Title) > 0 {
+		v.Title = *form.Title
+	}
+	if form.Description != nil {
+		v.Description = *form.Description
+	}
+	if form.CoverPath != nil {
+		v.CoverPath = *form.CoverPath
+	}
+	if err := variant_service.UpdateVariant(ctx.Repo.Repository, v); err != nil {
+		if models.IsErrVariantInvalidCover(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "UpdateVariant", err)
+		}
+		return
+	}
+
+	apiVariants := toAPIVariants(ctx, models.VariantList{v}, variantActivitySince(ctx))
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, apiVariants[0])
+}
+
+// DeleteVariant delete a variant of a repository
+func DeleteVariant(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/variants/{id} repository repoDeleteVariant
+	// ---
+	// summary: Delete a variant, its branch is kept
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the variant
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	v := getEditableVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.DeleteVariantByRepoID(ctx.Repo.Repository.ID, v.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteVariantByRepoID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// StarVariant star a variant to back it
+func StarVariant(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/variants/{id}/star repository repoStarVariant
+	// ---
+	// summary: Star a variant to back it
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the variant
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	starVariant(ctx, true)
+}
+
+// UnstarVariant unstar a variant
+func UnstarVariant(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/variants/{id}/star repository repoUnstarVariant
+	// ---
+	// summary: Unstar a variant
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the variant
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	starVariant(ctx, false)
+}
+
+func starVariant(ctx *context.APIContext, star bool) {
+	v := getVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.StarVariant(ctx.User.ID, v.ID, star); err != nil {
+		ctx.Error(http.StatusInternalServerError, "StarVariant", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,546 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListWikiPages lists the pages of a repository wiki
+func ListWikiPages(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/wiki/pages repository repoGetWikiPages
+	// ---
+	// summary: List the pages of a wiki with their last commit
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: per_page
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiPageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	if wikiRepo != nil {
+		defer wikiRepo.Close()
+	}
+
+	pages := make([]*api.WikiPageMetaData, 0)
+	if commit == nil {
+		ctx.Header().Set("X-Total-Count", "0")
+		ctx.JSON(http.StatusOK, pages)
+		return
+	}
+
+	entries, err := commit.ListEntries()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ListEntries", err)
+		return
+	}
+	wikiNames := make([]string, 0, len(entries))
+	filenames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsRegular() {
+			continue
+		}
+		wikiName, err := models.WikiFilenameToName(entry.Name())
+		if err != nil {
+			if models.IsErrWikiInvalidFileName(err) {
+				continue
+			}
+			ctx.Error(http.StatusInternalServerError, "WikiFilenameToName", err)
+			return
+		}
+		wikiNames = append(wikiNames, wikiName)
+		filenames = append(filenames, entry.Name())
+	}
+
+	page, perPage := utils.GetPagesInfo(ctx)
+	start := (page - 1) * perPage
+	if start < 0 {
+		start = 0
+	}
+	end := start + perPage
+	if end > len(wikiNames) {
+		end = len(wikiNames)
+	}
+	for i := start; i < end; i++ {
+		lastCommit, err := wikiRepo.GetCommitByPath(filenames[i])
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetCommitByPath", err)
+			return
+		}
+		pages = append(pages, convert.ToWikiPageMetaData(ctx.Repo.Repository, wikiNames[i], lastCommit))
+	}
+
+	ctx.SetLinkHeader(len(wikiNames), perPage)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", len(wikiNames)))
+	ctx.JSON(http.StatusOK, pages)
+}
+
+// GetWikiPage gets the content of a wiki page
+func GetWikiPage(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/wiki/page/{pageName} repository repoGetWikiPage
+	// ---
+	// summary: Get a wiki page with its raw and rendered content
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiPage"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiName := models.NormalizeWikiName(ctx.Params(":pageName"))
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	if wikiRepo != nil {
+		defer wikiRepo.Close()
+	}
+
+	writeWikiPage(ctx, http.StatusOK, wikiRepo, commit, wikiName)
+}
+
+// ListWikiPageRevisions lists the commits of a wiki page
+func ListWikiPageRevisions(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/wiki/revisions/{pageName} repository repoGetWikiPageRevisions
+	// ---
+	// summary: Get the revisions of a wiki page
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of revisions to return (1-based), a page has 50 revisions
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiCommitList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiName := models.NormalizeWikiName(ctx.Params(":pageName"))
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	if wikiRepo != nil {
+		defer wikiRepo.Close()
+	}
+
+	entry := findWikiEntry(ctx, commit, wikiName)
+	if ctx.Written() {
+		return
+	}
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	commitsCount, err := wikiRepo.FileCommitsCount("master", entry.Name())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FileCommitsCount", err)
+		return
+	}
+	commitsHistory, err := wikiRepo.CommitsByFileAndRangeNoFollow("master", entry.Name(), page)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CommitsByFileAndRangeNoFollow", err)
+		return
+	}
+
+	commits := make([]*api.WikiCommit, 0, commitsHistory.Len())
+	for e := commitsHistory.Front(); e != nil; e = e.Next() {
+		commits = append(commits, convert.ToWikiCommit(e.Value.(*git.Commit)))
+	}
+
+	ctx.SetLinkHeader(int(commitsCount), git.CommitsRangeSize)
+	ctx.JSON(http.StatusOK, &api.WikiCommitList{
+		WikiCommits: commits,
+		Count:       commitsCount,
+	})
+}
+
+// CreateWikiPage creates a wiki page
+func CreateWikiPage(ctx *context.APIContext, form api.CreateWikiPageOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/wiki/new repository repoCreateWikiPage
+	// ---
+	// summary: Create a wiki page
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateWikiPageOptions"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/WikiPage"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	if util.IsEmptyString(form.Title) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "title is required")
+		return
+	}
+
+	wikiName := models.NormalizeWikiName(strings.TrimSpace(form.Title))
+	if len(form.Message) == 0 {
+		form.Message = fmt.Sprintf("Add '%s'", wikiName)
+	}
+
+	if err := ctx.Repo.Repository.AddWikiPage(ctx.User, wikiName, form.Content, form.Message); err != nil {
+		if models.IsErrWikiReservedName(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "AddWikiPage", err)
+		} else if models.IsErrWikiAlreadyExist(err) {
+			ctx.Error(http.StatusConflict, "AddWikiPage", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "AddWikiPage", err)
+		}
+		return
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	if wikiRepo != nil {
+		defer wikiRepo.Close()
+	}
+
+	writeWikiPage(ctx, http.StatusCreated, wikiRepo, commit, wikiName)
+}
+
+// EditWikiPage edits the content or the name of a wiki page
+func EditWikiPage(ctx *context.APIContext, form api.CreateWikiPageOptions) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/wiki/page/{pageName} repository repoEditWikiPage
+	// ---
+	// summary: Edit a wiki page
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateWikiPageOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiPage"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	oldWikiName := models.NormalizeWikiName(ctx.Params(":pageName"))
+	newWikiName := oldWikiName
+	if !util.IsEmptyString(form.Title) {
+		newWikiName = models.NormalizeWikiName(strings.TrimSpace(form.Title))
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	entry := findWikiEntry(ctx, commit, oldWikiName)
+	if ctx.Written() {
+		if wikiRepo != nil {
+			wikiRepo.Close()
+		}
+		return
+	}
+	if newWikiName != oldWikiName {
+		if _, err := commit.GetTreeEntryByPath(models.WikiNameToFilename(newWikiName)); err == nil {
+			wikiRepo.Close()
+			ctx.Error(http.StatusConflict, "EditWikiPage", models.ErrWikiAlreadyExist{Title: newWikiName})
+			return
+		} else if !git.IsErrNotExist(err) {
+			wikiRepo.Close()
+			ctx.Error(http.StatusInternalServerError, "GetTreeEntryByPath", err)
+			return
+		}
+	}
+	if len(form.Content) == 0 {
+		content := wikiEntryContent(ctx, entry)
+		if ctx.Written() {
+			wikiRepo.Close()
+			return
+		}
+		form.Content = string(content)
+	}
+	wikiRepo.Close()
+
+	if len(form.Message) == 0 {
+		form.Message = fmt.Sprintf("Update '%s'", newWikiName)
+	}
+
+	if err := ctx.Repo.Repository.EditWikiPage(ctx.User, oldWikiName, newWikiName, form.Content, form.Message); err != nil {
+		if models.IsErrWikiReservedName(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "EditWikiPage", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "EditWikiPage", err)
+		}
+		return
+	}
+
+	wikiRepo, commit = findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	if wikiRepo != nil {
+		defer wikiRepo.Close()
+	}
+
+	writeWikiPage(ctx, http.StatusOK, wikiRepo, commit, newWikiName)
+}
+
+// DeleteWikiPage deletes a wiki page
+func DeleteWikiPage(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/wiki/page/{pageName} repository repoDeleteWikiPage
+	// ---
+	// summary: Delete a wiki page
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiName := models.NormalizeWikiName(ctx.Params(":pageName"))
+
+	if !ctx.Repo.Repository.HasWiki() {
+		ctx.NotFound()
+		return
+	}
+
+	if err := ctx.Repo.Repository.DeleteWikiPage(ctx.User, wikiName); err != nil {
+		if os.IsNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteWikiPage", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// findWikiRepoCommit opens the wiki repository and returns its master commit, the commit is nil if the wiki
+// has no page yet. Writes to ctx if an error occurs.
+func findWikiRepoCommit(ctx *context.APIContext) (*git.Repository, *git.Commit) {
+	if !ctx.Repo.Repository.HasWiki() {
+		return nil, nil
+	}
+
+	wikiRepo, err := git.OpenRepository(ctx.Repo.Repository.WikiPath())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OpenRepository", err)
+		return nil, nil
+	}
+
+	commit, err := wikiRepo.GetBranchCommit("master")
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return wikiRepo, nil
+		}
+		wikiRepo.Close()
+		ctx.Error(http.StatusInternalServerError, "GetBranchCommit", err)
+		return nil, nil
+	}
+	return wikiRepo, commit
+}
+
+// findWikiEntry returns the tree entry of a wiki page. Writes to ctx if the page doesn't exist or an error occurs.
+func findWikiEntry(ctx *context.APIContext, commit *git.Commit, wikiName string) *git.TreeEntry {
+	if commit == nil {
+		ctx.NotFound()
+		return nil
+	}
+
+	entry, err := commit.GetTreeEntryByPath(models.WikiNameToFilename(wikiName))
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetTreeEntryByPath", err)
+		}
+		return nil
+	}
+	if !entry.IsRegular() {
+		ctx.NotFound()
+		return nil
+	}
+	return entry
+}
+
+// wikiEntryContent returns the content of the wiki page of a tree entry. Writes to ctx if an error occurs.
+func wikiEntryContent(ctx *context.APIContext, entry *git.TreeEntry) []byte {
+	reader, err := entry.Blob().DataAsync()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Blob.Data", err)
+		return nil
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ReadAll", err)
+		return nil
+	}
+	return content
+}
+
+// writeWikiPage writes the wiki page with its raw and rendered content and its last commit
+func writeWikiPage(ctx *context.APIContext, status int, wikiRepo *git.Repository, commit *git.Commit, wikiName string) {
+	entry := findWikiEntry(ctx, commit, wikiName)
+	if ctx.Written() {
+		return
+	}
+
+	content := wikiEntryContent(ctx, entry)
+	if ctx.Written() {
+		return
+	}
+
+	lastCommit, err := wikiRepo.GetCommitByPath(entry.Name())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCommitByPath", err)
+		return
+	}
+	commitsCount, err := wikiRepo.FileCommitsCount("master", entry.Name())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FileCommitsCount", err)
+		return
+	}
+
+	repo := ctx.Repo.Repository
+	ctx.JSON(status, &api.WikiPage{
+		WikiPageMetaData: convert.ToWikiPageMetaData(repo, wikiName, lastCommit),
+		Content:          string(content),
+		HTML:             markdown.RenderWiki(content, repo.Link(), repo.ComposeMetas()),
+		CommitCount:      commitsCount,
+	})
+}
@@ -84,3 +84,17 @@ type swaggerIssueDeadline struct {
 	// in:body
 	Body api.IssueDeadline `json:"body"`
 }
+
+// Reaction
+// swagger:response Reaction
+type swaggerResponseReaction struct {
+	// in:body
+	Body api.Reaction `json:"body"`
+}
+
+// ReactionList
+// swagger:response ReactionList
+type swaggerResponseReactionList struct {
+	// in:body
+	Body []api.Reaction `json:"body"`
+}
@@ -120,4 +120,64 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	RepoTopicOptions api.RepoTopicOptions
+
+	// in:body
+	CreatePullReviewImageCommentOption api.CreatePullReviewImageCommentOption
+
+	// in:body
+	CreateProjectOption api.CreateProjectOption
+
+	// in:body
+	EditProjectOption api.EditProjectOption
+
+	// in:body
+	CreateProjectColumnOption api.CreateProjectColumnOption
+
+	// in:body
+	EditProjectColumnOption api.EditProjectColumnOption
+
+	// in:body
+	CreateProjectCardOption api.CreateProjectCardOption
+
+	// in:body
+	MoveProjectCardOption api.MoveProjectCardOption
+
+	// in:body
+	EditKeystonePageOption api.EditKeystonePageOption
+
+	// in:body
+	CreateVariantOption api.CreateVariantOption
+
+	// in:body
+	EditVariantOption api.EditVariantOption
+
+	// in:body
+	ImportAssetsOption api.ImportAssetsOption
+
+	// in:body
+	SetAssetTagsOption api.SetAssetTagsOption
+
+	// in:body
+	CreatePullReviewOptions api.CreatePullReviewOptions
+
+	// in:body
+	SubmitPullReviewOptions api.SubmitPullReviewOptions
+
+	// in:body
+	DismissPullReviewOptions api.DismissPullReviewOptions
+
+	// in:body
+	PullReviewRequestOptions api.PullReviewRequestOptions
+
+	// in:body
+	EditReactionOption api.EditReactionOption
+
+	// in:body
+	CreateBranchProtectionOption api.CreateBranchProtectionOption
+
+	// in:body
+	EditBranchProtectionOption api.EditBranchProtectionOption
+
+	// in:body
+	CreateWikiPageOptions api.CreateWikiPageOptions
 }
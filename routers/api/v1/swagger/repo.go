@@ -36,6 +36,20 @@ type swaggerResponseBranchList struct {
 	Body []api.Branch `json:"body"`
 }
 
+// BranchProtection
+// swagger:response BranchProtection
+type swaggerResponseBranchProtection struct {
+	// in:body
+	Body api.BranchProtection `json:"body"`
+}
+
+// BranchProtectionList
+// swagger:response BranchProtectionList
+type swaggerResponseBranchProtectionList struct {
+	// in:body
+	Body []api.BranchProtection `json:"body"`
+}
+
 // TagList
 // swagger:response TagList
 type swaggerResponseTagList struct {
@@ -127,6 +141,48 @@ type swaggerResponsePullRequestList struct {
 	Body []api.PullRequest `json:"body"`
 }
 
+// CompetingPullRequestList
+// swagger:response CompetingPullRequestList
+type swaggerResponseCompetingPullRequestList struct {
+	// in:body
+	Body []api.CompetingPullRequest `json:"body"`
+}
+
+// PullReviewImageComment
+// swagger:response PullReviewImageComment
+type swaggerResponsePullReviewImageComment struct {
+	// in:body
+	Body api.PullReviewImageComment `json:"body"`
+}
+
+// PullReviewImageCommentList
+// swagger:response PullReviewImageCommentList
+type swaggerResponsePullReviewImageCommentList struct {
+	// in:body
+	Body []api.PullReviewImageComment `json:"body"`
+}
+
+// PullReview
+// swagger:response PullReview
+type swaggerResponsePullReview struct {
+	// in:body
+	Body api.PullReview `json:"body"`
+}
+
+// PullReviewList
+// swagger:response PullReviewList
+type swaggerResponsePullReviewList struct {
+	// in:body
+	Body []api.PullReview `json:"body"`
+}
+
+// PullReviewCommentList
+// swagger:response PullReviewCommentList
+type swaggerResponsePullReviewCommentList struct {
+	// in:body
+	Body []api.PullReviewComment `json:"body"`
+}
+
 // Status
 // swagger:response Status
 type swaggerResponseStatus struct {
@@ -260,3 +316,108 @@ type swaggerTopicNames struct {
 	//in: body
 	Body api.TopicName `json:"body"`
 }
+
+// Project
+// swagger:response Project
+type swaggerProject struct {
+	// in:body
+	Body api.Project `json:"body"`
+}
+
+// ProjectList
+// swagger:response ProjectList
+type swaggerProjectList struct {
+	// in:body
+	Body []api.Project `json:"body"`
+}
+
+// ProjectColumn
+// swagger:response ProjectColumn
+type swaggerProjectColumn struct {
+	// in:body
+	Body api.ProjectColumn `json:"body"`
+}
+
+// ProjectColumnList
+// swagger:response ProjectColumnList
+type swaggerProjectColumnList struct {
+	// in:body
+	Body []api.ProjectColumn `json:"body"`
+}
+
+// ProjectCard
+// swagger:response ProjectCard
+type swaggerProjectCard struct {
+	// in:body
+	Body api.ProjectCard `json:"body"`
+}
+
+// ProjectCardList
+// swagger:response ProjectCardList
+type swaggerProjectCardList struct {
+	// in:body
+	Body []api.ProjectCard `json:"body"`
+}
+
+// Keystone
+// swagger:response Keystone
+type swaggerKeystone struct {
+	// in:body
+	Body api.Keystone `json:"body"`
+}
+
+// Variant
+// swagger:response Variant
+type swaggerVariant struct {
+	// in:body
+	Body api.Variant `json:"body"`
+}
+
+// VariantList
+// swagger:response VariantList
+type swaggerVariantList struct {
+	// in:body
+	Body []api.Variant `json:"body"`
+}
+
+// AssetUsageList
+// swagger:response AssetUsageList
+type swaggerAssetUsageList struct {
+	// in:body
+	Body []api.AssetUsage `json:"body"`
+}
+
+// AssetList
+// swagger:response AssetList
+type swaggerAssetList struct {
+	// in:body
+	Body []api.Asset `json:"body"`
+}
+
+// AssetTags
+// swagger:response AssetTags
+type swaggerAssetTags struct {
+	// in:body
+	Body api.AssetTags `json:"body"`
+}
+
+// WikiPageList
+// swagger:response WikiPageList
+type swaggerWikiPageList struct {
+	// in:body
+	Body []api.WikiPageMetaData `json:"body"`
+}
+
+// WikiPage
+// swagger:response WikiPage
+type swaggerWikiPage struct {
+	// in:body
+	Body api.WikiPage `json:"body"`
+}
+
+// WikiCommitList
+// swagger:response WikiCommitList
+type swaggerWikiCommitList struct {
+	// in:body
+	Body api.WikiCommitList `json:"body"`
+}
@@ -4,7 +4,10 @@
 
 package utils
 
-import "code.gitea.io/gitea/modules/context"
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+)
 
 // UserID user ID of authenticated user, or 0 if not authenticated
 func UserID(ctx *context.APIContext) int64 {
@@ -13,3 +16,19 @@ func UserID(ctx *context.APIContext) int64 {
 	}
 	return ctx.User.ID
 }
+
+// GetPagesInfo returns the page and the page size of a list from the "page" and "per_page" query
+// parameters, the page size is limited to the maximum number of items of a response
+func GetPagesInfo(ctx *context.APIContext) (int, int) {
+	page := ctx.QueryInt("page")
+	if page == 0 {
+		page = 1
+	}
+	perPage := ctx.QueryInt("per_page")
+	if perPage == 0 {
+		perPage = setting.API.DefaultPagingNum
+	} else if perPage > setting.API.MaxResponseItems {
+		perPage = setting.API.MaxResponseItems
+	}
+	return page, perPage
+}
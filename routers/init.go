@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/cron"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/highlight"
+	asset_indexer "code.gitea.io/gitea/modules/indexer/assets"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
@@ -22,6 +23,7 @@ import (
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/ssh"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/services/mailer"
@@ -83,6 +85,9 @@ func GlobalInit() {
 	NewServices()
 
 	if setting.InstallLock {
+		if err := storage.Init(); err != nil {
+			log.Fatal("Failed to initialize storage: %v", err)
+		}
 		highlight.NewContext()
 		external.RegisterParsers()
 		markup.Init()
@@ -101,6 +106,7 @@ func GlobalInit() {
 		// Booting long running goroutines.
 		cron.NewContext()
 		issue_indexer.InitIssueIndexer(false)
+		asset_indexer.InitAssetIndexer(false)
 		models.InitRepoIndexer()
 		mirror_service.InitSyncMirrors()
 		webhook.InitDeliverHooks()
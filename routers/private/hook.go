@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 
 	"gitea.com/macaron/macaron"
@@ -45,6 +46,22 @@ func HookPreReceive(ctx *macaron.Context) {
 		return
 	}
 	repo.OwnerName = ownerName
+
+	// the pushed objects are still in quarantine
+	env := os.Environ()
+	if gitAlternativeObjectDirectories != "" {
+		env = append(env,
+			private.GitAlternativeObjectDirectories+"="+gitAlternativeObjectDirectories)
+	}
+	if gitObjectDirectory != "" {
+		env = append(env,
+			private.GitObjectDirectory+"="+gitObjectDirectory)
+	}
+	if gitQuarantinePath != "" {
+		env = append(env,
+			private.GitQuarantinePath+"="+gitQuarantinePath)
+	}
+
 	protectBranch, err := models.GetProtectedBranchBy(repo.ID, branchName)
 	if err != nil {
 		log.Error("Unable to get protected branch: %s in %-v Error: %v", branchName, repo, err)
@@ -65,20 +82,6 @@ func HookPreReceive(ctx *macaron.Context) {
 
 		// detect force push
 		if git.EmptySHA != oldCommitID {
-			env := os.Environ()
-			if gitAlternativeObjectDirectories != "" {
-				env = append(env,
-					private.GitAlternativeObjectDirectories+"="+gitAlternativeObjectDirectories)
-			}
-			if gitObjectDirectory != "" {
-				env = append(env,
-					private.GitObjectDirectory+"="+gitObjectDirectory)
-			}
-			if gitQuarantinePath != "" {
-				env = append(env,
-					private.GitQuarantinePath+"="+gitQuarantinePath)
-			}
-
 			output, err := git.NewCommand("rev-list", "--max-count=1", oldCommitID, "^"+newCommitID).RunInDirWithEnv(repo.RepoPath(), env)
 			if err != nil {
 				log.Error("Unable to detect force push between: %s and %s in %-v Error: %v", oldCommitID, newCommitID, repo, err)
@@ -126,9 +129,77 @@ func HookPreReceive(ctx *macaron.Context) {
 			return
 		}
 	}
+
+	// reject the changes to files locked by other users
+	if setting.LFS.StartServer && strings.HasPrefix(refFullName, git.BranchPrefix) && newCommitID != git.EmptySHA {
+		locks, err := lockedFilesInPush(repo, userID, oldCommitID, newCommitID, env)
+		if err != nil {
+			log.Error("Unable to check the LFS locks of the push to: %s in %-v Error: %v", branchName, repo, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"err": fmt.Sprintf("Fail to check LFS locks: %v", err),
+			})
+			return
+		}
+		if len(locks) > 0 {
+			files := make([]string, 0, len(locks))
+			for _, lock := range locks {
+				owner := "a deleted user"
+				if lock.Owner != nil {
+					owner = lock.Owner.Name
+				}
+				files = append(files, fmt.Sprintf("%s (locked by %s)", lock.Path, owner))
+			}
+			log.Warn("Forbidden: User %d cannot push to branch: %s in %-v, files are locked by other users: %s", userID, branchName, repo, strings.Join(files, ", "))
+			ctx.JSON(http.StatusForbidden, map[string]interface{}{
+				"err": fmt.Sprintf("files locked by other users can not be changed: %s", strings.Join(files, ", ")),
+			})
+			return
+		}
+	}
 	ctx.PlainText(http.StatusOK, []byte("ok"))
 }
 
+// lockedFilesInPush returns the locks of other users on the files changed by the update of a branch
+func lockedFilesInPush(repo *models.Repository, userID int64, oldCommitID, newCommitID string, env []string) ([]*models.LFSLock, error) {
+	locks, err := models.GetLFSLockByRepoID(repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	others := make(map[string]*models.LFSLock, len(locks))
+	for _, lock := range locks {
+		if lock.OwnerID != userID {
+			others[lock.Path] = lock
+		}
+	}
+	if len(others) == 0 {
+		return nil, nil
+	}
+
+	// The files are compared between the trees of the branch before and after the push, whichever
+	// commits bring the changes. A new branch is compared with the point it forks from the default branch.
+	base := oldCommitID
+	if base == git.EmptySHA {
+		base = git.EmptyTreeSHA
+		stdout, err := git.NewCommand("merge-base", git.BranchPrefix+repo.DefaultBranch, newCommitID).RunInDirWithEnv(repo.RepoPath(), env)
+		if err == nil {
+			base = strings.TrimSpace(stdout)
+		}
+	}
+	stdout, err := git.NewCommand("diff", "--name-only", "--no-renames", "-z", base, newCommitID).RunInDirWithEnv(repo.RepoPath(), env)
+	if err != nil {
+		return nil, err
+	}
+
+	var touched []*models.LFSLock
+	for _, name := range strings.Split(stdout, "\x00") {
+		if lock, ok := others[name]; ok {
+			touched = append(touched, lock)
+			delete(others, name)
+		}
+	}
+	return touched, nil
+}
+
 // HookPostReceive updates services and users
 func HookPostReceive(ctx *macaron.Context) {
 	ownerName := ctx.Params(":owner")
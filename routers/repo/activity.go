@@ -16,34 +16,40 @@ const (
 	tplActivity base.TplName = "repo/activity"
 )
 
-// Activity render the page to show repository latest changes
-func Activity(ctx *context.Context) {
-	ctx.Data["Title"] = ctx.Tr("repo.activity")
-	ctx.Data["PageIsActivity"] = true
-
-	ctx.Data["Period"] = ctx.Params("period")
-
-	timeUntil := time.Now()
-	var timeFrom time.Time
-
-	switch ctx.Data["Period"] {
+// activityTimeFrom returns the start of the activity period ending at timeUntil,
+// an unknown period falls back to a week.
+func activityTimeFrom(timeUntil time.Time, period string) (time.Time, bool) {
+	switch period {
 	case "daily":
-		timeFrom = timeUntil.Add(-time.Hour * 24)
+		return timeUntil.Add(-time.Hour * 24), true
 	case "halfweekly":
-		timeFrom = timeUntil.Add(-time.Hour * 72)
+		return timeUntil.Add(-time.Hour * 72), true
 	case "weekly":
-		timeFrom = timeUntil.Add(-time.Hour * 168)
+		return timeUntil.Add(-time.Hour * 168), true
 	case "monthly":
-		timeFrom = timeUntil.AddDate(0, -1, 0)
+		return timeUntil.AddDate(0, -1, 0), true
 	case "quarterly":
-		timeFrom = timeUntil.AddDate(0, -3, 0)
+		return timeUntil.AddDate(0, -3, 0), true
 	case "semiyearly":
-		timeFrom = timeUntil.AddDate(0, -6, 0)
+		return timeUntil.AddDate(0, -6, 0), true
 	case "yearly":
-		timeFrom = timeUntil.AddDate(-1, 0, 0)
+		return timeUntil.AddDate(-1, 0, 0), true
 	default:
+		return timeUntil.Add(-time.Hour * 168), false
+	}
+}
+
+// Activity render the page to show repository latest changes
+func Activity(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.activity")
+	ctx.Data["PageIsActivity"] = true
+
+	ctx.Data["Period"] = ctx.Params("period")
+
+	timeUntil := time.Now()
+	timeFrom, ok := activityTimeFrom(timeUntil, ctx.Params("period"))
+	if !ok {
 		ctx.Data["Period"] = "weekly"
-		timeFrom = timeUntil.Add(-time.Hour * 168)
 	}
 	ctx.Data["DateFrom"] = timeFrom.Format("January 2, 2006")
 	ctx.Data["DateUntil"] = timeUntil.Format("January 2, 2006")
@@ -64,27 +70,7 @@ func Activity(ctx *context.Context) {
 
 // ActivityAuthors renders JSON with top commit authors for given time period over all branches
 func ActivityAuthors(ctx *context.Context) {
-	timeUntil := time.Now()
-	var timeFrom time.Time
-
-	switch ctx.Params("period") {
-	case "daily":
-		timeFrom = timeUntil.Add(-time.Hour * 24)
-	case "halfweekly":
-		timeFrom = timeUntil.Add(-time.Hour * 72)
-	case "weekly":
-		timeFrom = timeUntil.Add(-time.Hour * 168)
-	case "monthly":
-		timeFrom = timeUntil.AddDate(0, -1, 0)
-	case "quarterly":
-		timeFrom = timeUntil.AddDate(0, -3, 0)
-	case "semiyearly":
-		timeFrom = timeUntil.AddDate(0, -6, 0)
-	case "yearly":
-		timeFrom = timeUntil.AddDate(-1, 0, 0)
-	default:
-		timeFrom = timeUntil.Add(-time.Hour * 168)
-	}
+	timeFrom, _ := activityTimeFrom(time.Now(), ctx.Params("period"))
 
 	var err error
 	authors, err := models.GetActivityStatsTopAuthors(ctx.Repo.Repository, timeFrom, 10)
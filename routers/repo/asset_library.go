@@ -0,0 +1,232 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	asset_indexer "code.gitea.io/gitea/modules/indexer/assets"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	asset_service "code.gitea.io/gitea/services/asset"
+)
+
+const (
+	tplAssetLibrary    base.TplName = "repo/assets/library"
+	tplOrgAssetLibrary base.TplName = "org/assets/library"
+
+	// libraryThumbnailSize is the size of the thumbnails of the library, the closest available size is used
+	libraryThumbnailSize = 256
+)
+
+// libraryFilter is a filter of the asset library with the link toggling it
+type libraryFilter struct {
+	Name     string
+	Count    int64
+	Selected bool
+	Link     string
+}
+
+// libraryLink returns the link to the asset library with the current filters, one of them replaced
+func libraryLink(link string, filters url.Values, key, value string) string {
+	query := url.Values{}
+	for k, v := range filters {
+		query[k] = v
+	}
+	if value == "" {
+		query.Del(key)
+	} else {
+		query.Set(key, value)
+	}
+	if len(query) == 0 {
+		return link
+	}
+	return link + "?" + query.Encode()
+}
+
+// AssetLibrary renders the tagged assets of a repository or of the repositories of an organization
+func AssetLibrary(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.assets.library")
+	ctx.Data["PageIsAssetLibrary"] = true
+
+	var repos []*models.Repository
+	var link string
+	tpl := tplAssetLibrary
+	if len(ctx.Repo.RepoLink) > 0 {
+		repos = []*models.Repository{ctx.Repo.Repository}
+		link = ctx.Repo.RepoLink + "/assets"
+	} else {
+		var err error
+		if repos, err = asset_service.LibraryRepositories(ctx.Org.Organization, ctx.User); err != nil {
+			ctx.ServerError("LibraryRepositories", err)
+			return
+		}
+		link = ctx.Org.OrgLink + "/assets"
+		tpl = tplOrgAssetLibrary
+		ctx.Data["IsOrgAssetLibrary"] = true
+	}
+	ctx.Data["AssetLibraryLink"] = link
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	opts := &asset_indexer.SearchOptions{
+		Keyword:  strings.TrimSpace(ctx.Query("q")),
+		FileType: ctx.Query("type"),
+		Author:   strings.TrimSpace(ctx.Query("author")),
+		Page:     page,
+		PageSize: setting.UI.ExplorePagingNum,
+	}
+	for _, tag := range strings.Split(ctx.Query("tags"), ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	// the dates are days of the time zone of the instance, "before" is inclusive
+	since, before := ctx.Query("since"), ctx.Query("before")
+	if t, err := time.ParseInLocation("2006-01-02", since, setting.DefaultUILocation); err == nil {
+		opts.Since = t
+	}
+	if t, err := time.ParseInLocation("2006-01-02", before, setting.DefaultUILocation); err == nil {
+		opts.Before = t.AddDate(0, 0, 1)
+	}
+
+	assets, total, err := asset_service.SearchLibrary(repos, opts)
+	if err != nil {
+		ctx.ServerError("SearchLibrary", err)
+		return
+	}
+	ctx.Data["Assets"] = assets
+
+	tagCounts, err := models.GetAssetTagCounts(opts.RepoIDs)
+	if err != nil {
+		ctx.ServerError("GetAssetTagCounts", err)
+		return
+	}
+
+	filters := url.Values{}
+	for key, value := range map[string]string{
+		"q":      opts.Keyword,
+		"tags":   strings.Join(opts.Tags, ","),
+		"type":   opts.FileType,
+		"author": opts.Author,
+		"since":  since,
+		"before": before,
+	} {
+		if value != "" {
+			filters.Set(key, value)
+		}
+	}
+
+	// a tag is added to or removed from the selected tags
+	tagFilters := make([]*libraryFilter, 0, len(tagCounts))
+	for _, tc := range tagCounts {
+		f := &libraryFilter{Name: tc.Name, Count: tc.Count}
+		selected := make([]string, 0, len(opts.Tags)+1)
+		for _, tag := range opts.Tags {
+			if tag == tc.Name {
+				f.Selected = true
+			} else {
+				selected = append(selected, tag)
+			}
+		}
+		if !f.Selected {
+			selected = append(selected, tc.Name)
+		}
+		f.Link = libraryLink(link, filters, "tags", strings.Join(selected, ","))
+		tagFilters = append(tagFilters, f)
+	}
+	ctx.Data["TagFilters"] = tagFilters
+
+	typeFilters := make([]*libraryFilter, 0, len(asset_indexer.FileTypes))
+	for _, fileType := range asset_indexer.FileTypes {
+		typeFilters = append(typeFilters, &libraryFilter{
+			Name:     fileType,
+			Selected: fileType == opts.FileType,
+			Link:     libraryLink(link, filters, "type", fileType),
+		})
+	}
+	ctx.Data["TypeFilters"] = typeFilters
+	ctx.Data["AllTypesLink"] = libraryLink(link, filters, "type", "")
+
+	if setting.Thumbnail.Enabled {
+		size := setting.Thumbnail.Sizes[len(setting.Thumbnail.Sizes)-1]
+		for _, s := range setting.Thumbnail.Sizes {
+			if s >= libraryThumbnailSize {
+				size = s
+				break
+			}
+		}
+		ctx.Data["ThumbnailSize"] = size
+	}
+
+	ctx.Data["Keyword"] = opts.Keyword
+	ctx.Data["Tags"] = strings.Join(opts.Tags, ",")
+	ctx.Data["FileType"] = opts.FileType
+	ctx.Data["Author"] = opts.Author
+	ctx.Data["Since"] = since
+	ctx.Data["Before"] = before
+
+	pager := context.NewPagination(int(total), opts.PageSize, page, 5)
+	pager.AddParam(ctx, "q", "Keyword")
+	pager.AddParam(ctx, "tags", "Tags")
+	pager.AddParam(ctx, "type", "FileType")
+	pager.AddParam(ctx, "author", "Author")
+	pager.AddParam(ctx, "since", "Since")
+	pager.AddParam(ctx, "before", "Before")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tpl)
+}
+
+// renderAssetTags loads the tags of the file or the folder viewed in a branch
+func renderAssetTags(ctx *context.Context) {
+	if len(ctx.Repo.TreePath) == 0 || !ctx.Repo.IsViewBranch {
+		return
+	}
+
+	tags, err := models.GetAssetTags(ctx.Repo.Repository.ID, ctx.Repo.BranchName, ctx.Repo.TreePath)
+	if err != nil {
+		ctx.ServerError("GetAssetTags", err)
+		return
+	}
+	canEdit := ctx.Repo.CanWrite(models.UnitTypeCode) && !ctx.Repo.Repository.IsArchived
+	ctx.Data["ShowAssetTags"] = len(tags) > 0 || canEdit
+	ctx.Data["AssetTags"] = tags
+	ctx.Data["AssetTagsValue"] = strings.Join(tags, ", ")
+	ctx.Data["CanEditAssetTags"] = canEdit
+}
+
+// AssetTagsPost replaces the tags of a file or a folder of a branch
+func AssetTagsPost(ctx *context.Context, form auth.AssetTagsForm) {
+	link := ctx.Repo.RepoLink + "/src/branch/" + util.PathEscapeSegments(form.Branch) + "/" + util.PathEscapeSegments(form.TreePath)
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(ctx.Repo.RepoLink)
+		return
+	}
+
+	if _, err := asset_service.SetAssetTags(ctx.User, ctx.Repo.Repository, form.Branch, form.TreePath, strings.Split(form.Tags, ",")); err != nil {
+		switch {
+		case models.IsErrInvalidAssetTags(err):
+			ctx.Flash.Error(ctx.Tr("repo.assets.invalid_tags", strings.Join(err.(models.ErrInvalidAssetTags).Tags, ", ")))
+		case git.IsErrBranchNotExist(err), models.IsErrRepoFileDoesNotExist(err):
+			ctx.NotFound("SetAssetTags", err)
+			return
+		default:
+			ctx.ServerError("SetAssetTags", err)
+			return
+		}
+	}
+	ctx.Redirect(link)
+}
@@ -226,13 +226,20 @@ func Diff(ctx *context.Context) {
 
 	ctx.Data["CommitStatus"] = models.CalcCommitStatus(statuses)
 
-	diff, err := gitdiff.GetDiffCommit(models.RepoPath(userName, repoName),
+	diff, err := gitdiff.GetDiffCommit(ctx.Repo.Repository, models.RepoPath(userName, repoName),
 		commitID, setting.Git.MaxGitDiffLines,
 		setting.Git.MaxGitDiffLineCharacters, setting.Git.MaxGitDiffFiles)
 	if err != nil {
 		ctx.NotFound("GetDiffCommit", err)
 		return
 	}
+	// the comments on the images are made in the pull requests
+	if ctx.Repo.CanRead(models.UnitTypePullRequests) {
+		if err = diff.LoadRegionComments(ctx.Repo.Repository.ID, ctx.User); err != nil {
+			ctx.ServerError("LoadRegionComments", err)
+			return
+		}
+	}
 
 	parents := make([]string, commit.ParentCount())
 	for i := 0; i < commit.ParentCount(); i++ {
@@ -257,7 +264,6 @@ func Diff(ctx *context.Context) {
 			return
 		}
 	}
-	setImageCompareContext(ctx, parentCommit, commit)
 	headTarget := path.Join(userName, repoName)
 	setPathsCompareContext(ctx, parentCommit, commit, headTarget)
 	ctx.Data["Title"] = commit.Summary() + " · " + base.ShortSha(commitID)
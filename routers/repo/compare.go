@@ -27,42 +27,21 @@ const (
 	tplBlobExcerpt base.TplName = "repo/diff/blob_excerpt"
 )
 
-// setPathsCompareContext sets context data for source and raw paths
+// setPathsCompareContext sets context data for source and raw paths,
+// raw paths go through the media route so LFS-backed files are resolved
 func setPathsCompareContext(ctx *context.Context, base *git.Commit, head *git.Commit, headTarget string) {
 	sourcePath := setting.AppSubURL + "/%s/src/commit/%s"
-	rawPath := setting.AppSubURL + "/%s/raw/commit/%s"
+	rawPath := setting.AppSubURL + "/%s/media/commit/%s"
+	layersPath := setting.AppSubURL + "/%s/layers/commit/%s"
 
 	ctx.Data["SourcePath"] = fmt.Sprintf(sourcePath, headTarget, head.ID)
 	ctx.Data["RawPath"] = fmt.Sprintf(rawPath, headTarget, head.ID)
+	ctx.Data["LayersPath"] = fmt.Sprintf(layersPath, headTarget, head.ID)
 	if base != nil {
 		baseTarget := path.Join(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
 		ctx.Data["BeforeSourcePath"] = fmt.Sprintf(sourcePath, baseTarget, base.ID)
 		ctx.Data["BeforeRawPath"] = fmt.Sprintf(rawPath, baseTarget, base.ID)
-	}
-}
-
-// setImageCompareContext sets context data that is required by image compare template
-func setImageCompareContext(ctx *context.Context, base *git.Commit, head *git.Commit) {
-	ctx.Data["IsImageFileInHead"] = head.IsImageFile
-	ctx.Data["IsImageFileInBase"] = base.IsImageFile
-	ctx.Data["ImageInfoBase"] = func(name string) *git.ImageMetaData {
-		if base == nil {
-			return nil
-		}
-		result, err := base.ImageInfo(name)
-		if err != nil {
-			log.Error("ImageInfo failed: %v", err)
-			return nil
-		}
-		return result
-	}
-	ctx.Data["ImageInfo"] = func(name string) *git.ImageMetaData {
-		result, err := head.ImageInfo(name)
-		if err != nil {
-			log.Error("ImageInfo failed: %v", err)
-			return nil
-		}
-		return result
+		ctx.Data["BeforeLayersPath"] = fmt.Sprintf(layersPath, baseTarget, base.ID)
 	}
 }
 
@@ -283,7 +262,7 @@ func PrepareCompareDiff(
 		return true
 	}
 
-	diff, err := gitdiff.GetDiffRange(models.RepoPath(headUser.Name, headRepo.Name),
+	diff, err := gitdiff.GetDiffRange(headRepo, models.RepoPath(headUser.Name, headRepo.Name),
 		compareInfo.MergeBase, headCommitID, setting.Git.MaxGitDiffLines,
 		setting.Git.MaxGitDiffLineCharacters, setting.Git.MaxGitDiffFiles)
 	if err != nil {
@@ -344,7 +323,6 @@ func PrepareCompareDiff(
 	ctx.Data["Username"] = headUser.Name
 	ctx.Data["Reponame"] = headRepo.Name
 
-	setImageCompareContext(ctx, baseCommit, headCommit)
 	headTarget := path.Join(headUser.Name, repo.Name)
 	setPathsCompareContext(ctx, baseCommit, headCommit, headTarget)
 
@@ -5,7 +5,9 @@
 package repo
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path"
 	"path/filepath"
@@ -461,6 +463,11 @@ func renderUploadSettings(ctx *context.Context) {
 	ctx.Data["UploadAllowedTypes"] = strings.Join(setting.Repository.Upload.AllowedTypes, ",")
 	ctx.Data["UploadMaxSize"] = setting.Repository.Upload.FileMaxSize
 	ctx.Data["UploadMaxFiles"] = setting.Repository.Upload.MaxFiles
+	ctx.Data["UploadChunkSize"] = setting.Repository.Upload.ChunkSize
+	if setting.LFS.StartServer {
+		ctx.Data["UploadLFSExtensions"] = strings.Join(setting.Repository.Upload.LFSAutoTrackExtensions, ", ")
+		ctx.Data["UploadLFSMinSize"] = setting.Repository.Upload.LFSAutoTrackMinSize
+	}
 }
 
 // UploadFile render upload file page
@@ -635,7 +642,7 @@ func UploadFileToServer(ctx *context.Context) {
 		}
 	}
 
-	name := cleanUploadFileName(header.Filename)
+	name := uploadFileName(ctx, header.Filename)
 	if len(name) == 0 {
 		ctx.Error(500, "Upload file name is invalid")
 		return
@@ -653,6 +660,115 @@ func UploadFileToServer(ctx *context.Context) {
 	})
 }
 
+// uploadFileName returns the name of an uploaded file, the path relative to the uploaded folder
+// is kept when a folder is uploaded
+func uploadFileName(ctx *context.Context, filename string) string {
+	if fullPath := ctx.Query("full_path"); len(fullPath) > 0 {
+		return cleanUploadFileName(fullPath)
+	}
+	return cleanUploadFileName(filename)
+}
+
+// UploadFileChunkToServer receives a chunk of a file uploaded in chunks, the upload is
+// started by the first chunk and the file is ready to be committed after the last one
+func UploadFileChunkToServer(ctx *context.Context) {
+	file, header, err := ctx.Req.FormFile("file")
+	if err != nil {
+		ctx.Error(500, fmt.Sprintf("FormFile: %v", err))
+		return
+	}
+	defer file.Close()
+
+	uuid := ctx.Query("uuid")
+	offset := ctx.QueryInt64("offset")
+	totalSize := ctx.QueryInt64("total_size")
+	if totalSize <= 0 || offset < 0 || offset >= totalSize {
+		ctx.Error(400, "Invalid chunk offset or total size")
+		return
+	}
+	if maxSize := setting.Repository.Upload.FileMaxSize; maxSize > 0 && totalSize > maxSize*1024*1024 {
+		ctx.Error(413, fmt.Sprintf("File is bigger than %d MB", maxSize))
+		return
+	}
+
+	name := uploadFileName(ctx, header.Filename)
+	if len(name) == 0 {
+		ctx.Error(500, "Upload file name is invalid")
+		return
+	}
+
+	var chunk io.Reader = file
+	if offset == 0 {
+		buf := make([]byte, 1024)
+		n, _ := io.ReadFull(file, buf)
+		buf = buf[:n]
+
+		if len(setting.Repository.Upload.AllowedTypes) > 0 {
+			if err = upload.VerifyAllowedContentType(buf, setting.Repository.Upload.AllowedTypes); err != nil {
+				ctx.Error(400, err.Error())
+				return
+			}
+		}
+		chunk = io.MultiReader(bytes.NewReader(buf), file)
+
+		if len(uuid) == 0 {
+			if uuid, err = models.NewUploadInChunks(ctx.User.ID, ctx.Repo.Repository.ID); err != nil {
+				ctx.Error(500, fmt.Sprintf("NewUploadInChunks: %v", err))
+				return
+			}
+		}
+	}
+
+	// what is sent beyond the announced size is ignored
+	size, err := models.WriteUploadChunk(uuid, ctx.User.ID, ctx.Repo.Repository.ID, offset, io.LimitReader(chunk, totalSize-offset))
+	if err != nil {
+		switch {
+		case models.IsErrUploadNotExist(err):
+			ctx.Error(404, err.Error())
+		case models.IsErrUploadChunkOffset(err):
+			// the client resumes the upload from the size received
+			ctx.JSON(409, map[string]interface{}{
+				"uuid": uuid,
+				"size": err.(models.ErrUploadChunkOffset).Size,
+			})
+		default:
+			ctx.Error(500, fmt.Sprintf("WriteUploadChunk: %v", err))
+		}
+		return
+	}
+
+	if size == totalSize {
+		if _, err := models.CompleteUploadInChunks(uuid, ctx.User.ID, ctx.Repo.Repository.ID, name); err != nil {
+			ctx.Error(500, fmt.Sprintf("CompleteUploadInChunks: %v", err))
+			return
+		}
+		log.Trace("New file uploaded in chunks: %s", uuid)
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"uuid": uuid,
+		"size": size,
+	})
+}
+
+// GetUploadFileChunksSize returns the size received of a file uploaded in chunks,
+// an interrupted upload is resumed from this size
+func GetUploadFileChunksSize(ctx *context.Context) {
+	uuid := ctx.Query("uuid")
+	size, err := models.GetUploadInChunksSize(uuid, ctx.User.ID, ctx.Repo.Repository.ID)
+	if err != nil {
+		if models.IsErrUploadNotExist(err) {
+			ctx.Error(404, err.Error())
+		} else {
+			ctx.Error(500, fmt.Sprintf("GetUploadInChunksSize: %v", err))
+		}
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"uuid": uuid,
+		"size": size,
+	})
+}
+
 // RemoveUploadFileFromServer remove file from server file dir
 func RemoveUploadFileFromServer(ctx *context.Context, form auth.RemoveUploadFileForm) {
 	if len(form.File) == 0 {
@@ -660,7 +776,7 @@ func RemoveUploadFileFromServer(ctx *context.Context, form auth.RemoveUploadFile
 		return
 	}
 
-	if err := models.DeleteUploadByUUID(form.File); err != nil {
+	if err := models.DeleteUploadByUUID(form.File, ctx.User.ID, ctx.Repo.Repository.ID); err != nil {
 		ctx.Error(500, fmt.Sprintf("DeleteUploadByUUID: %v", err))
 		return
 	}
@@ -834,7 +834,22 @@ func ViewIssue(ctx *context.Context) {
 			if comment.MilestoneID > 0 && comment.Milestone == nil {
 				comment.Milestone = ghostMilestone
 			}
-		} else if comment.Type == models.CommentTypeAssignees {
+		} else if comment.Type == models.CommentTypeProject {
+			if err = comment.LoadProject(); err != nil {
+				ctx.ServerError("LoadProject", err)
+				return
+			}
+			ghostProject := &models.Project{
+				ID:    -1,
+				Title: ctx.Tr("repo.issues.deleted_project"),
+			}
+			if comment.OldProjectID > 0 && comment.OldProject == nil {
+				comment.OldProject = ghostProject
+			}
+			if comment.ProjectID > 0 && comment.Project == nil {
+				comment.Project = ghostProject
+			}
+		} else if comment.Type == models.CommentTypeAssignees || comment.Type == models.CommentTypeReviewRequest || comment.Type == models.CommentTypeDismissReview {
 			if err = comment.LoadAssigneeUser(); err != nil {
 				ctx.ServerError("LoadAssigneeUser", err)
 				return
@@ -954,6 +969,29 @@ func ViewIssue(ctx *context.Context) {
 		return
 	}
 
+	projectCards, err := models.GetProjectCardsByIssueID(issue.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectCardsByIssueID", err)
+		return
+	}
+	// cards of organization projects are shown to the members with access to the projects
+	orgProjectsMode := models.AccessModeNone
+	if ctx.Repo.Owner.IsOrganization() {
+		if orgProjectsMode, err = ctx.Repo.Owner.GetProjectsAccessMode(ctx.User); err != nil {
+			ctx.ServerError("GetProjectsAccessMode", err)
+			return
+		}
+	}
+	cards := projectCards[:0]
+	for _, card := range projectCards {
+		if (card.Project.IsOrganizationProject() && orgProjectsMode >= models.AccessModeRead) ||
+			(!card.Project.IsOrganizationProject() && ctx.Repo.CanRead(models.UnitTypeProjects)) {
+			cards = append(cards, card)
+		}
+	}
+	ctx.Data["ProjectCards"] = cards
+	ctx.Data["ShowProjects"] = ctx.Repo.CanRead(models.UnitTypeProjects) || orgProjectsMode >= models.AccessModeRead
+
 	ctx.Data["Participants"] = participants
 	ctx.Data["NumParticipants"] = len(participants)
 	ctx.Data["Issue"] = issue
@@ -0,0 +1,329 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"bytes"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/repofiles"
+
+	"github.com/unknwon/com"
+)
+
+const (
+	tplKeystoneStart   base.TplName = "repo/keystone/start"
+	tplKeystoneView    base.TplName = "repo/keystone/view"
+	tplKeystoneEdit    base.TplName = "repo/keystone/edit"
+	tplKeystoneHistory base.TplName = "repo/keystone/history"
+)
+
+// findKeystoneCommit returns the last commit of the keystone,
+// it renders the start page if the keystone has not been created yet.
+func findKeystoneCommit(ctx *context.Context) *git.Commit {
+	if ctx.Repo.GitRepo == nil {
+		ctx.NotFound("findKeystoneCommit", nil)
+		return nil
+	}
+	commit, err := repofiles.GetKeystoneCommit(ctx.Repo.GitRepo)
+	if err != nil {
+		if !git.IsErrNotExist(err) {
+			ctx.ServerError("GetKeystoneCommit", err)
+			return nil
+		}
+		ctx.Data["Title"] = ctx.Tr("repo.keystone")
+		ctx.HTML(200, tplKeystoneStart)
+		return nil
+	}
+	return commit
+}
+
+// renderKeystone renders a document of the keystone as markdown, links and images are
+// resolved against the keystone.
+func renderKeystone(ctx *context.Context, data []byte) string {
+	return string(markdown.Render(data, ctx.Repo.RepoLink+"/keystone/raw", ctx.Repo.Repository.ComposeMetas()))
+}
+
+// renderKeystoneLanding renders the KEYSTONE shown on the home page of the repository
+func renderKeystoneLanding(ctx *context.Context) {
+	commit, err := repofiles.GetKeystoneCommit(ctx.Repo.GitRepo)
+	if err != nil {
+		if !git.IsErrNotExist(err) {
+			ctx.ServerError("GetKeystoneCommit", err)
+		}
+		return
+	}
+	data, err := repofiles.GetKeystonePageContent(commit, repofiles.KeystonePages[0])
+	if err != nil {
+		ctx.ServerError("GetKeystonePageContent", err)
+		return
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		ctx.Data["KeystoneContent"] = renderKeystone(ctx, data)
+	}
+}
+
+func prepareKeystoneData(ctx *context.Context) {
+	ctx.Data["PageIsKeystone"] = true
+	ctx.Data["KeystonePage"] = repofiles.KeystonePage{}
+	ctx.Data["KeystonePages"] = repofiles.KeystonePages
+	ctx.Data["CanWriteKeystone"] = ctx.Repo.CanWrite(models.UnitTypeKeystone) && !ctx.Repo.Repository.IsArchived
+}
+
+// Keystone renders a document of the keystone with the open proposals to change it
+func Keystone(ctx *context.Context) {
+	prepareKeystoneData(ctx)
+
+	page, ok := repofiles.GetKeystonePage(ctx.Params(":page"))
+	if !ok {
+		if ctx.Params(":page") != "" {
+			ctx.NotFound("GetKeystonePage", nil)
+			return
+		}
+		page = repofiles.KeystonePages[0]
+	}
+	ctx.Data["KeystonePage"] = page
+
+	commit := findKeystoneCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	data, err := repofiles.GetKeystonePageContent(commit, page)
+	if err != nil {
+		ctx.ServerError("GetKeystonePageContent", err)
+		return
+	}
+	ctx.Data["Title"] = ctx.Tr("repo.keystone." + page.Name)
+	ctx.Data["RequireHighlightJS"] = true
+	ctx.Data["content"] = renderKeystone(ctx, data)
+	ctx.Data["Author"] = commit.Author
+
+	prs, err := models.GetUnmergedPullRequestsByBaseInfo(ctx.Repo.Repository.ID, repofiles.KeystoneRefName)
+	if err != nil {
+		ctx.ServerError("GetUnmergedPullRequestsByBaseInfo", err)
+		return
+	}
+	for _, pr := range prs {
+		if err := pr.LoadIssue(); err != nil {
+			ctx.ServerError("LoadIssue", err)
+			return
+		}
+	}
+	ctx.Data["Proposals"] = prs
+
+	ctx.HTML(200, tplKeystoneView)
+}
+
+// KeystoneHistory renders the commits of the keystone
+func KeystoneHistory(ctx *context.Context) {
+	prepareKeystoneData(ctx)
+	ctx.Data["PageIsKeystoneHistory"] = true
+	ctx.Data["Title"] = ctx.Tr("repo.keystone.history_title")
+
+	commit := findKeystoneCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	commitsCount, err := commit.CommitsCount()
+	if err != nil {
+		ctx.ServerError("CommitsCount", err)
+		return
+	}
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	commits, err := commit.CommitsByRange(page)
+	if err != nil {
+		ctx.ServerError("CommitsByRange", err)
+		return
+	}
+	commits = models.ValidateCommitsWithEmails(commits)
+	commits = models.ParseCommitsWithSignature(commits)
+	ctx.Data["Commits"] = commits
+	ctx.Data["CommitCount"] = commitsCount
+	ctx.Data["Username"] = ctx.Repo.Owner.Name
+	ctx.Data["Reponame"] = ctx.Repo.Repository.Name
+
+	pager := context.NewPagination(int(commitsCount), git.CommitsRangeSize, page, 5)
+	pager.SetDefaultParams(ctx)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplKeystoneHistory)
+}
+
+// KeystoneRaw serves a file of the keystone, the documents of the keystone are
+// redirected to their rendered page.
+func KeystoneRaw(ctx *context.Context) {
+	commit, err := repofiles.GetKeystoneCommit(ctx.Repo.GitRepo)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetKeystoneCommit", err)
+		} else {
+			ctx.ServerError("GetKeystoneCommit", err)
+		}
+		return
+	}
+
+	treePath := ctx.Params("*")
+	if page, ok := repofiles.GetKeystonePage(treePath); ok {
+		ctx.Redirect(ctx.Repo.RepoLink + "/keystone/" + page.Name)
+		return
+	}
+
+	blob, err := commit.GetBlobByPath(treePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetBlobByPath", err)
+		} else {
+			ctx.ServerError("GetBlobByPath", err)
+		}
+		return
+	}
+	if err = ServeBlobOrLFS(ctx, blob); err != nil {
+		ctx.ServerError("ServeBlobOrLFS", err)
+	}
+}
+
+// InitKeystonePost creates the keystone from the templates
+func InitKeystonePost(ctx *context.Context) {
+	if ctx.Repo.Repository.IsEmpty {
+		ctx.Flash.Error(ctx.Tr("repo.keystone.empty_repo"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/keystone")
+		return
+	}
+
+	if err := repofiles.InitKeystone(ctx.Repo.Repository, ctx.User); err != nil {
+		ctx.ServerError("InitKeystone", err)
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/keystone")
+}
+
+// renderKeystoneEditPage prepares the data shared by the edit page and its submission,
+// the commit form of the editor is reused for the keystone.
+func renderKeystoneEditPage(ctx *context.Context) (repofiles.KeystonePage, bool) {
+	prepareKeystoneData(ctx)
+	ctx.Data["PageIsKeystoneEdit"] = true
+	ctx.Data["RequireSimpleMDE"] = true
+
+	page, ok := repofiles.GetKeystonePage(ctx.Params(":page"))
+	if !ok {
+		ctx.NotFound("GetKeystonePage", nil)
+		return page, false
+	}
+	ctx.Data["KeystonePage"] = page
+	ctx.Data["Title"] = ctx.Tr("repo.keystone.edit_page", ctx.Tr("repo.keystone."+page.Name))
+
+	// a proposal is a new branch, so it is limited to the writers of the code
+	ctx.Data["CanCommitToBranch"] = true
+	ctx.Data["CannotCommitToNewBranch"] = !ctx.Repo.CanWrite(models.UnitTypeCode)
+	ctx.Data["BranchName"] = ctx.Tr("repo.keystone")
+	ctx.Data["BranchLink"] = ctx.Repo.RepoLink + "/keystone"
+	ctx.Data["TreePath"] = page.Name
+	return page, true
+}
+
+// EditKeystone renders the page to edit a document of the keystone
+func EditKeystone(ctx *context.Context) {
+	page, ok := renderKeystoneEditPage(ctx)
+	if !ok {
+		return
+	}
+
+	commit := findKeystoneCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+	data, err := repofiles.GetKeystonePageContent(commit, page)
+	if err != nil {
+		ctx.ServerError("GetKeystonePageContent", err)
+		return
+	}
+
+	ctx.Data["content"] = string(data)
+	ctx.Data["last_commit"] = commit.ID.String()
+	ctx.Data["commit_choice"] = frmCommitChoiceDirect
+	ctx.Data["new_branch_name"] = GetUniquePatchBranchName(ctx)
+	ctx.HTML(200, tplKeystoneEdit)
+}
+
+// EditKeystonePost commits a document of the keystone, directly or as a proposal on a new branch
+func EditKeystonePost(ctx *context.Context, form auth.EditKeystoneForm) {
+	page, ok := renderKeystoneEditPage(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Data["content"] = form.Content
+	ctx.Data["last_commit"] = form.LastCommit
+	ctx.Data["commit_summary"] = form.CommitSummary
+	ctx.Data["commit_message"] = form.CommitMessage
+	ctx.Data["commit_choice"] = form.CommitChoice
+	ctx.Data["new_branch_name"] = form.NewBranchName
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplKeystoneEdit)
+		return
+	}
+
+	var newBranch string
+	if form.CommitChoice == frmCommitChoiceNewBranch {
+		if ctx.Data["CannotCommitToNewBranch"].(bool) {
+			ctx.Data["commit_choice"] = frmCommitChoiceDirect
+			ctx.RenderWithErr(ctx.Tr("repo.keystone.cannot_propose"), tplKeystoneEdit, &form)
+			return
+		}
+		newBranch = form.NewBranchName
+	}
+
+	message := strings.TrimSpace(form.CommitSummary)
+	if len(message) == 0 {
+		message = ctx.Tr("repo.editor.update", page.FileName)
+	}
+	title := message
+	form.CommitMessage = strings.TrimSpace(form.CommitMessage)
+	if len(form.CommitMessage) > 0 {
+		message += "\n\n" + form.CommitMessage
+	}
+
+	if _, err := repofiles.UpdateKeystonePage(ctx.Repo.Repository, ctx.User, page, &repofiles.UpdateKeystonePageOptions{
+		LastCommitID: form.LastCommit,
+		NewBranch:    newBranch,
+		Message:      message,
+		Content:      form.Content,
+	}); err != nil {
+		if models.IsErrBranchAlreadyExists(err) {
+			ctx.Data["Err_NewBranchName"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.editor.branch_already_exists", newBranch), tplKeystoneEdit, &form)
+		} else if models.IsErrCommitIDDoesNotMatch(err) {
+			ctx.RenderWithErr(ctx.Tr("repo.editor.file_changed_while_editing", ctx.Repo.RepoLink+"/keystone/_history"), tplKeystoneEdit, &form)
+		} else {
+			ctx.ServerError("UpdateKeystonePage", err)
+		}
+		return
+	}
+
+	if newBranch != "" && ctx.Repo.Repository.UnitEnabled(models.UnitTypePullRequests) {
+		pr, err := repofiles.NewKeystoneProposal(ctx.Repo.Repository, ctx.User, newBranch, title, form.CommitMessage)
+		if err != nil {
+			ctx.ServerError("NewKeystoneProposal", err)
+			return
+		}
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/keystone/" + page.Name)
+}
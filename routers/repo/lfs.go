@@ -11,8 +11,6 @@ import (
 	gotemplate "html/template"
 	"io"
 	"io/ioutil"
-	"os"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,6 +26,7 @@ import (
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"github.com/mcuadros/go-version"
 	"github.com/unknwon/com"
@@ -179,9 +178,8 @@ func LFSDelete(ctx *context.Context) {
 	// FIXME: Warning: the LFS store is not locked - and can't be locked - there could be a race condition here
 	// Please note a similar condition happens in models/repo.go DeleteRepository
 	if count == 0 {
-		oidPath := filepath.Join(oid[0:2], oid[2:4], oid[4:])
-		err = os.Remove(filepath.Join(setting.LFS.ContentPath, oidPath))
-		if err != nil {
+		meta := &models.LFSMetaObject{Oid: oid}
+		if err = storage.LFS.Delete(meta.RelativePath()); err != nil {
 			ctx.ServerError("LFSDelete", err)
 			return
 		}
@@ -444,7 +442,7 @@ type pointerResult struct {
 func createPointerResultsFromCatFileBatch(catFileBatchReader *io.PipeReader, wg *sync.WaitGroup, pointerChan chan<- pointerResult, repo *models.Repository, user *models.User) {
 	defer wg.Done()
 	defer catFileBatchReader.Close()
-	contentStore := lfs.ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := lfs.ContentStore{ObjectStorage: storage.LFS}
 
 	bufferedReader := bufio.NewReader(catFileBatchReader)
 	buf := make([]byte, 1025)
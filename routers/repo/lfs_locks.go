@@ -0,0 +1,156 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplLFSLocks base.TplName = "repo/locks"
+)
+
+// MustEnableLFS checks if the LFS server is enabled in settings
+func MustEnableLFS(ctx *context.Context) {
+	if !setting.LFS.StartServer {
+		ctx.NotFound("MustEnableLFS", nil)
+	}
+}
+
+// LFSLocks lists the LFS locks of a repository
+func LFSLocks(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.locks")
+	ctx.Data["PageIsLocks"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	opts := &models.FindLFSLocksOptions{
+		RepoID:   ctx.Repo.Repository.ID,
+		Keyword:  ctx.QueryTrim("q"),
+		Page:     page,
+		PageSize: setting.UI.IssuePagingNum,
+	}
+	owner := ctx.Query("owner")
+	if owner == "mine" && ctx.IsSigned {
+		opts.OwnerID = ctx.User.ID
+	} else {
+		owner = ""
+	}
+
+	total, err := models.CountLFSLocks(opts)
+	if err != nil {
+		ctx.ServerError("CountLFSLocks", err)
+		return
+	}
+	locks, err := models.FindLFSLocks(opts)
+	if err != nil {
+		ctx.ServerError("FindLFSLocks", err)
+		return
+	}
+	ctx.Data["LFSLocks"] = locks
+	ctx.Data["Keyword"] = opts.Keyword
+	ctx.Data["LockOwner"] = owner
+	ctx.Data["CanLock"] = ctx.Repo.CanWrite(models.UnitTypeCode) && !ctx.Repo.Repository.IsArchived
+
+	pager := context.NewPagination(int(total), opts.PageSize, page, 5)
+	pager.AddParam(ctx, "q", "Keyword")
+	pager.AddParam(ctx, "owner", "LockOwner")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplLFSLocks)
+}
+
+// LFSLockPost locks a file of the default or the given branch for the signed user
+func LFSLockPost(ctx *context.Context) {
+	locksLink := ctx.Repo.RepoLink + "/locks"
+	redirectTo := ctx.Query("redirect_to")
+
+	lockPath := strings.Trim(path.Clean("/"+ctx.QueryTrim("path")), "/")
+	if lockPath == "" {
+		ctx.Flash.Error(ctx.Tr("repo.locks.path_required"))
+		ctx.RedirectToFirst(redirectTo, locksLink)
+		return
+	}
+
+	// only files can be locked, check it exists to catch typos
+	branch := ctx.QueryTrim("branch")
+	if branch == "" || !ctx.Repo.GitRepo.IsBranchExist(branch) {
+		branch = ctx.Repo.Repository.DefaultBranch
+	}
+	commit, err := ctx.Repo.GitRepo.GetBranchCommit(branch)
+	if err != nil {
+		ctx.ServerError("GetBranchCommit", err)
+		return
+	}
+	entry, err := commit.GetTreeEntryByPath(lockPath)
+	if err != nil && !git.IsErrNotExist(err) {
+		ctx.ServerError("GetTreeEntryByPath", err)
+		return
+	}
+	if entry == nil || entry.IsDir() {
+		ctx.Flash.Error(ctx.Tr("repo.locks.file_not_exist", lockPath, branch))
+		ctx.RedirectToFirst(redirectTo, locksLink)
+		return
+	}
+
+	lock, err := models.CreateLFSLock(&models.LFSLock{
+		Repo:  ctx.Repo.Repository,
+		Path:  lockPath,
+		Owner: ctx.User,
+	})
+	if models.IsErrLFSLockAlreadyExist(err) {
+		ctx.Flash.Error(ctx.Tr("repo.locks.already_locked", lockPath, lock.Owner.DisplayName()))
+		ctx.RedirectToFirst(redirectTo, locksLink)
+		return
+	} else if err != nil {
+		ctx.ServerError("CreateLFSLock", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.locks.lock_success", lockPath))
+	ctx.RedirectToFirst(redirectTo, locksLink)
+}
+
+// LFSUnlockPost releases a lock, the locks of other users are only released when forced
+func LFSUnlockPost(ctx *context.Context) {
+	locksLink := ctx.Repo.RepoLink + "/locks"
+	redirectTo := ctx.Query("redirect_to")
+
+	lock, err := models.GetLFSLockByID(ctx.ParamsInt64(":lid"))
+	if err != nil {
+		if models.IsErrLFSLockNotExist(err) {
+			ctx.NotFound("GetLFSLockByID", err)
+		} else {
+			ctx.ServerError("GetLFSLockByID", err)
+		}
+		return
+	}
+	if lock.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound("GetLFSLockByID", nil)
+		return
+	}
+	if lock.OwnerID != ctx.User.ID && !ctx.QueryBool("force") {
+		ctx.Flash.Error(ctx.Tr("repo.locks.not_owner", lock.Path))
+		ctx.RedirectToFirst(redirectTo, locksLink)
+		return
+	}
+
+	if _, err := models.DeleteLFSLockByID(lock.ID, ctx.User, true); err != nil {
+		ctx.ServerError("DeleteLFSLockByID", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.locks.unlock_success", lock.Path))
+	ctx.RedirectToFirst(redirectTo, locksLink)
+}
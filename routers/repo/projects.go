@@ -0,0 +1,590 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	issue_service "code.gitea.io/gitea/services/issue"
+
+	"github.com/unknwon/com"
+)
+
+const (
+	tplProjects       base.TplName = "repo/projects/list"
+	tplProjectNew     base.TplName = "repo/projects/new"
+	tplProjectView    base.TplName = "repo/projects/view"
+	tplOrgProjects    base.TplName = "org/projects/list"
+	tplOrgProjectNew  base.TplName = "org/projects/new"
+	tplOrgProjectView base.TplName = "org/projects/view"
+)
+
+// projectsCtx holds what differs between the projects of a repository and of an organization
+type projectsCtx struct {
+	RepoID       int64
+	OrgID        int64
+	Link         string
+	CanWrite     bool
+	ListTemplate base.TplName
+	NewTemplate  base.TplName
+	ViewTemplate base.TplName
+}
+
+// getProjectsCtx determines whether the projects of a repository or of an organization are handled,
+// the projects pages of both are served by the same handlers.
+func getProjectsCtx(ctx *context.Context) *projectsCtx {
+	var pCtx *projectsCtx
+	if len(ctx.Repo.RepoLink) > 0 {
+		pCtx = &projectsCtx{
+			RepoID:       ctx.Repo.Repository.ID,
+			Link:         ctx.Repo.RepoLink + "/projects",
+			CanWrite:     ctx.Repo.CanWrite(models.UnitTypeProjects) && !ctx.Repo.Repository.IsArchived,
+			ListTemplate: tplProjects,
+			NewTemplate:  tplProjectNew,
+			ViewTemplate: tplProjectView,
+		}
+	} else {
+		pCtx = &projectsCtx{
+			OrgID:        ctx.Org.Organization.ID,
+			Link:         ctx.Org.OrgLink + "/projects",
+			CanWrite:     ctx.Org.ProjectsAccessMode >= models.AccessModeWrite,
+			ListTemplate: tplOrgProjects,
+			NewTemplate:  tplOrgProjectNew,
+			ViewTemplate: tplOrgProjectView,
+		}
+		ctx.Data["IsOrgProjects"] = true
+	}
+	ctx.Data["ProjectsLink"] = pCtx.Link
+	ctx.Data["CanWriteProjects"] = pCtx.CanWrite
+	return pCtx
+}
+
+// renderProjectContent renders the markdown description of a project
+func renderProjectContent(ctx *context.Context, p *models.Project) {
+	if len(ctx.Repo.RepoLink) > 0 {
+		p.RenderedContent = string(markdown.Render([]byte(p.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+	} else {
+		p.RenderedContent = string(markdown.Render([]byte(p.Description), ctx.Org.OrgLink, nil))
+	}
+}
+
+// Projects renders the projects page
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+	ctx.Data["PageIsProjects"] = true
+	pCtx := getProjectsCtx(ctx)
+
+	isShowClosed := ctx.Query("state") == "closed"
+	var openCount, closedCount int64
+	var err error
+	if pCtx.OrgID > 0 {
+		openCount, closedCount, err = models.OrgProjectStats(pCtx.OrgID)
+	} else {
+		openCount, closedCount, err = models.ProjectStats(pCtx.RepoID)
+	}
+	if err != nil {
+		ctx.ServerError("ProjectStats", err)
+		return
+	}
+	ctx.Data["OpenCount"] = openCount
+	ctx.Data["ClosedCount"] = closedCount
+
+	sortType := ctx.Query("sort")
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var total int
+	if !isShowClosed {
+		total = int(openCount)
+	} else {
+		total = int(closedCount)
+	}
+
+	var projects []*models.Project
+	if pCtx.OrgID > 0 {
+		projects, err = models.GetOrgProjects(pCtx.OrgID, page, isShowClosed, sortType)
+	} else {
+		projects, err = models.GetProjects(pCtx.RepoID, page, isShowClosed, sortType)
+	}
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+	for _, p := range projects {
+		renderProjectContent(ctx, p)
+	}
+	ctx.Data["Projects"] = projects
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+
+	ctx.Data["SortType"] = sortType
+	ctx.Data["IsShowClosed"] = isShowClosed
+
+	pager := context.NewPagination(total, setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, pCtx.ListTemplate)
+}
+
+// NewProject renders the page to create a project
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["ColumnTemplates"] = models.GetProjectColumnTemplates()
+	ctx.HTML(200, getProjectsCtx(ctx).NewTemplate)
+}
+
+// NewProjectPost creates a project
+func NewProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["ColumnTemplates"] = models.GetProjectColumnTemplates()
+	pCtx := getProjectsCtx(ctx)
+
+	if ctx.HasError() {
+		ctx.HTML(200, pCtx.NewTemplate)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		RepoID:      pCtx.RepoID,
+		OwnerID:     pCtx.OrgID,
+		CreatorID:   ctx.User.ID,
+		Title:       form.Title,
+		Description: form.Content,
+	}, form.ColumnTemplate); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(pCtx.Link)
+}
+
+// EditProject renders the page to edit a project
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProject"] = true
+
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["title"] = p.Title
+	ctx.Data["content"] = p.Description
+	ctx.HTML(200, getProjectsCtx(ctx).NewTemplate)
+}
+
+// EditProjectPost updates the title and description of a project
+func EditProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProject"] = true
+	pCtx := getProjectsCtx(ctx)
+
+	if ctx.HasError() {
+		ctx.HTML(200, pCtx.NewTemplate)
+		return
+	}
+
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	p.Title = form.Title
+	p.Description = form.Content
+	if err := models.UpdateProject(p); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", p.Title))
+	ctx.Redirect(fmt.Sprintf("%s/%d", pCtx.Link, p.ID))
+}
+
+// ChangeProjectStatus opens or closes a project
+func ChangeProjectStatus(ctx *context.Context) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	link := getProjectsCtx(ctx).Link
+
+	switch ctx.Params(":action") {
+	case "open":
+		if p.IsClosed {
+			if err := models.ChangeProjectStatus(p, false); err != nil {
+				ctx.ServerError("ChangeProjectStatus", err)
+				return
+			}
+		}
+		ctx.Redirect(link + "?state=open")
+	case "close":
+		if !p.IsClosed {
+			if err := models.ChangeProjectStatus(p, true); err != nil {
+				ctx.ServerError("ChangeProjectStatus", err)
+				return
+			}
+		}
+		ctx.Redirect(link + "?state=closed")
+	default:
+		ctx.Redirect(link)
+	}
+}
+
+// DeleteProject deletes a project with its columns and cards
+func DeleteProject(ctx *context.Context) {
+	pCtx := getProjectsCtx(ctx)
+
+	var err error
+	if pCtx.OrgID > 0 {
+		err = models.DeleteProjectByOwnerID(pCtx.OrgID, ctx.QueryInt64("id"))
+	} else {
+		err = models.DeleteProjectByRepoID(pCtx.RepoID, ctx.QueryInt64("id"))
+	}
+	if err != nil {
+		ctx.Flash.Error("DeleteProject: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": pCtx.Link,
+	})
+}
+
+// ViewProject renders the columns and cards of a project
+func ViewProject(ctx *context.Context) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := p.LoadColumns(); err != nil {
+		ctx.ServerError("LoadColumns", err)
+		return
+	}
+
+	// only show the cards of the issues and pull requests the user can read
+	perms := make(map[int64]models.Permission)
+	for _, column := range p.Columns {
+		cards := column.Cards[:0]
+		for _, card := range column.Cards {
+			perm, ok := perms[card.Issue.RepoID]
+			if !ok {
+				var err error
+				if perm, err = getProjectIssuePermission(ctx, card.Issue); err != nil {
+					ctx.ServerError("GetUserRepoPermission", err)
+					return
+				}
+				perms[card.Issue.RepoID] = perm
+			}
+			if perm.CanReadIssuesOrPulls(card.Issue.IsPull) {
+				cards = append(cards, card)
+			}
+		}
+		column.Cards = cards
+	}
+	renderProjectContent(ctx, p)
+
+	ctx.Data["Title"] = p.Title
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["Project"] = p
+	ctx.HTML(200, getProjectsCtx(ctx).ViewTemplate)
+}
+
+// NewProjectColumn adds a column to the end of a project
+func NewProjectColumn(ctx *context.Context, form auth.ProjectColumnForm) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	projectLink := fmt.Sprintf("%s/%d", getProjectsCtx(ctx).Link, p.ID)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	if err := models.NewProjectColumn(&models.ProjectColumn{
+		ProjectID: p.ID,
+		CreatorID: ctx.User.ID,
+		Title:     form.Title,
+	}); err != nil {
+		ctx.ServerError("NewProjectColumn", err)
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+// EditProjectColumn renames a column of a project
+func EditProjectColumn(ctx *context.Context, form auth.ProjectColumnForm) {
+	p, column := getProjectColumnByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	projectLink := fmt.Sprintf("%s/%d", getProjectsCtx(ctx).Link, p.ID)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	column.Title = form.Title
+	if err := models.UpdateProjectColumn(column); err != nil {
+		ctx.ServerError("UpdateProjectColumn", err)
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+// SetDefaultProjectColumn makes the column the one new cards are added to
+func SetDefaultProjectColumn(ctx *context.Context) {
+	p, column := getProjectColumnByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.SetDefaultProjectColumn(column); err != nil {
+		ctx.ServerError("SetDefaultProjectColumn", err)
+		return
+	}
+	ctx.Redirect(fmt.Sprintf("%s/%d", getProjectsCtx(ctx).Link, p.ID))
+}
+
+// DeleteProjectColumn deletes a column, its cards are moved to the default column
+func DeleteProjectColumn(ctx *context.Context) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	projectLink := fmt.Sprintf("%s/%d", getProjectsCtx(ctx).Link, p.ID)
+
+	column, err := models.GetProjectColumn(p.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.NotFound("GetProjectColumn", err)
+		} else {
+			ctx.ServerError("GetProjectColumn", err)
+		}
+		return
+	}
+
+	if err = models.DeleteProjectColumn(column); err != nil {
+		if !models.IsErrProjectColumnIsDefault(err) {
+			ctx.ServerError("DeleteProjectColumn", err)
+			return
+		}
+		ctx.Flash.Error(ctx.Tr("repo.projects.column.delete_default"))
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.column.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": projectLink,
+	})
+}
+
+// SortProjectColumns orders the columns of a project
+func SortProjectColumns(ctx *context.Context) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.SortProjectColumns(p.ID, parseProjectIDs(ctx.Query("columns"))); err != nil {
+		ctx.ServerError("SortProjectColumns", err)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// AddProjectCard adds an issue or a pull request of the repository, or of a repository
+// of the organization, to a column of a project
+func AddProjectCard(ctx *context.Context, form auth.AddProjectCardForm) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	projectLink := fmt.Sprintf("%s/%d", getProjectsCtx(ctx).Link, p.ID)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Tr("repo.projects.card.issue_not_exist"))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	issue, err := getProjectIssue(ctx, p, form.Repo, form.IssueIndex)
+	if err != nil {
+		if !models.IsErrIssueNotExist(err) {
+			ctx.ServerError("getProjectIssue", err)
+			return
+		}
+		ctx.Flash.Error(ctx.Tr("repo.projects.card.issue_not_exist"))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	if _, err = issue_service.AddToProject(ctx.User, p, issue, form.ColumnID); err != nil {
+		switch {
+		case models.IsErrProjectCardAlreadyExist(err):
+			ctx.Flash.Error(ctx.Tr("repo.projects.card.already_exist", issue.Index))
+		case models.IsErrProjectColumnNotExist(err):
+			ctx.NotFound("AddToProject", err)
+			return
+		default:
+			ctx.ServerError("AddToProject", err)
+			return
+		}
+	}
+	ctx.Redirect(projectLink)
+}
+
+// DeleteProjectCard removes a card from a project
+func DeleteProjectCard(ctx *context.Context) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	card, err := models.GetProjectCard(p.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		if models.IsErrProjectCardNotExist(err) {
+			ctx.NotFound("GetProjectCard", err)
+		} else {
+			ctx.ServerError("GetProjectCard", err)
+		}
+		return
+	}
+
+	if err = issue_service.RemoveFromProject(ctx.User, p, card); err != nil {
+		ctx.ServerError("RemoveFromProject", err)
+		return
+	}
+	ctx.Redirect(fmt.Sprintf("%s/%d", getProjectsCtx(ctx).Link, p.ID))
+}
+
+// MoveProjectCards moves cards into a column in the posted order
+func MoveProjectCards(ctx *context.Context) {
+	_, column := getProjectColumnByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := issue_service.MoveProjectCards(ctx.User, column, parseProjectIDs(ctx.Query("cards"))); err != nil {
+		ctx.ServerError("MoveProjectCards", err)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// getProjectByParams returns the project of the repository or organization in the route
+func getProjectByParams(ctx *context.Context) *models.Project {
+	var p *models.Project
+	var err error
+	if len(ctx.Repo.RepoLink) > 0 {
+		p, err = models.GetProjectByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	} else {
+		p, err = models.GetProjectByOwnerID(ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	}
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("GetProjectByParams", err)
+		} else {
+			ctx.ServerError("GetProjectByParams", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// getProjectIssuePermission returns the permission of the signed user in the repository of the issue
+func getProjectIssuePermission(ctx *context.Context, issue *models.Issue) (models.Permission, error) {
+	if len(ctx.Repo.RepoLink) > 0 && issue.RepoID == ctx.Repo.Repository.ID {
+		return ctx.Repo.Permission, nil
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return models.Permission{}, err
+	}
+	return models.GetUserRepoPermission(issue.Repo, ctx.User)
+}
+
+// getProjectIssue returns the issue or pull request which can be added to the project,
+// repoName selects the repository of the issue for projects of organizations.
+func getProjectIssue(ctx *context.Context, p *models.Project, repoName string, index int64) (*models.Issue, error) {
+	repoID := p.RepoID
+	if p.IsOrganizationProject() {
+		repo, err := models.GetRepositoryByName(p.OwnerID, repoName)
+		if err != nil {
+			if models.IsErrRepoNotExist(err) {
+				return nil, models.ErrIssueNotExist{Index: index}
+			}
+			return nil, err
+		}
+		repoID = repo.ID
+	}
+
+	issue, err := models.GetIssueByIndex(repoID, index)
+	if err != nil {
+		return nil, err
+	}
+	perm, err := getProjectIssuePermission(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		return nil, models.ErrIssueNotExist{RepoID: repoID, Index: index}
+	}
+	return issue, nil
+}
+
+// getProjectColumnByParams returns the project and its column in the route
+func getProjectColumnByParams(ctx *context.Context) (*models.Project, *models.ProjectColumn) {
+	p := getProjectByParams(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+	column, err := models.GetProjectColumn(p.ID, ctx.ParamsInt64(":columnID"))
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.NotFound("GetProjectColumn", err)
+		} else {
+			ctx.ServerError("GetProjectColumn", err)
+		}
+		return nil, nil
+	}
+	return p, column
+}
+
+// parseProjectIDs parses a comma separated list of ids
+func parseProjectIDs(s string) []int64 {
+	ids := make([]int64, 0, 10)
+	for _, field := range strings.Split(s, ",") {
+		if id := com.StrTo(strings.TrimSpace(field)).MustInt64(); id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -420,6 +420,13 @@ func PrepareViewPullInfo(ctx *context.Context, issue *models.Issue) *git.Compare
 		ctx.Data["ConflictedFiles"] = pull.ConflictedFiles
 	}
 
+	competingPulls, err := pull_service.FindCompetingPullRequests(pull)
+	if err != nil {
+		ctx.ServerError("FindCompetingPullRequests", err)
+		return nil
+	}
+	ctx.Data["CompetingPulls"] = competingPulls
+
 	ctx.Data["NumCommits"] = compareInfo.Commits.Len()
 	ctx.Data["NumFiles"] = compareInfo.NumFiles
 	ctx.Data["AllowedReactions"] = setting.UI.Reactions
@@ -489,6 +496,7 @@ func ViewPullFiles(ctx *context.Context) {
 		"":              ""}
 
 	var (
+		diffRepo      *models.Repository
 		diffRepoPath  string
 		startCommitID string
 		endCommitID   string
@@ -505,6 +513,7 @@ func ViewPullFiles(ctx *context.Context) {
 			return
 		}
 
+		diffRepo = ctx.Repo.Repository
 		diffRepoPath = ctx.Repo.GitRepo.Path
 		gitRepo = ctx.Repo.GitRepo
 
@@ -544,6 +553,7 @@ func ViewPullFiles(ctx *context.Context) {
 			return
 		}
 
+		diffRepo = pull.HeadRepo
 		diffRepoPath = headRepoPath
 		startCommitID = prInfo.MergeBase
 		endCommitID = headCommitID
@@ -555,7 +565,7 @@ func ViewPullFiles(ctx *context.Context) {
 	}
 	ctx.Data["AfterCommitID"] = endCommitID
 
-	diff, err := gitdiff.GetDiffRangeWithWhitespaceBehavior(diffRepoPath,
+	diff, err := gitdiff.GetDiffRangeWithWhitespaceBehavior(diffRepo, diffRepoPath,
 		startCommitID, endCommitID, setting.Git.MaxGitDiffLines,
 		setting.Git.MaxGitDiffLineCharacters, setting.Git.MaxGitDiffFiles,
 		whitespaceFlags[ctx.Data["WhitespaceBehavior"].(string)])
@@ -583,7 +593,6 @@ func ViewPullFiles(ctx *context.Context) {
 		return
 	}
 
-	setImageCompareContext(ctx, baseCommit, commit)
 	setPathsCompareContext(ctx, baseCommit, commit, headTarget)
 
 	ctx.Data["RequireHighlightJS"] = true
@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
@@ -30,23 +31,60 @@ func CreateCodeComment(ctx *context.Context, form auth.CodeCommentForm) {
 		return
 	}
 
-	signedLine := form.Line
-	if form.Side == "previous" {
-		signedLine *= -1
-	}
+	var comment *models.Comment
+	var err error
+	if form.IsRegion {
+		region := models.CommentRegion{
+			X:      form.RegionX,
+			Y:      form.RegionY,
+			Width:  form.RegionWidth,
+			Height: form.RegionHeight,
+		}
+		if !region.IsValid() {
+			ctx.Flash.Error(ctx.Tr("repo.diff.comment.invalid_region"))
+			ctx.Redirect(fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, issue.Index))
+			return
+		}
 
-	comment, err := pull_service.CreateCodeComment(
-		ctx.User,
-		issue,
-		signedLine,
-		form.Content,
-		form.TreePath,
-		form.IsReview,
-		form.Reply,
-	)
-	if err != nil {
-		ctx.ServerError("CreateCodeComment", err)
-		return
+		comment, err = pull_service.CreateImageComment(
+			ctx.User,
+			issue,
+			region,
+			form.Content,
+			form.TreePath,
+			form.IsReview,
+			form.Reply,
+		)
+		if git.IsErrNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.diff.comment.image_not_exist", form.TreePath))
+			ctx.Redirect(fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, issue.Index))
+			return
+		} else if models.IsErrReviewNotExist(err) {
+			ctx.NotFound("CreateImageComment", err)
+			return
+		} else if err != nil {
+			ctx.ServerError("CreateImageComment", err)
+			return
+		}
+	} else {
+		signedLine := form.Line
+		if form.Side == "previous" {
+			signedLine *= -1
+		}
+
+		comment, err = pull_service.CreateCodeComment(
+			ctx.User,
+			issue,
+			signedLine,
+			form.Content,
+			form.TreePath,
+			form.IsReview,
+			form.Reply,
+		)
+		if err != nil {
+			ctx.ServerError("CreateCodeComment", err)
+			return
+		}
 	}
 
 	log.Trace("Comment created: %d/%d/%d", ctx.Repo.Repository.ID, issue.ID, comment.ID)
@@ -95,7 +133,7 @@ func SubmitReview(ctx *context.Context, form auth.SubmitReviewForm) {
 		}
 	}
 
-	_, comm, err := pull_service.SubmitReview(ctx.User, issue, reviewType, form.Content)
+	_, comm, err := pull_service.SubmitReview(ctx.User, issue, reviewType, form.Content, "")
 	if err != nil {
 		if models.IsContentEmptyErr(err) {
 			ctx.Flash.Error(ctx.Tr("repo.issues.review.content.empty"))
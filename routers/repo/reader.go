@@ -0,0 +1,136 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	tplReader base.TplName = "repo/reader"
+
+	// readerThumbnailSize is the size of the thumbnails of the pages, the closest available size is used
+	readerThumbnailSize = 128
+)
+
+// readerPage is a page of the reader with its links and the comments on its image
+type readerPage struct {
+	*repofiles.ReaderPage
+	Number         int
+	FileLink       string
+	ImageLink      string
+	ThumbnailLink  string
+	CommentThreads [][]*models.Comment
+}
+
+// NumComments returns the number of comments on the image of the page
+func (p *readerPage) NumComments() int {
+	n := 0
+	for _, thread := range p.CommentThreads {
+		n += len(thread)
+	}
+	return n
+}
+
+// canThumbnailPage returns true if thumbnails can be generated for the image of a page
+func canThumbnailPage(treePath string) bool {
+	switch strings.ToLower(path.Ext(treePath)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// Reader renders the images of a folder, or the ones listed by a pages.yml manifest, as pages to read
+func Reader(ctx *context.Context) {
+	ctx.Data["PageIsViewCode"] = true
+	ctx.Data["PageIsReader"] = true
+
+	book, err := repofiles.GetReaderBook(ctx.Repo.Commit, ctx.Repo.TreePath)
+	if err != nil {
+		switch {
+		case git.IsErrNotExist(err):
+			ctx.NotFound("GetReaderBook", err)
+		case repofiles.IsErrInvalidReaderManifest(err):
+			ctx.Data["Title"] = ctx.Repo.TreePath
+			ctx.Data["InvalidManifest"] = err.(repofiles.ErrInvalidReaderManifest)
+			ctx.HTML(200, tplReader)
+		default:
+			ctx.ServerError("GetReaderBook", err)
+		}
+		return
+	}
+
+	commitID := ctx.Repo.Commit.ID.String()
+	title := book.Title
+	if title == "" {
+		title = ctx.Repo.Repository.Name
+	}
+	ctx.Data["Title"] = title + " - " + ctx.Repo.Repository.FullName()
+	ctx.Data["Book"] = book
+	ctx.Data["BookTitle"] = title
+	ctx.Data["FolderLink"] = ctx.Repo.RepoLink + "/src/" + util.PathEscapeSegments(ctx.Repo.BranchNameSubURL()) + "/" + util.PathEscapeSegments(book.TreePath)
+	ctx.Data["PermaLink"] = ctx.Repo.RepoLink + "/reader/commit/" + commitID + "/" + util.PathEscapeSegments(ctx.Repo.TreePath)
+	if book.ManifestPath != "" {
+		ctx.Data["ManifestLink"] = ctx.Repo.RepoLink + "/src/commit/" + commitID + "/" + util.PathEscapeSegments(book.ManifestPath)
+	}
+
+	thumbnailSize := 0
+	if setting.Thumbnail.Enabled && len(setting.Thumbnail.Sizes) > 0 {
+		thumbnailSize = setting.Thumbnail.Sizes[len(setting.Thumbnail.Sizes)-1]
+		for _, s := range setting.Thumbnail.Sizes {
+			if s >= readerThumbnailSize {
+				thumbnailSize = s
+				break
+			}
+		}
+	}
+
+	pages := make([]*readerPage, 0, len(book.Pages))
+	blobSHAs := make([]string, 0, len(book.Pages))
+	for i, p := range book.Pages {
+		page := &readerPage{
+			ReaderPage: p,
+			Number:     i + 1,
+			FileLink:   ctx.Repo.RepoLink + "/src/commit/" + commitID + "/" + util.PathEscapeSegments(p.TreePath),
+			ImageLink:  ctx.Repo.RepoLink + "/media/commit/" + commitID + "/" + util.PathEscapeSegments(p.TreePath),
+		}
+		page.ThumbnailLink = page.ImageLink
+		if thumbnailSize > 0 && canThumbnailPage(p.TreePath) {
+			page.ThumbnailLink = fmt.Sprintf("%s/thumbnail/blob/%s?size=%d", ctx.Repo.RepoLink, p.Blob.ID, thumbnailSize)
+		}
+		pages = append(pages, page)
+		blobSHAs = append(blobSHAs, p.Blob.ID.String())
+	}
+
+	// the comments made in pull requests on regions of the images
+	if ctx.Repo.CanRead(models.UnitTypePullRequests) {
+		comments, err := models.FindRegionComments(ctx.Repo.Repository.ID, ctx.User, blobSHAs...)
+		if err != nil {
+			ctx.ServerError("FindRegionComments", err)
+			return
+		}
+		threads := make(map[string][][]*models.Comment, len(pages))
+		for _, thread := range models.GroupRegionComments(comments) {
+			threads[thread[0].BlobSHA] = append(threads[thread[0].BlobSHA], thread)
+		}
+		for _, page := range pages {
+			page.CommentThreads = threads[page.Blob.ID.String()]
+		}
+	}
+	ctx.Data["Pages"] = pages
+
+	ctx.HTML(200, tplReader)
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/layers"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/thumbnail"
+)
+
+// ServeThumbnail writes the thumbnail of a blob, the size is taken from the "size" query
+func ServeThumbnail(ctx *context.Context, blob *git.Blob) {
+	size := ctx.QueryInt("size")
+	if size == 0 && len(setting.Thumbnail.Sizes) > 0 {
+		size = setting.Thumbnail.Sizes[0]
+	}
+
+	f, err := thumbnail.OpenForBlob(ctx.Repo.Repository, blob, size)
+	if err != nil {
+		serveThumbnailError(ctx, "OpenForBlob", err)
+		return
+	}
+	defer f.Close()
+
+	ctx.Resp.Header().Set("Cache-Control", thumbnail.CacheControl(ctx.Repo.Repository))
+	ctx.Resp.Header().Set("Content-Type", "image/png")
+	ctx.Resp.Header().Set("ETag", fmt.Sprintf(`"%s-%d"`, blob.ID, size))
+	http.ServeContent(ctx.Resp, ctx.Req.Request, "", time.Time{}, f)
+}
+
+// SingleThumbnail serves the thumbnail of a file by repo path
+func SingleThumbnail(ctx *context.Context) {
+	blob, err := ctx.Repo.Commit.GetBlobByPath(ctx.Repo.TreePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetBlobByPath", nil)
+		} else {
+			ctx.ServerError("GetBlobByPath", err)
+		}
+		return
+	}
+	ServeThumbnail(ctx, blob)
+}
+
+// ThumbnailByID serves the thumbnail of a blob by sha1 ID
+func ThumbnailByID(ctx *context.Context) {
+	blob, err := ctx.Repo.GitRepo.GetBlob(ctx.Params("sha"))
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetBlob", nil)
+		} else {
+			ctx.ServerError("GetBlob", err)
+		}
+		return
+	}
+	ServeThumbnail(ctx, blob)
+}
+
+// MustEnableThumbnail checks if thumbnail generation is enabled in settings
+func MustEnableThumbnail(ctx *context.Context) {
+	if !setting.Thumbnail.Enabled {
+		ctx.NotFound("MustEnableThumbnail", nil)
+	}
+}
+
+// Layers serves the layer stack of a layered document by repo path as JSON,
+// or the image of one layer when the "layer" query is set
+func Layers(ctx *context.Context) {
+	blob, err := ctx.Repo.Commit.GetBlobByPath(ctx.Repo.TreePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetBlobByPath", nil)
+		} else {
+			ctx.ServerError("GetBlobByPath", err)
+		}
+		return
+	}
+	if !layers.IsLayeredFile(ctx.Repo.TreePath) {
+		ctx.NotFound("IsLayeredFile", nil)
+		return
+	}
+
+	layer := ctx.Query("layer")
+	if len(layer) == 0 {
+		info, err := thumbnail.GetLayers(ctx.Repo.Repository, blob, ctx.Repo.TreePath)
+		if err != nil {
+			serveThumbnailError(ctx, "GetLayers", err)
+			return
+		}
+		ctx.JSON(http.StatusOK, info)
+		return
+	}
+
+	index := thumbnail.CompositeLayer
+	if layer != "composite" {
+		if index, err = strconv.Atoi(layer); err != nil {
+			ctx.Error(http.StatusBadRequest, "invalid layer")
+			return
+		}
+	}
+	f, err := thumbnail.OpenLayer(ctx.Repo.Repository, blob, ctx.Repo.TreePath, index)
+	if err != nil {
+		if os.IsNotExist(err) {
+			ctx.NotFound("OpenLayer", nil)
+		} else {
+			serveThumbnailError(ctx, "OpenLayer", err)
+		}
+		return
+	}
+	defer f.Close()
+
+	ctx.Resp.Header().Set("Cache-Control", thumbnail.CacheControl(ctx.Repo.Repository))
+	ctx.Resp.Header().Set("Content-Type", "image/png")
+	ctx.Resp.Header().Set("ETag", fmt.Sprintf(`"%s-layer-%d"`, blob.ID, index))
+	http.ServeContent(ctx.Resp, ctx.Req.Request, "", time.Time{}, f)
+}
+
+func serveThumbnailError(ctx *context.Context, name string, err error) {
+	switch err {
+	case thumbnail.ErrInvalidSize, thumbnail.ErrNotImage, thumbnail.ErrSourceTooLarge:
+		ctx.Error(http.StatusUnprocessableEntity, err.Error())
+	default:
+		ctx.ServerError(name, err)
+	}
+}
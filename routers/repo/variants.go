@@ -0,0 +1,339 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/repofiles"
+	variant_service "code.gitea.io/gitea/services/variant"
+)
+
+const (
+	tplVariants    base.TplName = "repo/variants/list"
+	tplVariantNew  base.TplName = "repo/variants/new"
+	tplVariantView base.TplName = "repo/variants/view"
+)
+
+// variantInfo holds a variant with the state of its branch
+type variantInfo struct {
+	*models.Variant
+	Activity *git.CodeActivityStats
+	// Divergence from the default branch, nil if the branch is missing
+	Divergence *git.DivergeObject
+}
+
+// canEditVariant returns whether the signed user may edit or delete the variant
+func canEditVariant(ctx *context.Context, v *models.Variant) bool {
+	if !ctx.IsSigned || ctx.Repo.Repository.IsArchived {
+		return false
+	}
+	return ctx.User.ID == v.OwnerID || ctx.Repo.IsAdmin()
+}
+
+// prepareVariantsData sets the data shared by all variant pages and returns the start of the activity period
+func prepareVariantsData(ctx *context.Context) time.Time {
+	ctx.Data["PageIsVariants"] = true
+	ctx.Data["VariantsLink"] = ctx.Repo.RepoLink + "/variants"
+	ctx.Data["CanCreateVariant"] = ctx.Repo.CanCreateBranch() && !ctx.Repo.Repository.IsArchived
+
+	timeFrom, ok := activityTimeFrom(time.Now(), ctx.Query("period"))
+	if ok {
+		ctx.Data["Period"] = ctx.Query("period")
+	} else {
+		ctx.Data["Period"] = "weekly"
+	}
+	ctx.Data["PeriodText"] = ctx.Tr("repo.activity.period." + ctx.Data["Period"].(string))
+	return timeFrom
+}
+
+// loadVariantInfos loads the owners, the activity since timeFrom and the divergence from the default branch of the variants
+func loadVariantInfos(ctx *context.Context, variants models.VariantList, timeFrom time.Time) []*variantInfo {
+	for _, v := range variants {
+		v.Repo = ctx.Repo.Repository
+	}
+	if err := variants.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return nil
+	}
+	stats, err := models.GetVariantsActivityStats(ctx.Repo.Repository, variants, timeFrom)
+	if err != nil {
+		ctx.ServerError("GetVariantsActivityStats", err)
+		return nil
+	}
+
+	infos := make([]*variantInfo, 0, len(variants))
+	for _, v := range variants {
+		info := &variantInfo{
+			Variant:  v,
+			Activity: stats[v.ID],
+		}
+		if ctx.Repo.GitRepo.IsBranchExist(v.BranchName) {
+			info.Divergence, err = repofiles.CountDivergingCommits(ctx.Repo.Repository, v.BranchName)
+			if err != nil {
+				ctx.ServerError("CountDivergingCommits", err)
+				return nil
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Variants renders the gallery of the variants of a repository
+func Variants(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.variants")
+	timeFrom := prepareVariantsData(ctx)
+
+	sortType := ctx.Query("sort")
+	variants, err := models.GetVariants(ctx.Repo.Repository.ID, sortType)
+	if err != nil {
+		ctx.ServerError("GetVariants", err)
+		return
+	}
+	infos := loadVariantInfos(ctx, variants, timeFrom)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["Variants"] = infos
+	ctx.Data["SortType"] = sortType
+
+	ctx.HTML(200, tplVariants)
+}
+
+// ViewVariant renders the page of a variant
+func ViewVariant(ctx *context.Context) {
+	timeFrom := prepareVariantsData(ctx)
+
+	v := getVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	infos := loadVariantInfos(ctx, models.VariantList{v}, timeFrom)
+	if ctx.Written() {
+		return
+	}
+	v.RenderedContent = string(markdown.Render([]byte(v.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+
+	stargazers, err := v.GetStargazers(1)
+	if err != nil {
+		ctx.ServerError("GetStargazers", err)
+		return
+	}
+
+	ctx.Data["Title"] = v.Title
+	ctx.Data["Variant"] = infos[0]
+	ctx.Data["Stargazers"] = stargazers
+	ctx.Data["IsStaringVariant"] = ctx.IsSigned && models.IsStaringVariant(ctx.User.ID, v.ID)
+	ctx.Data["CanEditVariant"] = canEditVariant(ctx, v)
+
+	ctx.HTML(200, tplVariantView)
+}
+
+// prepareVariantBranches sets the branches a new variant can be based on
+func prepareVariantBranches(ctx *context.Context) {
+	branches, err := ctx.Repo.Repository.GetBranches()
+	if err != nil {
+		ctx.ServerError("GetBranches", err)
+		return
+	}
+	names := make([]string, 0, len(branches))
+	for _, branch := range branches {
+		names = append(names, branch.Name)
+	}
+	ctx.Data["Branches"] = names
+}
+
+// NewVariant renders the page to create a variant
+func NewVariant(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.variants.new")
+	prepareVariantsData(ctx)
+	prepareVariantBranches(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["branch"] = ctx.Query("branch")
+	ctx.Data["base_branch"] = ctx.Repo.Repository.DefaultBranch
+	ctx.HTML(200, tplVariantNew)
+}
+
+// NewVariantPost creates a variant, and its branch if it does not exist yet
+func NewVariantPost(ctx *context.Context, form auth.CreateVariantForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.variants.new")
+	prepareVariantsData(ctx)
+	prepareVariantBranches(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplVariantNew)
+		return
+	}
+
+	v := &models.Variant{
+		BranchName:  form.Branch,
+		Title:       form.Title,
+		Description: form.Content,
+		CoverPath:   form.CoverPath,
+	}
+	if err := variant_service.CreateVariant(ctx.User, ctx.Repo.Repository, v, form.BaseBranch); err != nil {
+		switch {
+		case models.IsErrVariantAlreadyExists(err):
+			ctx.Data["Err_Branch"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.variants.already_exists", form.Branch), tplVariantNew, &form)
+		case models.IsErrVariantInvalidCover(err):
+			ctx.Data["Err_CoverPath"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.variants.invalid_cover", form.CoverPath), tplVariantNew, &form)
+		case git.IsErrBranchNotExist(err):
+			ctx.RenderWithErr(ctx.Tr("repo.variants.base_branch_not_exist", form.BaseBranch), tplVariantNew, &form)
+		case models.IsErrTagAlreadyExists(err):
+			ctx.Data["Err_Branch"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.branch.tag_collision", form.Branch), tplVariantNew, &form)
+		case models.IsErrBranchNameConflict(err):
+			ctx.Data["Err_Branch"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.branch.branch_name_conflict", form.Branch, err.(models.ErrBranchNameConflict).BranchName), tplVariantNew, &form)
+		default:
+			ctx.ServerError("CreateVariant", err)
+		}
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.variants.create_success", v.Title))
+	ctx.Redirect(fmt.Sprintf("%s/variants/%d", ctx.Repo.RepoLink, v.ID))
+}
+
+// EditVariant renders the page to edit a variant
+func EditVariant(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.variants.edit")
+	ctx.Data["PageIsEditVariant"] = true
+	prepareVariantsData(ctx)
+
+	v := getEditableVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["branch"] = v.BranchName
+	ctx.Data["title"] = v.Title
+	ctx.Data["content"] = v.Description
+	ctx.Data["cover_path"] = v.CoverPath
+	ctx.HTML(200, tplVariantNew)
+}
+
+// EditVariantPost updates the title, the description and the cover of a variant
+func EditVariantPost(ctx *context.Context, form auth.EditVariantForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.variants.edit")
+	ctx.Data["PageIsEditVariant"] = true
+	prepareVariantsData(ctx)
+
+	v := getEditableVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["branch"] = v.BranchName
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplVariantNew)
+		return
+	}
+
+	v.Title = form.Title
+	v.Description = form.Content
+	v.CoverPath = form.CoverPath
+	if err := variant_service.UpdateVariant(ctx.Repo.Repository, v); err != nil {
+		if models.IsErrVariantInvalidCover(err) {
+			ctx.Data["Err_CoverPath"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.variants.invalid_cover", form.CoverPath), tplVariantNew, &form)
+			return
+		}
+		ctx.ServerError("UpdateVariant", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.variants.edit_success", v.Title))
+	ctx.Redirect(fmt.Sprintf("%s/variants/%d", ctx.Repo.RepoLink, v.ID))
+}
+
+// DeleteVariant deletes a variant, its branch is kept
+func DeleteVariant(ctx *context.Context) {
+	v, err := models.GetVariantByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		if models.IsErrVariantNotExist(err) {
+			ctx.NotFound("GetVariantByRepoID", err)
+		} else {
+			ctx.ServerError("GetVariantByRepoID", err)
+		}
+		return
+	}
+	if !canEditVariant(ctx, v) {
+		ctx.NotFound("DeleteVariant", nil)
+		return
+	}
+
+	if err := models.DeleteVariantByRepoID(ctx.Repo.Repository.ID, v.ID); err != nil {
+		ctx.Flash.Error("DeleteVariant: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.variants.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Repo.RepoLink + "/variants",
+	})
+}
+
+// VariantAction stars or unstars a variant to back it
+func VariantAction(ctx *context.Context) {
+	v := getVariantByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var err error
+	switch ctx.Params(":action") {
+	case "star":
+		err = models.StarVariant(ctx.User.ID, v.ID, true)
+	case "unstar":
+		err = models.StarVariant(ctx.User.ID, v.ID, false)
+	}
+	if err != nil {
+		ctx.ServerError(fmt.Sprintf("VariantAction (%s)", ctx.Params(":action")), err)
+		return
+	}
+
+	ctx.Redirect(fmt.Sprintf("%s/variants/%d", ctx.Repo.RepoLink, v.ID))
+}
+
+func getVariantByParams(ctx *context.Context) *models.Variant {
+	v, err := models.GetVariantByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrVariantNotExist(err) {
+			ctx.NotFound("GetVariantByParams", err)
+		} else {
+			ctx.ServerError("GetVariantByParams", err)
+		}
+		return nil
+	}
+	v.Repo = ctx.Repo.Repository
+	return v
+}
+
+func getEditableVariantByParams(ctx *context.Context) *models.Variant {
+	v := getVariantByParams(ctx)
+	if ctx.Written() {
+		return nil
+	}
+	if !canEditVariant(ctx, v) {
+		ctx.NotFound("getEditableVariantByParams", nil)
+		return nil
+	}
+	return v
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	gotemplate "html/template"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"path"
@@ -21,10 +22,14 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/highlight"
+	"code.gitea.io/gitea/modules/layers"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/repofiles"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/thumbnail"
 )
 
 const (
@@ -49,6 +54,34 @@ func renderDirectory(ctx *context.Context, treeLink string) {
 	}
 	entries.CustomSort(base.NaturalSortLess)
 
+	// folders of images and folders with a manifest can be read page by page
+	for _, entry := range entries {
+		if !entry.IsDir() && (entry.Name() == repofiles.ReaderManifest || repofiles.IsReaderImage(entry.Name())) {
+			ctx.Data["ReaderLink"] = ctx.Repo.RepoLink + "/reader/" + util.PathEscapeSegments(ctx.Repo.BranchNameSubURL()) + "/" + util.PathEscapeSegments(ctx.Repo.TreePath)
+			break
+		}
+	}
+
+	if setting.Thumbnail.Enabled && len(setting.Thumbnail.Sizes) > 0 {
+		ctx.Data["ThumbnailSize"] = setting.Thumbnail.Sizes[0]
+	}
+
+	// the locks of the files of this directory, by name
+	if setting.LFS.StartServer {
+		locks, err := models.GetLFSLockByRepoID(ctx.Repo.Repository.ID)
+		if err != nil {
+			ctx.ServerError("GetLFSLockByRepoID", err)
+			return
+		}
+		treeLocks := make(map[string]*models.LFSLock)
+		for _, lock := range locks {
+			if dir := path.Dir(lock.Path); dir == ctx.Repo.TreePath || (dir == "." && ctx.Repo.TreePath == "") {
+				treeLocks[path.Base(lock.Path)] = lock
+			}
+		}
+		ctx.Data["LFSLocks"] = treeLocks
+	}
+
 	var latestCommit *git.Commit
 	ctx.Data["Files"], latestCommit, err = entries.GetCommitsInfo(ctx.Repo.Commit, ctx.Repo.TreePath, nil)
 	if err != nil {
@@ -216,6 +249,9 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 	ctx.Data["FileName"] = blob.Name()
 	ctx.Data["HighlightClass"] = highlight.FileNameToHighlightClass(blob.Name())
 	ctx.Data["RawFileLink"] = rawLink + "/" + ctx.Repo.TreePath
+	if blob.Name() == repofiles.ReaderManifest {
+		ctx.Data["ReaderLink"] = ctx.Repo.RepoLink + "/reader/" + util.PathEscapeSegments(ctx.Repo.BranchNameSubURL()) + "/" + util.PathEscapeSegments(ctx.Repo.TreePath)
+	}
 
 	buf := make([]byte, 1024)
 	n, _ := dataRc.Read(buf)
@@ -277,6 +313,26 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 		ctx.Data["LFSLockHint"] = ctx.Tr("repo.editor.this_file_locked")
 	}
 
+	// Check whether the file was imported from another repository
+	assetUsage, err := models.GetAssetUsageByTreePath(ctx.Repo.Repository.ID, ctx.Repo.TreePath)
+	if err != nil {
+		ctx.ServerError("GetAssetUsageByTreePath", err)
+		return
+	}
+	if assetUsage != nil {
+		if err = assetUsage.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+		perm, err := models.GetUserRepoPermission(assetUsage.SourceRepo, ctx.User)
+		if err != nil {
+			ctx.ServerError("GetUserRepoPermission", err)
+			return
+		}
+		ctx.Data["AssetUsage"] = assetUsage
+		ctx.Data["CanReadAssetSource"] = perm.CanRead(models.UnitTypeCode)
+	}
+
 	// Assume file is not editable first.
 	if isLFSFile {
 		ctx.Data["EditFileTooltip"] = ctx.Tr("repo.editor.cannot_edit_lfs_files")
@@ -359,6 +415,9 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 			}
 		}
 
+	case setting.Thumbnail.Enabled && layers.IsLayeredFile(blob.Name()):
+		ctx.Data["IsLayeredFile"] = true
+		ctx.Data["LayersLink"] = fmt.Sprintf("%s/layers/commit/%s/%s", ctx.Repo.RepoLink, ctx.Repo.CommitID, ctx.Repo.TreePath)
 	case base.IsPDFFile(buf):
 		ctx.Data["IsPDFFile"] = true
 	case base.IsVideoFile(buf):
@@ -367,6 +426,20 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 		ctx.Data["IsAudioFile"] = true
 	case base.IsImageFile(buf):
 		ctx.Data["IsImageFile"] = true
+		// a source too large for a thumbnail is shown with the raw link
+		if setting.Thumbnail.Enabled && len(setting.Thumbnail.Sizes) > 0 &&
+			thumbnail.IsSupportedSource(fileSize, io.MultiReader(bytes.NewReader(buf), dataRc)) {
+			ctx.Data["ThumbnailLink"] = fmt.Sprintf("%s/thumbnail/blob/%s?size=%d", ctx.Repo.RepoLink, blob.ID, setting.Thumbnail.Sizes[len(setting.Thumbnail.Sizes)-1])
+		}
+		// show the comments made in pull requests on regions of this version of the image
+		if ctx.Repo.CanRead(models.UnitTypePullRequests) {
+			comments, err := models.FindRegionComments(ctx.Repo.Repository.ID, ctx.User, blob.ID.String())
+			if err != nil {
+				ctx.ServerError("FindRegionComments", err)
+				return
+			}
+			ctx.Data["RegionComments"] = models.GroupRegionComments(comments)
+		}
 	default:
 		if fileSize >= setting.UI.MaxDisplayFileSize {
 			ctx.Data["IsFileTooLarge"] = true
@@ -484,6 +557,14 @@ func renderCode(ctx *context.Context) {
 	}
 	ctx.Data["Topics"] = topics
 
+	// The keystone is shown before the tree of the repository root
+	if len(ctx.Repo.TreePath) == 0 && ctx.Repo.CanRead(models.UnitTypeKeystone) {
+		renderKeystoneLanding(ctx)
+		if ctx.Written() {
+			return
+		}
+	}
+
 	// Get current entry user currently looking at.
 	entry, err := ctx.Repo.Commit.GetTreeEntryByPath(ctx.Repo.TreePath)
 	if err != nil {
@@ -500,6 +581,11 @@ func renderCode(ctx *context.Context) {
 		return
 	}
 
+	renderAssetTags(ctx)
+	if ctx.Written() {
+		return
+	}
+
 	var treeNames []string
 	paths := make([]string, 0, 5)
 	if len(ctx.Repo.TreePath) > 0 {
@@ -8,8 +8,9 @@ import (
 	"bytes"
 	"encoding/gob"
 	"net/http"
-	"os"
 	"path"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers"
@@ -107,6 +109,53 @@ func RouterHandler(level log.Level) func(ctx *macaron.Context) {
 	}
 }
 
+// storageHandler serves the objects of a storage below prefix, local storages
+// are served like any other static files
+func storageHandler(cfg setting.Storage, prefix string, objStore storage.ObjectStorage) macaron.Handler {
+	if cfg.Type == setting.LocalStorageType {
+		return public.StaticHandler(cfg.Path, &public.Options{
+			Prefix:       prefix,
+			SkipLogging:  setting.DisableRouterLog,
+			ExpiresAfter: setting.StaticCacheTime,
+		})
+	}
+
+	prefix = "/" + prefix + "/"
+	return func(ctx *macaron.Context) {
+		if ctx.Req.Method != "GET" && ctx.Req.Method != "HEAD" {
+			return
+		}
+		if !strings.HasPrefix(ctx.Req.URL.Path, prefix) {
+			return
+		}
+		rPath := strings.TrimPrefix(ctx.Req.URL.Path, prefix)
+
+		if cfg.ServeDirect {
+			u, err := objStore.URL(rPath, path.Base(rPath), cfg.ServeDirectExpiry)
+			if err == nil {
+				http.Redirect(ctx.Resp, ctx.Req.Request, u.String(), http.StatusTemporaryRedirect)
+				return
+			}
+			log.Error("Unable to get direct URL of %s%s: %v", prefix, rPath, err)
+		}
+
+		obj, err := objStore.Open(rPath)
+		if err != nil {
+			if storage.IsNotExist(err) {
+				ctx.Error(http.StatusNotFound)
+			} else {
+				log.Error("Unable to open %s%s: %v", prefix, rPath, err)
+				ctx.Error(http.StatusInternalServerError)
+			}
+			return
+		}
+		defer obj.Close()
+
+		ctx.Resp.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(setting.StaticCacheTime.Seconds())))
+		http.ServeContent(ctx.Resp, ctx.Req.Request, path.Base(rPath), time.Time{}, obj)
+	}
+}
+
 // NewMacaron initializes Macaron instance.
 func NewMacaron() *macaron.Macaron {
 	gob.Register(&u2f.Challenge{})
@@ -149,22 +198,8 @@ func NewMacaron() *macaron.Macaron {
 			ExpiresAfter: setting.StaticCacheTime,
 		},
 	))
-	m.Use(public.StaticHandler(
-		setting.AvatarUploadPath,
-		&public.Options{
-			Prefix:       "avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
-	m.Use(public.StaticHandler(
-		setting.RepositoryAvatarUploadPath,
-		&public.Options{
-			Prefix:       "repo-avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
+	m.Use(storageHandler(setting.AvatarStorage, "avatars", storage.Avatars))
+	m.Use(storageHandler(setting.RepositoryAvatarStorage, "repo-avatars", storage.RepoAvatars))
 
 	m.Use(templates.HTMLRenderer())
 	mailer.InitMailRender(templates.Mailer())
@@ -495,17 +530,27 @@ func RegisterRoutes(m *macaron.Macaron) {
 				return
 			}
 
-			fr, err := os.Open(attach.LocalPath())
-			if err != nil {
-				ctx.ServerError("Open", err)
+			if err := attach.IncreaseDownloadCount(); err != nil {
+				ctx.ServerError("Update", err)
 				return
 			}
-			defer fr.Close()
 
-			if err := attach.IncreaseDownloadCount(); err != nil {
-				ctx.ServerError("Update", err)
+			if setting.AttachmentStorage.ServeDirect {
+				u, err := storage.Attachments.URL(attach.RelativePath(), attach.Name, setting.AttachmentStorage.ServeDirectExpiry)
+				if err == nil {
+					ctx.Redirect(u.String())
+					return
+				} else if err != storage.ErrURLNotSupported {
+					log.Error("Unable to get direct URL of attachment %s: %v", attach.UUID, err)
+				}
+			}
+
+			fr, err := storage.Attachments.Open(attach.RelativePath())
+			if err != nil {
+				ctx.ServerError("Open", err)
 				return
 			}
+			defer fr.Close()
 
 			if err = repo.ServeData(ctx, attach.Name, fr); err != nil {
 				ctx.ServerError("ServeData", err)
@@ -538,6 +583,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 	reqRepoPullsReader := context.RequireRepoReader(models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsWriter := context.RequireRepoWriterOr(models.UnitTypeIssues, models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsReader := context.RequireRepoReaderOr(models.UnitTypeIssues, models.UnitTypePullRequests)
+	reqRepoProjectsWriter := context.RequireRepoWriter(models.UnitTypeProjects)
+	reqRepoProjectsReader := context.RequireRepoReader(models.UnitTypeProjects)
+	reqRepoKeystoneWriter := context.RequireRepoWriter(models.UnitTypeKeystone)
+	reqRepoKeystoneReader := context.RequireRepoReader(models.UnitTypeKeystone)
 
 	reqRepoIssueWriter := func(ctx *context.Context) {
 		if !ctx.Repo.CanWrite(models.UnitTypeIssues) {
@@ -604,7 +653,33 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
 			})
 		}, context.OrgAssignment(true, true))
+
+		m.Group("/:org/projects", func() {
+			m.Combo("/new").Get(repo.NewProject).
+				Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+			m.Get("/:id/edit", repo.EditProject)
+			m.Post("/:id/edit", bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+			m.Get("/:id/:action", repo.ChangeProjectStatus)
+			m.Post("/delete", repo.DeleteProject)
+			m.Group("/:id", func() {
+				m.Post("/sort", repo.SortProjectColumns)
+				m.Post("/columns", bindIgnErr(auth.ProjectColumnForm{}), repo.NewProjectColumn)
+				m.Post("/columns/delete", repo.DeleteProjectColumn)
+				m.Post("/columns/:columnID", bindIgnErr(auth.ProjectColumnForm{}), repo.EditProjectColumn)
+				m.Post("/columns/:columnID/default", repo.SetDefaultProjectColumn)
+				m.Post("/columns/:columnID/cards", repo.MoveProjectCards)
+				m.Post("/cards", bindIgnErr(auth.AddProjectCardForm{}), repo.AddProjectCard)
+				m.Post("/cards/delete", repo.DeleteProjectCard)
+			})
+		}, context.OrgAssignment(true), context.RequireOrgProjectsAccess(models.AccessModeWrite))
+
+		m.Group("/:org/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ViewProject)
+		}, context.OrgAssignment(true), context.RequireOrgProjectsAccess(models.AccessModeRead))
 	}, reqSignIn)
+
+	m.Get("/org/:org/assets", ignSignIn, context.OrgAssignment(), repo.AssetLibrary)
 	// ***** END: Organization *****
 
 	// ***** START: Repository *****
@@ -753,6 +828,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Group("/milestone", func() {
 			m.Get("/:id", repo.MilestoneIssuesAndPulls)
 		}, reqRepoIssuesOrPullsReader, context.RepoRef())
+		m.Group("/projects", func() {
+			m.Combo("/new").Get(repo.NewProject).
+				Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+			m.Get("/:id/edit", repo.EditProject)
+			m.Post("/:id/edit", bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+			m.Get("/:id/:action", repo.ChangeProjectStatus)
+			m.Post("/delete", repo.DeleteProject)
+			m.Group("/:id", func() {
+				m.Post("/sort", repo.SortProjectColumns)
+				m.Post("/columns", bindIgnErr(auth.ProjectColumnForm{}), repo.NewProjectColumn)
+				m.Post("/columns/delete", repo.DeleteProjectColumn)
+				m.Post("/columns/:columnID", bindIgnErr(auth.ProjectColumnForm{}), repo.EditProjectColumn)
+				m.Post("/columns/:columnID/default", repo.SetDefaultProjectColumn)
+				m.Post("/columns/:columnID/cards", repo.MoveProjectCards)
+				m.Post("/cards", bindIgnErr(auth.AddProjectCardForm{}), repo.AddProjectCard)
+				m.Post("/cards/delete", repo.DeleteProjectCard)
+			})
+		}, context.RepoMustNotBeArchived(), reqRepoProjectsWriter, context.RepoRef())
 		m.Combo("/compare/*", repo.MustBeNotEmpty, reqRepoCodeReader, repo.SetEditorconfigIfExists).
 			Get(repo.SetDiffViewStyle, repo.CompareDiff).
 			Post(context.RepoMustNotBeArchived(), reqRepoPullsReader, repo.MustAllowPulls, bindIgnErr(auth.CreateIssueForm{}), repo.CompareAndPullRequestPost)
@@ -772,6 +865,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 			}, context.RepoRefByType(context.RepoRefBranch), repo.MustBeEditable)
 			m.Group("", func() {
 				m.Post("/upload-file", repo.UploadFileToServer)
+				m.Combo("/upload-file/chunk").Get(repo.GetUploadFileChunksSize).
+					Post(repo.UploadFileChunkToServer)
 				m.Post("/upload-remove", bindIgnErr(auth.RemoveUploadFileForm{}), repo.RemoveUploadFileFromServer)
 			}, context.RepoRef(), repo.MustBeEditable, repo.MustBeAbleToUpload)
 		}, context.RepoMustNotBeArchived(), reqRepoCodeWriter, repo.MustBeNotEmpty)
@@ -786,6 +881,23 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/restore", repo.RestoreBranchPost)
 		}, context.RepoMustNotBeArchived(), reqRepoCodeWriter, repo.MustBeNotEmpty)
 
+		m.Group("/variants", func() {
+			m.Combo("/new", reqRepoCodeWriter).Get(repo.NewVariant).
+				Post(bindIgnErr(auth.CreateVariantForm{}), repo.NewVariantPost)
+			m.Combo("/:id/edit").Get(repo.EditVariant).
+				Post(bindIgnErr(auth.EditVariantForm{}), repo.EditVariantPost)
+			m.Post("/delete", repo.DeleteVariant)
+		}, context.RepoMustNotBeArchived(), reqRepoCodeReader, repo.MustBeNotEmpty, context.RepoRef())
+		m.Post("/variants/:id/:action(star|unstar)", reqRepoCodeReader, repo.MustBeNotEmpty, context.RepoRef(), repo.VariantAction)
+
+		m.Post("/assets/tags", context.RepoMustNotBeArchived(), reqRepoCodeWriter, repo.MustBeNotEmpty,
+			bindIgnErr(auth.AssetTagsForm{}), repo.AssetTagsPost)
+
+		m.Group("/locks", func() {
+			m.Post("", repo.LFSLockPost)
+			m.Post("/:lid/unlock", repo.LFSUnlockPost)
+		}, repo.MustEnableLFS, context.RepoMustNotBeArchived(), reqRepoCodeWriter, repo.MustBeNotEmpty, context.RepoRef())
+
 	}, reqSignIn, context.RepoAssignment(), context.UnitTypes())
 
 	// Releases
@@ -829,6 +941,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/milestones", reqRepoIssuesOrPullsReader, repo.Milestones)
 		}, context.RepoRef())
 
+		m.Group("/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ViewProject)
+		}, reqRepoProjectsReader, context.RepoRef())
+
+		m.Group("/keystone", func() {
+			m.Get("/_history", repo.KeystoneHistory)
+			m.Get("/raw/*", repo.KeystoneRaw)
+
+			m.Group("", func() {
+				m.Post("/_init", repo.InitKeystonePost)
+				m.Combo("/:page/_edit").Get(repo.EditKeystone).
+					Post(bindIgnErr(auth.EditKeystoneForm{}), repo.EditKeystonePost)
+			}, context.RepoMustNotBeArchived(), reqSignIn, reqRepoKeystoneWriter)
+
+			m.Get("/?:page", repo.Keystone)
+		}, reqRepoKeystoneReader, context.RepoRef())
+
 		m.Group("/wiki", func() {
 			m.Get("/?:page", repo.Wiki)
 			m.Get("/_pages", repo.WikiPages)
@@ -865,6 +995,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("", repo.Branches)
 		}, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader)
 
+		m.Group("/variants", func() {
+			m.Get("", repo.Variants)
+			m.Get("/:id", repo.ViewVariant)
+		}, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader)
+
+		m.Get("/assets", repo.MustBeNotEmpty, reqRepoCodeReader, repo.AssetLibrary)
+
+		m.Get("/locks", repo.MustEnableLFS, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader, repo.LFSLocks)
+
 		m.Group("/blob_excerpt", func() {
 			m.Get("/:sha", repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.ExcerptBlob)
 		}, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader)
@@ -893,6 +1032,25 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/*", context.RepoRefByType(context.RepoRefLegacy), repo.SingleDownloadOrLFS)
 		}, repo.MustBeNotEmpty, reqRepoCodeReader)
 
+		m.Group("/thumbnail", func() {
+			m.Get("/branch/*", context.RepoRefByType(context.RepoRefBranch), repo.SingleThumbnail)
+			m.Get("/tag/*", context.RepoRefByType(context.RepoRefTag), repo.SingleThumbnail)
+			m.Get("/commit/*", context.RepoRefByType(context.RepoRefCommit), repo.SingleThumbnail)
+			m.Get("/blob/:sha", context.RepoRefByType(context.RepoRefBlob), repo.ThumbnailByID)
+		}, repo.MustEnableThumbnail, repo.MustBeNotEmpty, reqRepoCodeReader)
+
+		m.Group("/reader", func() {
+			m.Get("/branch/*", context.RepoRefByType(context.RepoRefBranch), repo.Reader)
+			m.Get("/tag/*", context.RepoRefByType(context.RepoRefTag), repo.Reader)
+			m.Get("/commit/*", context.RepoRefByType(context.RepoRefCommit), repo.Reader)
+		}, repo.MustBeNotEmpty, reqRepoCodeReader)
+
+		m.Group("/layers", func() {
+			m.Get("/branch/*", context.RepoRefByType(context.RepoRefBranch), repo.Layers)
+			m.Get("/tag/*", context.RepoRefByType(context.RepoRefTag), repo.Layers)
+			m.Get("/commit/*", context.RepoRefByType(context.RepoRefCommit), repo.Layers)
+		}, repo.MustEnableThumbnail, repo.MustBeNotEmpty, reqRepoCodeReader)
+
 		m.Group("/raw", func() {
 			m.Get("/branch/*", context.RepoRefByType(context.RepoRefBranch), repo.SingleDownload)
 			m.Get("/tag/*", context.RepoRefByType(context.RepoRefTag), repo.SingleDownload)
@@ -0,0 +1,133 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package asset
+
+import (
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	asset_indexer "code.gitea.io/gitea/modules/indexer/assets"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// SetAssetTags replaces the tags of a file or a folder of a branch and updates the asset library,
+// it returns the sanitized tags.
+func SetAssetTags(doer *models.User, repo *models.Repository, branchName, treePath string, tags []string) ([]string, error) {
+	validTags, invalidTags := models.SanitizeAndValidateAssetTags(tags)
+	if len(invalidTags) > 0 {
+		return nil, models.ErrInvalidAssetTags{Tags: invalidTags}
+	}
+
+	treePath = strings.Trim(treePath, "/")
+	if treePath == "" {
+		return nil, models.ErrRepoFileDoesNotExist{Path: treePath}
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	if !gitRepo.IsBranchExist(branchName) {
+		return nil, git.ErrBranchNotExist{Name: branchName}
+	}
+	commit, err := gitRepo.GetBranchCommit(branchName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := commit.GetTreeEntryByPath(treePath); err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, models.ErrRepoFileDoesNotExist{Path: treePath}
+		}
+		return nil, err
+	}
+
+	if err := models.SetAssetTags(repo.ID, branchName, treePath, doer.ID, validTags); err != nil {
+		return nil, err
+	}
+	return validTags, asset_indexer.UpdateAssetIndexer(repo, branchName, treePath)
+}
+
+// LibraryAsset is a tagged asset listed by an asset library
+type LibraryAsset struct {
+	*asset_indexer.IndexerData
+	Repo *models.Repository
+}
+
+// Link returns the link to the asset in its branch
+func (a *LibraryAsset) Link() string {
+	return a.Repo.Link() + "/src/branch/" + util.PathEscapeSegments(a.BranchName) + "/" + util.PathEscapeSegments(a.TreePath)
+}
+
+// HTMLURL returns the absolute URL to the asset in its branch
+func (a *LibraryAsset) HTMLURL() string {
+	return a.Repo.HTMLURL() + "/src/branch/" + util.PathEscapeSegments(a.BranchName) + "/" + util.PathEscapeSegments(a.TreePath)
+}
+
+// RawLink returns the link to the raw content of the asset
+func (a *LibraryAsset) RawLink() string {
+	return a.Repo.Link() + "/raw/branch/" + util.PathEscapeSegments(a.BranchName) + "/" + util.PathEscapeSegments(a.TreePath)
+}
+
+// ThumbnailLink returns the link to a thumbnail of the asset
+func (a *LibraryAsset) ThumbnailLink(size int) string {
+	return a.Repo.Link() + "/thumbnail/branch/" + util.PathEscapeSegments(a.BranchName) + "/" + util.PathEscapeSegments(a.TreePath) + "?size=" + strconv.Itoa(size)
+}
+
+// IsImage returns true if the asset is an image
+func (a *LibraryAsset) IsImage() bool {
+	return a.FileType == asset_indexer.FileTypeImage
+}
+
+// LibraryRepositories returns the repositories of an owner whose code the doer can read,
+// they make the library of the owner
+func LibraryRepositories(owner, doer *models.User) ([]*models.Repository, error) {
+	if owner.NumRepos == 0 {
+		return nil, nil
+	}
+	repos, err := models.GetUserRepositories(owner.ID, true, 1, owner.NumRepos, "")
+	if err != nil {
+		return nil, err
+	}
+
+	readable := make([]*models.Repository, 0, len(repos))
+	for _, repo := range repos {
+		perm, err := models.GetUserRepoPermission(repo, doer)
+		if err != nil {
+			return nil, err
+		}
+		if perm.CanRead(models.UnitTypeCode) {
+			readable = append(readable, repo)
+		}
+	}
+	return readable, nil
+}
+
+// SearchLibrary searches the tagged assets of some repositories, opts.RepoIDs is set from repos
+func SearchLibrary(repos []*models.Repository, opts *asset_indexer.SearchOptions) ([]*LibraryAsset, int64, error) {
+	reposByID := make(map[int64]*models.Repository, len(repos))
+	opts.RepoIDs = make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		reposByID[repo.ID] = repo
+		opts.RepoIDs = append(opts.RepoIDs, repo.ID)
+	}
+
+	result, err := asset_indexer.SearchAssets(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	assets := make([]*LibraryAsset, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		repo, ok := reposByID[hit.RepoID]
+		if !ok {
+			continue
+		}
+		assets = append(assets, &LibraryAsset{IndexerData: hit, Repo: repo})
+	}
+	return assets, result.Total, nil
+}
@@ -330,6 +330,13 @@ type DiffFile struct {
 	IsSubmodule        bool
 	Sections           []*DiffSection
 	IsIncomplete       bool
+	OldBlobID          string
+	NewBlobID          string
+	ImageDiff          *ImageDiff
+	OldRegionComments  [][]*models.Comment
+	NewRegionComments  [][]*models.Comment
+	LayerDiff          *LayerDiff
+	SVGDiff            *SVGDiff
 }
 
 // GetType returns type of diff file.
@@ -404,6 +411,9 @@ func (diff *Diff) LoadComments(issue *models.Issue, currentUser *models.User) er
 	}
 	for _, file := range diff.Files {
 		if lineCommits, ok := allComments[file.Name]; ok {
+			if file.IsImage() {
+				file.setRegionComments(lineCommits[0])
+			}
 			for _, section := range file.Sections {
 				for _, line := range section.Lines {
 					if comments, ok := lineCommits[int64(line.LeftIdx*-1)]; ok {
@@ -680,6 +690,10 @@ func ParsePatch(maxLines, maxLineCharacters, maxFiles int, reader io.Reader) (*D
 		case strings.HasPrefix(line, "Binary"):
 			curFile.IsBin = true
 			continue
+		case strings.HasPrefix(line, "index "):
+			// new and deleted files carry their index line after the mode line
+			parseIndexLine(curFile, line)
+			continue
 		}
 
 		// Get new file.
@@ -762,6 +776,7 @@ func ParsePatch(maxLines, maxLineCharacters, maxFiles int, reader io.Reader) (*D
 					curFile.IsDeleted = true
 				case strings.HasPrefix(line, "index"):
 					curFile.Type = DiffFileChange
+					parseIndexLine(curFile, line)
 				case strings.HasPrefix(line, "similarity index 100%"):
 					curFile.Type = DiffFileRename
 				}
@@ -806,15 +821,16 @@ func ParsePatch(maxLines, maxLineCharacters, maxFiles int, reader io.Reader) (*D
 
 // GetDiffRange builds a Diff between two commits of a repository.
 // passing the empty string as beforeCommitID returns a diff from the
-// parent commit.
-func GetDiffRange(repoPath, beforeCommitID, afterCommitID string, maxLines, maxLineCharacters, maxFiles int) (*Diff, error) {
-	return GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID, maxLines, maxLineCharacters, maxFiles, "")
+// parent commit. LFS pointers are resolved to the objects stored for repo.
+func GetDiffRange(repo *models.Repository, repoPath, beforeCommitID, afterCommitID string, maxLines, maxLineCharacters, maxFiles int) (*Diff, error) {
+	return GetDiffRangeWithWhitespaceBehavior(repo, repoPath, beforeCommitID, afterCommitID, maxLines, maxLineCharacters, maxFiles, "")
 }
 
 // GetDiffRangeWithWhitespaceBehavior builds a Diff between two commits of a repository.
 // Passing the empty string as beforeCommitID returns a diff from the parent commit.
-// The whitespaceBehavior is either an empty string or a git flag
-func GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID string, maxLines, maxLineCharacters, maxFiles int, whitespaceBehavior string) (*Diff, error) {
+// The whitespaceBehavior is either an empty string or a git flag.
+// LFS pointers are resolved to the objects stored for repo, none are resolved if it is nil.
+func GetDiffRangeWithWhitespaceBehavior(repo *models.Repository, repoPath, beforeCommitID, afterCommitID string, maxLines, maxLineCharacters, maxFiles int, whitespaceBehavior string) (*Diff, error) {
 	gitRepo, err := git.OpenRepository(repoPath)
 	if err != nil {
 		return nil, err
@@ -831,14 +847,14 @@ func GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID
 	defer cancel()
 	var cmd *exec.Cmd
 	if len(beforeCommitID) == 0 && commit.ParentCount() == 0 {
-		cmd = exec.CommandContext(ctx, git.GitExecutable, "show", afterCommitID)
+		cmd = exec.CommandContext(ctx, git.GitExecutable, "show", "--full-index", afterCommitID)
 	} else {
 		actualBeforeCommitID := beforeCommitID
 		if len(actualBeforeCommitID) == 0 {
 			parentCommit, _ := commit.Parent(0)
 			actualBeforeCommitID = parentCommit.ID.String()
 		}
-		diffArgs := []string{"diff", "-M"}
+		diffArgs := []string{"diff", "-M", "--full-index"}
 		if len(whitespaceBehavior) != 0 {
 			diffArgs = append(diffArgs, whitespaceBehavior)
 		}
@@ -871,6 +887,15 @@ func GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID
 		if tailSection != nil {
 			diffFile.Sections = append(diffFile.Sections, tailSection)
 		}
+		if err = diffFile.LoadImageDiff(repo, gitRepo); err != nil {
+			log.Error("LoadImageDiff [%s]: %v", diffFile.Name, err)
+		}
+		if err = diffFile.LoadLayerDiff(repo, gitRepo); err != nil {
+			log.Error("LoadLayerDiff [%s]: %v", diffFile.Name, err)
+		}
+		if err = diffFile.LoadSVGDiff(repo, gitRepo); err != nil {
+			log.Error("LoadSVGDiff [%s]: %v", diffFile.Name, err)
+		}
 	}
 
 	if err = cmd.Wait(); err != nil {
@@ -957,8 +982,8 @@ func GetRawDiffForFile(repoPath, startCommit, endCommit string, diffType RawDiff
 }
 
 // GetDiffCommit builds a Diff representing the given commitID.
-func GetDiffCommit(repoPath, commitID string, maxLines, maxLineCharacters, maxFiles int) (*Diff, error) {
-	return GetDiffRange(repoPath, "", commitID, maxLines, maxLineCharacters, maxFiles)
+func GetDiffCommit(repo *models.Repository, repoPath, commitID string, maxLines, maxLineCharacters, maxFiles int) (*Diff, error) {
+	return GetDiffRange(repo, repoPath, "", commitID, maxLines, maxLineCharacters, maxFiles)
 }
 
 // CommentAsDiff returns c.Patch as *Diff
@@ -0,0 +1,288 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	// Register the image formats whose dimensions can be compared
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/lfs"
+)
+
+// emptyBlobID is the ID git uses in an "index" line for a missing side
+const emptyBlobID = "0000000000000000000000000000000000000000"
+
+// DiffImage represents one revision of an image file in a diff.
+type DiffImage struct {
+	BlobID   string
+	IsLFS    bool
+	Width    int
+	Height   int
+	ByteSize int64
+}
+
+// ImageDiff holds the old and new revisions of an image file.
+// Either side is nil when the file was added or deleted.
+type ImageDiff struct {
+	Old *DiffImage
+	New *DiffImage
+}
+
+// IsComparable returns whether both revisions exist, so overlay modes
+// like swipe and onion skin can be offered.
+func (d *ImageDiff) IsComparable() bool {
+	return d.Old != nil && d.New != nil
+}
+
+// WidthDelta returns the change of width in pixels.
+func (d *ImageDiff) WidthDelta() int {
+	if !d.IsComparable() {
+		return 0
+	}
+	return d.New.Width - d.Old.Width
+}
+
+// HeightDelta returns the change of height in pixels.
+func (d *ImageDiff) HeightDelta() int {
+	if !d.IsComparable() {
+		return 0
+	}
+	return d.New.Height - d.Old.Height
+}
+
+// ByteSizeDelta returns the change of file size in bytes.
+func (d *ImageDiff) ByteSizeDelta() int64 {
+	if !d.IsComparable() {
+		return 0
+	}
+	return d.New.ByteSize - d.Old.ByteSize
+}
+
+// IsImage returns whether the file has been detected as an image on either side.
+func (diffFile *DiffFile) IsImage() bool {
+	return diffFile.ImageDiff != nil
+}
+
+// LoadImageDiff fills in ImageDiff if one of the revisions of a binary file
+// decodes as an image. LFS pointers are resolved to the objects stored for repo.
+func (diffFile *DiffFile) LoadImageDiff(repo *models.Repository, gitRepo *git.Repository) error {
+	if !diffFile.IsBin || diffFile.IsSubmodule {
+		return nil
+	}
+
+	oldImage, err := readDiffImage(repo, gitRepo, diffFile.OldBlobID)
+	if err != nil {
+		return err
+	}
+	newImage, err := readDiffImage(repo, gitRepo, diffFile.NewBlobID)
+	if err != nil {
+		return err
+	}
+	if oldImage == nil && newImage == nil {
+		return nil
+	}
+	diffFile.ImageDiff = &ImageDiff{
+		Old: oldImage,
+		New: newImage,
+	}
+	return nil
+}
+
+// setRegionComments groups the comments on regions of the old and new revision of the image into threads
+func (diffFile *DiffFile) setRegionComments(comments []*models.Comment) {
+	var oldComments, newComments []*models.Comment
+	for _, comment := range comments {
+		switch comment.BlobSHA {
+		case diffFile.NewBlobID:
+			newComments = append(newComments, comment)
+		case diffFile.OldBlobID:
+			oldComments = append(oldComments, comment)
+		}
+	}
+	diffFile.OldRegionComments = models.GroupRegionComments(oldComments)
+	diffFile.NewRegionComments = models.GroupRegionComments(newComments)
+}
+
+// LoadRegionComments loads the comments made in the pull requests of the repository
+// on regions of the images of the diff
+func (diff *Diff) LoadRegionComments(repoID int64, doer *models.User) error {
+	var blobIDs []string
+	for _, file := range diff.Files {
+		if !file.IsImage() {
+			continue
+		}
+		if file.ImageDiff.Old != nil {
+			blobIDs = append(blobIDs, file.ImageDiff.Old.BlobID)
+		}
+		if file.ImageDiff.New != nil {
+			blobIDs = append(blobIDs, file.ImageDiff.New.BlobID)
+		}
+	}
+	comments, err := models.FindRegionComments(repoID, doer, blobIDs...)
+	if err != nil || len(comments) == 0 {
+		return err
+	}
+	for _, file := range diff.Files {
+		if file.IsImage() {
+			file.setRegionComments(comments)
+		}
+	}
+	return nil
+}
+
+// getLFSMetaObject returns the LFS object of repo the pointer in buf refers to, isPointer
+// tells whether buf is a pointer at all. An object not stored for repo is not returned,
+// a pointer can name any object of the LFS store.
+func getLFSMetaObject(repo *models.Repository, buf *[]byte) (meta *models.LFSMetaObject, isPointer bool, err error) {
+	pointer := lfs.IsPointerFile(buf)
+	if pointer == nil {
+		return nil, false, nil
+	}
+	if repo == nil {
+		return nil, true, nil
+	}
+	meta, err = repo.GetLFSMetaObjectByOid(pointer.Oid)
+	if err == models.ErrLFSObjectNotExist {
+		return nil, true, nil
+	}
+	return meta, true, err
+}
+
+// readDiffImage decodes the image header of the blob, returning nil if the
+// blob is missing or is not an image.
+func readDiffImage(repo *models.Repository, gitRepo *git.Repository, blobID string) (*DiffImage, error) {
+	if len(blobID) == 0 || blobID == emptyBlobID {
+		return nil, nil
+	}
+
+	blob, err := gitRepo.GetBlob(blobID)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer dataRc.Close()
+
+	img := &DiffImage{
+		BlobID:   blobID,
+		ByteSize: blob.Size(),
+	}
+
+	buf := make([]byte, 1024)
+	n, _ := io.ReadFull(dataRc, buf)
+	buf = buf[:n]
+
+	reader := io.MultiReader(bytes.NewReader(buf), dataRc)
+	if meta, isPointer, err := getLFSMetaObject(repo, &buf); err != nil {
+		return nil, err
+	} else if isPointer {
+		if meta == nil {
+			return nil, nil
+		}
+		lfsDataRc, err := lfs.ReadMetaObject(meta)
+		if err != nil {
+			return nil, err
+		}
+		defer lfsDataRc.Close()
+		img.IsLFS = true
+		img.ByteSize = meta.Size
+
+		buf = make([]byte, 1024)
+		n, _ = io.ReadFull(lfsDataRc, buf)
+		buf = buf[:n]
+		reader = io.MultiReader(bytes.NewReader(buf), lfsDataRc)
+	}
+
+	if !strings.HasPrefix(http.DetectContentType(buf), "image/") {
+		return nil, nil
+	}
+
+	// Formats without a registered decoder (e.g. WebP) are still shown,
+	// only without their dimensions.
+	if config, _, err := image.DecodeConfig(reader); err == nil {
+		img.Width = config.Width
+		img.Height = config.Height
+	}
+	return img, nil
+}
+
+// readDiffBlob returns the content of the blob, LFS pointers are resolved to the objects
+// stored for repo. It returns nil if the blob is missing or larger than maxSize bytes.
+func readDiffBlob(repo *models.Repository, gitRepo *git.Repository, blobID string, maxSize int64) ([]byte, error) {
+	if len(blobID) == 0 || blobID == emptyBlobID {
+		return nil, nil
+	}
+
+	blob, err := gitRepo.GetBlob(blobID)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer dataRc.Close()
+
+	size := blob.Size()
+	buf := make([]byte, 1024)
+	n, _ := io.ReadFull(dataRc, buf)
+	buf = buf[:n]
+
+	reader := io.MultiReader(bytes.NewReader(buf), dataRc)
+	if meta, isPointer, err := getLFSMetaObject(repo, &buf); err != nil {
+		return nil, err
+	} else if isPointer {
+		if meta == nil {
+			return nil, nil
+		}
+		lfsDataRc, err := lfs.ReadMetaObject(meta)
+		if err != nil {
+			return nil, err
+		}
+		defer lfsDataRc.Close()
+		size = meta.Size
+		reader = lfsDataRc
+	}
+	if maxSize > 0 && size > maxSize {
+		return nil, nil
+	}
+	return ioutil.ReadAll(reader)
+}
+
+// parseIndexLine extracts the blob IDs from a line like
+// "index 3a1f..9bc2 100644" into the file.
+func parseIndexLine(curFile *DiffFile, line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+	ids := strings.SplitN(fields[1], "..", 2)
+	if len(ids) != 2 {
+		return
+	}
+	curFile.OldBlobID = ids[0]
+	curFile.NewBlobID = ids[1]
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePatch_BlobIDs(t *testing.T) {
+	const diff = `diff --git a/art/cover.png b/art/cover.png
+index 2b4f5c0cb5e5b4e0a5c1d1f2c3b4a5968778695a..9d1e8d2f7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a29 100644
+Binary files a/art/cover.png and b/art/cover.png differ
+diff --git a/art/page_01.png b/art/page_01.png
+new file mode 100644
+index 0000000000000000000000000000000000000000..5a4f3e2d1c0b9a8f7e6d5c4b3a299d1e8d2f7c6b
+Binary files /dev/null and b/art/page_01.png differ
+`
+	result, err := ParsePatch(setting.Git.MaxGitDiffLines, setting.Git.MaxGitDiffLineCharacters, setting.Git.MaxGitDiffFiles, strings.NewReader(diff))
+	assert.NoError(t, err)
+	assert.Len(t, result.Files, 2)
+
+	changed := result.Files[0]
+	assert.True(t, changed.IsBin)
+	assert.Equal(t, "2b4f5c0cb5e5b4e0a5c1d1f2c3b4a5968778695a", changed.OldBlobID)
+	assert.Equal(t, "9d1e8d2f7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a29", changed.NewBlobID)
+
+	added := result.Files[1]
+	assert.True(t, added.IsCreated)
+	assert.True(t, added.IsBin)
+	assert.Equal(t, emptyBlobID, added.OldBlobID)
+	assert.Equal(t, "5a4f3e2d1c0b9a8f7e6d5c4b3a299d1e8d2f7c6b", added.NewBlobID)
+}
+
+func TestImageDiff_Deltas(t *testing.T) {
+	d := &ImageDiff{
+		Old: &DiffImage{Width: 800, Height: 600, ByteSize: 2048},
+		New: &DiffImage{Width: 1024, Height: 600, ByteSize: 1024},
+	}
+	assert.True(t, d.IsComparable())
+	assert.Equal(t, 224, d.WidthDelta())
+	assert.Equal(t, 0, d.HeightDelta())
+	assert.EqualValues(t, -1024, d.ByteSizeDelta())
+
+	added := &ImageDiff{New: &DiffImage{Width: 10, Height: 10}}
+	assert.False(t, added.IsComparable())
+	assert.Equal(t, 0, added.WidthDelta())
+}
@@ -0,0 +1,301 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"io"
+	"math"
+	"sort"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/layers"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/thumbnail"
+)
+
+// DiffLayerType represents the kind of change of a layer.
+type DiffLayerType uint8
+
+// DiffLayerType possible values.
+const (
+	DiffLayerUnchanged DiffLayerType = iota
+	DiffLayerAdded
+	DiffLayerRemoved
+	DiffLayerChanged
+)
+
+// DiffLayerState holds the properties of a layer in one revision.
+type DiffLayerState struct {
+	Index     int
+	Name      string
+	Visible   bool
+	Opacity   float64
+	BlendMode string
+	X         int
+	Y         int
+	Width     int
+	Height    int
+	pixelHash string
+}
+
+// OpacityPercent returns the opacity of the layer in percent.
+func (s *DiffLayerState) OpacityPercent() int {
+	return int(math.Round(s.Opacity * 100))
+}
+
+// DiffLayer represents a layer of a layered document in a diff.
+// Old or New is nil when the layer was added or removed.
+type DiffLayer struct {
+	Type              DiffLayerType
+	Old               *DiffLayerState
+	New               *DiffLayerState
+	IsRenamed         bool
+	IsMoved           bool
+	PixelsChanged     bool
+	PropertiesChanged bool
+}
+
+// IsComparable returns whether both revisions of the layer have the same
+// bounds, so they can be overlaid by the swipe and onion skin modes.
+func (l *DiffLayer) IsComparable() bool {
+	return l.Old != nil && l.New != nil &&
+		l.Old.X == l.New.X && l.Old.Y == l.New.Y &&
+		l.Old.Width == l.New.Width && l.Old.Height == l.New.Height
+}
+
+// Name returns the current name of the layer.
+func (l *DiffLayer) Name() string {
+	if l.New != nil {
+		return l.New.Name
+	}
+	return l.Old.Name
+}
+
+// LayerDiff holds the layer changes between two revisions of a layered document.
+// Layers are ordered from top to bottom of the new revision, removed layers come last.
+type LayerDiff struct {
+	Layers []*DiffLayer
+}
+
+// ChangedLayers returns the layers that were added, removed or changed.
+func (d *LayerDiff) ChangedLayers() []*DiffLayer {
+	changed := make([]*DiffLayer, 0, len(d.Layers))
+	for _, l := range d.Layers {
+		if l.Type != DiffLayerUnchanged {
+			changed = append(changed, l)
+		}
+	}
+	return changed
+}
+
+// IsLayeredFile returns whether the file is a layered document which layers have been compared.
+func (diffFile *DiffFile) IsLayeredFile() bool {
+	return diffFile.LayerDiff != nil
+}
+
+// LoadLayerDiff fills in LayerDiff if the file is a layered document (PSD, OpenRaster, ...)
+// and both of its revisions can be decoded.
+func (diffFile *DiffFile) LoadLayerDiff(repo *models.Repository, gitRepo *git.Repository) error {
+	if !setting.Thumbnail.Enabled || !diffFile.IsBin || diffFile.IsSubmodule ||
+		!layers.IsLayeredFile(diffFile.Name) {
+		return nil
+	}
+	oldName := diffFile.OldName
+	if len(oldName) == 0 {
+		oldName = diffFile.Name
+	}
+
+	oldLayers, err := readDiffLayers(repo, gitRepo, diffFile.OldBlobID, oldName)
+	if err != nil {
+		return err
+	}
+	newLayers, err := readDiffLayers(repo, gitRepo, diffFile.NewBlobID, diffFile.Name)
+	if err != nil {
+		return err
+	}
+	if oldLayers == nil && newLayers == nil {
+		return nil
+	}
+	diffFile.LayerDiff = compareLayers(oldLayers, newLayers)
+	return nil
+}
+
+// readDiffLayers returns the layers of the blob from the layers manifest cached by
+// the thumbnail service, returning nil if the blob is missing, too large or cannot be decoded.
+func readDiffLayers(repo *models.Repository, gitRepo *git.Repository, blobID, filename string) ([]*DiffLayerState, error) {
+	key, size, source, err := diffBlobSource(repo, gitRepo, blobID)
+	if source == nil || err != nil {
+		return nil, err
+	}
+	info, err := thumbnail.GetLayersForSource(key, filename, size, source)
+	if err == thumbnail.ErrNotImage || err == thumbnail.ErrSourceTooLarge {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	states := make([]*DiffLayerState, 0, len(info.Layers))
+	for _, layer := range info.Layers {
+		states = append(states, &DiffLayerState{
+			Index:     layer.Index,
+			Name:      layer.Name,
+			Visible:   layer.Visible,
+			Opacity:   layer.Opacity,
+			BlendMode: layer.BlendMode,
+			X:         layer.X,
+			Y:         layer.Y,
+			Width:     layer.Width,
+			Height:    layer.Height,
+			pixelHash: layer.Hash,
+		})
+	}
+	return states, nil
+}
+
+// diffBlobSource returns the thumbnail cache key, size and content of the blob, LFS pointers
+// are resolved to the objects stored for repo. The source is nil if the blob is missing.
+func diffBlobSource(repo *models.Repository, gitRepo *git.Repository, blobID string) (string, int64, thumbnail.SourceFunc, error) {
+	if len(blobID) == 0 || blobID == emptyBlobID {
+		return "", 0, nil, nil
+	}
+
+	blob, err := gitRepo.GetBlob(blobID)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return "", 0, nil, nil
+		}
+		return "", 0, nil, err
+	}
+
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		return "", 0, nil, err
+	}
+	buf := make([]byte, 1024)
+	n, _ := io.ReadFull(dataRc, buf)
+	buf = buf[:n]
+	dataRc.Close()
+
+	if meta, isPointer, err := getLFSMetaObject(repo, &buf); err != nil {
+		return "", 0, nil, err
+	} else if isPointer {
+		if meta == nil {
+			return "", 0, nil, nil
+		}
+		return meta.Oid, meta.Size, func() (io.ReadCloser, error) {
+			return lfs.ReadMetaObject(meta)
+		}, nil
+	}
+	return blob.ID.String(), blob.Size(), blob.DataAsync, nil
+}
+
+// compareLayers matches the layers of two revisions, both ordered from bottom to top.
+// Layers are matched by name first (the n-th layer called "Sketch" matches the n-th
+// one in the other revision), then unmatched layers with identical pixels are
+// considered renamed.
+func compareLayers(oldLayers, newLayers []*DiffLayerState) *LayerDiff {
+	oldMatch := make([]*DiffLayerState, len(oldLayers))
+	newMatch := make([]*DiffLayerState, len(newLayers))
+
+	byName := make(map[string][]*DiffLayerState)
+	for _, l := range oldLayers {
+		byName[l.Name] = append(byName[l.Name], l)
+	}
+	for _, l := range newLayers {
+		if candidates := byName[l.Name]; len(candidates) > 0 {
+			newMatch[l.Index] = candidates[0]
+			oldMatch[candidates[0].Index] = l
+			byName[l.Name] = candidates[1:]
+		}
+	}
+
+	byHash := make(map[string][]*DiffLayerState)
+	for _, l := range oldLayers {
+		if oldMatch[l.Index] == nil {
+			byHash[l.pixelHash] = append(byHash[l.pixelHash], l)
+		}
+	}
+	for _, l := range newLayers {
+		if newMatch[l.Index] != nil {
+			continue
+		}
+		if candidates := byHash[l.pixelHash]; len(candidates) > 0 {
+			newMatch[l.Index] = candidates[0]
+			oldMatch[candidates[0].Index] = l
+			byHash[l.pixelHash] = candidates[1:]
+		}
+	}
+
+	// the matched layers that keep their relative order form the longest
+	// increasing subsequence of old indexes, all others have been moved
+	var matched []int
+	for _, l := range newLayers {
+		if old := newMatch[l.Index]; old != nil {
+			matched = append(matched, old.Index)
+		}
+	}
+	stable := longestIncreasingSubsequence(matched)
+
+	diff := &LayerDiff{Layers: make([]*DiffLayer, 0, len(newLayers))}
+	for i := len(newLayers) - 1; i >= 0; i-- {
+		l := newLayers[i]
+		old := newMatch[i]
+		if old == nil {
+			diff.Layers = append(diff.Layers, &DiffLayer{Type: DiffLayerAdded, New: l})
+			continue
+		}
+		layer := &DiffLayer{
+			Old:           old,
+			New:           l,
+			IsRenamed:     old.Name != l.Name,
+			IsMoved:       !stable[old.Index],
+			PixelsChanged: old.pixelHash != l.pixelHash,
+			PropertiesChanged: old.Visible != l.Visible || old.Opacity != l.Opacity ||
+				old.BlendMode != l.BlendMode,
+		}
+		if layer.IsRenamed || layer.IsMoved || layer.PixelsChanged || layer.PropertiesChanged {
+			layer.Type = DiffLayerChanged
+		}
+		diff.Layers = append(diff.Layers, layer)
+	}
+	for i := len(oldLayers) - 1; i >= 0; i-- {
+		if oldMatch[i] == nil {
+			diff.Layers = append(diff.Layers, &DiffLayer{Type: DiffLayerRemoved, Old: oldLayers[i]})
+		}
+	}
+	return diff
+}
+
+// longestIncreasingSubsequence returns the set of values which are part of
+// a longest strictly increasing subsequence of values.
+func longestIncreasingSubsequence(values []int) map[int]bool {
+	// tails[k] is the position of the smallest tail of an increasing subsequence of length k+1
+	tails := make([]int, 0, len(values))
+	prev := make([]int, len(values))
+	for i, v := range values {
+		k := sort.Search(len(tails), func(j int) bool { return values[tails[j]] >= v })
+		if k > 0 {
+			prev[i] = tails[k-1]
+		} else {
+			prev[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	set := make(map[int]bool, len(tails))
+	if len(tails) == 0 {
+		return set
+	}
+	for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+		set[values[i]] = true
+	}
+	return set
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLayers(layers ...[2]string) []*DiffLayerState {
+	states := make([]*DiffLayerState, 0, len(layers))
+	for i, l := range layers {
+		states = append(states, &DiffLayerState{Index: i, Name: l[0], Visible: true, Opacity: 1, pixelHash: l[1]})
+	}
+	return states
+}
+
+func TestCompareLayers(t *testing.T) {
+	oldLayers := testLayers(
+		[2]string{"paper", "p"},
+		[2]string{"flat", "f"},
+		[2]string{"shadow", "s"},
+		[2]string{"ink", "i"},
+		[2]string{"notes", "n"},
+	)
+	newLayers := testLayers(
+		[2]string{"paper", "p"},
+		[2]string{"shadow", "s"},
+		[2]string{"colors", "f"},
+		[2]string{"ink", "i2"},
+		[2]string{"highlights", "h"},
+	)
+	newLayers[0].Visible = false
+
+	diff := compareLayers(oldLayers, newLayers)
+	if !assert.Len(t, diff.Layers, 6) {
+		return
+	}
+	highlights, ink, colors, shadow, paper, notes := diff.Layers[0], diff.Layers[1], diff.Layers[2], diff.Layers[3], diff.Layers[4], diff.Layers[5]
+
+	assert.Equal(t, DiffLayerAdded, highlights.Type)
+	assert.Equal(t, "highlights", highlights.Name())
+
+	assert.Equal(t, DiffLayerChanged, ink.Type)
+	assert.True(t, ink.PixelsChanged)
+	assert.False(t, ink.IsMoved)
+
+	assert.Equal(t, DiffLayerChanged, colors.Type)
+	assert.True(t, colors.IsRenamed)
+	assert.Equal(t, "flat", colors.Old.Name)
+	assert.False(t, colors.PixelsChanged)
+	assert.False(t, colors.IsMoved)
+
+	assert.Equal(t, DiffLayerChanged, shadow.Type)
+	assert.True(t, shadow.IsMoved)
+	assert.False(t, shadow.PixelsChanged)
+
+	assert.Equal(t, DiffLayerChanged, paper.Type)
+	assert.True(t, paper.PropertiesChanged)
+	assert.False(t, paper.IsMoved)
+
+	assert.Equal(t, DiffLayerRemoved, notes.Type)
+	assert.Equal(t, "notes", notes.Name())
+
+	assert.Len(t, diff.ChangedLayers(), 6)
+}
+
+func TestCompareLayers_DuplicateNames(t *testing.T) {
+	oldLayers := testLayers([2]string{"Layer 1", "a"}, [2]string{"Layer 1", "b"})
+	newLayers := testLayers([2]string{"Layer 1", "a"}, [2]string{"Layer 1", "b"}, [2]string{"Layer 1", "c"})
+
+	diff := compareLayers(oldLayers, newLayers)
+	if assert.Len(t, diff.Layers, 3) {
+		assert.Equal(t, DiffLayerAdded, diff.Layers[0].Type)
+		assert.Equal(t, DiffLayerUnchanged, diff.Layers[1].Type)
+		assert.Equal(t, DiffLayerUnchanged, diff.Layers[2].Type)
+	}
+}
+
+func TestLongestIncreasingSubsequence(t *testing.T) {
+	assert.Equal(t, map[int]bool{0: true, 1: true, 3: true, 4: true}, longestIncreasingSubsequence([]int{0, 2, 1, 3, 4}))
+	assert.Empty(t, longestIncreasingSubsequence(nil))
+}
@@ -0,0 +1,270 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// DiffSVGElementType represents the kind of change of an SVG element.
+type DiffSVGElementType uint8
+
+// DiffSVGElementType possible values.
+const (
+	DiffSVGElementAdded DiffSVGElementType = iota + 1
+	DiffSVGElementRemoved
+	DiffSVGElementModified
+)
+
+// DiffSVG represents one revision of an SVG file in a diff.
+type DiffSVG struct {
+	BlobID string
+	// Sanitized is the document with all unsafe content removed, it is nil
+	// if the document could not be parsed.
+	Sanitized []byte
+}
+
+// DataURI returns the sanitized document as an URI which can be used as image source.
+func (s *DiffSVG) DataURI() template.URL {
+	return template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(s.Sanitized))
+}
+
+// DiffSVGAttribute represents a changed attribute of an SVG element,
+// Old or New is empty when the attribute was added or removed.
+type DiffSVGAttribute struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// DiffSVGElement represents an element which changed between two revisions of an SVG file.
+type DiffSVGElement struct {
+	Type DiffSVGElementType
+	// Key identifies the element across revisions, "#id" for elements with an id,
+	// else the path from the closest ancestor with an id like "#layer1/path[2]".
+	Key        string
+	Name       string
+	Attributes []*DiffSVGAttribute
+	OldText    string
+	NewText    string
+}
+
+// SVGDiff holds the old and new revisions of an SVG file and their structural changes.
+// Either side is nil when the file was added or deleted.
+type SVGDiff struct {
+	Old      *DiffSVG
+	New      *DiffSVG
+	Elements []*DiffSVGElement
+}
+
+// IsComparable returns whether both revisions can be rendered, so overlay modes
+// like swipe and onion skin can be offered.
+func (d *SVGDiff) IsComparable() bool {
+	return d.Old != nil && d.Old.Sanitized != nil && d.New != nil && d.New.Sanitized != nil
+}
+
+// IsSVG returns whether the file is an SVG image which revisions have been compared.
+func (diffFile *DiffFile) IsSVG() bool {
+	return diffFile.SVGDiff != nil
+}
+
+// LoadSVGDiff fills in SVGDiff if the file is an SVG image.
+func (diffFile *DiffFile) LoadSVGDiff(repo *models.Repository, gitRepo *git.Repository) error {
+	if diffFile.IsSubmodule || !markup.IsSVGFile(diffFile.Name) {
+		return nil
+	}
+
+	oldData, err := readDiffBlob(repo, gitRepo, diffFile.OldBlobID, setting.UI.MaxDisplayFileSize)
+	if err != nil {
+		return err
+	}
+	newData, err := readDiffBlob(repo, gitRepo, diffFile.NewBlobID, setting.UI.MaxDisplayFileSize)
+	if err != nil {
+		return err
+	}
+	if oldData == nil && newData == nil {
+		return nil
+	}
+
+	diff := &SVGDiff{
+		Old: newDiffSVG(diffFile.OldBlobID, oldData),
+		New: newDiffSVG(diffFile.NewBlobID, newData),
+	}
+	diffFile.SVGDiff = diff
+
+	// a document which cannot be parsed has no structure to compare
+	oldElements, err := parseSVGElements(oldData)
+	if err != nil {
+		log.Debug("Unable to parse SVG %s: %v", diffFile.OldBlobID, err)
+		return nil
+	}
+	newElements, err := parseSVGElements(newData)
+	if err != nil {
+		log.Debug("Unable to parse SVG %s: %v", diffFile.NewBlobID, err)
+		return nil
+	}
+	diff.Elements = compareSVGElements(oldElements, newElements)
+	return nil
+}
+
+func newDiffSVG(blobID string, data []byte) *DiffSVG {
+	if data == nil {
+		return nil
+	}
+	sanitized, err := markup.SanitizeSVG(data)
+	if err != nil {
+		log.Debug("Unable to sanitize SVG %s: %v", blobID, err)
+	}
+	return &DiffSVG{
+		BlobID:    blobID,
+		Sanitized: sanitized,
+	}
+}
+
+// svgElement is an element of a parsed SVG document
+type svgElement struct {
+	key   string
+	name  string
+	attrs []xml.Attr
+	text  string
+}
+
+func svgName(name xml.Name) string {
+	if len(name.Space) == 0 {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func svgAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if svgName(attr.Name) == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseSVGElements returns the elements of an SVG document in document order
+func parseSVGElements(data []byte) ([]*svgElement, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	type frame struct {
+		element *svgElement
+		// counts the children by element name to build positional keys
+		children map[string]int
+	}
+	var (
+		elements []*svgElement
+		stack    []*frame
+	)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &svgElement{
+				name:  svgName(t.Name),
+				attrs: t.Attr,
+			}
+			if id := svgAttr(t.Attr, "id"); len(id) > 0 {
+				el.key = "#" + id
+			} else if len(stack) == 0 {
+				el.key = el.name
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children[el.name]++
+				el.key = fmt.Sprintf("%s/%s[%d]", parent.element.key, el.name, parent.children[el.name])
+			}
+			elements = append(elements, el)
+			stack = append(stack, &frame{element: el, children: make(map[string]int)})
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].element.name != svgName(t.Name) {
+				return nil, fmt.Errorf("unexpected end element </%s>", svgName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].element.text += strings.TrimSpace(string(t))
+			}
+		}
+	}
+	return elements, nil
+}
+
+// compareSVGElements lists the added, removed and modified elements,
+// in the order of the new document followed by the removed elements.
+func compareSVGElements(oldElements, newElements []*svgElement) []*DiffSVGElement {
+	oldByKey := make(map[string]*svgElement, len(oldElements))
+	for _, el := range oldElements {
+		oldByKey[el.key] = el
+	}
+	newKeys := make(map[string]bool, len(newElements))
+
+	var diffs []*DiffSVGElement
+	for _, el := range newElements {
+		newKeys[el.key] = true
+		old, ok := oldByKey[el.key]
+		if !ok {
+			diff := &DiffSVGElement{Type: DiffSVGElementAdded, Key: el.key, Name: el.name, NewText: el.text}
+			for _, attr := range el.attrs {
+				diff.Attributes = append(diff.Attributes, &DiffSVGAttribute{Name: svgName(attr.Name), New: attr.Value})
+			}
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		diff := &DiffSVGElement{Type: DiffSVGElementModified, Key: el.key, Name: el.name}
+		for _, attr := range el.attrs {
+			name := svgName(attr.Name)
+			if oldValue := svgAttr(old.attrs, name); oldValue != attr.Value {
+				diff.Attributes = append(diff.Attributes, &DiffSVGAttribute{Name: name, Old: oldValue, New: attr.Value})
+			}
+		}
+		for _, attr := range old.attrs {
+			name := svgName(attr.Name)
+			if len(svgAttr(el.attrs, name)) == 0 && len(attr.Value) > 0 {
+				diff.Attributes = append(diff.Attributes, &DiffSVGAttribute{Name: name, Old: attr.Value})
+			}
+		}
+		if old.name != el.name || old.text != el.text || len(diff.Attributes) > 0 {
+			diff.OldText = old.text
+			diff.NewText = el.text
+			diffs = append(diffs, diff)
+		}
+	}
+	for _, el := range oldElements {
+		if newKeys[el.key] {
+			continue
+		}
+		diff := &DiffSVGElement{Type: DiffSVGElementRemoved, Key: el.key, Name: el.name, OldText: el.text}
+		for _, attr := range el.attrs {
+			diff.Attributes = append(diff.Attributes, &DiffSVGAttribute{Name: svgName(attr.Name), Old: attr.Value})
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSVGElements(t *testing.T) {
+	oldElements, err := parseSVGElements([]byte(`<svg viewBox="0 0 10 10">
+	<g id="hair"><path d="M0 0" fill="black"/><path d="M1 1"/></g>
+	<circle id="eye" r="1" stroke="red"/>
+	<text>Hello</text>
+</svg>`))
+	assert.NoError(t, err)
+	newElements, err := parseSVGElements([]byte(`<svg viewBox="0 0 10 10">
+	<g id="hair"><path d="M0 0" fill="brown"/></g>
+	<circle id="eye" r="1" stroke="red"/>
+	<rect id="frame" width="10" height="10"/>
+	<text>Hello world</text>
+</svg>`))
+	assert.NoError(t, err)
+
+	diffs := compareSVGElements(oldElements, newElements)
+	if !assert.Len(t, diffs, 4) {
+		return
+	}
+
+	assert.Equal(t, DiffSVGElementModified, diffs[0].Type)
+	assert.Equal(t, "#hair/path[1]", diffs[0].Key)
+	assert.Equal(t, []*DiffSVGAttribute{{Name: "fill", Old: "black", New: "brown"}}, diffs[0].Attributes)
+
+	assert.Equal(t, DiffSVGElementAdded, diffs[1].Type)
+	assert.Equal(t, "#frame", diffs[1].Key)
+	assert.Equal(t, "rect", diffs[1].Name)
+	assert.Len(t, diffs[1].Attributes, 3)
+
+	assert.Equal(t, DiffSVGElementModified, diffs[2].Type)
+	assert.Equal(t, "svg/text[1]", diffs[2].Key)
+	assert.Equal(t, "Hello", diffs[2].OldText)
+	assert.Equal(t, "Hello world", diffs[2].NewText)
+
+	assert.Equal(t, DiffSVGElementRemoved, diffs[3].Type)
+	assert.Equal(t, "#hair/path[2]", diffs[3].Key)
+}
+
+func TestParseSVGElements_Invalid(t *testing.T) {
+	_, err := parseSVGElements([]byte(`<svg><g></svg>`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+// AddToProject adds a card for the issue to the column of the project,
+// or to the default column of the project if columnID is 0.
+func AddToProject(doer *models.User, project *models.Project, issue *models.Issue, columnID int64) (*models.ProjectCard, error) {
+	card, err := models.AddProjectCard(doer, project, issue, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.NotifyIssueChangeProject(doer, issue, project, false)
+
+	return card, nil
+}
+
+// RemoveFromProject removes the card of an issue from its project.
+func RemoveFromProject(doer *models.User, project *models.Project, card *models.ProjectCard) error {
+	if err := models.DeleteProjectCard(doer, card); err != nil {
+		return err
+	}
+
+	notification.NotifyIssueChangeProject(doer, card.Issue, project, true)
+
+	return nil
+}
+
+// MoveProjectCards moves the cards into the column in the order of cardIDs.
+func MoveProjectCards(doer *models.User, column *models.ProjectColumn, cardIDs []int64) error {
+	cards, err := models.GetProjectCards(column.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if err := models.MoveProjectCards(column, cardIDs); err != nil {
+		return err
+	}
+
+	moved := make(map[int64]bool, len(cardIDs))
+	for _, id := range cardIDs {
+		moved[id] = true
+	}
+	for _, card := range cards {
+		if !moved[card.ID] || card.ColumnID == column.ID {
+			continue
+		}
+		oldColumnID := card.ColumnID
+		card.ColumnID = column.ID
+		card.Column = column
+		notification.NotifyMoveProjectCard(doer, card, oldColumnID)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package lfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	lfs_module "code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// GarbageCollectOptions represents the options of an LFS garbage collection
+type GarbageCollectOptions struct {
+	// Delete removes the unreferenced meta objects and the orphaned files
+	// which are older than the grace period, else they are only reported
+	Delete      bool
+	GracePeriod time.Duration
+}
+
+// RepoReport lists the LFS meta objects of a repository which are not referenced
+// by any commit reachable from its branches, tags or pull requests
+type RepoReport struct {
+	RepoID int64
+	// Repo is nil if the meta objects belong to a repository which does not exist anymore
+	Repo             *models.Repository
+	Unreferenced     []*models.LFSMetaObject
+	UnreferencedSize int64
+	// ReclaimableSize is the size of the unreferenced content no other repository has a meta object for
+	ReclaimableSize int64
+	Deleted         int
+}
+
+// Name returns the full name of the repository or its ID if it does not exist anymore
+func (r *RepoReport) Name() string {
+	if r.Repo == nil {
+		return fmt.Sprintf("#%d (deleted)", r.RepoID)
+	}
+	return r.Repo.FullName()
+}
+
+// Report is the outcome of an LFS garbage collection
+type Report struct {
+	Repos []*RepoReport
+	// OrphanFiles are stored contents without any meta object
+	OrphanFiles []*storage.ObjectInfo
+	OrphanSize  int64
+	// ReclaimableSize is the size of the stored contents which are referenced by no repository
+	ReclaimableSize int64
+
+	DeletedMetaObjects int
+	DeletedFiles       int
+	FreedSize          int64
+}
+
+// String summarizes the report
+func (r *Report) String() string {
+	var unreferenced int
+	for _, repo := range r.Repos {
+		unreferenced += len(repo.Unreferenced)
+	}
+	return fmt.Sprintf("%d unreferenced LFS meta objects in %d repositories, %d orphaned files (%s), %s reclaimable, removed %d meta objects and %d files (%s)",
+		unreferenced, len(r.Repos), len(r.OrphanFiles), base.FileSize(r.OrphanSize), base.FileSize(r.ReclaimableSize),
+		r.DeletedMetaObjects, r.DeletedFiles, base.FileSize(r.FreedSize))
+}
+
+// GarbageCollect finds the LFS meta objects not referenced by the git history of their
+// repository and the stored contents without meta object, and deletes them if requested.
+func GarbageCollect(opts GarbageCollectOptions) (*Report, error) {
+	metaObjects := make(map[int64][]*models.LFSMetaObject)
+	oidCounts := make(map[string]int)
+	if err := models.IterateLFS(func(mo *models.LFSMetaObject) error {
+		metaObjects[mo.RepositoryID] = append(metaObjects[mo.RepositoryID], mo)
+		oidCounts[mo.Oid]++
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("IterateLFS: %v", err)
+	}
+
+	repoIDs := make([]int64, 0, len(metaObjects))
+	for repoID := range metaObjects {
+		repoIDs = append(repoIDs, repoID)
+	}
+	sort.Slice(repoIDs, func(i, j int) bool { return repoIDs[i] < repoIDs[j] })
+
+	cutoff := timeutil.TimeStamp(time.Now().Add(-opts.GracePeriod).Unix())
+	contentStore := &lfs_module.ContentStore{ObjectStorage: storage.LFS}
+	report := &Report{}
+	unreferencedCounts := make(map[string]int)
+	for _, repoID := range repoIDs {
+		repoReport, err := collectRepository(repoID, metaObjects[repoID], oidCounts)
+		if err != nil {
+			// never delete anything based on an incomplete walk
+			log.Error("Unable to find the LFS pointers of repository %d: %v", repoID, err)
+			continue
+		}
+		if len(repoReport.Unreferenced) == 0 {
+			continue
+		}
+		report.Repos = append(report.Repos, repoReport)
+		for _, mo := range repoReport.Unreferenced {
+			unreferencedCounts[mo.Oid]++
+			if unreferencedCounts[mo.Oid] == oidCounts[mo.Oid] {
+				report.ReclaimableSize += mo.Size
+			}
+		}
+
+		if !opts.Delete {
+			continue
+		}
+		// delete right after the walk to keep the window for a concurrent push short
+		repo := repoReport.Repo
+		if repo == nil {
+			repo = &models.Repository{ID: repoID}
+		}
+		for _, mo := range repoReport.Unreferenced {
+			if mo.CreatedUnix > cutoff {
+				continue
+			}
+			remaining, err := repo.RemoveLFSMetaObjectByOid(mo.Oid)
+			if err != nil {
+				return report, fmt.Errorf("RemoveLFSMetaObjectByOid: %v", err)
+			}
+			repoReport.Deleted++
+			report.DeletedMetaObjects++
+			if remaining > 0 {
+				continue
+			}
+			if err := contentStore.Delete(mo.RelativePath()); err != nil {
+				return report, fmt.Errorf("Delete %s: %v", mo.Oid, err)
+			}
+			report.DeletedFiles++
+			report.FreedSize += mo.Size
+		}
+	}
+
+	if err := storage.LFS.IterateObjects(func(info *storage.ObjectInfo) error {
+		oid := strings.Replace(info.Path, "/", "", -1)
+		if oidCounts[oid] > 0 || !isStoredOid(oid, info.Path) {
+			return nil
+		}
+		report.OrphanFiles = append(report.OrphanFiles, info)
+		report.OrphanSize += info.Size
+		report.ReclaimableSize += info.Size
+		return nil
+	}); err != nil {
+		return report, fmt.Errorf("IterateObjects: %v", err)
+	}
+
+	if opts.Delete {
+		for _, info := range report.OrphanFiles {
+			if timeutil.TimeStamp(info.ModTime.Unix()) > cutoff {
+				continue
+			}
+			// the object may have been uploaded again since the meta objects were listed
+			if associated, err := models.LFSObjectIsAssociated(strings.Replace(info.Path, "/", "", -1)); err != nil {
+				return report, fmt.Errorf("LFSObjectIsAssociated: %v", err)
+			} else if associated {
+				continue
+			}
+			if err := contentStore.Delete(info.Path); err != nil {
+				return report, fmt.Errorf("Delete %s: %v", info.Path, err)
+			}
+			report.DeletedFiles++
+			report.FreedSize += info.Size
+		}
+	}
+
+	return report, nil
+}
+
+// isStoredOid returns whether the path is where the content of oid is stored,
+// other files are left alone
+func isStoredOid(oid, path string) bool {
+	if len(oid) != 64 || strings.Trim(oid, "0123456789abcdef") != "" {
+		return false
+	}
+	return (&models.LFSMetaObject{Oid: oid}).RelativePath() == path
+}
+
+// collectRepository compares the meta objects of a repository with the pointers reachable in its history
+func collectRepository(repoID int64, metaObjects []*models.LFSMetaObject, oidCounts map[string]int) (*RepoReport, error) {
+	report := &RepoReport{RepoID: repoID}
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil && !models.IsErrRepoNotExist(err) {
+		return nil, err
+	}
+
+	referenced := map[string]*models.LFSMetaObject{}
+	if repo != nil {
+		report.Repo = repo
+		if referenced, err = lfs_module.ReachablePointers(repo.RepoPath()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, mo := range metaObjects {
+		if _, ok := referenced[mo.Oid]; ok {
+			continue
+		}
+		report.Unreferenced = append(report.Unreferenced, mo)
+		report.UnreferencedSize += mo.Size
+		if oidCounts[mo.Oid] == 1 {
+			report.ReclaimableSize += mo.Size
+		}
+	}
+	return report, nil
+}
+
+// GarbageCollectCron runs the LFS garbage collection configured for the cron task
+func GarbageCollectCron() {
+	if !setting.LFS.StartServer {
+		return
+	}
+
+	log.Trace("Doing: LFSGarbageCollect")
+	report, err := GarbageCollect(GarbageCollectOptions{
+		Delete:      setting.Cron.LFSGarbageCollect.Delete,
+		GracePeriod: setting.Cron.LFSGarbageCollect.GracePeriod,
+	})
+	if err != nil {
+		log.Error("LFSGarbageCollect: %v", err)
+		if report == nil {
+			return
+		}
+	}
+	if len(report.Repos) > 0 || len(report.OrphanFiles) > 0 {
+		if err := models.CreateRepositoryNotice("LFS garbage collection: " + report.String()); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+	}
+	log.Trace("Finished: LFSGarbageCollect, %s", report)
+}
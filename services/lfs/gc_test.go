@@ -0,0 +1,118 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package lfs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// commitPointer commits an LFS pointer to a new branch of the repository
+func commitPointer(t *testing.T, repo *models.Repository, mo *models.LFSMetaObject) {
+	repoPath := repo.RepoPath()
+	var stdout bytes.Buffer
+	assert.NoError(t, git.NewCommand("hash-object", "-w", "--stdin").
+		RunInDirFullPipeline(repoPath, &stdout, nil, strings.NewReader(mo.Pointer())))
+	blob := strings.TrimSpace(stdout.String())
+
+	stdout.Reset()
+	assert.NoError(t, git.NewCommand("mktree").
+		RunInDirFullPipeline(repoPath, &stdout, nil, strings.NewReader("100644 blob "+blob+"\tasset.psd\n")))
+	tree := strings.TrimSpace(stdout.String())
+
+	commit, err := git.NewCommand("-c", "user.name=test", "-c", "user.email=test@example.com",
+		"commit-tree", tree, "-m", "add asset").RunInDir(repoPath)
+	assert.NoError(t, err)
+	_, err = git.NewCommand("update-ref", "refs/heads/lfs-gc", strings.TrimSpace(commit)).RunInDir(repoPath)
+	assert.NoError(t, err)
+}
+
+func newTestMetaObject(t *testing.T, repoID int64, content string) *models.LFSMetaObject {
+	oid, err := models.GenerateLFSOid(strings.NewReader(content))
+	assert.NoError(t, err)
+	mo, err := models.NewLFSMetaObject(&models.LFSMetaObject{Oid: oid, Size: int64(len(content)), RepositoryID: repoID})
+	assert.NoError(t, err)
+	_, err = storage.LFS.Save(mo.RelativePath(), strings.NewReader(content), mo.Size)
+	assert.NoError(t, err)
+	return mo
+}
+
+func TestGarbageCollect(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	referenced := newTestMetaObject(t, 1, "referenced")
+	unreferenced := newTestMetaObject(t, 1, "unreferenced")
+	commitPointer(t, repo1, referenced)
+	// the same content uploaded to another repository
+	shared := *unreferenced
+	shared.ID = 0
+	shared.RepositoryID = 16
+	_, err := models.NewLFSMetaObject(&shared)
+	assert.NoError(t, err)
+	// repository 2 has no git directory, its objects must be left alone
+	broken := newTestMetaObject(t, 2, "broken")
+
+	orphan, err := models.GenerateLFSOid(strings.NewReader("orphan"))
+	assert.NoError(t, err)
+	orphanPath := (&models.LFSMetaObject{Oid: orphan}).RelativePath()
+	_, err = storage.LFS.Save(orphanPath, strings.NewReader("orphan"), 6)
+	assert.NoError(t, err)
+	_, err = storage.LFS.Save("README", strings.NewReader("not an object"), 13)
+	assert.NoError(t, err)
+
+	report, err := GarbageCollect(GarbageCollectOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Repos, 2) {
+		assert.EqualValues(t, 1, report.Repos[0].RepoID)
+		assert.Equal(t, "user2/repo1", report.Repos[0].Name())
+		if assert.Len(t, report.Repos[0].Unreferenced, 1) {
+			assert.Equal(t, unreferenced.Oid, report.Repos[0].Unreferenced[0].Oid)
+		}
+		assert.EqualValues(t, 12, report.Repos[0].UnreferencedSize)
+		// repository 16 has a meta object for the same content
+		assert.EqualValues(t, 0, report.Repos[0].ReclaimableSize)
+		assert.EqualValues(t, 16, report.Repos[1].RepoID)
+	}
+	if assert.Len(t, report.OrphanFiles, 1) {
+		assert.Equal(t, orphanPath, report.OrphanFiles[0].Path)
+	}
+	assert.EqualValues(t, 12+6, report.ReclaimableSize)
+	assert.Zero(t, report.DeletedMetaObjects)
+
+	// nothing is old enough
+	report, err = GarbageCollect(GarbageCollectOptions{Delete: true, GracePeriod: time.Hour})
+	assert.NoError(t, err)
+	assert.Zero(t, report.DeletedMetaObjects)
+	assert.Zero(t, report.DeletedFiles)
+
+	report, err = GarbageCollect(GarbageCollectOptions{Delete: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.DeletedMetaObjects)
+	assert.Equal(t, 2, report.DeletedFiles)
+	assert.EqualValues(t, 12+6, report.FreedSize)
+
+	models.AssertNotExistsBean(t, &models.LFSMetaObject{Oid: unreferenced.Oid})
+	models.AssertExistsAndLoadBean(t, &models.LFSMetaObject{Oid: referenced.Oid, RepositoryID: 1})
+	models.AssertExistsAndLoadBean(t, &models.LFSMetaObject{Oid: broken.Oid, RepositoryID: 2})
+	for path, exists := range map[string]bool{
+		referenced.RelativePath():   true,
+		broken.RelativePath():       true,
+		"README":                    true,
+		unreferenced.RelativePath(): false,
+		orphanPath:                  false,
+	} {
+		_, err := storage.LFS.Stat(path)
+		assert.Equal(t, exists, err == nil, path)
+	}
+}
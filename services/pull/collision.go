@@ -0,0 +1,230 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// BinaryCollisionType is the way a binary file of a pull request collides with a file of another one
+type BinaryCollisionType string
+
+const (
+	// BinaryCollisionSamePath both pull requests change the same binary file
+	BinaryCollisionSamePath BinaryCollisionType = "same_path"
+	// BinaryCollisionName the names of the files only differ by case or by Unicode normalization,
+	// they are the same file on case insensitive or normalizing file systems
+	BinaryCollisionName BinaryCollisionType = "name"
+)
+
+// BinaryCollision is a binary file of a pull request colliding with a file of another pull request
+type BinaryCollision struct {
+	Type      BinaryCollisionType
+	Path      string
+	OtherPath string
+}
+
+// CompetingPullRequest is an open pull request with binary files colliding with the ones of a pull request.
+// PullRequest is nil for the files of the base branch colliding with the files added by the pull request.
+type CompetingPullRequest struct {
+	PullRequest *models.PullRequest
+	Collisions  []*BinaryCollision
+}
+
+// collisionKey returns the key under which the paths of the same file on case insensitive
+// or normalizing file systems collide
+func collisionKey(treePath string) string {
+	return norm.NFC.String(strings.ToLower(norm.NFD.String(treePath)))
+}
+
+// FindCompetingPullRequests returns the other open pull requests to the same branch changing the same
+// binary files as a pull request or introducing binary files whose names only differ by case or Unicode
+// normalization. Such changes can't be merged line by line so only one of the pull requests can win.
+// The files added by the pull request colliding by name with files of the base branch come first.
+func FindCompetingPullRequests(pr *models.PullRequest) ([]*CompetingPullRequest, error) {
+	if pr.HasMerged {
+		return nil, nil
+	}
+	if err := pr.LoadBaseRepo(); err != nil {
+		return nil, err
+	}
+
+	competing := make([]*CompetingPullRequest, 0, 2)
+	// the pull request view must not fail for a pull request without head reference
+	if collisions, err := findBaseCollisions(pr); err != nil {
+		log.Error("findBaseCollisions [%d]: %v", pr.ID, err)
+	} else if len(collisions) > 0 {
+		competing = append(competing, &CompetingPullRequest{Collisions: collisions})
+	}
+	if len(pr.BinaryFiles) == 0 {
+		return competing, nil
+	}
+
+	prs, err := models.GetUnmergedPullRequestsByBaseInfo(pr.BaseRepoID, pr.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string][]string, len(pr.BinaryFiles))
+	for _, p := range pr.BinaryFiles {
+		key := collisionKey(p)
+		paths[key] = append(paths[key], p)
+	}
+
+	others := make(models.PullRequestList, 0, 2)
+	competingPulls := make([]*CompetingPullRequest, 0, 2)
+	for _, other := range prs {
+		if other.ID == pr.ID {
+			continue
+		}
+		var collisions []*BinaryCollision
+		for _, otherPath := range other.BinaryFiles {
+			for _, p := range paths[collisionKey(otherPath)] {
+				collision := &BinaryCollision{Type: BinaryCollisionSamePath, Path: p, OtherPath: otherPath}
+				if p != otherPath {
+					collision.Type = BinaryCollisionName
+				}
+				collisions = append(collisions, collision)
+			}
+		}
+		if len(collisions) == 0 {
+			continue
+		}
+		sortCollisions(collisions)
+		competingPulls = append(competingPulls, &CompetingPullRequest{PullRequest: other, Collisions: collisions})
+		others = append(others, other)
+	}
+
+	if err := others.LoadAttributes(); err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		other.BaseRepo = pr.BaseRepo
+		other.Issue.Repo = pr.BaseRepo
+		other.Issue.PullRequest = other
+	}
+	sort.Slice(competingPulls, func(i, j int) bool {
+		return competingPulls[i].PullRequest.Index < competingPulls[j].PullRequest.Index
+	})
+	return append(competing, competingPulls...), nil
+}
+
+// findBaseCollisions returns the files added by a pull request whose names only differ by case or
+// Unicode normalization from a file of the base branch which the pull request doesn't delete
+func findBaseCollisions(pr *models.PullRequest) ([]*BinaryCollision, error) {
+	if len(pr.MergeBase) == 0 {
+		return nil, nil
+	}
+	repoPath := pr.BaseRepo.RepoPath()
+
+	stdout, err := git.NewCommand("diff", "--name-status", "--no-renames", "-z", pr.MergeBase, pr.GetGitRefName()).RunInDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	added := make(map[string][]string)
+	deleted := make(map[string]bool)
+	fields := strings.Split(stdout, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch fields[i] {
+		case "A":
+			key := collisionKey(fields[i+1])
+			added[key] = append(added[key], fields[i+1])
+		case "D":
+			deleted[fields[i+1]] = true
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+	commitID, err := gitRepo.GetRefCommitID(pr.GetBaseRefName())
+	if err != nil {
+		return nil, err
+	}
+	commit, err := gitRepo.GetCommit(commitID)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the directories leading to the added files are read, not the whole tree of the base
+	dirs := make(map[string]bool)
+	for key := range added {
+		for i := strings.LastIndexByte(key, '/'); i > 0; i = strings.LastIndexByte(key[:i], '/') {
+			dirs[key[:i]] = true
+		}
+	}
+	basePaths, err := findTreePaths(&commit.Tree, "", dirs, added)
+	if err != nil {
+		return nil, err
+	}
+
+	var collisions []*BinaryCollision
+	for _, basePath := range basePaths {
+		// a file renamed by case is deleted by the pull request
+		if deleted[basePath] {
+			continue
+		}
+		for _, p := range added[collisionKey(basePath)] {
+			// the same path added to both is a conflict of the merge
+			if p != basePath {
+				collisions = append(collisions, &BinaryCollision{Type: BinaryCollisionName, Path: p, OtherPath: basePath})
+			}
+		}
+	}
+	sortCollisions(collisions)
+	return collisions, nil
+}
+
+// findTreePaths returns the paths of the files in the tree at dir whose collision keys are among
+// the ones of files, descending only into the directories whose collision keys are among dirs
+func findTreePaths(tree *git.Tree, dir string, dirs map[string]bool, files map[string][]string) ([]string, error) {
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		treePath := path.Join(dir, entry.Name())
+		key := collisionKey(treePath)
+		if !entry.IsDir() {
+			if _, ok := files[key]; ok {
+				paths = append(paths, treePath)
+			}
+			continue
+		}
+		if !dirs[key] {
+			continue
+		}
+		subTree, err := tree.SubTree(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		subPaths, err := findTreePaths(subTree, treePath, dirs, files)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, subPaths...)
+	}
+	return paths, nil
+}
+
+func sortCollisions(collisions []*BinaryCollision) {
+	sort.SliceStable(collisions, func(i, j int) bool {
+		return collisions[i].Path < collisions[j].Path
+	})
+}
@@ -413,7 +413,7 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 	outbuf.Reset()
 	errbuf.Reset()
 
-	pr.MergedCommitID, err = baseGitRepo.GetBranchCommitID(pr.BaseBranch)
+	pr.MergedCommitID, err = baseGitRepo.GetRefCommitID(pr.GetBaseRefName())
 	if err != nil {
 		return fmt.Errorf("GetBranchCommit: %v", err)
 	}
@@ -38,21 +38,92 @@ func CreateCodeComment(doer *models.User, issue *models.Issue, line int64, conte
 		}
 	}
 
-	// Comments that are replies don't require a review header to show up in the issue view
-	if !isReview && existsReview {
-		if err = issue.LoadRepo(); err != nil {
-			return nil, err
-		}
-
-		comment, err := createCodeComment(
+	return addReviewComment(doer, issue, isReview, !isReview && existsReview, replyReviewID, func(reviewID int64) (*models.Comment, error) {
+		return createCodeComment(
 			doer,
 			issue.Repo,
 			issue,
 			content,
 			treePath,
 			line,
-			replyReviewID,
+			reviewID,
 		)
+	})
+}
+
+// CreateImageComment creates a comment on a region of the image at treePath in the head of the pull request
+func CreateImageComment(doer *models.User, issue *models.Issue, region models.CommentRegion, content, treePath string, isReview bool, replyReviewID int64) (*models.Comment, error) {
+	if err := issue.LoadRepo(); err != nil {
+		return nil, err
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		return nil, fmt.Errorf("GetPullRequestByIssueID: %v", err)
+	}
+	pr := issue.PullRequest
+	if err := pr.GetBaseRepo(); err != nil {
+		return nil, fmt.Errorf("GetBaseRepo: %v", err)
+	}
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return nil, fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	// the comment is anchored to the blob, so it is outdated once the image changes
+	headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+	if err != nil {
+		return nil, fmt.Errorf("GetRefCommitID[%s]: %v", pr.GetGitRefName(), err)
+	}
+	headCommit, err := gitRepo.GetCommit(headCommitID)
+	if err != nil {
+		return nil, fmt.Errorf("GetCommit[%s]: %v", headCommitID, err)
+	}
+	entry, err := headCommit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		return nil, err
+	}
+	if entry.IsDir() {
+		return nil, git.ErrNotExist{ID: headCommitID, RelPath: treePath}
+	}
+
+	// a comment on a region replies to the review of the thread it is added to
+	isReply := !isReview && replyReviewID != 0
+	if isReply {
+		review, err := models.GetReviewByID(replyReviewID)
+		if err != nil {
+			return nil, err
+		}
+		if review.IssueID != issue.ID {
+			return nil, models.ErrReviewNotExist{ID: replyReviewID}
+		}
+	}
+
+	return addReviewComment(doer, issue, isReview, isReply, replyReviewID, func(reviewID int64) (*models.Comment, error) {
+		return models.CreateCommentWithNoAction(&models.CreateCommentOptions{
+			Type:      models.CommentTypeCode,
+			Doer:      doer,
+			Repo:      issue.Repo,
+			Issue:     issue,
+			Content:   content,
+			TreePath:  treePath,
+			CommitSHA: headCommitID,
+			BlobSHA:   entry.ID.String(),
+			Region:    &region,
+			ReviewID:  reviewID,
+		})
+	})
+}
+
+// addReviewComment adds the comment made by create to the review it replies to, to the pending review of doer
+// or to a new review, which is submitted at once for a single comment
+func addReviewComment(doer *models.User, issue *models.Issue, isReview, isReply bool, replyReviewID int64, create func(reviewID int64) (*models.Comment, error)) (*models.Comment, error) {
+	// Comments that are replies don't require a review header to show up in the issue view
+	if isReply {
+		if err := issue.LoadRepo(); err != nil {
+			return nil, err
+		}
+
+		comment, err := create(replyReviewID)
 		if err != nil {
 			return nil, err
 		}
@@ -79,22 +150,14 @@ func CreateCodeComment(doer *models.User, issue *models.Issue, line int64, conte
 		}
 	}
 
-	comment, err := createCodeComment(
-		doer,
-		issue.Repo,
-		issue,
-		content,
-		treePath,
-		line,
-		review.ID,
-	)
+	comment, err := create(review.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if !isReview && !existsReview {
+	if !isReview {
 		// Submit the review we've just created so the comment shows up in the issue view
-		if _, _, err = SubmitReview(doer, issue, models.ReviewTypeComment, ""); err != nil {
+		if _, _, err = SubmitReview(doer, issue, models.ReviewTypeComment, "", ""); err != nil {
 			return nil, err
 		}
 	}
@@ -158,18 +221,75 @@ func createCodeComment(doer *models.User, repo *models.Repository, issue *models
 	})
 }
 
-// SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist
-func SubmitReview(doer *models.User, issue *models.Issue, reviewType models.ReviewType, content string) (*models.Review, *models.Comment, error) {
-	review, comm, err := models.SubmitReview(doer, issue, reviewType, content)
+// SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist,
+// commitID is the head commit of the pull request reviewed, the current one if empty
+func SubmitReview(doer *models.User, issue *models.Issue, reviewType models.ReviewType, content, commitID string) (*models.Review, *models.Comment, error) {
+	pr, err := issue.GetPullRequest()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pr, err := issue.GetPullRequest()
+	if commitID == "" {
+		if commitID, err = getHeadCommitID(pr); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	review, comm, err := models.SubmitReview(doer, issue, reviewType, content, commitID)
 	if err != nil {
 		return nil, nil, err
 	}
+
 	notification.NotifyPullRequestReview(pr, review, comm)
 
 	return review, comm, nil
 }
+
+// getHeadCommitID returns the current head commit of a pull request
+func getHeadCommitID(pr *models.PullRequest) (string, error) {
+	if err := pr.GetBaseRepo(); err != nil {
+		return "", fmt.Errorf("GetBaseRepo: %v", err)
+	}
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return "", fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	return GetHeadCommitID(gitRepo, pr)
+}
+
+// GetHeadCommitID returns the current head commit of a pull request from the git repository of its base repository,
+// the head branch is used if the pull request has no ref yet and an empty string is returned if neither exists
+func GetHeadCommitID(gitRepo *git.Repository, pr *models.PullRequest) (string, error) {
+	if git.IsReferenceExist(gitRepo.Path, pr.GetGitRefName()) {
+		commitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil {
+			return "", fmt.Errorf("GetRefCommitID[%s]: %v", pr.GetGitRefName(), err)
+		}
+		return commitID, nil
+	}
+	if pr.HeadRepoID == pr.BaseRepoID && gitRepo.IsBranchExist(pr.HeadBranch) {
+		commitID, err := gitRepo.GetBranchCommitID(pr.HeadBranch)
+		if err != nil {
+			return "", fmt.Errorf("GetBranchCommitID[%s]: %v", pr.HeadBranch, err)
+		}
+		return commitID, nil
+	}
+	return "", nil
+}
+
+// RequestReview requests a review of a pull request from reviewer, who is subscribed to the pull request and notified
+func RequestReview(doer, reviewer *models.User, issue *models.Issue) (*models.Comment, error) {
+	comment, err := models.AddReviewRequest(doer, reviewer, issue)
+	if err != nil || comment == nil {
+		return comment, err
+	}
+	if err := models.CreateOrUpdateIssueWatch(reviewer.ID, issue.ID, true); err != nil {
+		return nil, err
+	}
+	if err := models.CreateOrUpdateIssueNotifications(issue.ID, comment.ID, doer.ID); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package thumbnail
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/layers"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// CompositeLayer is the index used to request the flattened image of a layered document
+const CompositeLayer = -1
+
+// LayerInfo describes one layer of a rendered layered document
+type LayerInfo struct {
+	Index     int     `json:"index"`
+	Name      string  `json:"name"`
+	Visible   bool    `json:"visible"`
+	Opacity   float64 `json:"opacity"`
+	BlendMode string  `json:"blend_mode"`
+	X         int     `json:"x"`
+	Y         int     `json:"y"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	// Hash identifies the position and pixels of the layer, see layers.Layer.PixelHash
+	Hash string `json:"hash"`
+}
+
+// LayersInfo describes a rendered layered document, Layers are ordered from bottom to top
+type LayersInfo struct {
+	Width        int          `json:"width"`
+	Height       int          `json:"height"`
+	HasComposite bool         `json:"has_composite"`
+	Layers       []*LayerInfo `json:"layers"`
+}
+
+func layersDir(key string) string {
+	return filepath.Join(setting.Thumbnail.Path, "layers", key[0:2], key[2:4], key)
+}
+
+func layersManifestPath(key string) string {
+	return filepath.Join(layersDir(key), "layers.json")
+}
+
+func layerImagePath(key string, index int) string {
+	if index == CompositeLayer {
+		return filepath.Join(layersDir(key), "composite.png")
+	}
+	return filepath.Join(layersDir(key), strconv.Itoa(index)+".png")
+}
+
+// GetLayers returns the layer stack of a layered document blob, the format is chosen by filename.
+// The layer images are rendered and cached on first use.
+func GetLayers(repo *models.Repository, blob *git.Blob, filename string) (*LayersInfo, error) {
+	_, info, err := getLayers(repo, blob, filename)
+	return info, err
+}
+
+// GetLayersForSource returns the layer stack of a layered document identified by key,
+// like GetLayers for content which is not a blob of a repository.
+func GetLayersForSource(key, filename string, size int64, source SourceFunc) (*LayersInfo, error) {
+	if !keyPattern.MatchString(key) {
+		return nil, fmt.Errorf("invalid thumbnail key: %s", key)
+	}
+	return getLayersForSource(key, filename, size, source)
+}
+
+func getLayers(repo *models.Repository, blob *git.Blob, filename string) (string, *LayersInfo, error) {
+	key, size, source, err := blobSource(repo, blob)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := getLayersForSource(key, filename, size, source)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, info, nil
+}
+
+func getLayersForSource(key, filename string, size int64, source SourceFunc) (*LayersInfo, error) {
+	manifestPath := layersManifestPath(key)
+	generating.CheckIn(manifestPath)
+	defer generating.CheckOut(manifestPath)
+
+	if data, err := ioutil.ReadFile(manifestPath); err == nil {
+		info := &LayersInfo{}
+		if err = json.Unmarshal(data, info); err != nil {
+			log.Warn("Invalid cached layers manifest %s: %v", manifestPath, err)
+		} else if info.hasHashes() {
+			touch(manifestPath)
+			return info, nil
+		}
+	}
+
+	if setting.Thumbnail.MaxSourceSize > 0 && size > setting.Thumbnail.MaxSourceSize {
+		return nil, ErrSourceTooLarge
+	}
+	rc, err := source()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	r, ok := rc.(io.ReaderAt)
+	if !ok {
+		// git blobs and remote objects can only be streamed, the decoders need random access
+		tmp, err := ioutil.TempFile("", "gitea-layers")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}()
+		if size, err = io.Copy(tmp, rc); err != nil {
+			return nil, err
+		}
+		r = tmp
+	}
+	doc, err := layers.Decode(filename, r, size)
+	if err != nil {
+		log.Debug("Unable to decode layered document %s: %v", key, err)
+		return nil, ErrNotImage
+	}
+	return renderLayers(key, doc)
+}
+
+// hasHashes returns false for manifests cached before the layer hashes were stored
+func (info *LayersInfo) hasHashes() bool {
+	for _, l := range info.Layers {
+		if len(l.Hash) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func renderLayers(key string, doc *layers.Document) (*LayersInfo, error) {
+	if err := os.MkdirAll(layersDir(key), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("MkdirAll: %v", err)
+	}
+
+	info := &LayersInfo{
+		Width:        doc.Width,
+		Height:       doc.Height,
+		HasComposite: doc.Composite != nil,
+		Layers:       make([]*LayerInfo, 0, len(doc.Layers)),
+	}
+	if doc.Composite != nil {
+		if err := writePNG(layerImagePath(key, CompositeLayer), doc.Composite); err != nil {
+			return nil, err
+		}
+	}
+	for i, layer := range doc.Layers {
+		if err := writePNG(layerImagePath(key, i), layer.Image); err != nil {
+			return nil, err
+		}
+		info.Layers = append(info.Layers, &LayerInfo{
+			Index:     i,
+			Name:      layer.Name,
+			Visible:   layer.Visible,
+			Opacity:   layer.Opacity,
+			BlendMode: layer.BlendMode,
+			X:         layer.Bounds.Min.X,
+			Y:         layer.Bounds.Min.Y,
+			Width:     layer.Bounds.Dx(),
+			Height:    layer.Bounds.Dy(),
+			Hash:      layer.PixelHash(),
+		})
+	}
+
+	// the manifest is written last, it marks the cache entry as complete
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	manifestPath := layersManifestPath(key)
+	if err = ioutil.WriteFile(manifestPath+".tmp", data, 0644); err != nil {
+		return nil, err
+	}
+	return info, os.Rename(manifestPath+".tmp", manifestPath)
+}
+
+func writePNG(p string, img image.Image) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("Encode: %v", err)
+	}
+	return f.Close()
+}
+
+// OpenLayer returns the rendered image of one layer of a layered document blob,
+// index CompositeLayer returns the flattened image.
+func OpenLayer(repo *models.Repository, blob *git.Blob, filename string, index int) (*os.File, error) {
+	key, info, err := getLayers(repo, blob, filename)
+	if err != nil {
+		return nil, err
+	}
+	if (index == CompositeLayer && !info.HasComposite) || index < CompositeLayer || index >= len(info.Layers) {
+		return nil, os.ErrNotExist
+	}
+
+	p := layerImagePath(key, index)
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		// the image was pruned by the cleanup task, render the document again
+		if err = os.Remove(layersManifestPath(key)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if _, _, err = getLayers(repo, blob, filename); err != nil {
+			return nil, err
+		}
+		f, err = os.Open(p)
+	}
+	if err == nil {
+		touch(p)
+	}
+	return f, err
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	// Register the image formats thumbnails can be generated from
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+
+	"github.com/nfnt/resize"
+)
+
+var (
+	// ErrInvalidSize is returned when the requested size is not configured
+	ErrInvalidSize = errors.New("thumbnail size is not allowed")
+	// ErrSourceTooLarge is returned when the source exceeds MAX_SOURCE_SIZE or MAX_SOURCE_PIXELS
+	ErrSourceTooLarge = errors.New("thumbnail source is too large")
+	// ErrNotImage is returned when the source cannot be decoded as an image
+	ErrNotImage = errors.New("thumbnail source is not a supported image")
+)
+
+// keyPattern matches git blob SHA1s and LFS SHA256 OIDs
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// generating makes sure one thumbnail is generated once at a time
+var generating = sync.NewExclusivePool()
+
+// SourceFunc opens the original image a thumbnail is generated from
+type SourceFunc func() (io.ReadCloser, error)
+
+func cachePath(key string, size int) string {
+	return filepath.Join(setting.Thumbnail.Path, strconv.Itoa(size), key[0:2], key[2:4], key+".png")
+}
+
+// Open returns the cached thumbnail of the object identified by key at the given size.
+// The thumbnail is generated from source if it is not cached yet.
+func Open(key string, size int, sourceSize int64, source SourceFunc) (*os.File, error) {
+	if !keyPattern.MatchString(key) {
+		return nil, fmt.Errorf("invalid thumbnail key: %s", key)
+	}
+	if !setting.IsValidThumbnailSize(size) {
+		return nil, ErrInvalidSize
+	}
+
+	p := cachePath(key, size)
+	generating.CheckIn(p)
+	defer generating.CheckOut(p)
+
+	if f, err := os.Open(p); err == nil {
+		touch(p)
+		return f, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if setting.Thumbnail.MaxSourceSize > 0 && sourceSize > setting.Thumbnail.MaxSourceSize {
+		return nil, ErrSourceTooLarge
+	}
+	if err := generate(p, size, source); err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// touch bumps the modification time so the cleanup task keeps files in use
+func touch(p string) {
+	now := time.Now()
+	if err := os.Chtimes(p, now, now); err != nil {
+		log.Warn("Chtimes [%s]: %v", p, err)
+	}
+}
+
+func generate(p string, size int, source SourceFunc) error {
+	rc, err := source()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// check the dimensions from the header before allocating the decoded image,
+	// the consumed header is then replayed to the decoder
+	header := new(bytes.Buffer)
+	config, _, err := image.DecodeConfig(io.TeeReader(rc, header))
+	if err != nil {
+		return ErrNotImage
+	}
+	if setting.IsThumbnailSourceTooLarge(config.Width, config.Height) {
+		return ErrSourceTooLarge
+	}
+
+	img, _, err := image.Decode(io.MultiReader(header, rc))
+	if err != nil {
+		return ErrNotImage
+	}
+	img = resize.Thumbnail(uint(size), uint(size), img, resize.Lanczos3)
+
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return fmt.Errorf("MkdirAll: %v", err)
+	}
+	tmpPath := p + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+	defer os.Remove(tmpPath)
+
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("Encode: %v", err)
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p)
+}
+
+// OpenForBlob returns the thumbnail of a blob. Blobs that are LFS pointers
+// of the repository are resolved to their LFS object, which is then used as key.
+func OpenForBlob(repo *models.Repository, blob *git.Blob, size int) (*os.File, error) {
+	key, sourceSize, source, err := blobSource(repo, blob)
+	if err != nil {
+		return nil, err
+	}
+	return Open(key, size, sourceSize, source)
+}
+
+// IsSupportedSource returns whether a thumbnail can be generated from an image of the given size
+// within MAX_SOURCE_SIZE and MAX_SOURCE_PIXELS, r is read until the dimensions of the image are known
+func IsSupportedSource(size int64, r io.Reader) bool {
+	if setting.Thumbnail.MaxSourceSize > 0 && size > setting.Thumbnail.MaxSourceSize {
+		return false
+	}
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return false
+	}
+	return !setting.IsThumbnailSourceTooLarge(config.Width, config.Height)
+}
+
+// CacheControl returns the Cache-Control header of the thumbnails of a repository. Thumbnails are
+// keyed by content so they never change, but shared caches must not keep those of a repository
+// hidden from anonymous users.
+func CacheControl(repo *models.Repository) string {
+	if repo.IsPrivate || repo.GetOwner() != nil || !repo.Owner.Visibility.IsPublic() {
+		return "private,max-age=31536000,immutable"
+	}
+	return "public,max-age=31536000,immutable"
+}
+
+// blobSource returns the cache key, size and content of a blob,
+// resolving LFS pointers of the repository to their LFS object.
+func blobSource(repo *models.Repository, blob *git.Blob) (string, int64, SourceFunc, error) {
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		return "", 0, nil, err
+	}
+	buf := make([]byte, 1024)
+	n, _ := io.ReadFull(dataRc, buf)
+	buf = buf[:n]
+	dataRc.Close()
+
+	if meta := lfs.IsPointerFile(&buf); meta != nil {
+		if meta, _ = repo.GetLFSMetaObjectByOid(meta.Oid); meta != nil {
+			return meta.Oid, meta.Size, func() (io.ReadCloser, error) {
+				return lfs.ReadMetaObject(meta)
+			}, nil
+		}
+	}
+	return blob.ID.String(), blob.Size(), blob.DataAsync, nil
+}
+
+// DeleteOldThumbnails removes cached thumbnails that have not been accessed
+// within the configured cleanup period.
+func DeleteOldThumbnails() {
+	olderThan := time.Now().Add(-setting.Cron.ThumbnailCleanup.OlderThan)
+	log.Trace("Doing: DeleteOldThumbnails")
+
+	var removed int
+	err := filepath.Walk(setting.Thumbnail.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !info.ModTime().Before(olderThan) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			log.Error("Failed to remove thumbnail %s: %v", p, err)
+			return nil
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		log.Error("DeleteOldThumbnails: %v", err)
+	}
+	log.Trace("Finished: DeleteOldThumbnails, removed %d thumbnails", removed)
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package thumbnail
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "2b4f5c0cb5e5b4e0a5c1d1f2c3b4a5968778695a"
+
+func testSource(t *testing.T, width, height int) SourceFunc {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "thumbnails")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	setting.Thumbnail.Path = tmpDir
+	setting.Thumbnail.Sizes = []int{64}
+
+	f, err := Open(testKey, 64, 0, testSource(t, 400, 200))
+	assert.NoError(t, err)
+	config, err := png.DecodeConfig(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 64, config.Width)
+	assert.Equal(t, 32, config.Height)
+
+	// a cached thumbnail must not touch the source
+	f, err = Open(testKey, 64, 0, func() (io.ReadCloser, error) {
+		t.Fatal("source opened for cached thumbnail")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	f.Close()
+
+	_, err = Open(testKey, 128, 0, testSource(t, 10, 10))
+	assert.Equal(t, ErrInvalidSize, err)
+
+	_, err = Open("../../etc/passwd", 64, 0, testSource(t, 10, 10))
+	assert.Error(t, err)
+}
+
+func TestOpenTooManyPixels(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "thumbnails")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	setting.Thumbnail.Path = tmpDir
+	setting.Thumbnail.Sizes = []int{64}
+	defer func(pixels int64) { setting.Thumbnail.MaxSourcePixels = pixels }(setting.Thumbnail.MaxSourcePixels)
+	setting.Thumbnail.MaxSourcePixels = 100 * 100
+
+	_, err = Open(testKey, 64, 0, testSource(t, 101, 100))
+	assert.Equal(t, ErrSourceTooLarge, err)
+	_, err = os.Stat(cachePath(testKey, 64))
+	assert.True(t, os.IsNotExist(err))
+
+	f, err := Open(testKey, 64, 0, testSource(t, 100, 100))
+	assert.NoError(t, err)
+	f.Close()
+}
+
+func TestIsSupportedSource(t *testing.T) {
+	defer func(size, pixels int64) {
+		setting.Thumbnail.MaxSourceSize = size
+		setting.Thumbnail.MaxSourcePixels = pixels
+	}(setting.Thumbnail.MaxSourceSize, setting.Thumbnail.MaxSourcePixels)
+	setting.Thumbnail.MaxSourceSize = 1024 * 1024
+	setting.Thumbnail.MaxSourcePixels = 100 * 100
+
+	open := func(width, height int) io.Reader {
+		r, err := testSource(t, width, height)()
+		assert.NoError(t, err)
+		return r
+	}
+	assert.True(t, IsSupportedSource(1024, open(100, 100)))
+	assert.False(t, IsSupportedSource(1024, open(101, 100)))
+	assert.False(t, IsSupportedSource(1024*1024+1, open(10, 10)))
+	assert.False(t, IsSupportedSource(1024, bytes.NewReader([]byte("not an image"))))
+}
+
+func TestCacheControl(t *testing.T) {
+	public := &models.User{Visibility: structs.VisibleTypePublic}
+	limited := &models.User{Visibility: structs.VisibleTypeLimited}
+	assert.Equal(t, "public,max-age=31536000,immutable", CacheControl(&models.Repository{Owner: public}))
+	assert.Equal(t, "private,max-age=31536000,immutable", CacheControl(&models.Repository{Owner: public, IsPrivate: true}))
+	assert.Equal(t, "private,max-age=31536000,immutable", CacheControl(&models.Repository{Owner: limited}))
+}
+
+func TestDeleteOldThumbnails(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "thumbnails")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	setting.Thumbnail.Path = tmpDir
+	setting.Thumbnail.Sizes = []int{64}
+	setting.Cron.ThumbnailCleanup.OlderThan = time.Hour
+
+	f, err := Open(testKey, 64, 0, testSource(t, 100, 100))
+	assert.NoError(t, err)
+	f.Close()
+
+	DeleteOldThumbnails()
+	assert.FileExists(t, cachePath(testKey, 64))
+
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(cachePath(testKey, 64), old, old))
+	DeleteOldThumbnails()
+	_, err = os.Stat(cachePath(testKey, 64))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGetLayersForSource(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "thumbnails")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	setting.Thumbnail.Path = tmpDir
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("stack.xml")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(`<image w="4" h="4"><stack><layer name="ink" src="ink.png"/><layer name="paper" src="paper.png"/></stack></image>`))
+	assert.NoError(t, err)
+	for _, name := range []string{"ink.png", "paper.png"} {
+		w, err = zw.Create(name)
+		assert.NoError(t, err)
+		assert.NoError(t, png.Encode(w, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	}
+	assert.NoError(t, zw.Close())
+	source := func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+
+	info, err := GetLayersForSource(testKey, "page.ora", int64(buf.Len()), source)
+	assert.NoError(t, err)
+	if assert.Len(t, info.Layers, 2) {
+		assert.Equal(t, "paper", info.Layers[0].Name)
+		assert.NotEmpty(t, info.Layers[0].Hash)
+		assert.Equal(t, info.Layers[0].Hash, info.Layers[1].Hash)
+	}
+
+	// the hashes are read from the cached manifest
+	cached, err := GetLayersForSource(testKey, "page.ora", int64(buf.Len()), func() (io.ReadCloser, error) {
+		t.Fatal("source opened for cached layers")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, info, cached)
+
+	// manifests without hashes are rendered again
+	info.Layers[0].Hash = ""
+	data, err := json.Marshal(info)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(layersManifestPath(testKey), data, 0644))
+	cached, err = GetLayersForSource(testKey, "page.ora", int64(buf.Len()), source)
+	assert.NoError(t, err)
+	assert.Equal(t, cached.Layers[1].Hash, cached.Layers[0].Hash)
+
+	_, err = GetLayersForSource("../../etc", "page.ora", int64(buf.Len()), source)
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package variant
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+// checkCover checks that the cover of the variant is an image of its branch
+func checkCover(repo *models.Repository, v *models.Variant) error {
+	v.CoverPath = strings.Trim(v.CoverPath, "/")
+	if len(v.CoverPath) == 0 {
+		return nil
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(v.BranchName)
+	if err != nil {
+		return err
+	}
+	entry, err := commit.GetTreeEntryByPath(v.CoverPath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return models.ErrVariantInvalidCover{BranchName: v.BranchName, Path: v.CoverPath}
+		}
+		return err
+	}
+	if entry.IsDir() || entry.IsSubModule() {
+		return models.ErrVariantInvalidCover{BranchName: v.BranchName, Path: v.CoverPath}
+	}
+
+	reader, err := entry.Blob().DataAsync()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	buf := make([]byte, 1024)
+	n, _ := reader.Read(buf)
+	if !base.IsImageFile(buf[:n]) {
+		return models.ErrVariantInvalidCover{BranchName: v.BranchName, Path: v.CoverPath}
+	}
+	return nil
+}
+
+// CreateVariant creates a variant on its branch, the branch is created from baseBranch,
+// or from the default branch, if it does not exist yet.
+func CreateVariant(doer *models.User, repo *models.Repository, v *models.Variant, baseBranch string) error {
+	if _, err := models.GetVariantByBranch(repo.ID, v.BranchName); err == nil {
+		return models.ErrVariantAlreadyExists{RepoID: repo.ID, BranchName: v.BranchName}
+	} else if !models.IsErrVariantNotExist(err) {
+		return err
+	}
+
+	if !git.IsBranchExist(repo.RepoPath(), v.BranchName) {
+		if len(baseBranch) == 0 {
+			baseBranch = repo.DefaultBranch
+		}
+		if !git.IsBranchExist(repo.RepoPath(), baseBranch) {
+			return git.ErrBranchNotExist{Name: baseBranch}
+		}
+		if err := repo.CreateNewBranch(doer, baseBranch, v.BranchName); err != nil {
+			return err
+		}
+	}
+
+	if err := checkCover(repo, v); err != nil {
+		return err
+	}
+
+	v.RepoID = repo.ID
+	v.OwnerID = doer.ID
+	if err := models.NewVariant(v); err != nil {
+		return err
+	}
+
+	notification.NotifyCreateVariant(doer, v)
+
+	return nil
+}
+
+// UpdateVariant updates the title, the description and the cover of a variant.
+func UpdateVariant(repo *models.Repository, v *models.Variant) error {
+	if err := checkCover(repo, v); err != nil {
+		return err
+	}
+	return models.UpdateVariant(v)
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package variant
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateVariant(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User)
+
+	variant := &models.Variant{BranchName: "master", Title: "Bright timeline"}
+	assert.NoError(t, CreateVariant(doer, repo, variant, ""))
+	variant = models.AssertExistsAndLoadBean(t, &models.Variant{ID: variant.ID}).(*models.Variant)
+	assert.EqualValues(t, repo.ID, variant.RepoID)
+	assert.EqualValues(t, doer.ID, variant.OwnerID)
+
+	err := CreateVariant(doer, repo, &models.Variant{BranchName: "develop", Title: "Another"}, "")
+	assert.True(t, models.IsErrVariantAlreadyExists(err))
+
+	err = CreateVariant(doer, repo, &models.Variant{BranchName: "new-story", Title: "New story"}, "missing")
+	assert.True(t, git.IsErrBranchNotExist(err))
+	models.AssertNotExistsBean(t, &models.Variant{BranchName: "new-story"})
+}
+
+func TestUpdateVariant_Cover(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	variant := models.AssertExistsAndLoadBean(t, &models.Variant{ID: 1}).(*models.Variant)
+
+	// README.md is not an image
+	variant.CoverPath = "README.md"
+	assert.True(t, models.IsErrVariantInvalidCover(UpdateVariant(repo, variant)))
+	variant.CoverPath = "missing.png"
+	assert.True(t, models.IsErrVariantInvalidCover(UpdateVariant(repo, variant)))
+
+	variant.Title = "Darker timeline"
+	variant.CoverPath = "/"
+	assert.NoError(t, UpdateVariant(repo, variant))
+	variant = models.AssertExistsAndLoadBean(t, &models.Variant{ID: 1}).(*models.Variant)
+	assert.Equal(t, "Darker timeline", variant.Title)
+	assert.Empty(t, variant.CoverPath)
+}
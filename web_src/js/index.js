@@ -1184,6 +1184,98 @@ function initRepositoryCollaboration() {
   });
 }
 
+function initRepoProject() {
+  const $board = $('.project .project-board');
+  if ($board.length === 0) {
+    return;
+  }
+
+  $('.project-column-edit-button').click(function (e) {
+    e.preventDefault();
+    $(this).closest('.project-column').find('.project-column-edit').toggleClass('hide');
+  });
+
+  if (!$board.data('sort-url')) {
+    return;
+  }
+
+  // Cards and columns are moved with native drag and drop, the new order is saved
+  // once the item has been dropped.
+  let $dragged = null;
+  const insertBefore = ($container, selector, x, y, horizontal) => {
+    let before = null;
+    $container.children(selector).not($dragged).each((_, el) => {
+      const rect = el.getBoundingClientRect();
+      const middle = horizontal ? rect.left + rect.width / 2 : rect.top + rect.height / 2;
+      if ((horizontal ? x : y) < middle) {
+        before = el;
+        return false;
+      }
+    });
+    if (before) {
+      $dragged.insertBefore(before);
+    } else {
+      $container.append($dragged);
+    }
+  };
+  const ids = ($container, selector) => $container.children(selector).map((_, el) => $(el).data('id')).get().join(',');
+  const updateCounts = () => {
+    $board.find('.project-column').each((_, el) => {
+      $(el).find('.project-column-count').text($(el).find('.project-card').length);
+    });
+  };
+
+  $board.on('dragstart', '.project-card', function (e) {
+    e.stopPropagation();
+    $dragged = $(this).addClass('dragging');
+    e.originalEvent.dataTransfer.effectAllowed = 'move';
+    e.originalEvent.dataTransfer.setData('text/plain', $dragged.data('id'));
+  });
+  $board.on('dragstart', '.project-column-header', function (e) {
+    $dragged = $(this).closest('.project-column').addClass('dragging');
+    e.originalEvent.dataTransfer.effectAllowed = 'move';
+    e.originalEvent.dataTransfer.setData('text/plain', $dragged.data('id'));
+  });
+  $board.on('dragover', (e) => {
+    if (!$dragged) {
+      return;
+    }
+    e.preventDefault();
+    const ev = e.originalEvent;
+    if ($dragged.hasClass('project-card')) {
+      const $cards = $(e.target).closest('.project-column').find('.project-cards');
+      if ($cards.length > 0) {
+        insertBefore($cards, '.project-card', ev.clientX, ev.clientY, false);
+      }
+    } else {
+      insertBefore($board, '.project-column', ev.clientX, ev.clientY, true);
+    }
+  });
+  $board.on('drop', (e) => {
+    e.preventDefault();
+  });
+  $board.on('dragend', () => {
+    if (!$dragged) {
+      return;
+    }
+    const $item = $dragged.removeClass('dragging');
+    $dragged = null;
+    if ($item.hasClass('project-card')) {
+      const $cards = $item.closest('.project-cards');
+      updateCounts();
+      $.post($cards.data('url'), {
+        _csrf: csrf,
+        cards: ids($cards, '.project-card')
+      });
+    } else {
+      $.post($board.data('sort-url'), {
+        _csrf: csrf,
+        columns: ids($board, '.project-column')
+      });
+    }
+  });
+}
+
 function initTeamSettings() {
   // Change team access mode
   $('.organization.new.team input[name=permission]').change(() => {
@@ -1197,7 +1289,7 @@ function initTeamSettings() {
 }
 
 function initWikiForm() {
-  const $editArea = $('.repository.wiki textarea#edit_area');
+  const $editArea = $('.repository.wiki textarea#edit_area, .repository.keystone textarea#edit_area');
   let sideBySideChanges = 0;
   let sideBySideTimeout = null;
   if ($editArea.length > 0) {
@@ -2022,6 +2114,330 @@ function initCodeView() {
     });
   }
   $('.ui.blob-excerpt').on('click', (e) => { insertBlobExcerpt(e); });
+  initImageDiff();
+  initSVGDiff();
+  initLayeredFile();
+  initImageRegions();
+}
+
+function initImageDiff() {
+  $('.image-diff-tabs .button').on('click', function () {
+    const $tab = $(this);
+    const $diff = $($tab.parent().data('target'));
+    const mode = $tab.data('mode');
+    $tab.addClass('active').siblings().removeClass('active');
+    $diff.attr('data-mode', mode);
+    $diff.children().addClass('hide');
+    $diff.children(`.image-diff-${mode}`).removeClass('hide');
+    $diff.find('.image-diff-slider').trigger('input');
+  });
+
+  $('.image-diff-swipe .image-diff-slider').on('input', function () {
+    const $frame = $(this).siblings('.image-diff-frame');
+    $frame.find('.image-diff-swipe-after').css('width', `${this.value}%`);
+    // keep the new image at full size while its container is clipped
+    $frame.find('.image-diff-new').css('width', $frame.find('.image-diff-old').width());
+  }).trigger('input');
+
+  $('.image-diff-onion-skin .image-diff-slider').on('input', function () {
+    $(this).siblings('.image-diff-frame').find('.image-diff-new').css('opacity', this.value / 100);
+  });
+}
+
+function initImageRegions() {
+  // regions are normalized to the size of the image, so they follow it when it is scaled
+  const placeRegion = ($region, region) => {
+    $region.css({
+      left: `${region.x * 100}%`,
+      top: `${region.y * 100}%`,
+      width: `${region.width * 100}%`,
+      height: `${region.height * 100}%`
+    }).toggleClass('point', region.width === 0 && region.height === 0);
+  };
+  $('.image-region').each(function () {
+    const $region = $(this);
+    placeRegion($region, {
+      x: $region.data('x'),
+      y: $region.data('y'),
+      width: $region.data('width'),
+      height: $region.data('height')
+    });
+  });
+
+  $('.image-region-draw').each(function () {
+    const $frame = $(this);
+    const $selection = $('<div class="image-region selection hide"></div>').appendTo($frame);
+    const $row = $frame.closest('table').find('tr.image-region-comment');
+    let start = null;
+    let region = null;
+
+    const round = (value) => Math.round(value * 10000) / 10000;
+    const position = (e) => {
+      const offset = $frame.offset();
+      return {
+        x: Math.min(Math.max((e.pageX - offset.left) / $frame.width(), 0), 1),
+        y: Math.min(Math.max((e.pageY - offset.top) / $frame.height(), 0), 1)
+      };
+    };
+    const select = (end) => {
+      region = {
+        x: round(Math.min(start.x, end.x)),
+        y: round(Math.min(start.y, end.y)),
+        width: round(Math.abs(end.x - start.x)),
+        height: round(Math.abs(end.y - start.y))
+      };
+      // a click without dragging is a point
+      if (Math.abs(end.x - start.x) * $frame.width() < 3 && Math.abs(end.y - start.y) * $frame.height() < 3) {
+        region = {x: round(start.x), y: round(start.y), width: 0, height: 0};
+      }
+      placeRegion($selection.removeClass('hide'), region);
+    };
+
+    $frame.on('mousedown', (e) => {
+      // the markers of the existing comments link to their thread
+      if (e.button !== 0 || $(e.target).closest('.image-region').length > 0) {
+        return;
+      }
+      e.preventDefault();
+      start = position(e);
+      select(start);
+    });
+    $(document).on('mousemove', (e) => {
+      if (start) {
+        select(position(e));
+      }
+    }).on('mouseup', (e) => {
+      if (!start) {
+        return;
+      }
+      select(position(e));
+      start = null;
+
+      const $td = $row.find('td');
+      let $commentCloud = $td.find('.comment-code-cloud');
+      if ($commentCloud.length === 0) {
+        $td.html($('#pull_review_add_comment').html());
+        $commentCloud = $td.find('.comment-code-cloud');
+        assingMenuAttributes($commentCloud.find('.menu'));
+
+        const $form = $commentCloud.find('form');
+        $form.find("input[name='line']").val(0);
+        $form.find("input[name='side']").val('proposed');
+        $form.find("input[name='path']").val($row.data('path'));
+        $form.append('<input type="hidden" name="is_region" value="true">');
+        for (const name of ['x', 'y', 'width', 'height']) {
+          $form.append(`<input type="hidden" name="region_${name}">`);
+        }
+        $form.find('.btn-cancel').on('click', () => {
+          $selection.addClass('hide');
+        });
+      }
+      for (const name of ['x', 'y', 'width', 'height']) {
+        $commentCloud.find(`input[name='region_${name}']`).val(region[name]);
+      }
+      $commentCloud.find('textarea').focus();
+    });
+  });
+}
+
+function initSVGDiff() {
+  $('.svg-diff-tabs .button').on('click', function () {
+    const $tab = $(this);
+    const $diff = $tab.closest('.svg-diff');
+    const mode = $tab.data('mode');
+    $tab.addClass('active').siblings().removeClass('active');
+    $diff.children('.svg-diff-visual').toggleClass('hide', mode !== 'visual');
+    $diff.children('.svg-diff-structure').toggleClass('hide', mode !== 'structure');
+    $diff.siblings('.file-body').toggleClass('hide', mode !== 'source');
+    $diff.find('.image-diff-slider').trigger('input');
+  });
+  $('.svg-diff').siblings('.file-body').addClass('hide');
+}
+
+function initReader() {
+  const $reader = $('#reader');
+  if ($reader.length === 0) return;
+
+  const $pages = $reader.find('.reader-page');
+  const $thumbnails = $('.reader-thumbnail');
+  const rtl = $reader.hasClass('rtl');
+  let mode = localStorage.getItem('reader-mode') === 'double' ? 'double' : 'single';
+  let spreads = [];
+  let current = 0;
+
+  // in double page mode the cover is alone and the next pages are paired, a wide page is a spread by itself
+  const makeSpreads = () => {
+    spreads = [];
+    $pages.each(function (i) {
+      const last = spreads[spreads.length - 1];
+      if (mode === 'double' && i > 1 && last.length === 1 && !$(last[0]).hasClass('wide') && !$(this).hasClass('wide')) {
+        last.push(this);
+      } else {
+        spreads.push([this]);
+      }
+    });
+  };
+  const spreadOf = (number) => {
+    return Math.max(0, spreads.findIndex((spread) => spread.some((page) => $(page).data('number') === number)));
+  };
+  const loadImage = (page) => {
+    const $img = $(page).find('img');
+    if (!$img.attr('src')) {
+      $img.attr('src', $(page).data('src'));
+    }
+  };
+
+  const show = (index) => {
+    current = Math.min(Math.max(index, 0), spreads.length - 1);
+    const spread = spreads[current];
+    $pages.removeClass('visible');
+    $(spread).addClass('visible').each(function () {
+      loadImage(this);
+    });
+
+    // the next pages are fetched while the current ones are read
+    for (const next of [current + 1, current + 2, current - 1]) {
+      if (next >= 0 && next < spreads.length) {
+        spreads[next].forEach((page) => {
+          new Image().src = $(page).data('src');
+        });
+      }
+    }
+
+    const numbers = spread.map((page) => $(page).data('number'));
+    const first = numbers[0];
+    $('.reader-current').text(numbers.join('-'));
+    $thumbnails.removeClass('active').each(function () {
+      const number = $(this).data('number');
+      if (numbers.includes(number)) {
+        $(this).addClass('active');
+      }
+      // the thumbnails around the current pages are fetched before they are scrolled to
+      const $img = $(this).find('img');
+      if (Math.abs(number - first) <= 12 && !$img.attr('src')) {
+        $img.attr('src', $img.data('src'));
+      }
+    });
+    const $active = $thumbnails.filter('.active').first();
+    if ($active.length > 0) {
+      const $strip = $active.parent();
+      $strip.scrollLeft($strip.scrollLeft() + $active.position().left - ($strip.width() - $active.outerWidth()) / 2);
+    }
+    $('.reader-prev').toggleClass('disabled', current === 0);
+    $('.reader-next').toggleClass('disabled', current === spreads.length - 1);
+    window.history.replaceState(null, '', `#page-${first}`);
+  };
+
+  const setMode = (newMode) => {
+    const number = $(spreads[current][0]).data('number');
+    mode = newMode;
+    localStorage.setItem('reader-mode', mode);
+    $reader.toggleClass('double', mode === 'double');
+    $('.reader-modes .button').removeClass('active').filter(`[data-mode="${mode}"]`).addClass('active');
+    makeSpreads();
+    show(spreadOf(number));
+  };
+
+  const pageFromHash = () => {
+    const match = /^#page-(\d+)$/.exec(window.location.hash);
+    return match ? parseInt(match[1]) : 1;
+  };
+
+  $('.reader-modes .button').on('click', function () {
+    setMode($(this).data('mode'));
+  });
+  $('.reader-prev').on('click', () => show(current - 1));
+  $('.reader-next').on('click', () => show(current + 1));
+  $thumbnails.on('click', function (e) {
+    e.preventDefault();
+    show(spreadOf($(this).data('number')));
+  });
+  // a click on the left or right half of a page turns it, except on the comments
+  $reader.on('click', '.reader-page img', function (e) {
+    const forward = e.offsetX > $(this).width() / 2;
+    show(current + (forward !== rtl ? 1 : -1));
+  });
+  $(window).on('hashchange', () => show(spreadOf(pageFromHash())));
+  $(document).on('keydown', (e) => {
+    if (e.ctrlKey || e.altKey || e.metaKey || $(e.target).is('input, textarea, select, [contenteditable]')) return;
+    switch (e.key) {
+      case 'ArrowRight':
+        show(current + (rtl ? -1 : 1));
+        break;
+      case 'ArrowLeft':
+        show(current + (rtl ? 1 : -1));
+        break;
+      case 'PageDown':
+      case ' ':
+        show(current + 1);
+        break;
+      case 'PageUp':
+        show(current - 1);
+        break;
+      case 'Home':
+        show(0);
+        break;
+      case 'End':
+        show(spreads.length - 1);
+        break;
+      case 'd':
+      case 'D':
+        setMode(mode === 'double' ? 'single' : 'double');
+        break;
+      default:
+        return;
+    }
+    e.preventDefault();
+  });
+
+  makeSpreads();
+  current = spreadOf(pageFromHash());
+  setMode(mode);
+  $reader.focus();
+}
+
+function initLayeredFile() {
+  $('.layered-file').each(function () {
+    const $file = $(this);
+    const url = $file.data('url');
+    $.getJSON(url).done((info) => {
+      const $canvas = $file.find('.layered-file-canvas');
+      const $list = $file.find('.layered-file-list');
+      $canvas.css('padding-bottom', `${info.height / info.width * 100}%`);
+      for (const layer of info.layers) {
+        const $img = $('<img>').attr('src', `${url}?layer=${layer.index}`).css({
+          left: `${layer.x / info.width * 100}%`,
+          top: `${layer.y / info.height * 100}%`,
+          width: `${layer.width / info.width * 100}%`,
+          height: `${layer.height / info.height * 100}%`,
+          opacity: layer.opacity,
+          'mix-blend-mode': layer.blend_mode,
+        }).toggleClass('hide', !layer.visible);
+        $canvas.append($img);
+
+        // list the layers from top to bottom like editors do
+        const $checkbox = $(`<div class="item"><div class="ui checkbox"><input type="checkbox"><label>${htmlEncode(layer.name)}</label></div></div>`);
+        $checkbox.find('input').prop('checked', layer.visible).on('change', function () {
+          $img.toggleClass('hide', !this.checked);
+        });
+        $list.prepend($checkbox);
+      }
+      if (info.has_composite) {
+        const $composite = $('<img class="layered-file-composite-image hide">').attr('src', `${url}?layer=composite`);
+        $canvas.append($composite);
+        $file.find('.layered-file-composite').on('change', function () {
+          $composite.toggleClass('hide', !this.checked);
+        });
+      } else {
+        $file.find('.layered-file-composite').closest('.segment').addClass('hide');
+      }
+      $file.find('.layered-file-panel').removeClass('hide');
+    }).fail(() => {
+      $file.find('.layered-file-error').removeClass('hide');
+    }).always(() => {
+      $file.find('.loader').remove();
+    });
+  });
 }
 
 function initU2FAuth() {
@@ -2277,8 +2693,10 @@ $(document).ready(() => {
   const $dropzone = $('#dropzone');
   if ($dropzone.length > 0) {
     const filenameDict = {};
+    // the files of a dropped or picked folder keep their path relative to the folder
+    const fileKey = (file) => file.fullPath || file.name;
 
-    new Dropzone('#dropzone', {
+    const dropzone = new Dropzone('#dropzone', {
       url: $dropzone.data('upload-url'),
       headers: { 'X-Csrf-Token': csrf },
       maxFiles: $dropzone.data('max-file'),
@@ -2290,24 +2708,51 @@ $(document).ready(() => {
       dictFileTooBig: $dropzone.data('file-too-big'),
       dictRemoveFile: $dropzone.data('remove-file'),
       init() {
+        this.on('sending', (file, _xhr, formData) => {
+          if (file.fullPath) {
+            formData.append('full_path', file.fullPath);
+          }
+        });
         this.on('success', (file, data) => {
-          filenameDict[file.name] = data.uuid;
+          filenameDict[fileKey(file)] = data.uuid;
           const input = $(`<input id="${data.uuid}" name="files" type="hidden">`).val(data.uuid);
           $('.files').append(input);
         });
         this.on('removedfile', (file) => {
-          if (file.name in filenameDict) {
-            $(`#${filenameDict[file.name]}`).remove();
+          const uuid = filenameDict[fileKey(file)] || file.chunksUUID;
+          if (fileKey(file) in filenameDict) {
+            $(`#${filenameDict[fileKey(file)]}`).remove();
           }
           if ($dropzone.data('remove-url') && $dropzone.data('csrf')) {
             $.post($dropzone.data('remove-url'), {
-              file: filenameDict[file.name],
+              file: uuid,
               _csrf: $dropzone.data('csrf')
             });
           }
         });
       },
     });
+
+    const chunkUrl = $dropzone.data('chunk-url');
+    const chunkSize = ($dropzone.data('chunk-size') || 0) * 1024 * 1024;
+    if (chunkUrl && chunkSize > 0) {
+      const uploadFiles = dropzone.uploadFiles;
+      dropzone.uploadFiles = (files) => {
+        if (files.length === 1 && files[0].size > chunkSize) {
+          uploadInChunks(dropzone, files[0], chunkUrl, chunkSize);
+          return;
+        }
+        return uploadFiles.call(dropzone, files);
+      };
+    }
+
+    $('#upload-folder').on('change', function () {
+      Array.prototype.forEach.call(this.files, (file) => {
+        file.fullPath = file.webkitRelativePath;
+        dropzone.addFile(file);
+      });
+      this.value = '';
+    });
   }
 
   // Emojify
@@ -2449,6 +2894,7 @@ $(document).ready(() => {
   initWebhook();
   initAdmin();
   initCodeView();
+  initReader();
   initVueApp();
   initTeamSettings();
   initCtrlEnterSubmit();
@@ -2461,6 +2907,7 @@ $(document).ready(() => {
   initPullRequestReview();
   initRepoStatusChecker();
   initTemplateSearch();
+  initRepoProject();
 
   // Repo clone url.
   if ($('#repo-clone-url').length > 0) {
@@ -3445,3 +3892,95 @@ window.onOAuthLoginClick = function () {
     oauthNav.show();
   }, 5000);
 };
+
+// uploadInChunks uploads a file chunk after chunk. An interrupted upload is resumed from the size
+// received by the server, the upload in progress is remembered to be resumed after reloading the page.
+function uploadInChunks(dropzone, file, chunkUrl, chunkSize) {
+  const storageKey = `upload-chunks:${chunkUrl}:${file.fullPath || file.name}:${file.size}:${file.lastModified}`;
+  const maxRetries = 5;
+  let retries = 0;
+
+  const finish = (status, event, ...args) => {
+    file.status = status;
+    dropzone.emit(event, file, ...args);
+    dropzone.emit('complete', file);
+    dropzone.processQueue();
+  };
+  const fail = (xhr) => {
+    finish(Dropzone.ERROR, 'error', xhr.responseText || dropzone.options.dictResponseError.replace('{{statusCode}}', xhr.status), xhr);
+  };
+  // network errors and server errors are retried with an increasing delay
+  const retry = (xhr, uuid) => {
+    if (file.status !== Dropzone.UPLOADING) return;
+    if ((xhr.status === 0 || xhr.status >= 500) && retries < maxRetries) {
+      retries++;
+      setTimeout(() => resume(uuid), 1000 * 2 ** retries);
+      return;
+    }
+    fail(xhr);
+  };
+
+  const sendChunk = (uuid, offset) => {
+    if (file.status !== Dropzone.UPLOADING) return;
+    const formData = new FormData();
+    formData.append('uuid', uuid);
+    formData.append('offset', offset);
+    formData.append('total_size', file.size);
+    if (file.fullPath) {
+      formData.append('full_path', file.fullPath);
+    }
+    formData.append('file', file.slice(offset, offset + chunkSize), file.name);
+    file.xhr = $.ajax({
+      url: chunkUrl,
+      type: 'POST',
+      headers: { 'X-Csrf-Token': csrf },
+      data: formData,
+      processData: false,
+      contentType: false,
+    }).done((data) => {
+      retries = 0;
+      file.chunksUUID = data.uuid;
+      localStorage.setItem(storageKey, data.uuid);
+      file.upload.bytesSent = data.size;
+      file.upload.progress = 100 * data.size / file.size;
+      dropzone.emit('uploadprogress', file, file.upload.progress, file.upload.bytesSent);
+      if (data.size < file.size) {
+        sendChunk(data.uuid, data.size);
+        return;
+      }
+      localStorage.removeItem(storageKey);
+      finish(Dropzone.SUCCESS, 'success', data);
+    }).fail((xhr) => {
+      if (xhr.status === 409 && xhr.responseJSON) {
+        // the server didn't receive all the previous chunks
+        sendChunk(xhr.responseJSON.uuid, xhr.responseJSON.size);
+      } else if (xhr.status === 404) {
+        localStorage.removeItem(storageKey);
+        fail(xhr);
+      } else {
+        retry(xhr, uuid);
+      }
+    });
+  };
+
+  const resume = (uuid) => {
+    if (file.status !== Dropzone.UPLOADING) return;
+    if (!uuid) {
+      sendChunk('', 0);
+      return;
+    }
+    file.xhr = $.getJSON(chunkUrl, { uuid }).done((data) => {
+      sendChunk(data.uuid, data.size);
+    }).fail((xhr) => {
+      if (xhr.status === 404) {
+        // the upload in progress is gone, e.g. the server was restarted
+        localStorage.removeItem(storageKey);
+        sendChunk('', 0);
+      } else {
+        retry(xhr, uuid);
+      }
+    });
+  };
+
+  resume(localStorage.getItem(storageKey));
+}